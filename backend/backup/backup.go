@@ -0,0 +1,160 @@
+// Package backup writes and reads the encrypted backups of the password
+// cards.
+//
+// A backup is a JSON document holding a vault header and the sealed cards.
+// The key is derived with Argon2id from a passphrase chosen when the backup is
+// made, unrelated to the master password, so a backup can be restored on
+// another server. The cards are sealed with AES-256-GCM along with the format
+// and version of the backup, so any change to the document is detected.
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/vault"
+)
+
+const (
+	// Format identifies the backups.
+	Format = "password-manager-backup"
+	// Version is the version of the backups written by Write.
+	Version = 1
+)
+
+// The bounds of the Argon2id parameters of the backups read. The backups
+// written with the default parameters are far below them, the others would
+// tie up the server or make Argon2 panic.
+const (
+	maxKDFTime    = 10
+	maxKDFMemory  = 256 * 1024 // KiB
+	maxKDFThreads = 16
+)
+
+// ErrInvalidBackup is returned when a backup can't be decrypted, either
+// because the passphrase is wrong or because the backup was changed.
+var ErrInvalidBackup = errors.New("invalid backup or passphrase")
+
+// archive is the document written to the backups. Only Data is encrypted.
+type archive struct {
+	Format  string       `json:"format"`
+	Version int          `json:"version"`
+	Vault   vault.Header `json:"vault"`
+	Data    []byte       `json:"data"`
+}
+
+// Backup is the content of a backup.
+type Backup struct {
+	CreatedAt     time.Time            `json:"created_at"`
+	PasswordCards []model.PasswordCard `json:"password_cards"`
+	// Folders is the folder tree of the cards, the backups written before
+	// the folders have none.
+	Folders []model.Folder `json:"folders,omitempty"`
+}
+
+// Write writes the password cards and their folders as a backup encrypted
+// with passphrase. The cards keep their IDs and dates, but not their owner nor
+// what is computed when they are read, like whether they were breached. The
+// folders keep their IDs, names and parents.
+func Write(out io.Writer, passphrase string, passwordCards []model.PasswordCard, folders []model.Folder, createdAt time.Time, params vault.KDFParams) error {
+	backup := Backup{
+		CreatedAt:     createdAt.UTC(),
+		PasswordCards: make([]model.PasswordCard, 0, len(passwordCards)),
+		Folders:       make([]model.Folder, 0, len(folders)),
+	}
+	for _, folder := range folders {
+		folder.OwnerID = ""
+		backup.Folders = append(backup.Folders, folder)
+	}
+	for _, passwordCard := range passwordCards {
+		passwordCard.OwnerID = ""
+		passwordCard.DataKey = ""
+		passwordCard.Sealing = ""
+		passwordCard.Breached = nil
+		backup.PasswordCards = append(backup.PasswordCards, passwordCard)
+	}
+
+	plaintext, err := json.Marshal(backup)
+	if err != nil {
+		return fmt.Errorf("error writing backup: %w", err)
+	}
+
+	v, err := vault.Create([]byte(passphrase), params)
+	if err != nil {
+		return fmt.Errorf("error writing backup: %w", err)
+	}
+
+	data, err := v.Seal(plaintext, additionalData(Format, Version))
+	if err != nil {
+		return fmt.Errorf("error writing backup: %w", err)
+	}
+
+	if err := json.NewEncoder(out).Encode(archive{Format: Format, Version: Version, Vault: v.Header(), Data: data}); err != nil {
+		return fmt.Errorf("error writing backup: %w", err)
+	}
+
+	return nil
+}
+
+// Read decrypts a backup written by Write with its passphrase. The backups
+// whose key derivation parameters are out of bounds are invalid, the key isn't
+// derived.
+func Read(r io.Reader, passphrase string) (*Backup, error) {
+	var a archive
+	if err := json.NewDecoder(r).Decode(&a); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidBackup, err)
+	}
+
+	if a.Format != Format {
+		return nil, fmt.Errorf("%w: not a backup", ErrInvalidBackup)
+	}
+	if a.Version != Version {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidBackup, a.Version)
+	}
+
+	if err := checkKDFParams(a.Vault.KDF); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidBackup, err)
+	}
+
+	v, err := vault.Open(a.Vault, []byte(passphrase))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidBackup, err)
+	}
+
+	plaintext, err := v.Open(a.Data, additionalData(a.Format, a.Version))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidBackup, err)
+	}
+
+	var backup Backup
+	if err := json.Unmarshal(plaintext, &backup); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidBackup, err)
+	}
+
+	return &backup, nil
+}
+
+// checkKDFParams returns an error when the parameters of a backup are out of
+// the bounds, before the key is derived with them.
+func checkKDFParams(params vault.KDFParams) error {
+	switch {
+	case params.Time < 1 || params.Time > maxKDFTime:
+		return fmt.Errorf("the KDF time must be between 1 and %d", maxKDFTime)
+	case params.Memory > maxKDFMemory:
+		return fmt.Errorf("the KDF memory must be at most %d KiB", maxKDFMemory)
+	case params.Threads < 1 || params.Threads > maxKDFThreads:
+		return fmt.Errorf("the KDF threads must be between 1 and %d", maxKDFThreads)
+	}
+
+	return nil
+}
+
+// additionalData binds the sealed cards to the format and version they were
+// written with.
+func additionalData(format string, version int) []byte {
+	return []byte(fmt.Sprintf("%s:v%d", format, version))
+}
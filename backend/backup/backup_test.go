@@ -0,0 +1,100 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKDFParams are cheap parameters, the default ones take too long for
+// tests.
+var testKDFParams = vault.KDFParams{Salt: []byte("0123456789abcdef"), Time: 1, Memory: 64, Threads: 1}
+
+func TestBackup(t *testing.T) {
+	createdAt := time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+	breached := true
+	passwordCards := []model.PasswordCard{
+		{ID: "card-id-1", Name: "AWS", Username: "admin", Password: "supersecret", URL: "https://aws.com/login", OwnerID: "user-id-1", CreatedAt: createdAt, UpdatedAt: createdAt.Add(time.Hour), Breached: &breached, DataKey: "key:v1:wrapped"},
+		{ID: "card-id-2", Name: "GCP", Username: "ops", Password: "anothersecret", URL: "https://cloud.google.com/", FolderID: "folder-id-2"},
+	}
+	folders := []model.Folder{
+		{ID: "folder-id-1", Name: "Work", OwnerID: "user-id-1", CreatedAt: createdAt, UpdatedAt: createdAt},
+		{ID: "folder-id-2", Name: "Cloud", ParentID: "folder-id-1", OwnerID: "user-id-1", CreatedAt: createdAt, UpdatedAt: createdAt},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, "correct horse", passwordCards, folders, createdAt, testKDFParams))
+
+	t.Run("🎉 reads the password cards and folders back with the passphrase", func(t *testing.T) {
+		backup, err := Read(bytes.NewReader(buf.Bytes()), "correct horse")
+		require.NoError(t, err)
+
+		assert.True(t, createdAt.Equal(backup.CreatedAt))
+		assert.Equal(t, []model.PasswordCard{
+			{ID: "card-id-1", Name: "AWS", Username: "admin", Password: "supersecret", URL: "https://aws.com/login", CreatedAt: createdAt, UpdatedAt: createdAt.Add(time.Hour)},
+			{ID: "card-id-2", Name: "GCP", Username: "ops", Password: "anothersecret", URL: "https://cloud.google.com/", FolderID: "folder-id-2"},
+		}, backup.PasswordCards)
+		assert.Equal(t, []model.Folder{
+			{ID: "folder-id-1", Name: "Work", CreatedAt: createdAt, UpdatedAt: createdAt},
+			{ID: "folder-id-2", Name: "Cloud", ParentID: "folder-id-1", CreatedAt: createdAt, UpdatedAt: createdAt},
+		}, backup.Folders)
+	})
+
+	t.Run("can't be read without the passphrase", func(t *testing.T) {
+		_, err := Read(bytes.NewReader(buf.Bytes()), "wrong")
+		assert.ErrorIs(t, err, ErrInvalidBackup)
+		assert.NotContains(t, buf.String(), "supersecret")
+		assert.NotContains(t, buf.String(), "aws.com")
+	})
+
+	t.Run("detects changes", func(t *testing.T) {
+		tamper := func(change func(a map[string]any)) []byte {
+			var a map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &a))
+			change(a)
+			data, err := json.Marshal(a)
+			require.NoError(t, err)
+			return data
+		}
+
+		tests := map[string][]byte{
+			"not JSON": []byte("not a backup"),
+			"format": tamper(func(a map[string]any) {
+				a["format"] = "something-else"
+			}),
+			"version": tamper(func(a map[string]any) {
+				a["version"] = 2
+			}),
+			"KDF": tamper(func(a map[string]any) {
+				a["vault"].(map[string]any)["kdf"].(map[string]any)["time"] = 2
+			}),
+			"costly KDF time": tamper(func(a map[string]any) {
+				a["vault"].(map[string]any)["kdf"].(map[string]any)["time"] = maxKDFTime + 1
+			}),
+			"costly KDF memory": tamper(func(a map[string]any) {
+				a["vault"].(map[string]any)["kdf"].(map[string]any)["memory"] = maxKDFMemory + 1
+			}),
+			"no KDF threads": tamper(func(a map[string]any) {
+				a["vault"].(map[string]any)["kdf"].(map[string]any)["threads"] = 0
+			}),
+			"data": tamper(func(a map[string]any) {
+				data := []byte(a["data"].(string))
+				data[len(data)/2] ^= 1
+				a["data"] = string(data)
+			}),
+		}
+
+		for name, data := range tests {
+			t.Run(name, func(t *testing.T) {
+				_, err := Read(bytes.NewReader(data), "correct horse")
+				assert.ErrorIs(t, err, ErrInvalidBackup)
+			})
+		}
+	})
+}
@@ -0,0 +1,204 @@
+// Package breach checks passwords against a local copy of the Have I Been
+// Pwned Pwned Passwords list, without any network access.
+//
+// The list is the SHA-1 file ordered by hash, one "<SHA-1 hex>:<count>" line
+// per password. It's too large to load, so it's searched in place: a binary
+// search over the byte offsets of the file, aligned to the next line, finds a
+// hash in about 30 reads of a few hundred bytes for the full list.
+package breach
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	hashLength = 2 * sha1.Size
+
+	// readSize is enough to hold a whole line, the counts have at most 10
+	// digits.
+	readSize = 128
+)
+
+// ErrInvalidHashFile is returned when the file doesn't look like a Pwned
+// Passwords SHA-1 file.
+var ErrInvalidHashFile = errors.New("invalid hash file")
+
+// HashFile is a Pwned Passwords SHA-1 file ordered by hash. It's safe for
+// concurrent use.
+type HashFile struct {
+	file io.ReaderAt
+	size int64
+
+	// closer is nil for the files not opened by Open.
+	closer io.Closer
+}
+
+// Open opens the hash file at path.
+func Open(path string) (*HashFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening hash file: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error opening hash file: %w", err)
+	}
+
+	h, err := NewHashFile(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	h.closer = f
+
+	return h, nil
+}
+
+// NewHashFile returns a hash file reading size bytes from r.
+func NewHashFile(r io.ReaderAt, size int64) (*HashFile, error) {
+	h := &HashFile{file: r, size: size}
+
+	// checking the first line catches the wrong files, like the NTLM list or
+	// the one ordered by prevalence, which can't be searched
+	_, line, err := h.lineAt(0)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := parseLine(line); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+func (h *HashFile) Close() error {
+	if h.closer == nil {
+		return nil
+	}
+	return h.closer.Close()
+}
+
+// Count returns how many times password was seen in breaches, zero when it
+// wasn't.
+func (h *HashFile) Count(password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	return h.CountHash(hex.EncodeToString(sum[:]))
+}
+
+// CountHash is Count for the SHA-1 hex hash of a password.
+func (h *HashFile) CountHash(hash string) (int, error) {
+	if len(hash) != hashLength {
+		return 0, fmt.Errorf("invalid SHA-1 hash %q", hash)
+	}
+	target := []byte(strings.ToUpper(hash))
+
+	// the line of the target, if any, starts in [lo, hi)
+	lo, hi := int64(0), h.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		start, line, err := h.lineAt(mid)
+		if err != nil {
+			return 0, err
+		}
+		if line == nil || start >= hi {
+			hi = mid
+			continue
+		}
+
+		lineHash, count, err := parseLine(line)
+		if err != nil {
+			return 0, err
+		}
+
+		switch c := bytes.Compare(lineHash, target); {
+		case c == 0:
+			return count, nil
+		case c < 0:
+			lo = start + int64(len(line))
+		default:
+			// no line starts in [mid, start)
+			hi = mid
+		}
+	}
+
+	return 0, nil
+}
+
+// lineAt returns the first line starting at or after offset, with its line
+// break, and where it starts. The line is nil past the last one.
+func (h *HashFile) lineAt(offset int64) (int64, []byte, error) {
+	start := offset
+	if offset > 0 {
+		// the line starts after the first line break from offset-1
+		buf, err := h.readAt(offset - 1)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			return h.size, nil, nil
+		}
+		start = offset + int64(i)
+	}
+
+	if start >= h.size {
+		return start, nil, nil
+	}
+
+	buf, err := h.readAt(start)
+	if err != nil {
+		return 0, nil, err
+	}
+	if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+		buf = buf[:i+1]
+	}
+
+	return start, buf, nil
+}
+
+func (h *HashFile) readAt(offset int64) ([]byte, error) {
+	buf := make([]byte, readSize)
+	n, err := h.file.ReadAt(buf, offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("error reading hash file: %w", err)
+	}
+	return buf[:n], nil
+}
+
+// parseLine splits a "<hash>:<count>" line. The count is optional, a hash
+// alone counts once.
+func parseLine(line []byte) ([]byte, int, error) {
+	line = bytes.TrimRight(line, "\r\n")
+
+	hash, rawCount, hasCount := bytes.Cut(line, []byte(":"))
+	if len(hash) != hashLength {
+		return nil, 0, fmt.Errorf("%w: unexpected line %q", ErrInvalidHashFile, line)
+	}
+	if _, err := hex.Decode(make([]byte, sha1.Size), hash); err != nil {
+		return nil, 0, fmt.Errorf("%w: unexpected line %q", ErrInvalidHashFile, line)
+	}
+
+	count := 1
+	if hasCount {
+		var err error
+		count, err = strconv.Atoi(string(rawCount))
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: unexpected line %q", ErrInvalidHashFile, line)
+		}
+	}
+
+	return bytes.ToUpper(hash), count, nil
+}
@@ -0,0 +1,125 @@
+package breach
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// writeHashFile writes the hashes of the passwords ordered by hash, like the
+// Pwned Passwords file, with the given counts and line break.
+func writeHashFile(t *testing.T, counts map[string]int, lineBreak string) string {
+	lines := make([]string, 0, len(counts))
+	for password, count := range counts {
+		if count == 0 {
+			lines = append(lines, sha1Hex(password))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s:%d", sha1Hex(password), count))
+	}
+	sort.Strings(lines)
+
+	path := filepath.Join(t.TempDir(), "pwned-passwords-sha1-ordered-by-hash.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, lineBreak)+lineBreak), 0o600))
+
+	return path
+}
+
+func TestOpen(t *testing.T) {
+	t.Run("returns error for files that aren't SHA-1 hash files", func(t *testing.T) {
+		for _, content := range []string{"", "password\n", "8846F7EAEE8FB117AD06BDD830B7586C:2\n"} {
+			path := filepath.Join(t.TempDir(), "hashes.txt")
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+			_, err := Open(path)
+			assert.ErrorIs(t, err, ErrInvalidHashFile, content)
+		}
+	})
+
+	t.Run("returns error for missing files", func(t *testing.T) {
+		_, err := Open(filepath.Join(t.TempDir(), "missing.txt"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestCount(t *testing.T) {
+	counts := map[string]int{
+		"password":    9545824,
+		"123456":      37359195,
+		"supersecret": 2419,
+		"qwerty":      10000000,
+		"letmein":     0,
+	}
+	for i := 0; i < 1000; i++ {
+		counts[fmt.Sprintf("breached-%d", i)] = i + 1
+	}
+
+	for _, lineBreak := range []string{"\n", "\r\n"} {
+		t.Run(fmt.Sprintf("line break %q", lineBreak), func(t *testing.T) {
+			h, err := Open(writeHashFile(t, counts, lineBreak))
+			require.NoError(t, err)
+			t.Cleanup(func() { h.Close() })
+
+			t.Run("🎉 finds every breached password", func(t *testing.T) {
+				for password, count := range counts {
+					if count == 0 {
+						count = 1
+					}
+
+					found, err := h.Count(password)
+					require.NoError(t, err)
+					assert.Equal(t, count, found, password)
+				}
+			})
+
+			t.Run("🎉 doesn't find the other passwords", func(t *testing.T) {
+				for _, password := range []string{"kX9#mQ2$vL7!pR4@", "breached-1000", ""} {
+					found, err := h.Count(password)
+					require.NoError(t, err)
+					assert.Zero(t, found, password)
+				}
+
+				// before the first and after the last hash
+				for _, hash := range []string{strings.Repeat("0", 40), strings.Repeat("f", 40)} {
+					found, err := h.CountHash(hash)
+					require.NoError(t, err)
+					assert.Zero(t, found, hash)
+				}
+			})
+		})
+	}
+
+	t.Run("🎉 searches a single line file", func(t *testing.T) {
+		h, err := Open(writeHashFile(t, map[string]int{"password": 3}, "\n"))
+		require.NoError(t, err)
+
+		found, err := h.Count("password")
+		require.NoError(t, err)
+		assert.Equal(t, 3, found)
+
+		found, err = h.Count("supersecret")
+		require.NoError(t, err)
+		assert.Zero(t, found)
+	})
+
+	t.Run("returns error for invalid hashes", func(t *testing.T) {
+		h, err := Open(writeHashFile(t, map[string]int{"password": 3}, "\n"))
+		require.NoError(t, err)
+
+		_, err = h.CountHash("5BAA61E4")
+		assert.EqualError(t, err, `invalid SHA-1 hash "5BAA61E4"`)
+	})
+}
@@ -0,0 +1,173 @@
+// Package exporter writes the password cards in the formats of other password
+// managers.
+package exporter
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/otp"
+	"github.com/google/uuid"
+	"github.com/tobischo/gokeepasslib/v3"
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+)
+
+// KDBXParams are the Argon2d parameters deriving the key of the exported
+// KeePass databases from their password.
+type KDBXParams struct {
+	Iterations uint64
+	// Memory is in bytes.
+	Memory      uint64
+	Parallelism uint32
+}
+
+// DefaultKDBXParams returns parameters close to the KeePassXC defaults, they
+// take about a second to derive the key.
+func DefaultKDBXParams() KDBXParams {
+	return KDBXParams{
+		Iterations:  10,
+		Memory:      64 * 1024 * 1024,
+		Parallelism: 2,
+	}
+}
+
+// WriteKDBX writes the password cards as a KDBX 4 KeePass database encrypted
+// with ChaCha20 under a key derived from password. The folders are groups
+// nested in the root group, the cards are the entries of the group of their
+// folder, or of the root group when it isn't one of the folders. Both keep their
+// ID as UUID when it's one, so importing the database back in KeePass updates
+// the same entries. The custom fields are additional fields of the entries.
+func WriteKDBX(out io.Writer, password string, passwordCards []model.PasswordCard, folders []model.Folder, params KDBXParams) error {
+	db := gokeepasslib.NewDatabase(gokeepasslib.WithDatabaseKDBXVersion4())
+	db.Credentials = gokeepasslib.NewPasswordCredentials(password)
+
+	kdf := db.Header.FileHeaders.KdfParameters
+	kdf.Iterations = params.Iterations
+	kdf.Memory = params.Memory
+	kdf.Parallelism = params.Parallelism
+
+	db.Content.Meta.Generator = "password-manager"
+	db.Content.Meta.DatabaseName = "Passwords"
+
+	knownFolders := make(map[string]bool, len(folders))
+	for _, folder := range folders {
+		knownFolders[folder.ID] = true
+	}
+
+	entries := make(map[string][]gokeepasslib.Entry)
+	for _, passwordCard := range passwordCards {
+		folderID := passwordCard.FolderID
+		if !knownFolders[folderID] {
+			folderID = ""
+		}
+		entries[folderID] = append(entries[folderID], kdbxEntry(passwordCard))
+	}
+
+	subfolders := make(map[string][]model.Folder)
+	for _, folder := range folders {
+		parentID := folder.ParentID
+		if !knownFolders[parentID] {
+			parentID = ""
+		}
+		subfolders[parentID] = append(subfolders[parentID], folder)
+	}
+
+	var group func(folderID string) []gokeepasslib.Group
+	group = func(folderID string) []gokeepasslib.Group {
+		groups := make([]gokeepasslib.Group, 0, len(subfolders[folderID]))
+		for _, folder := range subfolders[folderID] {
+			g := gokeepasslib.NewGroup()
+			if id, err := uuid.Parse(folder.ID); err == nil {
+				g.UUID = gokeepasslib.UUID(id)
+			}
+			g.Name = folder.Name
+			g.Entries = entries[folder.ID]
+			g.Groups = group(folder.ID)
+			groups = append(groups, g)
+		}
+		return groups
+	}
+
+	root := gokeepasslib.NewGroup()
+	root.Name = "Passwords"
+	root.Entries = entries[""]
+	root.Groups = group("")
+	db.Content.Root = &gokeepasslib.RootData{Groups: []gokeepasslib.Group{root}}
+
+	if err := db.LockProtectedEntries(); err != nil {
+		return fmt.Errorf("error writing KeePass database: %w", err)
+	}
+
+	if err := gokeepasslib.NewEncoder(out).Encode(db); err != nil {
+		return fmt.Errorf("error writing KeePass database: %w", err)
+	}
+
+	return nil
+}
+
+func kdbxEntry(passwordCard model.PasswordCard) gokeepasslib.Entry {
+	entry := gokeepasslib.NewEntry()
+	if id, err := uuid.Parse(passwordCard.ID); err == nil {
+		entry.UUID = gokeepasslib.UUID(id)
+	}
+
+	if !passwordCard.CreatedAt.IsZero() {
+		entry.Times.CreationTime = &w.TimeWrapper{Time: passwordCard.CreatedAt}
+	}
+	if !passwordCard.UpdatedAt.IsZero() {
+		entry.Times.LastModificationTime = &w.TimeWrapper{Time: passwordCard.UpdatedAt}
+	}
+
+	entry.Values = append(entry.Values,
+		gokeepasslib.ValueData{Key: "Title", Value: gokeepasslib.V{Content: passwordCard.Name}},
+		gokeepasslib.ValueData{Key: "UserName", Value: gokeepasslib.V{Content: passwordCard.Username}},
+		gokeepasslib.ValueData{Key: "Password", Value: gokeepasslib.V{Content: passwordCard.Password, Protected: w.NewBoolWrapper(true)}},
+		gokeepasslib.ValueData{Key: "URL", Value: gokeepasslib.V{Content: passwordCard.URL}},
+	)
+	if passwordCard.Notes != "" {
+		entry.Values = append(entry.Values, gokeepasslib.ValueData{Key: "Notes", Value: gokeepasslib.V{Content: passwordCard.Notes, Protected: w.NewBoolWrapper(true)}})
+	}
+
+	// KeePass has no field types, the hidden fields are the protected ones,
+	// and the names of the standard fields are taken
+	taken := map[string]bool{"Title": true, "UserName": true, "Password": true, "URL": true, "Notes": true, "otp": true}
+	for _, field := range passwordCard.CustomFields {
+		key := field.Name
+		for n := 2; taken[key]; n++ {
+			key = fmt.Sprintf("%s (%d)", field.Name, n)
+		}
+		taken[key] = true
+
+		value := gokeepasslib.V{Content: field.Value}
+		if field.Type == model.CustomFieldHidden {
+			value.Protected = w.NewBoolWrapper(true)
+		}
+		entry.Values = append(entry.Values, gokeepasslib.ValueData{Key: key, Value: value})
+	}
+
+	// KeePassXC reads the TOTP keys from otpauth URIs, the bare secrets are
+	// labelled with the card
+	if key, err := otp.ParseTOTP(passwordCard.TOTP); passwordCard.TOTP != "" && err == nil {
+		if key.Issuer == "" {
+			key.Issuer = passwordCard.Name
+		}
+		if key.AccountName == "" {
+			key.AccountName = passwordCard.Username
+		}
+		entry.Values = append(entry.Values, gokeepasslib.ValueData{Key: "otp", Value: gokeepasslib.V{Content: key.URI(), Protected: w.NewBoolWrapper(true)}})
+	} else if key, err := otp.ParseHOTP(passwordCard.HOTP); passwordCard.HOTP != "" && err == nil {
+		// the field holds a single key, the HOTP one is only written for the
+		// cards without TOTP, with the counter of its next code
+		key.Counter = passwordCard.HOTPCounter
+		if key.Issuer == "" {
+			key.Issuer = passwordCard.Name
+		}
+		if key.AccountName == "" {
+			key.AccountName = passwordCard.Username
+		}
+		entry.Values = append(entry.Values, gokeepasslib.ValueData{Key: "otp", Value: gokeepasslib.V{Content: key.URI(), Protected: w.NewBoolWrapper(true)}})
+	}
+
+	return entry
+}
@@ -0,0 +1,106 @@
+package exporter
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tobischo/gokeepasslib/v3"
+)
+
+// testKDBXParams are cheap parameters, the default ones take too long for
+// tests.
+var testKDBXParams = KDBXParams{Iterations: 1, Memory: 64 * 1024, Parallelism: 1}
+
+func TestWriteKDBX(t *testing.T) {
+	createdAt := time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+	passwordCards := []model.PasswordCard{
+		{ID: "5ec0d6d5-6f48-4ab5-ab6d-6ad2e9a8fa5a", Name: "AWS", Username: "admin", Password: "supersecret", URL: "https://aws.com/login", TOTP: "JBSWY3DPEHPK3PXP", CreatedAt: createdAt, UpdatedAt: createdAt.Add(time.Hour), CustomFields: []model.CustomField{
+			{Name: "Account ID", Type: model.CustomFieldText, Value: "123456789012"},
+			{Name: "PIN", Type: model.CustomFieldHidden, Value: "4321"},
+			{Name: "URL", Type: model.CustomFieldLink, Value: "https://console.aws.com/"},
+		}},
+		{ID: "card-id-2", Name: "GCP", Username: "ops", Password: "anothersecret", URL: "https://cloud.google.com/", Notes: "billing account 42"},
+		{ID: "card-id-3", Name: "VPN", Username: "admin", Password: "vpnsecret", URL: "https://vpn.example.com/", HOTP: "JBSWY3DPEHPK3PXP", HOTPCounter: 7, FolderID: "unknown-folder-id"},
+		{ID: "card-id-4", Name: "S3", Username: "ops", Password: "thirdsecret", URL: "https://s3.amazonaws.com/", FolderID: "folder-id-2"},
+	}
+	folders := []model.Folder{
+		{ID: "folder-id-1", Name: "Work"},
+		{ID: "folder-id-2", Name: "Cloud", ParentID: "folder-id-1"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteKDBX(&buf, "master", passwordCards, folders, testKDBXParams))
+
+	t.Run("🎉 writes a KDBX 4 database encrypted with ChaCha20 and Argon2d", func(t *testing.T) {
+		db := gokeepasslib.NewDatabase()
+		db.Credentials = gokeepasslib.NewPasswordCredentials("master")
+		require.NoError(t, gokeepasslib.NewDecoder(bytes.NewReader(buf.Bytes())).Decode(db))
+		require.NoError(t, db.UnlockProtectedEntries())
+
+		assert.True(t, db.Header.IsKdbx4())
+		assert.Equal(t, gokeepasslib.CipherChaCha20, db.Header.FileHeaders.CipherID)
+		assert.Equal(t, gokeepasslib.KdfArgon2, db.Header.FileHeaders.KdfParameters.UUID)
+		assert.Equal(t, uint64(64*1024), db.Header.FileHeaders.KdfParameters.Memory)
+
+		require.Len(t, db.Content.Root.Groups, 1)
+		entries := db.Content.Root.Groups[0].Entries
+		require.Len(t, entries, 3)
+
+		assert.Equal(t, "5ec0d6d5-6f48-4ab5-ab6d-6ad2e9a8fa5a", uuid.UUID(entries[0].UUID).String())
+		assert.Equal(t, "AWS", entries[0].GetTitle())
+		assert.Equal(t, "admin", entries[0].GetContent("UserName"))
+		assert.Equal(t, "supersecret", entries[0].GetPassword())
+		assert.Equal(t, "https://aws.com/login", entries[0].GetContent("URL"))
+		assert.True(t, createdAt.Equal(entries[0].Times.CreationTime.Time))
+		assert.True(t, createdAt.Add(time.Hour).Equal(entries[0].Times.LastModificationTime.Time))
+
+		assert.Equal(t, "otpauth://totp/AWS:admin?algorithm=SHA1&digits=6&issuer=AWS&period=30&secret=JBSWY3DPEHPK3PXP", entries[0].GetContent("otp"))
+
+		// the custom fields don't replace the standard ones
+		assert.Equal(t, "123456789012", entries[0].GetContent("Account ID"))
+		assert.False(t, entries[0].Get("Account ID").Value.Protected.Bool)
+		assert.Equal(t, "4321", entries[0].GetContent("PIN"))
+		assert.True(t, entries[0].Get("PIN").Value.Protected.Bool)
+		assert.Equal(t, "https://console.aws.com/", entries[0].GetContent("URL (2)"))
+
+		assert.Equal(t, "GCP", entries[1].GetTitle())
+		assert.Equal(t, "anothersecret", entries[1].GetPassword())
+		assert.Nil(t, entries[1].Get("otp"))
+		assert.Equal(t, "billing account 42", entries[1].GetContent("Notes"))
+
+		// the counter of the next code is written
+		assert.Equal(t, "otpauth://hotp/VPN:admin?algorithm=SHA1&counter=7&digits=6&issuer=VPN&secret=JBSWY3DPEHPK3PXP", entries[2].GetContent("otp"))
+	})
+
+	t.Run("🎉 writes the folders as nested groups", func(t *testing.T) {
+		db := gokeepasslib.NewDatabase()
+		db.Credentials = gokeepasslib.NewPasswordCredentials("master")
+		require.NoError(t, gokeepasslib.NewDecoder(bytes.NewReader(buf.Bytes())).Decode(db))
+		require.NoError(t, db.UnlockProtectedEntries())
+
+		root := db.Content.Root.Groups[0]
+		require.Len(t, root.Groups, 1)
+		work := root.Groups[0]
+		assert.Equal(t, "Work", work.Name)
+		assert.Empty(t, work.Entries)
+
+		require.Len(t, work.Groups, 1)
+		cloud := work.Groups[0]
+		assert.Equal(t, "Cloud", cloud.Name)
+		require.Len(t, cloud.Entries, 1)
+		assert.Equal(t, "S3", cloud.Entries[0].GetTitle())
+		assert.Equal(t, "thirdsecret", cloud.Entries[0].GetPassword())
+	})
+
+	t.Run("can't be read without the password", func(t *testing.T) {
+		db := gokeepasslib.NewDatabase()
+		db.Credentials = gokeepasslib.NewPasswordCredentials("wrong")
+		assert.Error(t, gokeepasslib.NewDecoder(bytes.NewReader(buf.Bytes())).Decode(db))
+		assert.NotContains(t, buf.String(), "supersecret")
+	})
+}
@@ -0,0 +1,125 @@
+package generator
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MinWords and MaxWords bound the number of words of the passphrases.
+	MinWords = 3
+	MaxWords = 20
+
+	// DefaultWords is the number of words of the passphrases generated with
+	// DefaultPassphraseOptions.
+	DefaultWords = 6
+
+	// MaxSeparatorLength is the longest separator between the words.
+	MaxSeparatorLength = 8
+)
+
+// effLargeWordlist is the EFF large wordlist, one "<dice rolls>\t<word>" line
+// per word. It's published by the Electronic Frontier Foundation under the
+// CC BY 3.0 US license:
+// https://www.eff.org/deeplinks/2016/07/new-wordlists-random-passphrases
+//
+//go:embed eff_large_wordlist.txt
+var effLargeWordlist string
+
+// Wordlist holds the words the passphrases are made of. The words are
+// distinct, so every one of them adds log2(len(Wordlist)) bits of entropy.
+var Wordlist = parseWordlist(effLargeWordlist)
+
+func parseWordlist(raw string) []string {
+	lines := strings.Split(strings.TrimSpace(raw), "\n")
+
+	words := make([]string, 0, len(lines))
+	for _, line := range lines {
+		_, word, ok := strings.Cut(line, "\t")
+		if !ok || word == "" {
+			panic(fmt.Sprintf("generator: invalid wordlist line %q", line))
+		}
+		words = append(words, word)
+	}
+
+	return words
+}
+
+// PassphraseOptions configures the passphrases. The zero value is invalid,
+// start from DefaultPassphraseOptions.
+type PassphraseOptions struct {
+	Words     int    `query:"words"`
+	Separator string `query:"separator"`
+	// Capitalize upper cases the first letter of every word.
+	Capitalize bool `query:"capitalize"`
+	// AppendDigit appends a random digit to the passphrase.
+	AppendDigit bool `query:"append_digit"`
+}
+
+// DefaultPassphraseOptions returns options for a 6 words passphrase separated
+// by "-".
+func DefaultPassphraseOptions() PassphraseOptions {
+	return PassphraseOptions{
+		Words:     DefaultWords,
+		Separator: "-",
+	}
+}
+
+func (o *PassphraseOptions) Validate() error {
+	if o.Words < MinWords || o.Words > MaxWords {
+		return fmt.Errorf("words must be between %d and %d", MinWords, MaxWords)
+	}
+
+	if utf8.RuneCountInString(o.Separator) > MaxSeparatorLength {
+		return fmt.Errorf("separator can't be longer than %d characters", MaxSeparatorLength)
+	}
+
+	return nil
+}
+
+// Entropy returns the bits of entropy of the passphrases generated with the
+// options. Only the random choices count: the separator and the
+// capitalization are known to an attacker who knows the options.
+func (o *PassphraseOptions) Entropy() float64 {
+	bits := float64(o.Words) * math.Log2(float64(len(Wordlist)))
+	if o.AppendDigit {
+		bits += math.Log2(float64(len(Digits)))
+	}
+	return bits
+}
+
+// GeneratePassphrase returns a new passphrase following the options.
+func GeneratePassphrase(o PassphraseOptions) (string, error) {
+	if err := o.Validate(); err != nil {
+		return "", err
+	}
+
+	words := make([]string, 0, o.Words)
+	for i := 0; i < o.Words; i++ {
+		j, err := randomInt(len(Wordlist))
+		if err != nil {
+			return "", err
+		}
+
+		word := Wordlist[j]
+		if o.Capitalize {
+			word = strings.ToUpper(word[:1]) + word[1:]
+		}
+		words = append(words, word)
+	}
+
+	passphrase := strings.Join(words, o.Separator)
+
+	if o.AppendDigit {
+		digit, err := randomChar(Digits)
+		if err != nil {
+			return "", err
+		}
+		passphrase += string(digit)
+	}
+
+	return passphrase, nil
+}
@@ -0,0 +1,112 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWordlist(t *testing.T) {
+	require.Len(t, Wordlist, 7776)
+	assert.Equal(t, "abacus", Wordlist[0])
+	assert.Equal(t, "zoom", Wordlist[len(Wordlist)-1])
+
+	seen := make(map[string]bool, len(Wordlist))
+	for _, word := range Wordlist {
+		assert.False(t, seen[word], word)
+		seen[word] = true
+	}
+}
+
+func TestPassphraseOptionsValidate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		modify func(o *PassphraseOptions)
+		err    string
+	}{
+		{
+			name:   "too few words",
+			modify: func(o *PassphraseOptions) { o.Words = 2 },
+			err:    "words must be between 3 and 20",
+		},
+		{
+			name:   "too many words",
+			modify: func(o *PassphraseOptions) { o.Words = 21 },
+			err:    "words must be between 3 and 20",
+		},
+		{
+			name:   "long separator",
+			modify: func(o *PassphraseOptions) { o.Separator = "---------" },
+			err:    "separator can't be longer than 8 characters",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := DefaultPassphraseOptions()
+			tc.modify(&o)
+
+			assert.EqualError(t, o.Validate(), tc.err)
+
+			_, err := GeneratePassphrase(o)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestPassphraseEntropy(t *testing.T) {
+	o := DefaultPassphraseOptions()
+	assert.InDelta(t, 77.55, o.Entropy(), 0.01)
+
+	o.Capitalize = true
+	assert.InDelta(t, 77.55, o.Entropy(), 0.01)
+
+	o.AppendDigit = true
+	assert.InDelta(t, 80.87, o.Entropy(), 0.01)
+}
+
+func TestGeneratePassphrase(t *testing.T) {
+	words := make(map[string]bool, len(Wordlist))
+	for _, word := range Wordlist {
+		words[word] = true
+	}
+
+	t.Run("🎉 generates passphrases with the default options", func(t *testing.T) {
+		passphrase, err := GeneratePassphrase(DefaultPassphraseOptions())
+		require.NoError(t, err)
+
+		// a few words hold the separator, they are matched as pairs
+		parts := strings.Split(passphrase, "-")
+		assert.GreaterOrEqual(t, len(parts), DefaultWords)
+		for i := 0; i < len(parts); i++ {
+			if !words[parts[i]] && i+1 < len(parts) && words[parts[i]+"-"+parts[i+1]] {
+				i++
+				continue
+			}
+			assert.True(t, words[parts[i]], passphrase)
+		}
+
+		another, err := GeneratePassphrase(DefaultPassphraseOptions())
+		require.NoError(t, err)
+		assert.NotEqual(t, passphrase, another)
+	})
+
+	t.Run("🎉 capitalizes and appends a digit", func(t *testing.T) {
+		o := PassphraseOptions{Words: 4, Separator: " ", Capitalize: true, AppendDigit: true}
+
+		passphrase, err := GeneratePassphrase(o)
+		require.NoError(t, err)
+
+		last := passphrase[len(passphrase)-1:]
+		assert.Contains(t, Digits, last)
+
+		parts := strings.Split(passphrase[:len(passphrase)-1], " ")
+		require.Len(t, parts, 4)
+		for _, part := range parts {
+			assert.Equal(t, strings.ToUpper(part[:1]), part[:1], passphrase)
+			assert.True(t, words[strings.ToLower(part)], passphrase)
+		}
+	})
+}
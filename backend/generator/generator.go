@@ -0,0 +1,285 @@
+// Package generator generates random passwords and diceware passphrases.
+//
+// Every random choice is read from crypto/rand, so the passwords are as
+// unpredictable as the operating system's random source. The characters are
+// picked with rejection sampling, none of them is more likely than the others
+// of its set.
+package generator
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	// MinLength and MaxLength bound the length of the generated passwords.
+	MinLength = 4
+	MaxLength = 128
+
+	// DefaultLength is the length of the passwords generated with
+	// DefaultOptions.
+	DefaultLength = 20
+)
+
+// Character sets of the password classes.
+const (
+	Lowercase = "abcdefghijklmnopqrstuvwxyz"
+	Uppercase = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	Digits    = "0123456789"
+	Symbols   = "!@#$%^&*()-_=+[]{};:,.<>?/~|"
+
+	// Ambiguous holds the characters that are easily mistaken for one another
+	// when a password is read or typed.
+	Ambiguous = "Il1O0o|"
+)
+
+// Letters of the pronounceable passwords.
+const (
+	consonants = "bcdfghjklmnprstvwxz"
+	vowels     = "aeiou"
+)
+
+// Options configures the passwords. The zero value is invalid, start from
+// DefaultOptions.
+type Options struct {
+	Length int `query:"length"`
+
+	// The classes of characters the password may hold.
+	Lowercase bool `query:"lowercase"`
+	Uppercase bool `query:"uppercase"`
+	Digits    bool `query:"digits"`
+	Symbols   bool `query:"symbols"`
+
+	// ExcludeAmbiguous leaves out the characters of Ambiguous.
+	ExcludeAmbiguous bool `query:"exclude_ambiguous"`
+
+	// The minimum number of characters of each class. A class with a minimum
+	// must be enabled.
+	MinLowercase int `query:"min_lowercase"`
+	MinUppercase int `query:"min_uppercase"`
+	MinDigits    int `query:"min_digits"`
+	MinSymbols   int `query:"min_symbols"`
+
+	// Pronounceable alternates consonants and vowels, e.g. "kabodimu". Only
+	// MinUppercase letters are capitalized, or all of them without Lowercase,
+	// and MinDigits digits followed by MinSymbols symbols are appended. Digits
+	// and Symbols alone add nothing.
+	Pronounceable bool `query:"pronounceable"`
+}
+
+// DefaultOptions returns options for a 20 characters password of every class.
+func DefaultOptions() Options {
+	return Options{
+		Length:    DefaultLength,
+		Lowercase: true,
+		Uppercase: true,
+		Digits:    true,
+		Symbols:   true,
+	}
+}
+
+type class struct {
+	name    string
+	enabled bool
+	min     int
+	chars   string
+}
+
+func (o *Options) classes() []class {
+	return []class{
+		{name: "lowercase", enabled: o.Lowercase, min: o.MinLowercase, chars: o.filter(Lowercase)},
+		{name: "uppercase", enabled: o.Uppercase, min: o.MinUppercase, chars: o.filter(Uppercase)},
+		{name: "digits", enabled: o.Digits, min: o.MinDigits, chars: o.filter(Digits)},
+		{name: "symbols", enabled: o.Symbols, min: o.MinSymbols, chars: o.filter(Symbols)},
+	}
+}
+
+// filter removes the ambiguous characters from chars when they are excluded.
+func (o *Options) filter(chars string) string {
+	if !o.ExcludeAmbiguous {
+		return chars
+	}
+
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(Ambiguous, r) {
+			return -1
+		}
+		return r
+	}, chars)
+}
+
+func (o *Options) Validate() error {
+	if o.Length < MinLength || o.Length > MaxLength {
+		return fmt.Errorf("length must be between %d and %d", MinLength, MaxLength)
+	}
+
+	enabled, total := false, 0
+	for _, c := range o.classes() {
+		if c.min < 0 {
+			return fmt.Errorf("min_%s can't be negative", c.name)
+		}
+		if c.min > 0 && !c.enabled {
+			return fmt.Errorf("min_%s requires %s to be enabled", c.name, c.name)
+		}
+
+		enabled = enabled || c.enabled
+		total += c.min
+	}
+
+	if !enabled {
+		return fmt.Errorf("at least one character class must be enabled")
+	}
+
+	if total > o.Length {
+		return fmt.Errorf("the minimum counts add up to more than the length")
+	}
+
+	if o.Pronounceable {
+		if !o.Lowercase && !o.Uppercase {
+			return fmt.Errorf("pronounceable passwords require lowercase or uppercase")
+		}
+		letters := o.Length - o.MinDigits - o.MinSymbols
+		if letters < 1 || o.MinUppercase > letters {
+			return fmt.Errorf("pronounceable passwords require at least one letter")
+		}
+	}
+
+	return nil
+}
+
+// Generate returns a new password following the options.
+func Generate(o Options) (string, error) {
+	if err := o.Validate(); err != nil {
+		return "", err
+	}
+
+	if o.Pronounceable {
+		return generatePronounceable(o)
+	}
+
+	password := make([]byte, 0, o.Length)
+	var all string
+	for _, c := range o.classes() {
+		if !c.enabled {
+			continue
+		}
+		all += c.chars
+
+		for i := 0; i < c.min; i++ {
+			char, err := randomChar(c.chars)
+			if err != nil {
+				return "", err
+			}
+			password = append(password, char)
+		}
+	}
+
+	for len(password) < o.Length {
+		char, err := randomChar(all)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, char)
+	}
+
+	// the characters of the minimum counts would be first otherwise
+	if err := shuffle(password); err != nil {
+		return "", err
+	}
+
+	return string(password), nil
+}
+
+func generatePronounceable(o Options) (string, error) {
+	letters := o.Length - o.MinDigits - o.MinSymbols
+
+	capitalize := o.MinUppercase
+	if !o.Lowercase {
+		capitalize = letters
+	}
+	positions, err := randomPositions(letters, capitalize)
+	if err != nil {
+		return "", err
+	}
+	capitalized := make([]bool, letters)
+	for _, i := range positions {
+		capitalized[i] = true
+	}
+
+	// the ambiguous characters are filtered once capitalized: "i" is fine but
+	// "I" isn't
+	sets := [2][2]string{
+		{o.filter(consonants), o.filter(strings.ToUpper(consonants))},
+		{o.filter(vowels), o.filter(strings.ToUpper(vowels))},
+	}
+
+	password := make([]byte, 0, o.Length)
+	for i := 0; i < letters; i++ {
+		set := sets[i%2][0]
+		if capitalized[i] {
+			set = sets[i%2][1]
+		}
+
+		char, err := randomChar(set)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, char)
+	}
+
+	for _, c := range o.classes()[2:] {
+		for i := 0; i < c.min; i++ {
+			char, err := randomChar(c.chars)
+			if err != nil {
+				return "", err
+			}
+			password = append(password, char)
+		}
+	}
+
+	return string(password), nil
+}
+
+// randomInt returns a uniform random integer in [0, n).
+func randomInt(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("error reading random source: %w", err)
+	}
+	return int(i.Int64()), nil
+}
+
+func randomChar(chars string) (byte, error) {
+	i, err := randomInt(len(chars))
+	if err != nil {
+		return 0, err
+	}
+	return chars[i], nil
+}
+
+// shuffle permutes b uniformly with the Fisher-Yates algorithm.
+func shuffle[T any](b []T) error {
+	for i := len(b) - 1; i > 0; i-- {
+		j, err := randomInt(i + 1)
+		if err != nil {
+			return err
+		}
+		b[i], b[j] = b[j], b[i]
+	}
+	return nil
+}
+
+// randomPositions returns k distinct random positions in [0, n).
+func randomPositions(n, k int) ([]int, error) {
+	positions := make([]int, n)
+	for i := range positions {
+		positions[i] = i
+	}
+	if err := shuffle(positions); err != nil {
+		return nil, err
+	}
+
+	return positions[:k], nil
+}
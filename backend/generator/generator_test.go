@@ -0,0 +1,192 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func count(password, chars string) int {
+	n := 0
+	for _, r := range password {
+		if strings.ContainsRune(chars, r) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestOptionsValidate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		modify func(o *Options)
+		err    string
+	}{
+		{
+			name:   "too short",
+			modify: func(o *Options) { o.Length = 3 },
+			err:    "length must be between 4 and 128",
+		},
+		{
+			name:   "too long",
+			modify: func(o *Options) { o.Length = 129 },
+			err:    "length must be between 4 and 128",
+		},
+		{
+			name: "no class",
+			modify: func(o *Options) {
+				o.Lowercase, o.Uppercase, o.Digits, o.Symbols = false, false, false, false
+			},
+			err: "at least one character class must be enabled",
+		},
+		{
+			name:   "negative minimum",
+			modify: func(o *Options) { o.MinDigits = -1 },
+			err:    "min_digits can't be negative",
+		},
+		{
+			name: "minimum of a disabled class",
+			modify: func(o *Options) {
+				o.Symbols = false
+				o.MinSymbols = 1
+			},
+			err: "min_symbols requires symbols to be enabled",
+		},
+		{
+			name: "minimums longer than the length",
+			modify: func(o *Options) {
+				o.Length = 4
+				o.MinLowercase, o.MinUppercase, o.MinDigits = 2, 2, 1
+			},
+			err: "the minimum counts add up to more than the length",
+		},
+		{
+			name: "pronounceable without letters",
+			modify: func(o *Options) {
+				o.Pronounceable = true
+				o.Lowercase, o.Uppercase = false, false
+			},
+			err: "pronounceable passwords require lowercase or uppercase",
+		},
+		{
+			name: "pronounceable with only digits",
+			modify: func(o *Options) {
+				o.Pronounceable = true
+				o.Length = 4
+				o.MinDigits = 4
+			},
+			err: "pronounceable passwords require at least one letter",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := DefaultOptions()
+			tc.modify(&o)
+
+			assert.EqualError(t, o.Validate(), tc.err)
+
+			_, err := Generate(o)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	t.Run("🎉 generates passwords with the default options", func(t *testing.T) {
+		password, err := Generate(DefaultOptions())
+		require.NoError(t, err)
+		assert.Len(t, password, DefaultLength)
+		assert.Equal(t, DefaultLength, count(password, Lowercase+Uppercase+Digits+Symbols))
+
+		another, err := Generate(DefaultOptions())
+		require.NoError(t, err)
+		assert.NotEqual(t, password, another)
+	})
+
+	t.Run("🎉 only uses the enabled classes", func(t *testing.T) {
+		o := DefaultOptions()
+		o.Uppercase, o.Symbols = false, false
+
+		for i := 0; i < 50; i++ {
+			password, err := Generate(o)
+			require.NoError(t, err)
+			assert.Equal(t, DefaultLength, count(password, Lowercase+Digits), password)
+		}
+	})
+
+	t.Run("🎉 honors the minimum counts", func(t *testing.T) {
+		o := DefaultOptions()
+		o.Length = 12
+		o.MinLowercase, o.MinUppercase, o.MinDigits, o.MinSymbols = 2, 3, 4, 3
+
+		for i := 0; i < 50; i++ {
+			password, err := Generate(o)
+			require.NoError(t, err)
+			assert.Equal(t, 2, count(password, Lowercase), password)
+			assert.Equal(t, 3, count(password, Uppercase), password)
+			assert.Equal(t, 4, count(password, Digits), password)
+			assert.Equal(t, 3, count(password, Symbols), password)
+		}
+	})
+
+	t.Run("🎉 excludes the ambiguous characters", func(t *testing.T) {
+		o := DefaultOptions()
+		o.Length = MaxLength
+		o.ExcludeAmbiguous = true
+
+		for i := 0; i < 20; i++ {
+			password, err := Generate(o)
+			require.NoError(t, err)
+			assert.Zero(t, count(password, Ambiguous), password)
+		}
+	})
+
+	t.Run("🎉 generates pronounceable passwords", func(t *testing.T) {
+		o := DefaultOptions()
+		o.Length = 12
+		o.Pronounceable = true
+		o.MinUppercase, o.MinDigits, o.MinSymbols = 1, 2, 1
+
+		for i := 0; i < 50; i++ {
+			password, err := Generate(o)
+			require.NoError(t, err)
+			require.Len(t, password, 12)
+
+			letters := strings.ToLower(password[:9])
+			for j, r := range letters {
+				if j%2 == 0 {
+					assert.Contains(t, consonants, string(r), password)
+				} else {
+					assert.Contains(t, vowels, string(r), password)
+				}
+			}
+			assert.Equal(t, 1, count(password, Uppercase), password)
+			assert.Equal(t, 2, count(password[9:11], Digits), password)
+			assert.Equal(t, 1, count(password[11:], Symbols), password)
+		}
+	})
+
+	t.Run("🎉 excludes the ambiguous characters of the capitalized pronounceable passwords", func(t *testing.T) {
+		for _, o := range []Options{
+			{Length: 16, Uppercase: true, Pronounceable: true, ExcludeAmbiguous: true},
+			{Length: 16, Lowercase: true, Uppercase: true, MinUppercase: 8, Pronounceable: true, ExcludeAmbiguous: true},
+		} {
+			for i := 0; i < 500; i++ {
+				password, err := Generate(o)
+				require.NoError(t, err)
+				assert.Zero(t, count(password, Ambiguous), password)
+			}
+		}
+	})
+
+	t.Run("🎉 generates uppercase pronounceable passwords", func(t *testing.T) {
+		o := Options{Length: 8, Uppercase: true, Pronounceable: true}
+
+		password, err := Generate(o)
+		require.NoError(t, err)
+		assert.Equal(t, 8, count(password, Uppercase), password)
+	})
+}
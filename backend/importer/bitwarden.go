@@ -0,0 +1,209 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+)
+
+// ErrEncryptedExport is returned for the exports encrypted by the password
+// manager itself, they must be exported unencrypted.
+var ErrEncryptedExport = errors.New("encrypted exports aren't supported, export the vault unencrypted")
+
+// Types of the items of the Bitwarden exports.
+const (
+	bitwardenLoginType      = 1
+	bitwardenSecureNoteType = 2
+	bitwardenCardType       = 3
+	bitwardenIdentityType   = 4
+	bitwardenSSHKeyType     = 5
+)
+
+// Types of the custom fields of the Bitwarden items. The linked fields point
+// to another field of the item, they have no value of their own.
+const (
+	bitwardenTextField    = 0
+	bitwardenHiddenField  = 1
+	bitwardenBooleanField = 2
+	bitwardenLinkedField  = 3
+)
+
+var bitwardenFieldTypes = map[int]model.CustomFieldType{
+	bitwardenTextField:    model.CustomFieldText,
+	bitwardenHiddenField:  model.CustomFieldHidden,
+	bitwardenBooleanField: model.CustomFieldBoolean,
+}
+
+type bitwardenExport struct {
+	Encrypted bool            `json:"encrypted"`
+	Items     []bitwardenItem `json:"items"`
+}
+
+type bitwardenItem struct {
+	Type   int    `json:"type"`
+	Name   string `json:"name"`
+	Notes  string `json:"notes"`
+	Fields []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+		Type  int    `json:"type"`
+	} `json:"fields"`
+	Login *struct {
+		URIs []struct {
+			URI string `json:"uri"`
+		} `json:"uris"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+		TOTP     string `json:"totp"`
+	} `json:"login"`
+	Card *struct {
+		CardholderName string `json:"cardholderName"`
+		Brand          string `json:"brand"`
+		Number         string `json:"number"`
+		ExpMonth       string `json:"expMonth"`
+		ExpYear        string `json:"expYear"`
+		Code           string `json:"code"`
+	} `json:"card"`
+	Identity *struct {
+		Title          string `json:"title"`
+		FirstName      string `json:"firstName"`
+		MiddleName     string `json:"middleName"`
+		LastName       string `json:"lastName"`
+		Company        string `json:"company"`
+		Email          string `json:"email"`
+		Phone          string `json:"phone"`
+		Address1       string `json:"address1"`
+		Address2       string `json:"address2"`
+		Address3       string `json:"address3"`
+		City           string `json:"city"`
+		State          string `json:"state"`
+		PostalCode     string `json:"postalCode"`
+		Country        string `json:"country"`
+		SSN            string `json:"ssn"`
+		PassportNumber string `json:"passportNumber"`
+		LicenseNumber  string `json:"licenseNumber"`
+	} `json:"identity"`
+	SSHKey *struct {
+		PrivateKey string `json:"privateKey"`
+		PublicKey  string `json:"publicKey"`
+	} `json:"sshKey"`
+}
+
+// ParseBitwardenJSON reads an unencrypted JSON export of Bitwarden. Every type
+// of item is mapped to the card type of the same kind, along with its custom
+// fields but the linked ones. The cards have a single URL, the first URI of
+// the logins, the other ones are custom fields. The Row of the entries is
+// their position in the items, starting at 1.
+func ParseBitwardenJSON(r io.Reader) ([]Entry, error) {
+	var export bitwardenExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("error reading Bitwarden export: %w", err)
+	}
+
+	if export.Encrypted {
+		return nil, ErrEncryptedExport
+	}
+	if export.Items == nil {
+		return nil, ErrUnknownFormat
+	}
+
+	entries := make([]Entry, 0, len(export.Items))
+	for i, item := range export.Items {
+		passwordCard, ok := bitwardenPasswordCard(item)
+
+		entry := newEntry(i+1, passwordCard)
+		if !ok {
+			entry.Err = ErrUnsupportedItem{Reason: fmt.Sprintf("items of type %d aren't supported", item.Type)}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// bitwardenPasswordCard maps an item to a card, it returns false for the
+// unknown types.
+func bitwardenPasswordCard(item bitwardenItem) (model.PasswordCard, bool) {
+	passwordCard := model.PasswordCard{Name: item.Name, Notes: item.Notes}
+	for _, field := range item.Fields {
+		if fieldType, ok := bitwardenFieldTypes[field.Type]; ok {
+			addCustomField(&passwordCard, model.CustomField{Name: field.Name, Type: fieldType, Value: field.Value})
+		}
+	}
+
+	switch item.Type {
+	case bitwardenLoginType:
+		if item.Login != nil {
+			passwordCard.Username = item.Login.Username
+			passwordCard.Password = item.Login.Password
+			passwordCard.TOTP = strings.TrimSpace(item.Login.TOTP)
+			if len(item.Login.URIs) > 0 {
+				passwordCard.URL = item.Login.URIs[0].URI
+			}
+			for _, uri := range item.Login.URIs {
+				if uri.URI != "" && uri.URI != passwordCard.URL {
+					addURLField(&passwordCard, "", uri.URI)
+				}
+			}
+		}
+	case bitwardenSecureNoteType:
+		passwordCard.Type = model.ItemTypeSecureNote
+	case bitwardenCardType:
+		passwordCard.Type = model.ItemTypeCreditCard
+		if card := item.Card; card != nil {
+			// the invalid dates are left to the validation
+			expiryMonth, _ := strconv.Atoi(card.ExpMonth)
+			expiryYear, _ := strconv.Atoi(card.ExpYear)
+			passwordCard.CreditCard = &model.CreditCard{
+				CardholderName: card.CardholderName,
+				Brand:          card.Brand,
+				Number:         card.Number,
+				ExpiryMonth:    expiryMonth,
+				ExpiryYear:     expiryYear,
+				Code:           card.Code,
+			}
+		}
+	case bitwardenIdentityType:
+		passwordCard.Type = model.ItemTypeIdentity
+		if identity := item.Identity; identity != nil {
+			var address []string
+			for _, line := range []string{identity.Address1, identity.Address2, identity.Address3} {
+				if line != "" {
+					address = append(address, line)
+				}
+			}
+			passwordCard.Identity = &model.Identity{
+				Title:          identity.Title,
+				FirstName:      identity.FirstName,
+				MiddleName:     identity.MiddleName,
+				LastName:       identity.LastName,
+				Company:        identity.Company,
+				Email:          identity.Email,
+				Phone:          identity.Phone,
+				Address:        strings.Join(address, "\n"),
+				City:           identity.City,
+				State:          identity.State,
+				PostalCode:     identity.PostalCode,
+				Country:        identity.Country,
+				SSN:            identity.SSN,
+				PassportNumber: identity.PassportNumber,
+				LicenseNumber:  identity.LicenseNumber,
+			}
+		}
+	case bitwardenSSHKeyType:
+		passwordCard.Type = model.ItemTypeSSHKey
+		if item.SSHKey != nil {
+			passwordCard.SSHKey = &model.SSHKey{PrivateKey: item.SSHKey.PrivateKey, PublicKey: item.SSHKey.PublicKey}
+		}
+	default:
+		return passwordCard, false
+	}
+
+	return passwordCard, true
+}
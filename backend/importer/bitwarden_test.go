@@ -0,0 +1,111 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBitwardenJSON(t *testing.T) {
+	t.Run("🎉 parses every type of item", func(t *testing.T) {
+		format, entries, err := Parse([]byte(`
+			{
+				"encrypted": false,
+				"folders": [],
+				"items": [
+					{
+						"id": "1b3e5c0a-5d1f-4d6e-9a43-b07f0135c71a",
+						"type": 1,
+						"name": "AWS",
+						"notes": "root account",
+						"fields": [
+							{"name": "Account ID", "value": "123456789012", "type": 0, "linkedId": null},
+							{"name": "PIN", "value": "4321", "type": 1, "linkedId": null},
+							{"name": "MFA enforced", "value": "true", "type": 2, "linkedId": null},
+							{"name": "Login", "value": null, "type": 3, "linkedId": 100}
+						],
+						"login": {
+							"uris": [
+								{"match": null, "uri": "https://aws.com/login"},
+								{"match": null, "uri": "https://console.aws.com/"},
+								{"match": null, "uri": "aws.amazon.com"}
+							],
+							"username": "admin",
+							"password": "supersecret",
+							"totp": "JBSWY3DPEHPK3PXP"
+						}
+					},
+					{"id": "2c4f6d1b-6e20-4e7f-8b54-c18f0246d82b", "type": 2, "name": "Wifi", "notes": "fridge", "secureNote": {"type": 0}},
+					{"id": "3d5a7e2c-7f31-4f80-9c65-d29a1357e93c", "type": 1, "name": "No URL", "login": {"username": "me", "password": "secret"}},
+					{
+						"id": "4e6b8f3d-8042-4091-ad76-e3ab2468fa4d",
+						"type": 3,
+						"name": "Visa",
+						"card": {"cardholderName": "Jane Doe", "brand": "Visa", "number": "4111111111111111", "expMonth": "12", "expYear": "2030", "code": "123"}
+					},
+					{
+						"id": "5f7c9a4e-9153-41a2-be87-f4bc3579ab5e",
+						"type": 4,
+						"name": "Me",
+						"identity": {"firstName": "Jane", "lastName": "Doe", "email": "jane@example.com", "address1": "1 Main St", "address2": "Apt 2", "address3": null, "ssn": "123-45-6789"}
+					},
+					{"id": "6a8dab5f-a264-42b3-8f98-a5cd468abc6f", "type": 5, "name": "Deploy key", "sshKey": {"privateKey": "not a key", "publicKey": "", "keyFingerprint": ""}},
+					{"id": "7b9ebc6a-b375-43c4-9a09-b6de579bcd7a", "type": 9, "name": "Unknown"}
+				]
+			}
+		`), "")
+		require.NoError(t, err)
+		assert.Equal(t, FormatBitwardenJSON, format)
+
+		passwordCards, errs := withoutIDs(t, entries)
+		assert.Equal(t, []model.PasswordCard{
+			{
+				Name:     "AWS",
+				URL:      "https://aws.com/login",
+				Username: "admin",
+				Password: "supersecret",
+				TOTP:     "JBSWY3DPEHPK3PXP",
+				Notes:    "root account",
+				CustomFields: []model.CustomField{
+					{Name: "Account ID", Type: model.CustomFieldText, Value: "123456789012"},
+					{Name: "PIN", Type: model.CustomFieldHidden, Value: "4321"},
+					{Name: "MFA enforced", Type: model.CustomFieldBoolean, Value: "true"},
+					{Name: "URL", Type: model.CustomFieldLink, Value: "https://console.aws.com/"},
+					{Name: "URL (2)", Type: model.CustomFieldText, Value: "aws.amazon.com"},
+				},
+			},
+			{Type: model.ItemTypeSecureNote, Name: "Wifi", Notes: "fridge"},
+			{Name: "No URL", Username: "me", Password: "secret"},
+			{
+				Type:       model.ItemTypeCreditCard,
+				Name:       "Visa",
+				CreditCard: &model.CreditCard{CardholderName: "Jane Doe", Brand: "Visa", Number: "4111111111111111", ExpiryMonth: 12, ExpiryYear: 2030, Code: "123"},
+			},
+			{
+				Type:     model.ItemTypeIdentity,
+				Name:     "Me",
+				Identity: &model.Identity{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com", Address: "1 Main St\nApt 2", SSN: "123-45-6789"},
+			},
+			{Type: model.ItemTypeSSHKey, Name: "Deploy key", SSHKey: &model.SSHKey{PrivateKey: "not a key"}},
+			{Name: "Unknown"},
+		}, passwordCards)
+		assert.Equal(t, []string{"", "", "invalid URL", "", "", "invalid private key: ssh: no key found", "items of type 9 aren't supported"}, errs)
+		assert.ErrorAs(t, entries[6].Err, &ErrUnsupportedItem{})
+		assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7}, []int{entries[0].Row, entries[1].Row, entries[2].Row, entries[3].Row, entries[4].Row, entries[5].Row, entries[6].Row})
+	})
+
+	t.Run("returns error for encrypted exports", func(t *testing.T) {
+		_, _, err := Parse([]byte(`{"encrypted": true, "passwordProtected": true, "data": "2.abc"}`), "")
+		assert.ErrorIs(t, err, ErrEncryptedExport)
+	})
+
+	t.Run("returns error for other JSON files", func(t *testing.T) {
+		_, _, err := Parse([]byte(`{"name": "package"}`), "")
+		assert.ErrorIs(t, err, ErrUnknownFormat)
+
+		_, _, err = Parse([]byte(`{"items": `), "")
+		assert.ErrorContains(t, err, "error reading Bitwarden export")
+	})
+}
@@ -0,0 +1,186 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+)
+
+// csvLayout is the layout of the CSV exports of an application.
+type csvLayout struct {
+	format Format
+	// required are the columns telling the layout apart from the others.
+	required []string
+	// The columns mapped to the card, name and totp are empty when there's
+	// none.
+	name, url, username, password, totp string
+	// check returns an error for the rows that aren't logins. It may be nil.
+	check func(row map[string]string) error
+}
+
+// csvLayouts are tried in order, the most specific first: the Chrome columns
+// are a subset of the LastPass ones.
+var csvLayouts = []csvLayout{
+	{
+		format:   FormatBitwarden,
+		required: []string{"type", "name", "login_uri", "login_username", "login_password"},
+		name:     "name",
+		url:      "login_uri",
+		username: "login_username",
+		password: "login_password",
+		totp:     "login_totp",
+		check: func(row map[string]string) error {
+			if row["type"] != "login" {
+				return ErrUnsupportedItem{Reason: fmt.Sprintf("items of type %q aren't supported", row["type"])}
+			}
+			return nil
+		},
+	},
+	{
+		format:   FormatLastPass,
+		required: []string{"url", "username", "password", "extra", "name", "grouping"},
+		name:     "name",
+		url:      "url",
+		username: "username",
+		password: "password",
+		totp:     "totp",
+		check: func(row map[string]string) error {
+			// the secure notes are exported with this fake URL
+			if row["url"] == "http://sn" {
+				return ErrUnsupportedItem{Reason: "secure notes aren't supported"}
+			}
+			return nil
+		},
+	},
+	{
+		format:   FormatFirefox,
+		required: []string{"url", "username", "password", "httprealm", "formactionorigin"},
+		url:      "url",
+		username: "username",
+		password: "password",
+	},
+	{
+		format:   FormatChrome,
+		required: []string{"name", "url", "username", "password"},
+		name:     "name",
+		url:      "url",
+		username: "username",
+		password: "password",
+	},
+}
+
+// DetectCSVFormat returns the format of a CSV export from its header.
+func DetectCSVFormat(header []string) (Format, error) {
+	layout, err := detectCSVLayout(normalizeHeader(header))
+	if err != nil {
+		return "", err
+	}
+	return layout.format, nil
+}
+
+func detectCSVLayout(header []string) (*csvLayout, error) {
+	columns := make(map[string]bool, len(header))
+	for _, column := range header {
+		columns[column] = true
+	}
+
+	for i := range csvLayouts {
+		matches := true
+		for _, column := range csvLayouts[i].required {
+			matches = matches && columns[column]
+		}
+		if matches {
+			return &csvLayouts[i], nil
+		}
+	}
+
+	return nil, ErrUnknownFormat
+}
+
+func normalizeHeader(header []string) []string {
+	normalized := make([]string, 0, len(header))
+	for i, column := range header {
+		if i == 0 {
+			// Excel saves UTF-8 files with a byte order mark
+			column = strings.TrimPrefix(column, "\ufeff")
+		}
+		normalized = append(normalized, strings.ToLower(strings.TrimSpace(column)))
+	}
+	return normalized
+}
+
+// ParseCSV reads a CSV export of Chrome, Firefox, Bitwarden or LastPass, the
+// format is detected from the header. It only fails when the file itself
+// can't be read, the invalid rows are returned with their error.
+func ParseCSV(r io.Reader) (Format, []Entry, error) {
+	reader := csv.NewReader(r)
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return "", nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("error reading CSV header: %w", err)
+	}
+	header = normalizeHeader(header)
+
+	layout, err := detectCSVLayout(header)
+	if err != nil {
+		return "", nil, err
+	}
+
+	entries := make([]Entry, 0)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		// a row with a wrong number of fields is still returned
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return "", nil, fmt.Errorf("error reading CSV: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		row := make(map[string]string, len(header))
+		for i, column := range header {
+			if i < len(record) {
+				row[column] = record[i]
+			}
+		}
+
+		entry := newEntry(line, model.PasswordCard{
+			Name:     row[layout.name],
+			URL:      firstURL(layout, row[layout.url]),
+			Username: row[layout.username],
+			Password: row[layout.password],
+			TOTP:     strings.TrimSpace(row[layout.totp]),
+		})
+
+		switch {
+		case err != nil:
+			entry.Err = fmt.Errorf("row has %d fields, the header has %d", len(record), len(header))
+		case layout.check != nil:
+			if err := layout.check(row); err != nil {
+				entry.Err = err
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return layout.format, entries, nil
+}
+
+// firstURL returns the first URL of the column, Bitwarden joins the URLs of
+// an item with commas.
+func firstURL(layout *csvLayout, value string) string {
+	if layout.format == FormatBitwarden {
+		value, _, _ = strings.Cut(value, ",")
+	}
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,135 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withoutIDs returns the cards of the entries without their random IDs, and
+// the entry errors.
+func withoutIDs(t *testing.T, entries []Entry) ([]model.PasswordCard, []string) {
+	passwordCards := make([]model.PasswordCard, 0, len(entries))
+	errs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		assert.Len(t, entry.PasswordCard.ID, 36)
+
+		entry.PasswordCard.ID = ""
+		passwordCards = append(passwordCards, entry.PasswordCard)
+
+		if entry.Err != nil {
+			errs = append(errs, entry.Err.Error())
+		} else {
+			errs = append(errs, "")
+		}
+	}
+	return passwordCards, errs
+}
+
+func TestDetectCSVFormat(t *testing.T) {
+	testCases := []struct {
+		header []string
+		format Format
+	}{
+		{[]string{"name", "url", "username", "password"}, FormatChrome},
+		{[]string{"name", "url", "username", "password", "note"}, FormatChrome},
+		{[]string{"url", "username", "password", "httpRealm", "formActionOrigin", "guid", "timeCreated", "timeLastUsed", "timePasswordChanged"}, FormatFirefox},
+		{[]string{"folder", "favorite", "type", "name", "notes", "fields", "reprompt", "login_uri", "login_username", "login_password", "login_totp"}, FormatBitwarden},
+		{[]string{"url", "username", "password", "totp", "extra", "name", "grouping", "fav"}, FormatLastPass},
+		{[]string{"\ufeffName", " URL", "Username", "Password"}, FormatChrome},
+	}
+
+	for _, tc := range testCases {
+		format, err := DetectCSVFormat(tc.header)
+		require.NoError(t, err)
+		assert.Equal(t, tc.format, format, tc.header)
+	}
+
+	_, err := DetectCSVFormat([]string{"title", "login", "secret"})
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestParseCSV(t *testing.T) {
+	t.Run("🎉 parses Chrome exports", func(t *testing.T) {
+		format, entries, err := ParseCSV(strings.NewReader("name,url,username,password,note\n" +
+			"AWS,https://aws.com/login,admin,supersecret,\n" +
+			",https://cloud.google.com/,ops,anothersecret,\"multi\nline\"\n" +
+			"GitHub,https://github.com/,,secret,\n"))
+		require.NoError(t, err)
+		assert.Equal(t, FormatChrome, format)
+
+		passwordCards, errs := withoutIDs(t, entries)
+		assert.Equal(t, []model.PasswordCard{
+			{Name: "AWS", URL: "https://aws.com/login", Username: "admin", Password: "supersecret"},
+			{Name: "cloud.google.com", URL: "https://cloud.google.com/", Username: "ops", Password: "anothersecret"},
+			{Name: "GitHub", URL: "https://github.com/", Password: "secret"},
+		}, passwordCards)
+		assert.Equal(t, []string{"", "", "username can't be empty"}, errs)
+		assert.Equal(t, []int{2, 3, 5}, []int{entries[0].Row, entries[1].Row, entries[2].Row})
+	})
+
+	t.Run("🎉 parses Firefox exports", func(t *testing.T) {
+		format, entries, err := ParseCSV(strings.NewReader(`"url","username","password","httpRealm","formActionOrigin","guid","timeCreated","timeLastUsed","timePasswordChanged"
+"https://aws.com","admin","supersecret",,"https://aws.com","{5ec0d6d5-6f48-4ab5-ab6d-6ad2e9a8fa5a}","1690000000000","1690000000000","1690000000000"
+`))
+		require.NoError(t, err)
+		assert.Equal(t, FormatFirefox, format)
+
+		passwordCards, errs := withoutIDs(t, entries)
+		assert.Equal(t, []model.PasswordCard{
+			{Name: "aws.com", URL: "https://aws.com", Username: "admin", Password: "supersecret"},
+		}, passwordCards)
+		assert.Equal(t, []string{""}, errs)
+	})
+
+	t.Run("🎉 parses Bitwarden exports", func(t *testing.T) {
+		format, entries, err := ParseCSV(strings.NewReader("folder,favorite,type,name,notes,fields,reprompt,login_uri,login_username,login_password,login_totp\n" +
+			"Work,1,login,AWS,,,0,\"https://aws.com/login,https://console.aws.com/\",admin,supersecret,JBSWY3DPEHPK3PXP\n" +
+			",,note,Wifi,the password is on the fridge,,0,,,,\n"))
+		require.NoError(t, err)
+		assert.Equal(t, FormatBitwarden, format)
+
+		passwordCards, errs := withoutIDs(t, entries)
+		assert.Equal(t, model.PasswordCard{Name: "AWS", URL: "https://aws.com/login", Username: "admin", Password: "supersecret", TOTP: "JBSWY3DPEHPK3PXP"}, passwordCards[0])
+		assert.Equal(t, []string{"", `items of type "note" aren't supported`}, errs)
+	})
+
+	t.Run("🎉 parses LastPass exports", func(t *testing.T) {
+		format, entries, err := ParseCSV(strings.NewReader("url,username,password,totp,extra,name,grouping,fav\n" +
+			"https://aws.com/login,admin,supersecret,otpauth://totp/AWS:admin?secret=JBSWY3DPEHPK3PXP,,AWS,Work,0\n" +
+			"http://sn,,,,NoteType:Server,Server,,0\n" +
+			"https://github.com/,me,secret\n"))
+		require.NoError(t, err)
+		assert.Equal(t, FormatLastPass, format)
+
+		passwordCards, errs := withoutIDs(t, entries)
+		assert.Equal(t, model.PasswordCard{Name: "AWS", URL: "https://aws.com/login", Username: "admin", Password: "supersecret", TOTP: "otpauth://totp/AWS:admin?secret=JBSWY3DPEHPK3PXP"}, passwordCards[0])
+		assert.Equal(t, []string{"", "secure notes aren't supported", "row has 3 fields, the header has 8"}, errs)
+	})
+
+	t.Run("🎉 keeps the HOTP keys apart", func(t *testing.T) {
+		_, entries, err := ParseCSV(strings.NewReader("url,username,password,totp,extra,name,grouping,fav\n" +
+			"https://vpn.example.com/,admin,supersecret,otpauth://hotp/VPN:admin?secret=JBSWY3DPEHPK3PXP&counter=3,,VPN,Work,0\n"))
+		require.NoError(t, err)
+
+		passwordCards, errs := withoutIDs(t, entries)
+		assert.Equal(t, model.PasswordCard{Name: "VPN", URL: "https://vpn.example.com/", Username: "admin", Password: "supersecret", HOTP: "otpauth://hotp/VPN:admin?secret=JBSWY3DPEHPK3PXP&counter=3"}, passwordCards[0])
+		assert.Equal(t, []string{""}, errs)
+	})
+
+	t.Run("returns error for unknown formats", func(t *testing.T) {
+		_, _, err := ParseCSV(strings.NewReader("title,login,secret\n"))
+		assert.ErrorIs(t, err, ErrUnknownFormat)
+
+		_, _, err = ParseCSV(strings.NewReader(""))
+		assert.ErrorIs(t, err, ErrUnknownFormat)
+	})
+
+	t.Run("returns error for malformed files", func(t *testing.T) {
+		_, _, err := ParseCSV(strings.NewReader("name,url,username,password\n\"AWS,https://aws.com,admin,secret\n"))
+		assert.ErrorContains(t, err, "error reading CSV")
+	})
+}
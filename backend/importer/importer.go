@@ -0,0 +1,175 @@
+// Package importer reads the exports of browsers and other password managers
+// and maps their entries to password cards.
+//
+// The importers never fail on a single bad entry: every entry is returned with
+// its own error, so the valid ones can still be imported and the others
+// reported.
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/google/uuid"
+)
+
+// Format names the application that exported a file.
+type Format string
+
+// Supported formats.
+const (
+	FormatChrome        Format = "chrome"
+	FormatFirefox       Format = "firefox"
+	FormatBitwarden     Format = "bitwarden"
+	FormatBitwardenJSON Format = "bitwarden_json"
+	FormatLastPass      Format = "lastpass"
+	FormatKeePass       Format = "keepass"
+	FormatOnePassword   Format = "1password"
+)
+
+// ErrUnknownFormat is returned when a file doesn't match any supported format.
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// ErrUnsupportedItem is the error of the entries that aren't logins, like the
+// secure notes. They are skipped rather than invalid.
+type ErrUnsupportedItem struct {
+	Reason string
+}
+
+// Error implements error type interface.
+func (e ErrUnsupportedItem) Error() string {
+	return e.Reason
+}
+
+// Entry is an entry of an export mapped to a password card.
+type Entry struct {
+	// Row is the line where the entry starts in the text files, or its
+	// position in the other ones, starting at 1.
+	Row          int
+	PasswordCard model.PasswordCard
+	// Folder is the path of the folder of the entry from the root, e.g.
+	// ["Work", "Cloud"], empty for the entries out of any folder.
+	Folder []string
+	// Err is set when the entry can't be imported, the card then only holds
+	// what could be read of it.
+	Err error
+}
+
+// Parse detects the format of an export and returns its entries. The password
+// is only used by the encrypted formats.
+func Parse(data []byte, password string) (Format, []Entry, error) {
+	switch {
+	case IsKDBX(data):
+		entries, err := ParseKDBX(bytes.NewReader(data), password)
+		return FormatKeePass, entries, err
+	case Is1PUX(data):
+		entries, err := Parse1PUX(bytes.NewReader(data), int64(len(data)))
+		return FormatOnePassword, entries, err
+	case isJSON(data):
+		entries, err := ParseBitwardenJSON(bytes.NewReader(data))
+		return FormatBitwardenJSON, entries, err
+	}
+
+	return ParseCSV(bytes.NewReader(data))
+}
+
+// isJSON tells whether data looks like a JSON object. No CSV header starts
+// with a brace.
+func isJSON(data []byte) bool {
+	data = bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\ufeff")), " \t\r\n")
+	return bytes.HasPrefix(data, []byte("{"))
+}
+
+// newEntry returns the entry of a card read from row, with a new ID. The name
+// defaults to the host of the URL, not every format has one.
+func newEntry(row int, passwordCard model.PasswordCard) Entry {
+	passwordCard.Name = strings.TrimSpace(passwordCard.Name)
+	if passwordCard.Name == "" {
+		passwordCard.Name = hostname(passwordCard.URL)
+	}
+
+	// the formats keep every one-time password key in the same field, the
+	// counter based ones are told apart by their URI
+	if strings.HasPrefix(strings.ToLower(passwordCard.TOTP), "otpauth://hotp") {
+		passwordCard.HOTP = passwordCard.TOTP
+		passwordCard.TOTP = ""
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return Entry{Row: row, PasswordCard: passwordCard, Err: fmt.Errorf("error generating ID: %w", err)}
+	}
+	passwordCard.ID = id.String()
+
+	return Entry{Row: row, PasswordCard: passwordCard, Err: passwordCard.Validate()}
+}
+
+func hostname(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Hostname() == "" {
+		return strings.TrimSpace(rawURL)
+	}
+	return u.Hostname()
+}
+
+// folderName returns the name of an imported folder, cut to the longest name
+// the folders accept. The names left empty are skipped.
+func folderName(name string) string {
+	name = strings.TrimSpace(name)
+
+	// the length is counted in characters, the cut falls on the start of one
+	n := 0
+	for i := range name {
+		if n == model.MaxFolderNameLength {
+			return strings.TrimSpace(name[:i])
+		}
+		n++
+	}
+	return name
+}
+
+// addURLField appends an additional URL of an entry as a link custom field
+// named after its label, "URL" by default. The URLs without a scheme, which
+// the links don't accept, are text fields.
+func addURLField(passwordCard *model.PasswordCard, label, value string) {
+	name := strings.TrimSpace(label)
+	if name == "" {
+		name = "URL"
+	}
+
+	field := model.CustomField{Name: name, Type: model.CustomFieldLink, Value: value}
+	if u, err := url.Parse(value); err != nil || u.Scheme == "" {
+		field.Type = model.CustomFieldText
+	}
+
+	addCustomField(passwordCard, field)
+}
+
+// addCustomField appends a custom field to the card. The names must be unique
+// in a card and not every format has them, so the missing ones are "Field"
+// and the taken ones get a number, e.g. "PIN (2)".
+func addCustomField(passwordCard *model.PasswordCard, field model.CustomField) {
+	name := strings.TrimSpace(field.Name)
+	if name == "" {
+		name = "Field"
+	}
+
+	taken := func(name string) bool {
+		for _, customField := range passwordCard.CustomFields {
+			if customField.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+	field.Name = name
+	for n := 2; taken(field.Name); n++ {
+		field.Name = fmt.Sprintf("%s (%d)", name, n)
+	}
+
+	passwordCard.CustomFields = append(passwordCard.CustomFields, field)
+}
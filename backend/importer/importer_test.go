@@ -0,0 +1,26 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFolderName(t *testing.T) {
+	t.Run("🎉 trims the names", func(t *testing.T) {
+		assert.Equal(t, "Work", folderName("  Work "))
+		assert.Empty(t, folderName(" "))
+	})
+
+	t.Run("🎉 cuts the long names counting the characters", func(t *testing.T) {
+		name := strings.Repeat("ü", model.MaxFolderNameLength)
+		assert.Equal(t, name, folderName(name))
+
+		cut := folderName(name + "ber")
+		assert.Equal(t, name, cut)
+		assert.Equal(t, model.MaxFolderNameLength, utf8.RuneCountInString(cut))
+	})
+}
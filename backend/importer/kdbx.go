@@ -0,0 +1,177 @@
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/tobischo/gokeepasslib/v3"
+)
+
+// ErrInvalidKDBX is returned when a KeePass database can't be decrypted,
+// either because the password is wrong or because the file is corrupted: the
+// format can't tell them apart.
+var ErrInvalidKDBX = errors.New("invalid KeePass database or password")
+
+// ErrKDBXTooCostly is returned when deriving the key of a KeePass database
+// would take more time or memory than the bounds below allow.
+var ErrKDBXTooCostly = errors.New("the key derivation of the KeePass database is too costly")
+
+// The bounds of the key derivation parameters of the imported databases, the
+// Argon2 ones match the bounds of the backups. Anyone can upload a database,
+// one past them would tie up the server.
+const (
+	maxKDBXIterations  = 10
+	maxKDBXMemory      = 256 * 1024 * 1024 // bytes
+	maxKDBXParallelism = 16
+	maxKDBXRounds      = 100_000_000
+)
+
+// kdbxStandardFields are the fields of the KeePass entries mapped to the
+// fields of the cards, the others are custom fields.
+var kdbxStandardFields = map[string]bool{
+	"Title":    true,
+	"UserName": true,
+	"Password": true,
+	"URL":      true,
+	"Notes":    true,
+	"otp":      true,
+}
+
+// kdbxSignature starts every KeePass 2 database.
+var kdbxSignature = []byte{0x03, 0xd9, 0xa2, 0x9a, 0x67, 0xfb, 0x4b, 0xb5}
+
+// IsKDBX tells whether data starts like a KeePass 2 database.
+func IsKDBX(data []byte) bool {
+	return bytes.HasPrefix(data, kdbxSignature)
+}
+
+// ParseKDBX decrypts a KeePass database, KDBX 4 or 3.1, with its password and
+// returns its entries. The key derivation parameters are checked before the
+// key is derived, the costly ones return ErrKDBXTooCostly. The groups are the
+// folders of the entries, except the root group whose entries are out of any
+// folder, and the recycle bin whose entries are left out. The fields of the
+// entries other than the standard ones are custom fields. The Row of the
+// entries is their position in the order of the tree, starting at 1.
+func ParseKDBX(r io.Reader, password string) ([]Entry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidKDBX, err)
+	}
+
+	if err := checkKDBXCost(data); err != nil {
+		return nil, err
+	}
+
+	db := gokeepasslib.NewDatabase()
+	db.Credentials = gokeepasslib.NewPasswordCredentials(password)
+	if err := gokeepasslib.NewDecoder(bytes.NewReader(data)).Decode(db); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidKDBX, err)
+	}
+	if err := db.UnlockProtectedEntries(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidKDBX, err)
+	}
+
+	var recycleBin gokeepasslib.UUID
+	if db.Content.Meta.RecycleBinEnabled.Bool {
+		recycleBin = db.Content.Meta.RecycleBinUUID
+	}
+
+	entries := make([]Entry, 0)
+	var walk func(group gokeepasslib.Group, folder []string)
+	walk = func(group gokeepasslib.Group, folder []string) {
+		if group.UUID.Compare(recycleBin) {
+			return
+		}
+
+		for _, kdbxEntry := range group.Entries {
+			passwordCard := model.PasswordCard{
+				Name:     kdbxEntry.GetTitle(),
+				URL:      kdbxEntry.GetContent("URL"),
+				Username: kdbxEntry.GetContent("UserName"),
+				Password: kdbxEntry.GetPassword(),
+				// KeePassXC keeps the TOTP keys as otpauth URIs
+				TOTP:  strings.TrimSpace(kdbxEntry.GetContent("otp")),
+				Notes: kdbxEntry.GetContent("Notes"),
+			}
+			// the protected fields are the hidden ones
+			for _, value := range kdbxEntry.Values {
+				if kdbxStandardFields[value.Key] {
+					continue
+				}
+				fieldType := model.CustomFieldText
+				if value.Value.Protected.Bool {
+					fieldType = model.CustomFieldHidden
+				}
+				addCustomField(&passwordCard, model.CustomField{Name: value.Key, Type: fieldType, Value: value.Value.Content})
+			}
+
+			entry := newEntry(len(entries)+1, passwordCard)
+			entry.Folder = folder
+			entries = append(entries, entry)
+		}
+
+		// the groups without a name are merged in their parent
+		for _, subgroup := range group.Groups {
+			subfolder := folder
+			if name := folderName(subgroup.Name); name != "" {
+				subfolder = append(folder[:len(folder):len(folder)], name)
+			}
+			walk(subgroup, subfolder)
+		}
+	}
+	for _, root := range db.Content.Root.Groups {
+		walk(root, nil)
+	}
+
+	return entries, nil
+}
+
+// checkKDBXCost reads the header of a KeePass database and returns
+// ErrKDBXTooCostly when its key derivation parameters are past the bounds.
+func checkKDBXCost(data []byte) error {
+	// the decoder reads the header before it fails on the missing
+	// credentials, the key isn't derived
+	db := gokeepasslib.NewDatabase()
+	db.Credentials = nil
+	err := gokeepasslib.NewDecoder(bytes.NewReader(data)).Decode(db)
+	var errMissing gokeepasslib.ErrRequiredAttributeMissing
+	if !errors.As(err, &errMissing) {
+		return fmt.Errorf("%w: %v", ErrInvalidKDBX, err)
+	}
+
+	fileHeaders := db.Header.FileHeaders
+	if !db.Header.IsKdbx4() {
+		if fileHeaders.TransformRounds > maxKDBXRounds {
+			return fmt.Errorf("%w: %d rounds", ErrKDBXTooCostly, fileHeaders.TransformRounds)
+		}
+		return nil
+	}
+
+	kdf := fileHeaders.KdfParameters
+	if kdf == nil {
+		return fmt.Errorf("%w: missing key derivation parameters", ErrInvalidKDBX)
+	}
+	if !bytes.Equal(kdf.UUID[:], gokeepasslib.KdfArgon2) {
+		if kdf.Rounds > maxKDBXRounds {
+			return fmt.Errorf("%w: %d rounds", ErrKDBXTooCostly, kdf.Rounds)
+		}
+		return nil
+	}
+
+	switch {
+	case kdf.Iterations < 1 || kdf.Parallelism < 1:
+		return fmt.Errorf("%w: invalid Argon2 parameters", ErrInvalidKDBX)
+	case kdf.Iterations > maxKDBXIterations:
+		return fmt.Errorf("%w: %d iterations", ErrKDBXTooCostly, kdf.Iterations)
+	case kdf.Memory > maxKDBXMemory:
+		return fmt.Errorf("%w: %d bytes of memory", ErrKDBXTooCostly, kdf.Memory)
+	case kdf.Parallelism > maxKDBXParallelism:
+		return fmt.Errorf("%w: %d lanes", ErrKDBXTooCostly, kdf.Parallelism)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tobischo/gokeepasslib/v3"
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+)
+
+func kdbxTestEntry(title, username, password, url string) gokeepasslib.Entry {
+	entry := gokeepasslib.NewEntry()
+	entry.Values = append(entry.Values,
+		gokeepasslib.ValueData{Key: "Title", Value: gokeepasslib.V{Content: title}},
+		gokeepasslib.ValueData{Key: "UserName", Value: gokeepasslib.V{Content: username}},
+		gokeepasslib.ValueData{Key: "Password", Value: gokeepasslib.V{Content: password, Protected: w.NewBoolWrapper(true)}},
+		gokeepasslib.ValueData{Key: "URL", Value: gokeepasslib.V{Content: url}},
+	)
+	return entry
+}
+
+// newTestKDBX returns a KDBX 4 database with nested groups and a recycle bin,
+// derived with Argon2 using little memory.
+func newTestKDBX(t *testing.T, password string, iterations uint64) []byte {
+	db := gokeepasslib.NewDatabase(gokeepasslib.WithDatabaseKDBXVersion4())
+	db.Credentials = gokeepasslib.NewPasswordCredentials(password)
+	db.Header.FileHeaders.KdfParameters.Memory = 64 * 1024
+	db.Header.FileHeaders.KdfParameters.Iterations = iterations
+
+	work := gokeepasslib.NewGroup()
+	work.Name = "Work"
+	work.Entries = append(work.Entries, kdbxTestEntry("GCP", "ops", "anothersecret", "https://cloud.google.com/"))
+
+	cloud := gokeepasslib.NewGroup()
+	cloud.Name = "Cloud"
+	cloud.Entries = append(cloud.Entries, kdbxTestEntry("S3", "ops", "thirdsecret", "https://s3.amazonaws.com/"))
+	work.Groups = append(work.Groups, cloud)
+
+	recycleBin := gokeepasslib.NewGroup()
+	recycleBin.Name = "Recycle Bin"
+	recycleBin.Entries = append(recycleBin.Entries, kdbxTestEntry("Old", "me", "oldsecret", "https://old.com/"))
+
+	aws := kdbxTestEntry("AWS", "admin", "supersecret", "https://aws.com/login")
+	aws.Values = append(aws.Values,
+		gokeepasslib.ValueData{Key: "otp", Value: gokeepasslib.V{Content: "otpauth://totp/AWS:admin?secret=JBSWY3DPEHPK3PXP", Protected: w.NewBoolWrapper(true)}},
+		gokeepasslib.ValueData{Key: "Account ID", Value: gokeepasslib.V{Content: "123456789012"}},
+		gokeepasslib.ValueData{Key: "PIN", Value: gokeepasslib.V{Content: "4321", Protected: w.NewBoolWrapper(true)}},
+	)
+
+	root := gokeepasslib.NewGroup()
+	root.Name = "Root"
+	root.Entries = append(root.Entries, aws, kdbxTestEntry("Wifi", "", "fridge", ""))
+	root.Groups = append(root.Groups, work, recycleBin)
+
+	db.Content.Root = &gokeepasslib.RootData{Groups: []gokeepasslib.Group{root}}
+	db.Content.Meta.RecycleBinEnabled = w.NewBoolWrapper(true)
+	db.Content.Meta.RecycleBinUUID = recycleBin.UUID
+
+	require.NoError(t, db.LockProtectedEntries())
+
+	var buf bytes.Buffer
+	require.NoError(t, gokeepasslib.NewEncoder(&buf).Encode(db))
+
+	return buf.Bytes()
+}
+
+func TestParseKDBX(t *testing.T) {
+	data := newTestKDBX(t, "master", 1)
+
+	t.Run("🎉 parses the entries of every group but the recycle bin", func(t *testing.T) {
+		format, entries, err := Parse(data, "master")
+		require.NoError(t, err)
+		assert.Equal(t, FormatKeePass, format)
+
+		passwordCards, errs := withoutIDs(t, entries)
+		assert.Equal(t, []model.PasswordCard{
+			{
+				Name:     "AWS",
+				URL:      "https://aws.com/login",
+				Username: "admin",
+				Password: "supersecret",
+				TOTP:     "otpauth://totp/AWS:admin?secret=JBSWY3DPEHPK3PXP",
+				CustomFields: []model.CustomField{
+					{Name: "Account ID", Type: model.CustomFieldText, Value: "123456789012"},
+					{Name: "PIN", Type: model.CustomFieldHidden, Value: "4321"},
+				},
+			},
+			{Name: "Wifi", Password: "fridge"},
+			{Name: "GCP", URL: "https://cloud.google.com/", Username: "ops", Password: "anothersecret"},
+			{Name: "S3", URL: "https://s3.amazonaws.com/", Username: "ops", Password: "thirdsecret"},
+		}, passwordCards)
+		assert.Equal(t, []string{"", "username can't be empty", "", ""}, errs)
+		assert.Equal(t, []int{1, 2, 3, 4}, []int{entries[0].Row, entries[1].Row, entries[2].Row, entries[3].Row})
+		// the entries of the root group are out of any folder
+		assert.Equal(t, [][]string{nil, nil, {"Work"}, {"Work", "Cloud"}}, [][]string{entries[0].Folder, entries[1].Folder, entries[2].Folder, entries[3].Folder})
+	})
+
+	t.Run("returns error for wrong passwords", func(t *testing.T) {
+		_, _, err := Parse(data, "wrong")
+		assert.ErrorIs(t, err, ErrInvalidKDBX)
+	})
+
+	t.Run("returns error for corrupted databases", func(t *testing.T) {
+		_, err := ParseKDBX(bytes.NewReader(data[:200]), "master")
+		assert.ErrorIs(t, err, ErrInvalidKDBX)
+
+		_, err = ParseKDBX(bytes.NewReader(data[:20]), "master")
+		assert.ErrorIs(t, err, ErrInvalidKDBX)
+	})
+
+	t.Run("returns error for costly key derivations", func(t *testing.T) {
+		_, err := ParseKDBX(bytes.NewReader(newTestKDBX(t, "master", maxKDBXIterations)), "master")
+		require.NoError(t, err)
+
+		costly := newTestKDBX(t, "master", maxKDBXIterations+1)
+		_, err = ParseKDBX(bytes.NewReader(costly), "master")
+		assert.ErrorIs(t, err, ErrKDBXTooCostly)
+
+		// the header is read before the key is derived, the memory is
+		// changed in place rather than derived
+		costly = newTestKDBX(t, "master", 2)
+		setKDBXMemory(t, costly, maxKDBXMemory+1024)
+		_, err = ParseKDBX(bytes.NewReader(costly), "master")
+		assert.ErrorIs(t, err, ErrKDBXTooCostly)
+	})
+}
+
+// setKDBXMemory replaces the Argon2 memory of a database made by newTestKDBX.
+func setKDBXMemory(t *testing.T, data []byte, memory uint64) {
+	// the memory is the uint64 entry "M" of the KDF parameters dictionary
+	pattern := binary.LittleEndian.AppendUint64([]byte{0x05, 0x01, 0, 0, 0, 'M', 0x08, 0, 0, 0}, 64*1024)
+	i := bytes.Index(data, pattern)
+	require.GreaterOrEqual(t, i, 0)
+
+	binary.LittleEndian.PutUint64(data[i+len(pattern)-8:], memory)
+}
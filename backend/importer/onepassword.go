@@ -0,0 +1,213 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+)
+
+// zipSignature starts every zip archive, like the 1PUX exports.
+var zipSignature = []byte("PK\x03\x04")
+
+// max1PUXDataSize is the largest uncompressed export.data read from a 1PUX
+// export. The archive is compressed, its size doesn't bound what it holds.
+const max1PUXDataSize = 32 * 1024 * 1024
+
+// Err1PUXTooLarge is returned when the items of a 1PUX export are larger than
+// max1PUXDataSize once uncompressed.
+var Err1PUXTooLarge = errors.New("the items of the 1Password export can't take more than 32 MiB")
+
+// Is1PUX tells whether data looks like a 1Password 1PUX export, a zip archive.
+func Is1PUX(data []byte) bool {
+	return bytes.HasPrefix(data, zipSignature)
+}
+
+// Categories of the 1Password items holding a password.
+const (
+	onePasswordLoginCategory    = "001"
+	onePasswordPasswordCategory = "005"
+)
+
+var onePasswordCategoryNames = map[string]string{
+	"002": "credit card",
+	"003": "secure note",
+	"004": "identity",
+	"006": "document",
+	"100": "software license",
+	"101": "bank account",
+	"102": "database",
+	"103": "driver license",
+	"104": "outdoor license",
+	"105": "membership",
+	"106": "passport",
+	"107": "rewards program",
+	"108": "social security number",
+	"109": "wireless router",
+	"110": "server",
+	"111": "email account",
+	"112": "API credential",
+	"113": "medical record",
+	"114": "SSH key",
+}
+
+type onePasswordExport struct {
+	Accounts []struct {
+		Vaults []struct {
+			Items []onePasswordItem `json:"items"`
+		} `json:"vaults"`
+	} `json:"accounts"`
+}
+
+type onePasswordItem struct {
+	State        string `json:"state"`
+	CategoryUUID string `json:"categoryUuid"`
+	Details      struct {
+		LoginFields []struct {
+			Value       string `json:"value"`
+			Designation string `json:"designation"`
+		} `json:"loginFields"`
+		Password   string `json:"password"`
+		NotesPlain string `json:"notesPlain"`
+		Sections   []struct {
+			Fields []struct {
+				Title string `json:"title"`
+				Value struct {
+					TOTP      string  `json:"totp"`
+					String    *string `json:"string"`
+					Concealed *string `json:"concealed"`
+					URL       *string `json:"url"`
+				} `json:"value"`
+			} `json:"fields"`
+		} `json:"sections"`
+	} `json:"details"`
+	Overview struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+		URLs  []struct {
+			Label string `json:"label"`
+			URL   string `json:"url"`
+		} `json:"urls"`
+	} `json:"overview"`
+}
+
+// Parse1PUX reads a 1Password 1PUX export of size bytes. The items of every
+// vault are returned, the archived ones are skipped. The exports holding more
+// than max1PUXDataSize of items return Err1PUXTooLarge. The cards have a single
+// URL, the main URL of the items, the other ones are custom fields named
+// after their label. The Row of the entries is their position in the export,
+// starting at 1.
+func Parse1PUX(r io.ReaderAt, size int64) ([]Entry, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("error reading 1Password export: %w", err)
+	}
+
+	data, err := archive.Open("export.data")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading 1Password export: %w", err)
+	}
+	defer data.Close()
+
+	// the size of the archive entry can lie, the read is bounded too
+	info, err := data.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("error reading 1Password export: %w", err)
+	}
+	if info.Size() > max1PUXDataSize {
+		return nil, Err1PUXTooLarge
+	}
+
+	content, err := io.ReadAll(io.LimitReader(data, max1PUXDataSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading 1Password export: %w", err)
+	}
+	if len(content) > max1PUXDataSize {
+		return nil, Err1PUXTooLarge
+	}
+
+	var export onePasswordExport
+	if err := json.Unmarshal(content, &export); err != nil {
+		return nil, fmt.Errorf("error reading 1Password export: %w", err)
+	}
+
+	entries := make([]Entry, 0)
+	for _, account := range export.Accounts {
+		for _, vault := range account.Vaults {
+			for _, item := range vault.Items {
+				entries = append(entries, onePasswordEntry(len(entries)+1, item))
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+func onePasswordEntry(row int, item onePasswordItem) Entry {
+	passwordCard := model.PasswordCard{
+		Name:     item.Overview.Title,
+		URL:      item.Overview.URL,
+		Password: item.Details.Password,
+		Notes:    item.Details.NotesPlain,
+	}
+	if passwordCard.URL == "" && len(item.Overview.URLs) > 0 {
+		passwordCard.URL = item.Overview.URLs[0].URL
+	}
+	for _, u := range item.Overview.URLs {
+		if u.URL != "" && u.URL != passwordCard.URL {
+			addURLField(&passwordCard, u.Label, u.URL)
+		}
+	}
+	for _, field := range item.Details.LoginFields {
+		switch field.Designation {
+		case "username":
+			passwordCard.Username = field.Value
+		case "password":
+			passwordCard.Password = field.Value
+		}
+	}
+	// the one-time password fields are in the sections, only the first one
+	// is kept, along with the text, concealed and URL fields, which become
+	// custom fields
+	for _, section := range item.Details.Sections {
+		for _, field := range section.Fields {
+			value := field.Value
+			switch {
+			case value.TOTP != "":
+				if passwordCard.TOTP == "" {
+					passwordCard.TOTP = strings.TrimSpace(value.TOTP)
+				}
+			case value.String != nil:
+				addCustomField(&passwordCard, model.CustomField{Name: field.Title, Type: model.CustomFieldText, Value: *value.String})
+			case value.Concealed != nil:
+				addCustomField(&passwordCard, model.CustomField{Name: field.Title, Type: model.CustomFieldHidden, Value: *value.Concealed})
+			case value.URL != nil:
+				addCustomField(&passwordCard, model.CustomField{Name: field.Title, Type: model.CustomFieldLink, Value: *value.URL})
+			}
+		}
+	}
+
+	entry := newEntry(row, passwordCard)
+
+	switch {
+	case item.State == "archived":
+		entry.Err = ErrUnsupportedItem{Reason: "archived items aren't imported"}
+	case item.CategoryUUID != onePasswordLoginCategory && item.CategoryUUID != onePasswordPasswordCategory:
+		categoryName, ok := onePasswordCategoryNames[item.CategoryUUID]
+		if !ok {
+			categoryName = item.CategoryUUID
+		}
+		entry.Err = ErrUnsupportedItem{Reason: fmt.Sprintf("items of category %q aren't supported", categoryName)}
+	}
+
+	return entry
+}
@@ -0,0 +1,163 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// new1PUX returns a 1PUX archive holding the files.
+func new1PUX(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := archive.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+
+	return buf.Bytes()
+}
+
+func TestParse1PUX(t *testing.T) {
+	t.Run("🎉 parses the logins and passwords of every vault with their fields", func(t *testing.T) {
+		data := new1PUX(t, map[string]string{
+			"export.attributes": `{"version": 3, "description": "1Password Unencrypted Export", "createdAt": 1690000000}`,
+			"export.data": `
+				{
+					"accounts": [
+						{
+							"attrs": {"accountName": "Team"},
+							"vaults": [
+								{
+									"attrs": {"name": "Private"},
+									"items": [
+										{
+											"uuid": "fkruyzrldvizuqlnavfj3gltfe",
+											"state": "active",
+											"categoryUuid": "001",
+											"details": {
+												"loginFields": [
+													{"value": "admin", "name": "username", "fieldType": "T", "designation": "username"},
+													{"value": "supersecret", "name": "password", "fieldType": "P", "designation": "password"}
+												],
+												"notesPlain": "root account",
+												"sections": [
+													{
+														"title": "",
+														"fields": [
+															{"title": "one-time password", "id": "TOTP_1", "value": {"totp": "otpauth://totp/AWS:admin?secret=JBSWY3DPEHPK3PXP"}}
+														]
+													},
+													{
+														"title": "Support",
+														"fields": [
+															{"title": "account ID", "id": "accountid", "value": {"string": "123456789012"}},
+															{"title": "PIN", "id": "pin", "value": {"concealed": "4321"}},
+															{"title": "PIN", "id": "pin2", "value": {"concealed": "8765"}},
+															{"title": "portal", "id": "portal", "value": {"url": "https://support.aws.com/"}}
+														]
+													}
+												]
+											},
+											"overview": {
+												"title": "AWS",
+												"url": "https://aws.com/login",
+												"urls": [
+													{"label": "", "url": "https://aws.com/login"},
+													{"label": "console", "url": "https://console.aws.com/"}
+												]
+											}
+										},
+										{
+											"uuid": "a3shlmm5ov7ynsmz4pjaxtfa4y",
+											"state": "archived",
+											"categoryUuid": "001",
+											"details": {"loginFields": [{"value": "old", "designation": "username"}, {"value": "oldsecret", "designation": "password"}]},
+											"overview": {"title": "Old", "url": "https://old.com/"}
+										}
+									]
+								},
+								{
+									"attrs": {"name": "Shared"},
+									"items": [
+										{
+											"uuid": "r5kn5glzmfe6oxo3gaqztvlk5e",
+											"state": "active",
+											"categoryUuid": "005",
+											"details": {"password": "anothersecret"},
+											"overview": {"title": "GCP", "urls": [{"url": "https://cloud.google.com/"}]}
+										},
+										{
+											"uuid": "q2t3z6y5ojc3ojstrl3hy4clgy",
+											"state": "active",
+											"categoryUuid": "002",
+											"details": {"sections": []},
+											"overview": {"title": "Visa"}
+										}
+									]
+								}
+							]
+						}
+					]
+				}
+			`,
+		})
+
+		format, entries, err := Parse(data, "")
+		require.NoError(t, err)
+		assert.Equal(t, FormatOnePassword, format)
+
+		passwordCards, errs := withoutIDs(t, entries)
+		assert.Equal(t, []model.PasswordCard{
+			{
+				Name:     "AWS",
+				URL:      "https://aws.com/login",
+				Username: "admin",
+				Password: "supersecret",
+				TOTP:     "otpauth://totp/AWS:admin?secret=JBSWY3DPEHPK3PXP",
+				Notes:    "root account",
+				CustomFields: []model.CustomField{
+					{Name: "console", Type: model.CustomFieldLink, Value: "https://console.aws.com/"},
+					{Name: "account ID", Type: model.CustomFieldText, Value: "123456789012"},
+					{Name: "PIN", Type: model.CustomFieldHidden, Value: "4321"},
+					{Name: "PIN (2)", Type: model.CustomFieldHidden, Value: "8765"},
+					{Name: "portal", Type: model.CustomFieldLink, Value: "https://support.aws.com/"},
+				},
+			},
+			{Name: "Old", URL: "https://old.com/", Username: "old", Password: "oldsecret"},
+			{Name: "GCP", URL: "https://cloud.google.com/", Password: "anothersecret"},
+			{Name: "Visa"},
+		}, passwordCards)
+		assert.Equal(t, []string{
+			"",
+			"archived items aren't imported",
+			"username can't be empty",
+			`items of category "credit card" aren't supported`,
+		}, errs)
+		assert.Equal(t, []int{1, 2, 3, 4}, []int{entries[0].Row, entries[1].Row, entries[2].Row, entries[3].Row})
+	})
+
+	t.Run("returns error for other zip archives", func(t *testing.T) {
+		_, _, err := Parse(new1PUX(t, map[string]string{"readme.txt": "hello"}), "")
+		assert.ErrorIs(t, err, ErrUnknownFormat)
+
+		_, _, err = Parse([]byte("PK\x03\x04 truncated"), "")
+		assert.ErrorContains(t, err, "error reading 1Password export")
+	})
+
+	t.Run("returns error for exports too large once uncompressed", func(t *testing.T) {
+		// the spaces compress to a few kilobytes
+		data := new1PUX(t, map[string]string{"export.data": strings.Repeat(" ", max1PUXDataSize) + "{}"})
+
+		_, _, err := Parse(data, "")
+		assert.ErrorIs(t, err, Err1PUXTooLarge)
+	})
+}
@@ -2,27 +2,194 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/CaioTeixeira95/password-manager/backend/breach"
+	"github.com/CaioTeixeira95/password-manager/backend/model"
 	"github.com/CaioTeixeira95/password-manager/backend/repository"
 	"github.com/CaioTeixeira95/password-manager/backend/serve"
 	"github.com/CaioTeixeira95/password-manager/backend/service"
 	"github.com/gofiber/fiber/v2"
 )
 
+// masterPasswordEnv is the environment variable holding the master password.
+// It's not a flag so it doesn't show up in the process list.
+const masterPasswordEnv = "MASTER_PASSWORD"
+
 func main() {
 	port := flag.Int("port", 8000, "Web server port")
+	storage := flag.String("storage", "memory", "Storage backend: memory, file or sqlite")
+	dataFile := flag.String("data-file", "", "Vault file used by the file and sqlite storages (default vault.json or vault.db)")
+	autoLock := flag.Duration("auto-lock", 15*time.Minute, "Lock the vault after this long without requests, 0 disables it")
+	auth := flag.Bool("auth", true, "Require user accounts, every caller shares the same password cards when disabled")
+	admins := flag.String("admin", "", "Comma-separated usernames of the accounts allowed to unlock, lock and rotate the vault, registered with the master password")
+	minPasswordScore := flag.Int("min-password-score", 0, "Reject the new passwords whose strength score, from 0 to 4, is lower")
+	hibpFile := flag.String("hibp-file", "", "Have I Been Pwned SHA-1 passwords file, ordered by hash, to flag the breached passwords")
 
 	flag.Parse()
 
-	s := serve.NewServe(
-		fiber.New(),
-		service.NewPasswordCardService(
-			repository.NewPasswordCardRepository(),
-		),
-	)
+	if *minPasswordScore < model.MinPasswordScore || *minPasswordScore > model.MaxPasswordScore {
+		log.Fatalf("min-password-score must be between %d and %d", model.MinPasswordScore, model.MaxPasswordScore)
+	}
+
+	repos, err := newRepositories(*storage, *dataFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	passwordCardService := service.NewPasswordCardService(repos.passwordCards)
+	var options []serve.Option
+
+	masterPassword := os.Getenv(masterPasswordEnv)
+	os.Unsetenv(masterPasswordEnv)
+
+	// the memory storage is only encrypted when a master password is given,
+	// the other ones always are and start locked without it
+	var vaultService *service.VaultService
+	if masterPassword != "" || *storage != "memory" {
+		vaultService = service.NewVaultService(repos.vaultHeader)
+		passwordCardService = service.NewEncryptedPasswordCardService(repos.passwordCards, vaultService)
+		options = append(options, serve.WithVaultService(vaultService))
+
+		if masterPassword != "" {
+			if err := vaultService.Unlock([]byte(masterPassword)); err != nil {
+				log.Fatal(err)
+			}
+
+			if err := passwordCardService.MigrateSecrets(); err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			log.Print("vault is locked, unlock it with POST /vault/unlock")
+		}
+
+		if *autoLock > 0 {
+			stop := vaultService.AutoLock(*autoLock)
+			defer stop()
+		}
+	}
+
+	passwordCardService.SetMinPasswordScore(*minPasswordScore)
+	passwordCardService.SetFolderRepository(repos.folders)
+
+	if *hibpFile != "" {
+		hashFile, err := breach.Open(*hibpFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer hashFile.Close()
+
+		passwordCardService.SetBreachChecker(hashFile)
+	}
+
+	options = append(options, serve.WithAuditService(service.NewAuditService(repos.auditEvents)))
+
+	if *auth {
+		userService := service.NewUserService(repos.users, repos.sessions)
+		userService.SetAdmins(strings.Split(*admins, ","), vaultService)
+
+		options = append(options,
+			serve.WithUserService(userService),
+			serve.WithAPITokenService(service.NewAPITokenService(repos.apiTokens, repos.users)),
+		)
+	}
+
+	s := serve.NewServe(fiber.New(), passwordCardService, options...)
 
 	if err := s.Run(*port); err != nil {
 		log.Fatal(err)
 	}
 }
+
+type repositories struct {
+	passwordCards repository.PasswordCardStore
+	vaultHeader   repository.VaultHeaderStore
+	users         repository.UserStore
+	sessions      repository.SessionStore
+	apiTokens     repository.APITokenStore
+	auditEvents   repository.AuditStore
+	folders       repository.FolderStore
+}
+
+func newRepositories(storage, dataFile string) (*repositories, error) {
+	switch storage {
+	case "memory":
+		return &repositories{
+			passwordCards: repository.NewPasswordCardRepository(),
+			vaultHeader:   repository.NewVaultHeaderRepository(),
+			users:         repository.NewUserRepository(),
+			sessions:      repository.NewSessionRepository(),
+			apiTokens:     repository.NewAPITokenRepository(),
+			auditEvents:   repository.NewAuditRepository(),
+			folders:       repository.NewFolderRepository(),
+		}, nil
+	case "file":
+		if dataFile == "" {
+			dataFile = "vault.json"
+		}
+		fileStorage, err := repository.OpenFileStorage(dataFile)
+		if err != nil {
+			return nil, err
+		}
+		passwordCards, err := repository.NewFilePasswordCardRepository(fileStorage)
+		if err != nil {
+			return nil, err
+		}
+		vaultHeader, err := repository.NewFileVaultHeaderRepository(fileStorage)
+		if err != nil {
+			return nil, err
+		}
+		users, err := repository.NewFileUserRepository(fileStorage)
+		if err != nil {
+			return nil, err
+		}
+		sessions, err := repository.NewFileSessionRepository(fileStorage)
+		if err != nil {
+			return nil, err
+		}
+		apiTokens, err := repository.NewFileAPITokenRepository(fileStorage)
+		if err != nil {
+			return nil, err
+		}
+		auditEvents, err := repository.NewFileAuditRepository(fileStorage)
+		if err != nil {
+			return nil, err
+		}
+		folders, err := repository.NewFileFolderRepository(fileStorage)
+		if err != nil {
+			return nil, err
+		}
+		return &repositories{
+			passwordCards: passwordCards,
+			vaultHeader:   vaultHeader,
+			users:         users,
+			sessions:      sessions,
+			apiTokens:     apiTokens,
+			auditEvents:   auditEvents,
+			folders:       folders,
+		}, nil
+	case "sqlite":
+		if dataFile == "" {
+			dataFile = "vault.db"
+		}
+		db, err := repository.OpenSQLite(dataFile)
+		if err != nil {
+			return nil, err
+		}
+		return &repositories{
+			passwordCards: repository.NewSQLitePasswordCardRepository(db),
+			vaultHeader:   repository.NewSQLiteVaultHeaderRepository(db),
+			users:         repository.NewSQLiteUserRepository(db),
+			sessions:      repository.NewSQLiteSessionRepository(db),
+			apiTokens:     repository.NewSQLiteAPITokenRepository(db),
+			auditEvents:   repository.NewSQLiteAuditRepository(db),
+			folders:       repository.NewSQLiteFolderRepository(db),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown storage %q", storage)
+	}
+}
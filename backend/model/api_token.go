@@ -0,0 +1,62 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Scopes of the API tokens.
+const (
+	ScopeRead      = "read"
+	ScopeReadWrite = "read-write"
+)
+
+const (
+	// DefaultAPITokenTTLDays is the lifetime of the API tokens created without
+	// an explicit one.
+	DefaultAPITokenTTLDays = 30
+	// MaxAPITokenTTLDays is the longest lifetime of an API token.
+	MaxAPITokenTTLDays = 365
+)
+
+// APIToken gives scripts access to the password cards of a user without a
+// login. Only the hash of the token is stored.
+type APIToken struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name"`
+	Scope     string    `json:"scope"`
+	TokenHash string    `json:"token_hash,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CanWrite reports whether the token allows changing the password cards.
+func (t *APIToken) CanWrite() bool {
+	return t.Scope == ScopeReadWrite
+}
+
+// APITokenRequest holds the attributes of a new API token.
+type APITokenRequest struct {
+	Name  string `json:"name"`
+	Scope string `json:"scope"`
+	// ExpiresInDays defaults to DefaultAPITokenTTLDays when it's zero.
+	ExpiresInDays int `json:"expires_in_days"`
+}
+
+func (r *APITokenRequest) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("name can't be empty")
+	}
+
+	if r.Scope != ScopeRead && r.Scope != ScopeReadWrite {
+		return fmt.Errorf("scope must be %q or %q", ScopeRead, ScopeReadWrite)
+	}
+
+	if r.ExpiresInDays < 0 || r.ExpiresInDays > MaxAPITokenTTLDays {
+		return fmt.Errorf("expires_in_days must be between 1 and %d", MaxAPITokenTTLDays)
+	}
+
+	return nil
+}
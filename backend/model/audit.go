@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// Audit actions.
+const (
+	// AuditActionRevealPassword is recorded when the password of a card is
+	// revealed.
+	AuditActionRevealPassword = "password_card.reveal"
+	// AuditActionExportPassword is recorded for every card of an export.
+	AuditActionExportPassword = "password_card.export"
+	// AuditActionGenerateTOTP is recorded when a TOTP code of a card is
+	// generated.
+	AuditActionGenerateTOTP = "password_card.totp"
+	// AuditActionGenerateHOTP is recorded when a HOTP code of a card is
+	// generated.
+	AuditActionGenerateHOTP = "password_card.hotp"
+)
+
+// AuditEvent records who accessed a secret and when.
+type AuditEvent struct {
+	ID     string `json:"id"`
+	Action string `json:"action"`
+	// UserID is empty when the server runs without accounts.
+	UserID string `json:"user_id,omitempty"`
+	// APITokenID is set when the request was authenticated with an API token.
+	APITokenID     string    `json:"api_token_id,omitempty"`
+	PasswordCardID string    `json:"password_card_id"`
+	RemoteIP       string    `json:"remote_ip"`
+	CreatedAt      time.Time `json:"created_at"`
+}
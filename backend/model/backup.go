@@ -0,0 +1,56 @@
+package model
+
+import "fmt"
+
+// RestoreMode tells what happens to the existing password cards when a backup
+// is restored.
+type RestoreMode string
+
+const (
+	// RestoreModeMerge keeps the existing cards, the cards of the backup whose
+	// ID or URL is already taken are reported as conflicts.
+	RestoreModeMerge RestoreMode = "merge"
+	// RestoreModeReplace deletes the existing cards before restoring the
+	// backup.
+	RestoreModeReplace RestoreMode = "replace"
+)
+
+// RestoreQuery holds the options of a backup restore.
+type RestoreQuery struct {
+	Mode RestoreMode `query:"mode"`
+}
+
+// DefaultRestoreQuery returns the options used when the query doesn't set
+// them, the merge keeps every existing card.
+func DefaultRestoreQuery() RestoreQuery {
+	return RestoreQuery{Mode: RestoreModeMerge}
+}
+
+func (q *RestoreQuery) Validate() error {
+	switch q.Mode {
+	case RestoreModeMerge, RestoreModeReplace:
+	default:
+		return fmt.Errorf("mode must be %q or %q", RestoreModeMerge, RestoreModeReplace)
+	}
+
+	return nil
+}
+
+// RestoreReport tells what happened to every card of a restored backup. It
+// never holds the passwords.
+type RestoreReport struct {
+	Mode RestoreMode `json:"mode"`
+	// Deleted is the number of cards deleted before restoring the backup in
+	// the replace mode.
+	Deleted int `json:"deleted"`
+	// Restored lists the cards stored from the backup.
+	Restored []ImportRow `json:"restored"`
+	// Folders is the number of folders of the backup created, the others
+	// were already there.
+	Folders int `json:"folders"`
+	// Conflicts lists the cards whose ID or URL is already taken by another
+	// card.
+	Conflicts []ImportRow `json:"conflicts"`
+	// Invalid lists the cards of the backup that aren't valid.
+	Invalid []ImportRow `json:"invalid"`
+}
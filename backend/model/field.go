@@ -0,0 +1,84 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// CustomFieldType is the kind of value of a custom field.
+type CustomFieldType string
+
+// Custom field types. The hidden fields are secrets, sealed and masked like
+// the password.
+const (
+	CustomFieldText    CustomFieldType = "text"
+	CustomFieldHidden  CustomFieldType = "hidden"
+	CustomFieldBoolean CustomFieldType = "boolean"
+	CustomFieldLink    CustomFieldType = "link"
+)
+
+// CustomFieldTypes are the valid custom field types.
+var CustomFieldTypes = []CustomFieldType{CustomFieldText, CustomFieldHidden, CustomFieldBoolean, CustomFieldLink}
+
+// CustomField is a named value of a card for what doesn't fit the other
+// fields, like an account ID, a PIN or the answer to a security question.
+type CustomField struct {
+	// Name is unique in the card.
+	Name string          `json:"name"`
+	Type CustomFieldType `json:"type"`
+	// Value is "true" or "false" for the boolean fields and a URL for the
+	// link ones. Any field can be empty.
+	Value string `json:"value"`
+}
+
+func (f *CustomField) Validate() error {
+	if strings.TrimSpace(f.Name) == "" {
+		return fmt.Errorf("custom field names can't be empty")
+	}
+
+	switch f.Type {
+	case CustomFieldText, CustomFieldHidden:
+	case CustomFieldBoolean:
+		if f.Value != "" && f.Value != "true" && f.Value != "false" {
+			return fmt.Errorf("custom field %q must be \"true\" or \"false\"", f.Name)
+		}
+	case CustomFieldLink:
+		if f.Value != "" {
+			u, err := url.Parse(f.Value)
+			if err != nil {
+				return fmt.Errorf("custom field %q has an invalid URL: %w", f.Name, err)
+			}
+			if u.Scheme == "" {
+				return fmt.Errorf("custom field %q has an invalid URL: missing scheme", f.Name)
+			}
+		}
+	default:
+		names := make([]string, 0, len(CustomFieldTypes))
+		for _, fieldType := range CustomFieldTypes {
+			names = append(names, fmt.Sprintf("%q", fieldType))
+		}
+		return fmt.Errorf("custom field %q type must be one of %s", f.Name, strings.Join(names, ", "))
+	}
+
+	return nil
+}
+
+// validateCustomFields checks every custom field of the card and that their
+// names are unique, the sealed values are bound to them.
+func (p *PasswordCard) validateCustomFields() error {
+	names := make(map[string]bool, len(p.CustomFields))
+	for i := range p.CustomFields {
+		field := &p.CustomFields[i]
+		if err := field.Validate(); err != nil {
+			return err
+		}
+
+		if names[field.Name] {
+			return fmt.Errorf("duplicate custom field %q", field.Name)
+		}
+		names[field.Name] = true
+	}
+
+	return nil
+}
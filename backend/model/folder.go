@@ -0,0 +1,87 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxFolderNameLength and MaxTagLength bound the names shown in the clients.
+const (
+	MaxFolderNameLength = 100
+	MaxTagLength        = 50
+)
+
+// Folder groups password cards. The folders nest: the root ones have no
+// parent.
+type Folder struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// ParentID is empty for the root folders.
+	ParentID string `json:"parent_id,omitempty"`
+	// OwnerID is the user the folder belongs to. It's empty when the server
+	// runs without accounts.
+	OwnerID   string    `json:"owner_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FolderRequest holds the attributes of a new folder, or the new attributes
+// of a folder. Changing the parent moves the folder with its content.
+type FolderRequest struct {
+	Name string `json:"name"`
+	// ParentID is empty for the root folders.
+	ParentID string `json:"parent_id"`
+}
+
+func (r *FolderRequest) Validate() error {
+	name := strings.TrimSpace(r.Name)
+	if name == "" {
+		return fmt.Errorf("name can't be empty")
+	}
+
+	if utf8.RuneCountInString(name) > MaxFolderNameLength {
+		return fmt.Errorf("name can't be longer than %d characters", MaxFolderNameLength)
+	}
+
+	return nil
+}
+
+// MovePasswordCardRequest moves a card to another folder.
+type MovePasswordCardRequest struct {
+	// FolderID is empty to move the card out of its folder.
+	FolderID string `json:"folder_id"`
+}
+
+// validateTags checks that the tags of the card aren't empty nor repeated,
+// ignoring the case.
+func (p *PasswordCard) validateTags() error {
+	tags := make(map[string]bool, len(p.Tags))
+	for _, tag := range p.Tags {
+		if strings.TrimSpace(tag) == "" {
+			return fmt.Errorf("tags can't be empty")
+		}
+
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			return fmt.Errorf("tags can't be longer than %d characters", MaxTagLength)
+		}
+
+		if tags[strings.ToLower(tag)] {
+			return fmt.Errorf("duplicate tag %q", tag)
+		}
+		tags[strings.ToLower(tag)] = true
+	}
+
+	return nil
+}
+
+// HasTag tells whether the card has the tag, ignoring the case.
+func (p *PasswordCard) HasTag(tag string) bool {
+	for _, cardTag := range p.Tags {
+		if strings.EqualFold(cardTag, tag) {
+			return true
+		}
+	}
+	return false
+}
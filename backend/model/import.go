@@ -0,0 +1,32 @@
+package model
+
+// ImportReport tells what happened to every row of an imported file. It never
+// holds the passwords.
+type ImportReport struct {
+	// Format is the detected format of the file, e.g. "chrome".
+	Format string `json:"format"`
+	// DryRun is set when nothing was stored, the report tells what the import
+	// would do.
+	DryRun bool `json:"dry_run"`
+	// Created lists the rows stored as new password cards.
+	Created []ImportRow `json:"created"`
+	// Skipped lists the rows that aren't logins, like the secure notes.
+	Skipped []ImportRow `json:"skipped"`
+	// Conflicts lists the rows whose URL is already taken by another card.
+	Conflicts []ImportRow `json:"conflicts"`
+	// Invalid lists the rows that couldn't be mapped to a valid card.
+	Invalid []ImportRow `json:"invalid"`
+}
+
+// ImportRow identifies a row of an imported file.
+type ImportRow struct {
+	// Row is the line of the row in the text files, or its position in the
+	// other ones, starting at 1.
+	Row int `json:"row"`
+	// ID is only set for the created password cards, not in the dry runs.
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Error is why the row wasn't imported.
+	Error string `json:"error,omitempty"`
+}
@@ -0,0 +1,279 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ItemType is the kind of a password card. The cards were all logins before
+// the types were introduced, so an empty type is a login.
+type ItemType string
+
+// Item types. Every type but the login has its details in the field of the
+// same name, e.g. the credit_card items in PasswordCard.CreditCard.
+const (
+	ItemTypeLogin      ItemType = "login"
+	ItemTypeSecureNote ItemType = "secure_note"
+	ItemTypeCreditCard ItemType = "credit_card"
+	ItemTypeIdentity   ItemType = "identity"
+	ItemTypeSSHKey     ItemType = "ssh_key"
+)
+
+// ItemTypes are the valid item types.
+var ItemTypes = []ItemType{ItemTypeLogin, ItemTypeSecureNote, ItemTypeCreditCard, ItemTypeIdentity, ItemTypeSSHKey}
+
+// Validate returns an error for the unknown types.
+func (t ItemType) Validate() error {
+	for _, itemType := range ItemTypes {
+		if t == itemType {
+			return nil
+		}
+	}
+
+	names := make([]string, 0, len(ItemTypes))
+	for _, itemType := range ItemTypes {
+		names = append(names, fmt.Sprintf("%q", itemType))
+	}
+	return fmt.Errorf("type must be one of %s", strings.Join(names, ", "))
+}
+
+// CreditCard holds the details of the credit_card items. The number and the
+// security code are secrets, sealed and masked like the password.
+type CreditCard struct {
+	CardholderName string `json:"cardholder_name,omitempty"`
+	Brand          string `json:"brand,omitempty"`
+	Number         string `json:"number"`
+	ExpiryMonth    int    `json:"expiry_month"`
+	ExpiryYear     int    `json:"expiry_year"`
+	Code           string `json:"code,omitempty"`
+}
+
+// Validate checks the number with the Luhn algorithm and the expiry date.
+// Expired cards are valid, they are kept for the record.
+func (c *CreditCard) Validate() error {
+	if strings.TrimSpace(c.Number) == "" {
+		return fmt.Errorf("card number can't be empty")
+	}
+
+	// the clients send back the masked secrets when they weren't changed
+	if c.Number != MaskedPassword && !validCardNumber(c.Number) {
+		return fmt.Errorf("invalid card number")
+	}
+
+	if c.ExpiryMonth < 1 || c.ExpiryMonth > 12 {
+		return fmt.Errorf("expiry month must be between 1 and 12")
+	}
+
+	if c.ExpiryYear < 1000 || c.ExpiryYear > 9999 {
+		return fmt.Errorf("expiry year must have 4 digits")
+	}
+
+	if c.Code != "" && c.Code != MaskedPassword {
+		if len(c.Code) < 3 || len(c.Code) > 4 || strings.Trim(c.Code, "0123456789") != "" {
+			return fmt.Errorf("security code must have 3 or 4 digits")
+		}
+	}
+
+	return nil
+}
+
+// validCardNumber tells whether number has 12 to 19 digits, optionally
+// grouped by spaces or dashes, and a valid Luhn check digit.
+func validCardNumber(number string) bool {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(number)
+	if len(digits) < 12 || len(digits) > 19 {
+		return false
+	}
+
+	sum := 0
+	// every second digit from the right is doubled
+	for i := 0; i < len(digits); i++ {
+		digit := int(digits[len(digits)-1-i] - '0')
+		if digit < 0 || digit > 9 {
+			return false
+		}
+		if i%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+
+	return sum%10 == 0
+}
+
+// Identity holds the details of the identity items. The document numbers are
+// secrets, sealed and masked like the password.
+type Identity struct {
+	Title      string `json:"title,omitempty"`
+	FirstName  string `json:"first_name,omitempty"`
+	MiddleName string `json:"middle_name,omitempty"`
+	LastName   string `json:"last_name,omitempty"`
+	Company    string `json:"company,omitempty"`
+	Email      string `json:"email,omitempty"`
+	Phone      string `json:"phone,omitempty"`
+	Address    string `json:"address,omitempty"`
+	City       string `json:"city,omitempty"`
+	State      string `json:"state,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	Country    string `json:"country,omitempty"`
+
+	SSN            string `json:"ssn,omitempty"`
+	PassportNumber string `json:"passport_number,omitempty"`
+	LicenseNumber  string `json:"license_number,omitempty"`
+}
+
+func (i *Identity) Validate() error {
+	if strings.TrimSpace(i.FirstName) == "" && strings.TrimSpace(i.LastName) == "" {
+		return fmt.Errorf("identity must have a first or a last name")
+	}
+
+	if i.Email != "" {
+		if _, err := mail.ParseAddress(i.Email); err != nil {
+			return fmt.Errorf("invalid email: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SSHKey holds the details of the ssh_key items. The private key and its
+// passphrase are secrets, sealed and masked like the password.
+type SSHKey struct {
+	// PrivateKey is PEM encoded, in the OpenSSH or PKCS formats.
+	PrivateKey string `json:"private_key"`
+	// Passphrase decrypts the private key, it's empty for the unencrypted
+	// ones.
+	Passphrase string `json:"passphrase,omitempty"`
+	// PublicKey is in the authorized_keys format.
+	PublicKey string `json:"public_key,omitempty"`
+	// Fingerprint is the SHA256 fingerprint of the public key, set by
+	// Complete.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+func (k *SSHKey) Validate() error {
+	if strings.TrimSpace(k.PrivateKey) == "" {
+		return fmt.Errorf("private key can't be empty")
+	}
+
+	if k.PrivateKey != MaskedPassword {
+		// the encrypted keys can only be checked with their passphrase
+		if _, err := k.parsePrivateKey(); err != nil && !isPassphraseMissing(err) {
+			return fmt.Errorf("invalid private key: %w", err)
+		}
+	}
+
+	if k.PublicKey != "" {
+		if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(k.PublicKey)); err != nil {
+			return fmt.Errorf("invalid public key: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Complete derives the public key from the private key when it's missing and
+// sets the fingerprint of the public key. The keys that can't be parsed, like
+// the masked ones, are left as they are.
+func (k *SSHKey) Complete() {
+	if k.PublicKey == "" && k.PrivateKey != MaskedPassword {
+		if signer, err := k.parsePrivateKey(); err == nil {
+			k.PublicKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+		}
+	}
+
+	k.Fingerprint = ""
+	if publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(k.PublicKey)); err == nil {
+		k.Fingerprint = ssh.FingerprintSHA256(publicKey)
+	}
+}
+
+func (k *SSHKey) parsePrivateKey() (ssh.Signer, error) {
+	if k.Passphrase != "" && k.Passphrase != MaskedPassword {
+		return ssh.ParsePrivateKeyWithPassphrase([]byte(k.PrivateKey), []byte(k.Passphrase))
+	}
+	return ssh.ParsePrivateKey([]byte(k.PrivateKey))
+}
+
+func isPassphraseMissing(err error) bool {
+	var errMissing *ssh.PassphraseMissingError
+	return errors.As(err, &errMissing)
+}
+
+// Secret is a secret field of a card. The service seals every secret bound to
+// its field, and the responses that don't reveal them mask them.
+type Secret struct {
+	Field string
+	Value *string
+}
+
+// Secrets returns the secret fields of the card that are set. The password is
+// always the first one, even when it's empty: the stored cards whose password
+// is sealed are the sealed ones.
+//
+// The values point into the card, so they can be changed in place, which
+// changes the details shared with the copies of the card. Clone the card
+// first.
+func (p *PasswordCard) Secrets() []Secret {
+	secrets := []Secret{{Field: "password", Value: &p.Password}}
+	add := func(field string, value *string) {
+		if *value != "" {
+			secrets = append(secrets, Secret{Field: field, Value: value})
+		}
+	}
+
+	add("totp", &p.TOTP)
+	add("hotp", &p.HOTP)
+	add("notes", &p.Notes)
+	if p.CreditCard != nil {
+		add("credit_card.number", &p.CreditCard.Number)
+		add("credit_card.code", &p.CreditCard.Code)
+	}
+	if p.Identity != nil {
+		add("identity.ssn", &p.Identity.SSN)
+		add("identity.passport_number", &p.Identity.PassportNumber)
+		add("identity.license_number", &p.Identity.LicenseNumber)
+	}
+	if p.SSHKey != nil {
+		add("ssh_key.private_key", &p.SSHKey.PrivateKey)
+		add("ssh_key.passphrase", &p.SSHKey.Passphrase)
+	}
+	// the names are unique in the card
+	for i := range p.CustomFields {
+		if p.CustomFields[i].Type == CustomFieldHidden {
+			add("custom_fields."+p.CustomFields[i].Name, &p.CustomFields[i].Value)
+		}
+	}
+
+	return secrets
+}
+
+// Clone returns a copy of the card that shares none of its details.
+func (p PasswordCard) Clone() PasswordCard {
+	if p.CreditCard != nil {
+		creditCard := *p.CreditCard
+		p.CreditCard = &creditCard
+	}
+	if p.Identity != nil {
+		identity := *p.Identity
+		p.Identity = &identity
+	}
+	if p.SSHKey != nil {
+		sshKey := *p.SSHKey
+		p.SSHKey = &sshKey
+	}
+	if p.CustomFields != nil {
+		p.CustomFields = append([]CustomField(nil), p.CustomFields...)
+	}
+	if p.Tags != nil {
+		p.Tags = append([]string(nil), p.Tags...)
+	}
+	return p
+}
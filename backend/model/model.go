@@ -4,14 +4,146 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/CaioTeixeira95/password-manager/backend/otp"
 )
 
+// MaskedPassword replaces the passwords in the responses that don't reveal
+// them. It doesn't depend on the password, so it leaks nothing, not even its
+// length.
+const MaskedPassword = "********"
+
+// MaxPasswordLength is the longest password accepted, in characters.
+const MaxPasswordLength = 1024
+
 type PasswordCard struct {
-	ID       string `json:"id"`
-	Name     string `json:"name"`
-	Username string `json:"username"`
-	Password string `json:"password"`
-	URL      string `json:"url"`
+	ID string `json:"id"`
+	// Type is the kind of item, see Kind. The username, password, URL and
+	// one-time password keys are the details of the logins, the other types
+	// have theirs in the field of their name.
+	Type     ItemType `json:"type,omitempty"`
+	Name     string   `json:"name"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	URL      string   `json:"url"`
+	// TOTP is the key of the second factor of the account, an otpauth:// URI
+	// or a bare base32 secret. It's a secret, sealed and masked like the
+	// password. It's empty for the accounts without one.
+	TOTP string `json:"totp,omitempty"`
+	// HOTP is the key of a counter based second factor, an otpauth:// URI or
+	// a bare base32 secret, sealed and masked like TOTP.
+	HOTP string `json:"hotp,omitempty"`
+	// HOTPCounter is the counter of the next HOTP code. It's maintained by
+	// the server: it starts at the counter of the key and only the code
+	// generation and the resync change it.
+	HOTPCounter uint64 `json:"hotp_counter,omitempty"`
+	// Notes are free text, kept with every type of item. They are a secret,
+	// sealed and masked like the password.
+	Notes string `json:"notes,omitempty"`
+
+	// CreditCard, Identity and SSHKey are the details of the items of their
+	// type, only the one of the type of the card is set.
+	CreditCard *CreditCard `json:"credit_card,omitempty"`
+	Identity   *Identity   `json:"identity,omitempty"`
+	SSHKey     *SSHKey     `json:"ssh_key,omitempty"`
+
+	// CustomFields are the named values of the card, on every type of item.
+	CustomFields []CustomField `json:"custom_fields,omitempty"`
+
+	// FolderID is the folder of the card, empty for the cards out of any
+	// folder. It's set when the card is created, then only moving the card
+	// changes it: the updates keep it.
+	FolderID string `json:"folder_id,omitempty"`
+	// Tags are free-form labels, unique in the card ignoring the case.
+	Tags []string `json:"tags,omitempty"`
+
+	// OwnerID is the user the card belongs to. It's empty when the server
+	// runs without accounts.
+	OwnerID string `json:"owner_id,omitempty"`
+	// CreatedAt and UpdatedAt are set by the service, they are zero for the
+	// cards stored before they were recorded.
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// PasswordChangedAt is only updated when the password changes. It's zero
+	// for the cards stored before it was recorded.
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+
+	// Strength is estimated when the password is stored. It's nil for the
+	// cards stored before it was, until they are estimated on unlock, and for
+	// the cards handed to the repository, which get SealedStrength instead.
+	Strength *PasswordStrength `json:"strength,omitempty"`
+	// SealedStrength is Strength encoded in JSON and sealed like the secrets,
+	// it tells how easy the password is to guess. It's only set on the cards
+	// handed to the repository.
+	SealedStrength string `json:"sealed_strength,omitempty"`
+
+	// Breached tells whether the password appears in the breached passwords
+	// list. It's nil when the list isn't configured, and never stored.
+	Breached *bool `json:"breached,omitempty"`
+
+	// DataKey is the wrapped key that encrypts the secrets of the card. It's
+	// only set on the cards handed to the repository.
+	DataKey string `json:"data_key,omitempty"`
+	// Sealing tells how the secrets of the card are stored. Like DataKey, it's
+	// only set on the cards handed to the repository, and it's empty for the
+	// cards stored before it was recorded.
+	Sealing Sealing `json:"sealing,omitempty"`
+}
+
+// Sealing is how the secrets of a stored card are protected.
+type Sealing string
+
+const (
+	// SealingPlaintext cards are stored by the services without encryption.
+	SealingPlaintext Sealing = "plaintext"
+	// SealingMasterKey cards were sealed with the master key, before the data
+	// keys were introduced.
+	SealingMasterKey Sealing = "master_key"
+	// SealingDataKey cards are sealed with their own data key, wrapped by the
+	// master key.
+	SealingDataKey Sealing = "data_key"
+)
+
+// Password strength scores, from the easiest to guess to the hardest.
+const (
+	MinPasswordScore = 0
+	MaxPasswordScore = 4
+)
+
+// PasswordStrength is an estimate of how hard a password is to guess.
+type PasswordStrength struct {
+	// Score goes from MinPasswordScore, guessed in less than 10^3 attempts, to
+	// MaxPasswordScore, more than 10^10 attempts.
+	Score int `json:"score"`
+	// CrackTimeSeconds is the time an offline attack against a slow hash takes
+	// to guess the password.
+	CrackTimeSeconds float64 `json:"crack_time_seconds"`
+	// CrackTimeDisplay is CrackTimeSeconds for humans, e.g. "3.0 hours".
+	CrackTimeDisplay string `json:"crack_time_display"`
+}
+
+// Kind returns the type of the card, ItemTypeLogin for the cards without
+// one.
+func (p *PasswordCard) Kind() ItemType {
+	if p.Type == "" {
+		return ItemTypeLogin
+	}
+	return p.Type
+}
+
+// Masked returns a copy of the card with its secrets, the password, the
+// one-time password keys, the notes, the secrets of the details and the
+// hidden custom fields, replaced by MaskedPassword when they are set.
+func (p PasswordCard) Masked() PasswordCard {
+	p = p.Clone()
+	for _, secret := range p.Secrets() {
+		if *secret.Value != "" {
+			*secret.Value = MaskedPassword
+		}
+	}
+	return p
 }
 
 func (p *PasswordCard) Validate() error {
@@ -19,10 +151,58 @@ func (p *PasswordCard) Validate() error {
 		return fmt.Errorf("invalid id")
 	}
 
+	if err := p.Kind().Validate(); err != nil {
+		return err
+	}
+
 	if strings.TrimSpace(p.Name) == "" {
 		return fmt.Errorf("invalid name")
 	}
 
+	if err := p.validateDetails(); err != nil {
+		return err
+	}
+
+	if err := p.validateCustomFields(); err != nil {
+		return err
+	}
+
+	if err := p.validateTags(); err != nil {
+		return err
+	}
+
+	switch p.Kind() {
+	case ItemTypeLogin:
+		return p.validateLogin()
+	case ItemTypeSecureNote:
+		if strings.TrimSpace(p.Notes) == "" {
+			return fmt.Errorf("notes can't be empty")
+		}
+	case ItemTypeCreditCard:
+		if err := p.CreditCard.Validate(); err != nil {
+			return err
+		}
+	case ItemTypeIdentity:
+		if err := p.Identity.Validate(); err != nil {
+			return err
+		}
+	case ItemTypeSSHKey:
+		if err := p.SSHKey.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// the other items can still link to a site
+	if p.URL != "" {
+		if _, err := url.Parse(p.URL); err != nil {
+			return fmt.Errorf("invalid URL provided: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (p *PasswordCard) validateLogin() error {
 	if strings.TrimSpace(p.Username) == "" {
 		return fmt.Errorf("username can't be empty")
 	}
@@ -31,6 +211,10 @@ func (p *PasswordCard) Validate() error {
 		return fmt.Errorf("password can't be empty")
 	}
 
+	if utf8.RuneCountInString(p.Password) > MaxPasswordLength {
+		return fmt.Errorf("password must have at most %d characters", MaxPasswordLength)
+	}
+
 	if strings.TrimSpace(p.URL) == "" {
 		return fmt.Errorf("invalid URL")
 	}
@@ -40,5 +224,96 @@ func (p *PasswordCard) Validate() error {
 		return fmt.Errorf("invalid URL provided: %w", err)
 	}
 
+	// the clients send back the masked keys when they weren't changed
+	if p.TOTP != "" && p.TOTP != MaskedPassword {
+		if _, err := otp.ParseTOTP(p.TOTP); err != nil {
+			return fmt.Errorf("invalid TOTP: %w", err)
+		}
+	}
+
+	if p.HOTP != "" && p.HOTP != MaskedPassword {
+		if _, err := otp.ParseHOTP(p.HOTP); err != nil {
+			return fmt.Errorf("invalid HOTP: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateDetails checks that the card only has the details of its type: the
+// password and the one-time password keys for the logins, the field of their
+// type for the others.
+func (p *PasswordCard) validateDetails() error {
+	itemType := p.Kind()
+
+	if itemType != ItemTypeLogin {
+		loginFields := []struct {
+			name, value string
+		}{
+			{"password", p.Password},
+			{"totp", p.TOTP},
+			{"hotp", p.HOTP},
+		}
+		for _, field := range loginFields {
+			if field.value != "" {
+				return fmt.Errorf("%s is only allowed on %q items", field.name, ItemTypeLogin)
+			}
+		}
+	}
+
+	details := []struct {
+		itemType ItemType
+		set      bool
+	}{
+		{ItemTypeCreditCard, p.CreditCard != nil},
+		{ItemTypeIdentity, p.Identity != nil},
+		{ItemTypeSSHKey, p.SSHKey != nil},
+	}
+	for _, detail := range details {
+		switch {
+		case detail.set && detail.itemType != itemType:
+			return fmt.Errorf("%s is only allowed on %q items", detail.itemType, detail.itemType)
+		case !detail.set && detail.itemType == itemType:
+			return fmt.Errorf("%s can't be empty", detail.itemType)
+		}
+	}
+
+	return nil
+}
+
+// TOTPCode is the current code of the TOTP key of a card.
+type TOTPCode struct {
+	Code string `json:"code"`
+	// SecondsRemaining is how long the code stays valid.
+	SecondsRemaining int `json:"seconds_remaining"`
+	// Period is how long every code is valid, in seconds.
+	Period int `json:"period"`
+}
+
+// HOTPCode is a code of the HOTP key of a card.
+type HOTPCode struct {
+	Code string `json:"code"`
+	// Counter is the counter the code was generated with.
+	Counter uint64 `json:"counter"`
+}
+
+// HOTPResync holds the codes resynchronizing the HOTP counter of a card with
+// its token.
+type HOTPResync struct {
+	// Codes are two consecutive codes of the token.
+	Codes []string `json:"codes"`
+}
+
+func (r *HOTPResync) Validate() error {
+	if len(r.Codes) != 2 {
+		return fmt.Errorf("two consecutive codes are required")
+	}
+
+	for _, code := range r.Codes {
+		if strings.TrimSpace(code) == "" {
+			return fmt.Errorf("codes can't be empty")
+		}
+	}
+
 	return nil
 }
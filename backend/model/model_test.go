@@ -1,10 +1,16 @@
 package model
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
 )
 
 func TestPasswordCardValidate(t *testing.T) {
@@ -47,6 +53,17 @@ func TestPasswordCardValidate(t *testing.T) {
 			},
 			err: errors.New("password can't be empty"),
 		},
+		{
+			name: "password too long",
+			model: PasswordCard{
+				ID:       "card-id",
+				Name:     "AWS",
+				Username: "username",
+				Password: strings.Repeat("a", MaxPasswordLength+1),
+				URL:      "https://aws.com/login",
+			},
+			err: errors.New("password must have at most 1024 characters"),
+		},
 		{
 			name: "URL can't be empty",
 			model: PasswordCard{
@@ -80,6 +97,295 @@ func TestPasswordCardValidate(t *testing.T) {
 			},
 			err: nil,
 		},
+		{
+			name: "invalid TOTP",
+			model: PasswordCard{
+				ID:       "card-id",
+				Name:     "AWS",
+				Username: "username",
+				Password: "supersecret",
+				URL:      "https://aws.com/login",
+				TOTP:     "otpauth://totp/AWS:username?secret=JBSWY3DPEHPK3PXP&digits=4",
+			},
+			err: errors.New("invalid TOTP: digits must be between 6 and 8"),
+		},
+		{
+			name: "🎉 valid password card with TOTP",
+			model: PasswordCard{
+				ID:       "card-id",
+				Name:     "AWS",
+				Username: "username",
+				Password: "supersecret",
+				URL:      "https://aws.com/login",
+				TOTP:     "otpauth://totp/AWS:username?secret=JBSWY3DPEHPK3PXP&issuer=AWS",
+			},
+			err: nil,
+		},
+		{
+			name: "invalid HOTP",
+			model: PasswordCard{
+				ID:       "card-id",
+				Name:     "VPN",
+				Username: "username",
+				Password: "supersecret",
+				URL:      "https://vpn.example.com/",
+				HOTP:     "otpauth://totp/VPN:username?secret=JBSWY3DPEHPK3PXP",
+			},
+			err: errors.New(`invalid HOTP: unsupported otpauth type "totp", only hotp is supported`),
+		},
+		{
+			name: "🎉 valid password card with HOTP",
+			model: PasswordCard{
+				ID:       "card-id",
+				Name:     "VPN",
+				Username: "username",
+				Password: "supersecret",
+				URL:      "https://vpn.example.com/",
+				HOTP:     "otpauth://hotp/VPN:username?secret=JBSWY3DPEHPK3PXP&counter=7",
+			},
+			err: nil,
+		},
+		{
+			name: "🎉 valid password card with masked TOTP",
+			model: PasswordCard{
+				ID:       "card-id",
+				Name:     "AWS",
+				Username: "username",
+				Password: MaskedPassword,
+				URL:      "https://aws.com/login",
+				TOTP:     MaskedPassword,
+			},
+			err: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.model.Validate()
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPasswordCardValidateItemTypes(t *testing.T) {
+	privateKey := testSSHPrivateKey(t)
+
+	testCases := []struct {
+		name  string
+		model PasswordCard
+		err   error
+	}{
+		{
+			name:  "unknown type",
+			model: PasswordCard{ID: "card-id", Type: "wifi", Name: "Home"},
+			err:   errors.New(`type must be one of "login", "secure_note", "credit_card", "identity", "ssh_key"`),
+		},
+		{
+			name:  "details of another type",
+			model: PasswordCard{ID: "card-id", Name: "AWS", Username: "username", Password: "supersecret", URL: "https://aws.com/login", CreditCard: &CreditCard{}},
+			err:   errors.New(`credit_card is only allowed on "credit_card" items`),
+		},
+		{
+			name:  "missing details",
+			model: PasswordCard{ID: "card-id", Type: ItemTypeIdentity, Name: "Me"},
+			err:   errors.New("identity can't be empty"),
+		},
+		{
+			name:  "password of a secure note",
+			model: PasswordCard{ID: "card-id", Type: ItemTypeSecureNote, Name: "Wi-Fi", Password: "supersecret", Notes: "the password is on the router"},
+			err:   errors.New(`password is only allowed on "login" items`),
+		},
+		{
+			name:  "empty secure note",
+			model: PasswordCard{ID: "card-id", Type: ItemTypeSecureNote, Name: "Wi-Fi"},
+			err:   errors.New("notes can't be empty"),
+		},
+		{
+			name:  "🎉 valid secure note",
+			model: PasswordCard{ID: "card-id", Type: ItemTypeSecureNote, Name: "Wi-Fi", Notes: "the password is on the router"},
+			err:   nil,
+		},
+		{
+			name:  "card number failing the Luhn check",
+			model: PasswordCard{ID: "card-id", Type: ItemTypeCreditCard, Name: "Visa", CreditCard: &CreditCard{Number: "4111 1111 1111 1112", ExpiryMonth: 12, ExpiryYear: 2030}},
+			err:   errors.New("invalid card number"),
+		},
+		{
+			name:  "too short card number",
+			model: PasswordCard{ID: "card-id", Type: ItemTypeCreditCard, Name: "Visa", CreditCard: &CreditCard{Number: "4242", ExpiryMonth: 12, ExpiryYear: 2030}},
+			err:   errors.New("invalid card number"),
+		},
+		{
+			name:  "invalid expiry month",
+			model: PasswordCard{ID: "card-id", Type: ItemTypeCreditCard, Name: "Visa", CreditCard: &CreditCard{Number: "4111 1111 1111 1111", ExpiryMonth: 13, ExpiryYear: 2030}},
+			err:   errors.New("expiry month must be between 1 and 12"),
+		},
+		{
+			name:  "invalid expiry year",
+			model: PasswordCard{ID: "card-id", Type: ItemTypeCreditCard, Name: "Visa", CreditCard: &CreditCard{Number: "4111 1111 1111 1111", ExpiryMonth: 12, ExpiryYear: 30}},
+			err:   errors.New("expiry year must have 4 digits"),
+		},
+		{
+			name:  "invalid security code",
+			model: PasswordCard{ID: "card-id", Type: ItemTypeCreditCard, Name: "Visa", CreditCard: &CreditCard{Number: "4111 1111 1111 1111", ExpiryMonth: 12, ExpiryYear: 2030, Code: "12a"}},
+			err:   errors.New("security code must have 3 or 4 digits"),
+		},
+		{
+			name:  "🎉 valid credit card",
+			model: PasswordCard{ID: "card-id", Type: ItemTypeCreditCard, Name: "Visa", CreditCard: &CreditCard{CardholderName: "Jane Doe", Number: "4111-1111-1111-1111", ExpiryMonth: 12, ExpiryYear: 2030, Code: "123"}},
+			err:   nil,
+		},
+		{
+			name:  "🎉 valid credit card with masked secrets",
+			model: PasswordCard{ID: "card-id", Type: ItemTypeCreditCard, Name: "Visa", CreditCard: &CreditCard{Number: MaskedPassword, ExpiryMonth: 12, ExpiryYear: 2030, Code: MaskedPassword}},
+			err:   nil,
+		},
+		{
+			name:  "identity without name",
+			model: PasswordCard{ID: "card-id", Type: ItemTypeIdentity, Name: "Me", Identity: &Identity{Email: "jane@example.com"}},
+			err:   errors.New("identity must have a first or a last name"),
+		},
+		{
+			name:  "invalid email",
+			model: PasswordCard{ID: "card-id", Type: ItemTypeIdentity, Name: "Me", Identity: &Identity{FirstName: "Jane", Email: "jane"}},
+			err:   errors.New("invalid email: mail: missing '@' or angle-addr"),
+		},
+		{
+			name:  "🎉 valid identity",
+			model: PasswordCard{ID: "card-id", Type: ItemTypeIdentity, Name: "Me", Identity: &Identity{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com", PassportNumber: "X1234567"}},
+			err:   nil,
+		},
+		{
+			name:  "invalid private key",
+			model: PasswordCard{ID: "card-id", Type: ItemTypeSSHKey, Name: "Deploy key", SSHKey: &SSHKey{PrivateKey: "not a key"}},
+			err:   errors.New("invalid private key: ssh: no key found"),
+		},
+		{
+			name:  "invalid public key",
+			model: PasswordCard{ID: "card-id", Type: ItemTypeSSHKey, Name: "Deploy key", SSHKey: &SSHKey{PrivateKey: privateKey, PublicKey: "ssh-ed25519"}},
+			err:   errors.New("invalid public key: ssh: no key found"),
+		},
+		{
+			name:  "🎉 valid SSH key",
+			model: PasswordCard{ID: "card-id", Type: ItemTypeSSHKey, Name: "Deploy key", URL: "ssh://git@github.com", SSHKey: &SSHKey{PrivateKey: privateKey}},
+			err:   nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.model.Validate()
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPasswordCardValidateCustomFields(t *testing.T) {
+	newCard := func(fields ...CustomField) PasswordCard {
+		return PasswordCard{ID: "card-id", Name: "AWS", Username: "username", Password: "supersecret", URL: "https://aws.com/login", CustomFields: fields}
+	}
+
+	testCases := []struct {
+		name  string
+		model PasswordCard
+		err   error
+	}{
+		{
+			name:  "empty name",
+			model: newCard(CustomField{Name: " ", Type: CustomFieldText, Value: "42"}),
+			err:   errors.New("custom field names can't be empty"),
+		},
+		{
+			name:  "unknown type",
+			model: newCard(CustomField{Name: "Account ID", Type: "number", Value: "42"}),
+			err:   errors.New(`custom field "Account ID" type must be one of "text", "hidden", "boolean", "link"`),
+		},
+		{
+			name:  "invalid boolean",
+			model: newCard(CustomField{Name: "MFA", Type: CustomFieldBoolean, Value: "yes"}),
+			err:   errors.New(`custom field "MFA" must be "true" or "false"`),
+		},
+		{
+			name:  "link without scheme",
+			model: newCard(CustomField{Name: "Console", Type: CustomFieldLink, Value: "console.aws.com"}),
+			err:   errors.New(`custom field "Console" has an invalid URL: missing scheme`),
+		},
+		{
+			name: "duplicate name",
+			model: newCard(
+				CustomField{Name: "PIN", Type: CustomFieldHidden, Value: "1234"},
+				CustomField{Name: "PIN", Type: CustomFieldText, Value: "5678"},
+			),
+			err: errors.New(`duplicate custom field "PIN"`),
+		},
+		{
+			name: "🎉 valid custom fields",
+			model: newCard(
+				CustomField{Name: "Account ID", Type: CustomFieldText, Value: "123456789012"},
+				CustomField{Name: "PIN", Type: CustomFieldHidden, Value: MaskedPassword},
+				CustomField{Name: "MFA", Type: CustomFieldBoolean, Value: "true"},
+				CustomField{Name: "Console", Type: CustomFieldLink, Value: "https://console.aws.com/"},
+				CustomField{Name: "Security question", Type: CustomFieldHidden},
+			),
+			err: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.model.Validate()
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPasswordCardValidateTags(t *testing.T) {
+	newCard := func(tags ...string) PasswordCard {
+		return PasswordCard{ID: "card-id", Name: "AWS", Username: "username", Password: "supersecret", URL: "https://aws.com/login", Tags: tags}
+	}
+
+	testCases := []struct {
+		name  string
+		model PasswordCard
+		err   error
+	}{
+		{
+			name:  "empty tag",
+			model: newCard("work", " "),
+			err:   errors.New("tags can't be empty"),
+		},
+		{
+			name:  "too long tag",
+			model: newCard(strings.Repeat("a", MaxTagLength+1)),
+			err:   errors.New("tags can't be longer than 50 characters"),
+		},
+		{
+			name:  "🎉 tag counted in characters",
+			model: newCard(strings.Repeat("é", MaxTagLength)),
+			err:   nil,
+		},
+		{
+			name:  "duplicate tag ignoring the case",
+			model: newCard("work", "Work"),
+			err:   errors.New(`duplicate tag "Work"`),
+		},
+		{
+			name:  "🎉 valid tags",
+			model: newCard("work", "cloud", "2fa"),
+			err:   nil,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -93,3 +399,227 @@ func TestPasswordCardValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestFolderRequestValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		request FolderRequest
+		err     error
+	}{
+		{
+			name:    "empty name",
+			request: FolderRequest{Name: "  "},
+			err:     errors.New("name can't be empty"),
+		},
+		{
+			name:    "too long name",
+			request: FolderRequest{Name: strings.Repeat("a", MaxFolderNameLength+1)},
+			err:     errors.New("name can't be longer than 100 characters"),
+		},
+		{
+			name:    "🎉 name counted in characters",
+			request: FolderRequest{Name: strings.Repeat("ü", MaxFolderNameLength)},
+			err:     nil,
+		},
+		{
+			name:    "too long multibyte name",
+			request: FolderRequest{Name: strings.Repeat("ü", MaxFolderNameLength+1)},
+			err:     errors.New("name can't be longer than 100 characters"),
+		},
+		{
+			name:    "🎉 valid subfolder",
+			request: FolderRequest{Name: "Banks", ParentID: "folder-id"},
+			err:     nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.request.Validate()
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPasswordCardMasked(t *testing.T) {
+	passwordCard := PasswordCard{
+		ID:         "card-id",
+		Type:       ItemTypeCreditCard,
+		Name:       "Visa",
+		Notes:      "the PIN is 1234",
+		CreditCard: &CreditCard{Number: "4111 1111 1111 1111", ExpiryMonth: 12, ExpiryYear: 2030},
+		CustomFields: []CustomField{
+			{Name: "PIN", Type: CustomFieldHidden, Value: "1234"},
+			{Name: "Question", Type: CustomFieldHidden},
+			{Name: "Bank", Type: CustomFieldText, Value: "ACME"},
+		},
+	}
+
+	masked := passwordCard.Masked()
+	assert.Equal(t, PasswordCard{
+		ID:         "card-id",
+		Type:       ItemTypeCreditCard,
+		Name:       "Visa",
+		Notes:      MaskedPassword,
+		CreditCard: &CreditCard{Number: MaskedPassword, ExpiryMonth: 12, ExpiryYear: 2030},
+		CustomFields: []CustomField{
+			{Name: "PIN", Type: CustomFieldHidden, Value: MaskedPassword},
+			{Name: "Question", Type: CustomFieldHidden},
+			{Name: "Bank", Type: CustomFieldText, Value: "ACME"},
+		},
+	}, masked)
+
+	// the details of the card aren't changed
+	assert.Equal(t, "4111 1111 1111 1111", passwordCard.CreditCard.Number)
+	assert.Equal(t, "1234", passwordCard.CustomFields[0].Value)
+}
+
+func TestSSHKeyComplete(t *testing.T) {
+	sshKey := SSHKey{PrivateKey: testSSHPrivateKey(t)}
+	sshKey.Complete()
+
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(sshKey.PublicKey))
+	require.NoError(t, err)
+	assert.Equal(t, ssh.KeyAlgoED25519, publicKey.Type())
+	assert.Equal(t, ssh.FingerprintSHA256(publicKey), sshKey.Fingerprint)
+}
+
+// testSSHPrivateKey returns a new ed25519 private key in the OpenSSH format.
+func testSSHPrivateKey(t *testing.T) string {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	block, err := ssh.MarshalPrivateKey(privateKey, "")
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(block))
+}
+
+func TestCredentialsValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		credentials Credentials
+		err         error
+	}{
+		{
+			name:        "empty username",
+			credentials: Credentials{Username: " ", Password: "supersecret"},
+			err:         errors.New("username can't be empty"),
+		},
+		{
+			name:        "short password",
+			credentials: Credentials{Username: "alice", Password: "secret"},
+			err:         errors.New("password must have at least 8 characters"),
+		},
+		{
+			name:        "🎉 valid credentials",
+			credentials: Credentials{Username: "alice", Password: "supersecret"},
+			err:         nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.credentials.Validate()
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAPITokenRequestValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		request APITokenRequest
+		err     error
+	}{
+		{
+			name:    "empty name",
+			request: APITokenRequest{Name: " ", Scope: ScopeRead},
+			err:     errors.New("name can't be empty"),
+		},
+		{
+			name:    "unknown scope",
+			request: APITokenRequest{Name: "ci", Scope: "admin"},
+			err:     errors.New(`scope must be "read" or "read-write"`),
+		},
+		{
+			name:    "too long expiration",
+			request: APITokenRequest{Name: "ci", Scope: ScopeRead, ExpiresInDays: 366},
+			err:     errors.New("expires_in_days must be between 1 and 365"),
+		},
+		{
+			name:    "🎉 valid API token request",
+			request: APITokenRequest{Name: "ci", Scope: ScopeReadWrite, ExpiresInDays: 90},
+			err:     nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.request.Validate()
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPasswordCardQueryValidate(t *testing.T) {
+	testCases := []struct {
+		name  string
+		query PasswordCardQuery
+		err   error
+	}{
+		{
+			name:  "unknown sort",
+			query: PasswordCardQuery{Sort: "password"},
+			err:   errors.New(`sort must be one of "name", "created_at" or "updated_at", optionally prefixed by "-"`),
+		},
+		{
+			name:  "too large limit",
+			query: PasswordCardQuery{Limit: 101},
+			err:   errors.New("limit must be between 1 and 100"),
+		},
+		{
+			name:  "negative offset",
+			query: PasswordCardQuery{Offset: -1},
+			err:   errors.New("offset can't be negative"),
+		},
+		{
+			name:  "offset and cursor",
+			query: PasswordCardQuery{Offset: 10, Cursor: "cursor"},
+			err:   errors.New("offset and cursor can't be used together"),
+		},
+		{
+			name:  "unknown type",
+			query: PasswordCardQuery{Type: "wifi"},
+			err:   errors.New(`type must be one of "login", "secure_note", "credit_card", "identity", "ssh_key"`),
+		},
+		{
+			name:  "🎉 valid query",
+			query: PasswordCardQuery{Search: "aws", Host: "aws.com", Type: ItemTypeLogin, Sort: "-updated_at", Limit: 10, Offset: 10},
+			err:   nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.query.Validate()
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sort orders of the password cards, a leading "-" reverses them.
+const (
+	SortByName      = "name"
+	SortByCreatedAt = "created_at"
+	SortByUpdatedAt = "updated_at"
+)
+
+// MaxPageSize is the largest page of password cards that can be requested.
+const MaxPageSize = 100
+
+// PasswordCardQuery selects a page of password cards. The zero value selects
+// every card in creation order.
+type PasswordCardQuery struct {
+	// Search matches the name, username, URL and custom fields, ignoring the
+	// case. The values of the hidden custom fields aren't searched.
+	Search string `query:"q"`
+	// Host matches the host of the URL and its subdomains, e.g. "google.com"
+	// matches "https://cloud.google.com/".
+	Host string `query:"host"`
+	// Type keeps only the items of the type, see PasswordCard.Kind.
+	Type ItemType `query:"type"`
+	// Folder keeps only the cards of the folder with this ID and of its
+	// subfolders.
+	Folder string `query:"folder"`
+	// Tag keeps only the cards with the tag, ignoring the case.
+	Tag string `query:"tag"`
+	// Breached keeps only the cards whose password is, or isn't, in the
+	// breached passwords list. It requires the list.
+	Breached *bool `query:"breached"`
+	// Sort is one of the Sort constants, optionally prefixed by "-".
+	Sort string `query:"sort"`
+	// Limit is the page size, zero means no limit.
+	Limit int `query:"limit"`
+	// Offset skips the first cards. It can't be used with Cursor.
+	Offset int `query:"offset"`
+	// Cursor is the NextCursor of the previous page.
+	Cursor string `query:"cursor"`
+}
+
+// PasswordCardPage is a page of the password cards selected by a query.
+type PasswordCardPage struct {
+	PasswordCards []PasswordCard `json:"password_cards"`
+	// Total is the number of cards matching the query in every page.
+	Total int `json:"total"`
+	// NextCursor is empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// SortField returns the field to sort by and whether the order is descending.
+func (q *PasswordCardQuery) SortField() (field string, descending bool) {
+	if q.Sort == "" {
+		return SortByCreatedAt, false
+	}
+	if strings.HasPrefix(q.Sort, "-") {
+		return strings.TrimPrefix(q.Sort, "-"), true
+	}
+	return q.Sort, false
+}
+
+func (q *PasswordCardQuery) Validate() error {
+	switch field, _ := q.SortField(); field {
+	case SortByName, SortByCreatedAt, SortByUpdatedAt:
+	default:
+		return fmt.Errorf("sort must be one of %q, %q or %q, optionally prefixed by \"-\"", SortByName, SortByCreatedAt, SortByUpdatedAt)
+	}
+
+	if q.Type != "" {
+		if err := q.Type.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if q.Limit < 0 || q.Limit > MaxPageSize {
+		return fmt.Errorf("limit must be between 1 and %d", MaxPageSize)
+	}
+
+	if q.Offset < 0 {
+		return fmt.Errorf("offset can't be negative")
+	}
+
+	if q.Offset > 0 && q.Cursor != "" {
+		return fmt.Errorf("offset and cursor can't be used together")
+	}
+
+	return nil
+}
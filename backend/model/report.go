@@ -0,0 +1,75 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultMaxPasswordAgeDays is the age after which the passwords are
+	// reported as old.
+	DefaultMaxPasswordAgeDays = 365
+	// DefaultMinHealthyScore is the lowest strength score not reported as
+	// weak.
+	DefaultMinHealthyScore = 3
+)
+
+// HealthReportQuery sets the thresholds of the health report.
+type HealthReportQuery struct {
+	// MaxAgeDays reports the passwords that didn't change for longer.
+	MaxAgeDays int `query:"max_age_days"`
+	// MinScore reports the passwords whose strength score is lower.
+	MinScore int `query:"min_score"`
+}
+
+// DefaultHealthReportQuery returns the thresholds used when the query doesn't
+// set them.
+func DefaultHealthReportQuery() HealthReportQuery {
+	return HealthReportQuery{
+		MaxAgeDays: DefaultMaxPasswordAgeDays,
+		MinScore:   DefaultMinHealthyScore,
+	}
+}
+
+func (q *HealthReportQuery) Validate() error {
+	if q.MaxAgeDays < 1 {
+		return fmt.Errorf("max_age_days must be at least 1")
+	}
+
+	if q.MinScore < MinPasswordScore || q.MinScore > MaxPasswordScore {
+		return fmt.Errorf("min_score must be between %d and %d", MinPasswordScore, MaxPasswordScore)
+	}
+
+	return nil
+}
+
+// HealthReport lists the password cards whose passwords are at risk. It never
+// holds the passwords.
+type HealthReport struct {
+	// Score is the percentage of cards without any issue, 100 for an empty
+	// vault.
+	Score int `json:"score"`
+	// TotalCards counts the logins, the only items with a password.
+	TotalCards int `json:"total_cards"`
+
+	// Reused groups the cards sharing the same password.
+	Reused       [][]HealthReportCard `json:"reused"`
+	Weak         []HealthReportCard   `json:"weak"`
+	Old          []HealthReportCard   `json:"old"`
+	InsecureURLs []HealthReportCard   `json:"insecure_urls"`
+	// Breached is always empty when the breached passwords list isn't
+	// configured.
+	Breached []HealthReportCard `json:"breached"`
+}
+
+// HealthReportCard identifies a card of the health report.
+type HealthReportCard struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Score is only set in the weak passwords.
+	Score *int `json:"score,omitempty"`
+	// PasswordChangedAt is only set in the old passwords. It's omitted when
+	// it's unknown.
+	PasswordChangedAt *time.Time `json:"password_changed_at,omitempty"`
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MinPasswordLength is the minimum length of an account password.
+const MinPasswordLength = 8
+
+type User struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	// PasswordHash is the Argon2id hash of the account password.
+	PasswordHash string    `json:"password_hash,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Session is a logged in user. Only the hash of the session token is stored.
+type Session struct {
+	TokenHash string    `json:"token_hash"`
+	UserID    string    `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Credentials are the username and password given to register or log in.
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// MasterPassword is the vault master password, only the admins give it,
+	// when they register.
+	MasterPassword string `json:"master_password,omitempty"`
+}
+
+func (c *Credentials) Validate() error {
+	if strings.TrimSpace(c.Username) == "" {
+		return fmt.Errorf("username can't be empty")
+	}
+
+	if len(c.Password) < MinPasswordLength {
+		return fmt.Errorf("password must have at least %d characters", MinPasswordLength)
+	}
+
+	return nil
+}
+
+// ClaimReport tells what happened to the password cards without owner when an
+// admin claimed them. It never holds the passwords.
+type ClaimReport struct {
+	// Claimed lists the cards given to the admin.
+	Claimed []ImportRow `json:"claimed"`
+	// Folders is the number of folders given to the admin.
+	Folders int `json:"folders"`
+	// Conflicts lists the cards whose URL the admin already uses, they stay
+	// without owner.
+	Conflicts []ImportRow `json:"conflicts"`
+}
@@ -0,0 +1,289 @@
+// Package otp generates the one-time codes of the second factors: the
+// HMAC-based ones of RFC 4226 (HOTP), derived from a counter, and the
+// time-based ones of RFC 6238 (TOTP), derived from the current time.
+//
+// The keys are given either as otpauth:// URIs, the format of the QR codes
+// shown by the services, or as bare base32 secrets using the defaults.
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Type is the type of a key, the host of its otpauth URI.
+type Type string
+
+const (
+	TypeHOTP Type = "hotp"
+	TypeTOTP Type = "totp"
+)
+
+// Algorithm is the HMAC hash function of a key.
+type Algorithm string
+
+const (
+	AlgorithmSHA1   Algorithm = "SHA1"
+	AlgorithmSHA256 Algorithm = "SHA256"
+	AlgorithmSHA512 Algorithm = "SHA512"
+)
+
+// Defaults of the keys, used by every authenticator app.
+const (
+	DefaultAlgorithm = AlgorithmSHA1
+	DefaultDigits    = 6
+	DefaultPeriod    = 30 * time.Second
+)
+
+// Bounds of the keys. The codes have 6 to 8 digits, as allowed by RFC 4226.
+const (
+	MinDigits = 6
+	MaxDigits = 8
+	MaxPeriod = time.Hour
+)
+
+// ErrInvalidSecret is returned for secrets that aren't valid base32.
+var ErrInvalidSecret = errors.New("secret must be a base32 string")
+
+// Key is a HOTP or TOTP key.
+type Key struct {
+	Type      Type
+	Secret    []byte
+	Algorithm Algorithm
+	Digits    int
+	// Period is only set for the TOTP keys.
+	Period time.Duration
+	// Counter is the initial counter of the HOTP keys.
+	Counter uint64
+	// Issuer and AccountName come from the URIs, they are empty for the bare
+	// secrets.
+	Issuer      string
+	AccountName string
+}
+
+// ParseTOTP parses an otpauth://totp/ URI or a bare base32 secret.
+func ParseTOTP(s string) (*Key, error) {
+	return parse(s, TypeTOTP)
+}
+
+// ParseHOTP parses an otpauth://hotp/ URI or a bare base32 secret, whose
+// counter starts at 0.
+func ParseHOTP(s string) (*Key, error) {
+	return parse(s, TypeHOTP)
+}
+
+func parse(s string, keyType Type) (*Key, error) {
+	key := &Key{Type: keyType, Algorithm: DefaultAlgorithm, Digits: DefaultDigits}
+	if keyType == TypeTOTP {
+		key.Period = DefaultPeriod
+	}
+
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(strings.ToLower(s), "otpauth:") {
+		secret, err := decodeSecret(s)
+		if err != nil {
+			return nil, err
+		}
+
+		key.Secret = secret
+		return key, nil
+	}
+
+	uri, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid otpauth URI: %w", err)
+	}
+
+	if !strings.EqualFold(uri.Host, string(keyType)) {
+		return nil, fmt.Errorf("unsupported otpauth type %q, only %s is supported", uri.Host, keyType)
+	}
+
+	query := uri.Query()
+
+	key.Secret, err = decodeSecret(query.Get("secret"))
+	if err != nil {
+		return nil, err
+	}
+
+	// the label is "issuer:account", or only the account
+	label := strings.TrimPrefix(uri.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		key.Issuer = strings.TrimSpace(issuer)
+		key.AccountName = strings.TrimSpace(account)
+	} else {
+		key.AccountName = strings.TrimSpace(label)
+	}
+	// the issuer parameter is the recommended one
+	if issuer := query.Get("issuer"); issuer != "" {
+		key.Issuer = issuer
+	}
+
+	if algorithm := query.Get("algorithm"); algorithm != "" {
+		key.Algorithm = Algorithm(strings.ToUpper(algorithm))
+		if _, err := key.Algorithm.hash(); err != nil {
+			return nil, err
+		}
+	}
+
+	if digits := query.Get("digits"); digits != "" {
+		key.Digits, err = strconv.Atoi(digits)
+		if err != nil || key.Digits < MinDigits || key.Digits > MaxDigits {
+			return nil, fmt.Errorf("digits must be between %d and %d", MinDigits, MaxDigits)
+		}
+	}
+
+	if counter := query.Get("counter"); keyType == TypeHOTP && counter != "" {
+		key.Counter, err = strconv.ParseUint(counter, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("counter must be a positive integer")
+		}
+	}
+
+	if period := query.Get("period"); keyType == TypeTOTP && period != "" {
+		seconds, err := strconv.Atoi(period)
+		if err != nil || seconds < 1 || time.Duration(seconds)*time.Second > MaxPeriod {
+			return nil, fmt.Errorf("period must be between 1 and %d seconds", int(MaxPeriod.Seconds()))
+		}
+		key.Period = time.Duration(seconds) * time.Second
+	}
+
+	return key, nil
+}
+
+// URI returns the otpauth:// URI of the key, understood by the authenticator
+// apps.
+func (k *Key) URI() string {
+	label := k.AccountName
+	if k.Issuer != "" {
+		label = k.Issuer + ":" + k.AccountName
+	}
+
+	query := url.Values{}
+	query.Set("secret", base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(k.Secret))
+	if k.Issuer != "" {
+		query.Set("issuer", k.Issuer)
+	}
+	query.Set("algorithm", string(k.Algorithm))
+	query.Set("digits", strconv.Itoa(k.Digits))
+	if k.Type == TypeHOTP {
+		query.Set("counter", strconv.FormatUint(k.Counter, 10))
+	} else {
+		query.Set("period", strconv.Itoa(int(k.Period/time.Second)))
+	}
+
+	uri := url.URL{Scheme: "otpauth", Host: string(k.Type), Path: "/" + label, RawQuery: query.Encode()}
+	return uri.String()
+}
+
+// Code returns the code of the TOTP key at t.
+func (k *Key) Code(t time.Time) (string, error) {
+	return hotp(k.Secret, k.Algorithm, k.Digits, k.counter(t))
+}
+
+// CounterCode returns the code of the HOTP key for counter.
+func (k *Key) CounterCode(counter uint64) (string, error) {
+	return hotp(k.Secret, k.Algorithm, k.Digits, counter)
+}
+
+// Resync looks for two consecutive codes of the HOTP key among the window
+// counters following counter, for tokens whose counter moved ahead of the
+// server one. It returns the counter following the second code.
+func (k *Key) Resync(code1, code2 string, counter, window uint64) (uint64, bool, error) {
+	for c := counter; c < counter+window; c++ {
+		code, err := k.CounterCode(c)
+		if err != nil {
+			return 0, false, err
+		}
+		if !hmac.Equal([]byte(code), []byte(code1)) {
+			continue
+		}
+
+		next, err := k.CounterCode(c + 1)
+		if err != nil {
+			return 0, false, err
+		}
+		if hmac.Equal([]byte(next), []byte(code2)) {
+			return c + 2, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+// Remaining returns how long the code of t stays valid.
+func (k *Key) Remaining(t time.Time) time.Duration {
+	period := int64(k.Period / time.Second)
+	return time.Duration(period-t.Unix()%period) * time.Second
+}
+
+// counter is the number of periods since the Unix epoch.
+func (k *Key) counter(t time.Time) uint64 {
+	return uint64(t.Unix() / int64(k.Period/time.Second))
+}
+
+func (a Algorithm) hash() (func() hash.Hash, error) {
+	switch a {
+	case AlgorithmSHA1:
+		return sha1.New, nil
+	case AlgorithmSHA256:
+		return sha256.New, nil
+	case AlgorithmSHA512:
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("algorithm must be one of %s, %s or %s", AlgorithmSHA1, AlgorithmSHA256, AlgorithmSHA512)
+	}
+}
+
+// hotp returns the code of the counter as defined by RFC 4226.
+func hotp(secret []byte, algorithm Algorithm, digits int, counter uint64) (string, error) {
+	newHash, err := algorithm.hash()
+	if err != nil {
+		return "", err
+	}
+	if digits < MinDigits || digits > MaxDigits {
+		return "", fmt.Errorf("digits must be between %d and %d", MinDigits, MaxDigits)
+	}
+
+	mac := hmac.New(newHash, secret)
+	_ = binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < digits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, value%modulo), nil
+}
+
+// decodeSecret decodes a base32 secret. Spaces, lowercase letters and a
+// missing padding are accepted, the services show the secrets in many ways.
+func decodeSecret(s string) ([]byte, error) {
+	s = strings.ToUpper(strings.Join(strings.Fields(s), ""))
+	s = strings.TrimRight(s, "=")
+	if s == "" {
+		return nil, fmt.Errorf("secret can't be empty")
+	}
+
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidSecret
+	}
+
+	return secret, nil
+}
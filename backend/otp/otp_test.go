@@ -0,0 +1,202 @@
+package otp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHOTP(t *testing.T) {
+	// RFC 4226 appendix D
+	secret := []byte("12345678901234567890")
+	expected := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+	for counter, code := range expected {
+		actual, err := hotp(secret, AlgorithmSHA1, 6, uint64(counter))
+		require.NoError(t, err)
+		assert.Equal(t, code, actual, "counter %d", counter)
+	}
+}
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238 appendix B, the secrets are the ASCII seeds
+	keys := map[Algorithm]*Key{
+		AlgorithmSHA1:   {Type: TypeTOTP, Secret: []byte("12345678901234567890"), Algorithm: AlgorithmSHA1, Digits: 8, Period: 30 * time.Second},
+		AlgorithmSHA256: {Type: TypeTOTP, Secret: []byte("12345678901234567890123456789012"), Algorithm: AlgorithmSHA256, Digits: 8, Period: 30 * time.Second},
+		AlgorithmSHA512: {Type: TypeTOTP, Secret: []byte("1234567890123456789012345678901234567890123456789012345678901234"), Algorithm: AlgorithmSHA512, Digits: 8, Period: 30 * time.Second},
+	}
+
+	tests := []struct {
+		unix      int64
+		algorithm Algorithm
+		code      string
+	}{
+		{59, AlgorithmSHA1, "94287082"},
+		{59, AlgorithmSHA256, "46119246"},
+		{59, AlgorithmSHA512, "90693936"},
+		{1111111109, AlgorithmSHA1, "07081804"},
+		{1111111109, AlgorithmSHA256, "68084774"},
+		{1111111109, AlgorithmSHA512, "25091201"},
+		{20000000000, AlgorithmSHA1, "65353130"},
+		{20000000000, AlgorithmSHA256, "77737706"},
+		{20000000000, AlgorithmSHA512, "47863826"},
+	}
+
+	for _, tt := range tests {
+		code, err := keys[tt.algorithm].Code(time.Unix(tt.unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, tt.code, code, "%s at %d", tt.algorithm, tt.unix)
+	}
+
+	t.Run("🎉 tells how long the code stays valid", func(t *testing.T) {
+		key := keys[AlgorithmSHA1]
+		assert.Equal(t, 30*time.Second, key.Remaining(time.Unix(60, 0)))
+		assert.Equal(t, time.Second, key.Remaining(time.Unix(59, 0)))
+		assert.Equal(t, 19*time.Second, key.Remaining(time.Unix(71, 500)))
+	})
+}
+
+func TestParseTOTP(t *testing.T) {
+	t.Run("🎉 parses a bare secret with the defaults", func(t *testing.T) {
+		key, err := ParseTOTP("gezd gnbv gy3t qojq gezd gnbv gy3t qojq")
+		require.NoError(t, err)
+		assert.Equal(t, &Key{
+			Type:      TypeTOTP,
+			Secret:    []byte("12345678901234567890"),
+			Algorithm: AlgorithmSHA1,
+			Digits:    6,
+			Period:    30 * time.Second,
+		}, key)
+	})
+
+	t.Run("🎉 parses an otpauth URI", func(t *testing.T) {
+		key, err := ParseTOTP("otpauth://totp/ACME%20Co:john@example.com?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=ACME+Co&algorithm=SHA256&digits=8&period=60")
+		require.NoError(t, err)
+		assert.Equal(t, &Key{
+			Type:        TypeTOTP,
+			Secret:      []byte("12345678901234567890"),
+			Algorithm:   AlgorithmSHA256,
+			Digits:      8,
+			Period:      time.Minute,
+			Issuer:      "ACME Co",
+			AccountName: "john@example.com",
+		}, key)
+	})
+
+	t.Run("🎉 parses an otpauth URI with the defaults", func(t *testing.T) {
+		key, err := ParseTOTP("otpauth://totp/john?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+		require.NoError(t, err)
+		assert.Equal(t, &Key{
+			Type:        TypeTOTP,
+			Secret:      []byte("12345678901234567890"),
+			Algorithm:   AlgorithmSHA1,
+			Digits:      6,
+			Period:      30 * time.Second,
+			AccountName: "john",
+		}, key)
+	})
+
+	t.Run("🎉 round trips through the URI", func(t *testing.T) {
+		key := &Key{
+			Type:        TypeTOTP,
+			Secret:      []byte("12345678901234567890"),
+			Algorithm:   AlgorithmSHA512,
+			Digits:      7,
+			Period:      45 * time.Second,
+			Issuer:      "ACME Co",
+			AccountName: "john@example.com",
+		}
+
+		parsed, err := ParseTOTP(key.URI())
+		require.NoError(t, err)
+		assert.Equal(t, key, parsed)
+	})
+
+	tests := map[string]struct {
+		key string
+		err string
+	}{
+		"empty":             {key: "", err: "secret can't be empty"},
+		"not base32":        {key: "not-base32!", err: "secret must be a base32 string"},
+		"without secret":    {key: "otpauth://totp/john", err: "secret can't be empty"},
+		"HOTP":              {key: "otpauth://hotp/john?secret=GEZDGNBV&counter=0", err: `unsupported otpauth type "hotp", only totp is supported`},
+		"unknown algorithm": {key: "otpauth://totp/john?secret=GEZDGNBV&algorithm=MD5", err: "algorithm must be one of SHA1, SHA256 or SHA512"},
+		"too few digits":    {key: "otpauth://totp/john?secret=GEZDGNBV&digits=4", err: "digits must be between 6 and 8"},
+		"too many digits":   {key: "otpauth://totp/john?secret=GEZDGNBV&digits=10", err: "digits must be between 6 and 8"},
+		"zero period":       {key: "otpauth://totp/john?secret=GEZDGNBV&period=0", err: "period must be between 1 and 3600 seconds"},
+	}
+
+	for name, tt := range tests {
+		t.Run("returns error for "+name, func(t *testing.T) {
+			_, err := ParseTOTP(tt.key)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestParseHOTP(t *testing.T) {
+	t.Run("🎉 parses an otpauth URI", func(t *testing.T) {
+		key, err := ParseHOTP("otpauth://hotp/VPN:john?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&counter=42&digits=8")
+		require.NoError(t, err)
+		assert.Equal(t, &Key{
+			Type:        TypeHOTP,
+			Secret:      []byte("12345678901234567890"),
+			Algorithm:   AlgorithmSHA1,
+			Digits:      8,
+			Counter:     42,
+			Issuer:      "VPN",
+			AccountName: "john",
+		}, key)
+
+		parsed, err := ParseHOTP(key.URI())
+		require.NoError(t, err)
+		assert.Equal(t, key, parsed)
+	})
+
+	t.Run("🎉 parses a bare secret with the counter at 0", func(t *testing.T) {
+		key, err := ParseHOTP("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+		require.NoError(t, err)
+		assert.Equal(t, &Key{Type: TypeHOTP, Secret: []byte("12345678901234567890"), Algorithm: AlgorithmSHA1, Digits: 6}, key)
+	})
+
+	t.Run("returns error for TOTP URIs", func(t *testing.T) {
+		_, err := ParseHOTP("otpauth://totp/john?secret=GEZDGNBV")
+		assert.EqualError(t, err, `unsupported otpauth type "totp", only hotp is supported`)
+	})
+
+	t.Run("returns error for invalid counters", func(t *testing.T) {
+		_, err := ParseHOTP("otpauth://hotp/john?secret=GEZDGNBV&counter=-1")
+		assert.EqualError(t, err, "counter must be a positive integer")
+	})
+}
+
+func TestResync(t *testing.T) {
+	// the RFC 4226 codes, see TestHOTP
+	key := &Key{Type: TypeHOTP, Secret: []byte("12345678901234567890"), Algorithm: AlgorithmSHA1, Digits: 6}
+
+	t.Run("🎉 finds the counter following two consecutive codes", func(t *testing.T) {
+		counter, ok, err := key.Resync("162583", "399871", 2, 10)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, uint64(9), counter)
+	})
+
+	t.Run("doesn't match codes that aren't consecutive", func(t *testing.T) {
+		_, ok, err := key.Resync("162583", "520489", 2, 10)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("doesn't match codes out of the window", func(t *testing.T) {
+		_, ok, err := key.Resync("162583", "399871", 2, 3)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		// the codes behind the counter are already used
+		_, ok, err = key.Resync("287082", "359152", 2, 10)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
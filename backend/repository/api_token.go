@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+const apiTokensSection = "api_tokens"
+
+// ErrAPITokenAlreadyExists is returned when the user already has a token with
+// the same name.
+type ErrAPITokenAlreadyExists struct {
+	Name string
+}
+
+// Error implements error type interface.
+func (e ErrAPITokenAlreadyExists) Error() string {
+	return fmt.Sprintf("API token %q already exists", e.Name)
+}
+
+// ErrAPITokenNotFound is returned for unknown API tokens. ID is empty when the
+// token was looked up by its hash.
+type ErrAPITokenNotFound struct {
+	ID string
+}
+
+// Error implements error type interface.
+func (e ErrAPITokenNotFound) Error() string {
+	if e.ID == "" {
+		return "API token not found"
+	}
+	return fmt.Sprintf("API token with ID %q not found", e.ID)
+}
+
+// APITokenStore is implemented by every API token storage backend.
+type APITokenStore interface {
+	// Insert stores a new token. Token names are unique per user.
+	Insert(newAPIToken model.APIToken) error
+	GetByTokenHash(tokenHash string) (*model.APIToken, error)
+	// ListByUserID returns the tokens of the user in creation order.
+	ListByUserID(userID string) ([]model.APIToken, error)
+	// Delete removes a token of the user.
+	Delete(userID, apiTokenID string) error
+}
+
+var (
+	_ APITokenStore = (*APITokenRepository)(nil)
+	_ APITokenStore = (*SQLiteAPITokenRepository)(nil)
+)
+
+// APITokenRepository stores the API tokens in memory and, optionally, in a
+// vault file.
+type APITokenRepository struct {
+	apiTokens []model.APIToken
+	mu        sync.Mutex
+
+	// storage is nil for repositories that only live in memory.
+	storage *FileStorage
+}
+
+func NewAPITokenRepository() *APITokenRepository {
+	return &APITokenRepository{apiTokens: make([]model.APIToken, 0)}
+}
+
+// NewFileAPITokenRepository returns a repository whose tokens are persisted in
+// the given file storage.
+func NewFileAPITokenRepository(storage *FileStorage) (*APITokenRepository, error) {
+	tr := NewAPITokenRepository()
+	if _, err := storage.Load(apiTokensSection, &tr.apiTokens); err != nil {
+		return nil, fmt.Errorf("error loading API tokens: %w", err)
+	}
+
+	tr.storage = storage
+
+	return tr, nil
+}
+
+func (tr *APITokenRepository) Insert(newAPIToken model.APIToken) error {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	for _, apiToken := range tr.apiTokens {
+		if apiToken.UserID == newAPIToken.UserID && apiToken.Name == newAPIToken.Name {
+			return ErrAPITokenAlreadyExists{Name: newAPIToken.Name}
+		}
+	}
+
+	apiTokens := make([]model.APIToken, 0, len(tr.apiTokens)+1)
+	apiTokens = append(apiTokens, tr.apiTokens...)
+	apiTokens = append(apiTokens, newAPIToken)
+
+	return tr.save(apiTokens)
+}
+
+func (tr *APITokenRepository) GetByTokenHash(tokenHash string) (*model.APIToken, error) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	for _, apiToken := range tr.apiTokens {
+		if apiToken.TokenHash == tokenHash {
+			return &apiToken, nil
+		}
+	}
+
+	return nil, ErrAPITokenNotFound{}
+}
+
+func (tr *APITokenRepository) ListByUserID(userID string) ([]model.APIToken, error) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	apiTokens := make([]model.APIToken, 0)
+	for _, apiToken := range tr.apiTokens {
+		if apiToken.UserID == userID {
+			apiTokens = append(apiTokens, apiToken)
+		}
+	}
+
+	return apiTokens, nil
+}
+
+func (tr *APITokenRepository) Delete(userID, apiTokenID string) error {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	apiTokens := make([]model.APIToken, 0, len(tr.apiTokens))
+	for _, apiToken := range tr.apiTokens {
+		if apiToken.UserID != userID || apiToken.ID != apiTokenID {
+			apiTokens = append(apiTokens, apiToken)
+		}
+	}
+
+	if len(apiTokens) == len(tr.apiTokens) {
+		return ErrAPITokenNotFound{ID: apiTokenID}
+	}
+
+	return tr.save(apiTokens)
+}
+
+// save must be called with the lock held.
+func (tr *APITokenRepository) save(apiTokens []model.APIToken) error {
+	if tr.storage != nil {
+		if err := tr.storage.Save(apiTokensSection, apiTokens); err != nil {
+			return fmt.Errorf("error saving API tokens: %w", err)
+		}
+	}
+
+	tr.apiTokens = apiTokens
+
+	return nil
+}
+
+// SQLiteAPITokenRepository stores the API tokens in a SQLite database.
+type SQLiteAPITokenRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteAPITokenRepository(db *sql.DB) *SQLiteAPITokenRepository {
+	return &SQLiteAPITokenRepository{db: db}
+}
+
+// apiTokenColumns are the api_tokens columns in the order used by the queries
+// and by scanAPIToken.
+const apiTokenColumns = `id, user_id, name, scope, token_hash, created_at, expires_at`
+
+func (tr *SQLiteAPITokenRepository) Insert(newAPIToken model.APIToken) error {
+	_, err := tr.db.Exec(
+		`INSERT INTO api_tokens (`+apiTokenColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		newAPIToken.ID,
+		newAPIToken.UserID,
+		newAPIToken.Name,
+		newAPIToken.Scope,
+		newAPIToken.TokenHash,
+		newAPIToken.CreatedAt.UTC(),
+		newAPIToken.ExpiresAt.UTC(),
+	)
+	if err != nil {
+		var sqliteErr *sqlite.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+			return ErrAPITokenAlreadyExists{Name: newAPIToken.Name}
+		}
+		return fmt.Errorf("error saving API token: %w", err)
+	}
+
+	return nil
+}
+
+func (tr *SQLiteAPITokenRepository) GetByTokenHash(tokenHash string) (*model.APIToken, error) {
+	apiToken, err := scanAPIToken(tr.db.QueryRow(`SELECT `+apiTokenColumns+` FROM api_tokens WHERE token_hash = ?`, tokenHash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAPITokenNotFound{}
+		}
+		return nil, fmt.Errorf("error getting API token: %w", err)
+	}
+
+	return apiToken, nil
+}
+
+func (tr *SQLiteAPITokenRepository) ListByUserID(userID string) ([]model.APIToken, error) {
+	rows, err := tr.db.Query(`SELECT `+apiTokenColumns+` FROM api_tokens WHERE user_id = ? ORDER BY rowid`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing API tokens: %w", err)
+	}
+	defer rows.Close()
+
+	apiTokens := make([]model.APIToken, 0)
+	for rows.Next() {
+		apiToken, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning API token: %w", err)
+		}
+
+		apiTokens = append(apiTokens, *apiToken)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing API tokens: %w", err)
+	}
+
+	return apiTokens, nil
+}
+
+func (tr *SQLiteAPITokenRepository) Delete(userID, apiTokenID string) error {
+	result, err := tr.db.Exec(`DELETE FROM api_tokens WHERE user_id = ? AND id = ?`, userID, apiTokenID)
+	if err != nil {
+		return fmt.Errorf("error deleting API token: %w", err)
+	}
+
+	return expectAffected(result, ErrAPITokenNotFound{ID: apiTokenID})
+}
+
+func scanAPIToken(row scanner) (*model.APIToken, error) {
+	var apiToken model.APIToken
+	err := row.Scan(
+		&apiToken.ID,
+		&apiToken.UserID,
+		&apiToken.Name,
+		&apiToken.Scope,
+		&apiToken.TokenHash,
+		&apiToken.CreatedAt,
+		&apiToken.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// the driver returns the times in the local time zone, they are stored in UTC
+	apiToken.CreatedAt = apiToken.CreatedAt.UTC()
+	apiToken.ExpiresAt = apiToken.ExpiresAt.UTC()
+
+	return &apiToken, nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPITokenStores(t *testing.T) {
+	now := time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+	users := []model.User{
+		{ID: "user-id-1", Username: "alice", PasswordHash: "$argon2id$hash", CreatedAt: now},
+		{ID: "user-id-2", Username: "bob", PasswordHash: "$argon2id$hash", CreatedAt: now},
+	}
+	ciToken := model.APIToken{
+		ID:        "token-id-1",
+		UserID:    "user-id-1",
+		Name:      "ci",
+		Scope:     model.ScopeRead,
+		TokenHash: "token-hash-1",
+		CreatedAt: now,
+		ExpiresAt: now.AddDate(0, 0, 30),
+	}
+	deployToken := model.APIToken{
+		ID:        "token-id-2",
+		UserID:    "user-id-1",
+		Name:      "deploy",
+		Scope:     model.ScopeReadWrite,
+		TokenHash: "token-hash-2",
+		CreatedAt: now,
+		ExpiresAt: now.AddDate(0, 0, 30),
+	}
+
+	testCases := []struct {
+		name string
+		open func(t *testing.T, path string) APITokenStore
+	}{
+		{
+			name: "memory",
+			open: func(t *testing.T, path string) APITokenStore {
+				return NewAPITokenRepository()
+			},
+		},
+		{
+			name: "file",
+			open: func(t *testing.T, path string) APITokenStore {
+				s, err := OpenFileStorage(path)
+				require.NoError(t, err)
+
+				tr, err := NewFileAPITokenRepository(s)
+				require.NoError(t, err)
+				return tr
+			},
+		},
+		{
+			name: "sqlite",
+			open: func(t *testing.T, path string) APITokenStore {
+				db, err := OpenSQLite(path)
+				require.NoError(t, err)
+				t.Cleanup(func() { db.Close() })
+
+				// the tokens reference their user
+				ur := NewSQLiteUserRepository(db)
+				for _, user := range users {
+					require.NoError(t, ur.Insert(user))
+				}
+
+				return NewSQLiteAPITokenRepository(db)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := tc.open(t, filepath.Join(t.TempDir(), "vault"))
+
+			_, err := tr.GetByTokenHash(ciToken.TokenHash)
+			assert.ErrorIs(t, err, ErrAPITokenNotFound{})
+
+			require.NoError(t, tr.Insert(ciToken))
+			require.NoError(t, tr.Insert(deployToken))
+
+			duplicated := ciToken
+			duplicated.ID = "token-id-3"
+			duplicated.TokenHash = "token-hash-3"
+			assert.ErrorIs(t, tr.Insert(duplicated), ErrAPITokenAlreadyExists{Name: "ci"})
+
+			// the names are only unique per user
+			duplicated.UserID = "user-id-2"
+			require.NoError(t, tr.Insert(duplicated))
+
+			loaded, err := tr.GetByTokenHash(ciToken.TokenHash)
+			require.NoError(t, err)
+			assert.Equal(t, &ciToken, loaded)
+
+			apiTokens, err := tr.ListByUserID("user-id-1")
+			require.NoError(t, err)
+			assert.Equal(t, []model.APIToken{ciToken, deployToken}, apiTokens)
+
+			// a user can't revoke the tokens of another one
+			assert.ErrorIs(t, tr.Delete("user-id-2", ciToken.ID), ErrAPITokenNotFound{ID: ciToken.ID})
+
+			require.NoError(t, tr.Delete("user-id-1", ciToken.ID))
+			assert.ErrorIs(t, tr.Delete("user-id-1", ciToken.ID), ErrAPITokenNotFound{ID: ciToken.ID})
+
+			apiTokens, err = tr.ListByUserID("user-id-1")
+			require.NoError(t, err)
+			assert.Equal(t, []model.APIToken{deployToken}, apiTokens)
+		})
+	}
+}
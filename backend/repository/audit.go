@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+)
+
+const auditEventsSection = "audit_events"
+
+// AuditStore is implemented by every audit log storage backend. The events
+// are only appended, never changed.
+type AuditStore interface {
+	Insert(newAuditEvent model.AuditEvent) error
+	// ListByPasswordCardID returns the events of the card, oldest first.
+	ListByPasswordCardID(passwordCardID string) ([]model.AuditEvent, error)
+}
+
+var (
+	_ AuditStore = (*AuditRepository)(nil)
+	_ AuditStore = (*SQLiteAuditRepository)(nil)
+)
+
+// AuditRepository stores the audit events in memory and, optionally, in a
+// vault file.
+type AuditRepository struct {
+	auditEvents []model.AuditEvent
+	mu          sync.Mutex
+
+	// storage is nil for repositories that only live in memory.
+	storage *FileStorage
+}
+
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{auditEvents: make([]model.AuditEvent, 0)}
+}
+
+// NewFileAuditRepository returns a repository whose events are persisted in
+// the given file storage.
+func NewFileAuditRepository(storage *FileStorage) (*AuditRepository, error) {
+	ar := NewAuditRepository()
+	if _, err := storage.Load(auditEventsSection, &ar.auditEvents); err != nil {
+		return nil, fmt.Errorf("error loading audit events: %w", err)
+	}
+
+	ar.storage = storage
+
+	return ar, nil
+}
+
+func (ar *AuditRepository) Insert(newAuditEvent model.AuditEvent) error {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	auditEvents := make([]model.AuditEvent, 0, len(ar.auditEvents)+1)
+	auditEvents = append(auditEvents, ar.auditEvents...)
+	auditEvents = append(auditEvents, newAuditEvent)
+
+	if ar.storage != nil {
+		if err := ar.storage.Save(auditEventsSection, auditEvents); err != nil {
+			return fmt.Errorf("error saving audit events: %w", err)
+		}
+	}
+
+	ar.auditEvents = auditEvents
+
+	return nil
+}
+
+func (ar *AuditRepository) ListByPasswordCardID(passwordCardID string) ([]model.AuditEvent, error) {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	auditEvents := make([]model.AuditEvent, 0)
+	for _, auditEvent := range ar.auditEvents {
+		if auditEvent.PasswordCardID == passwordCardID {
+			auditEvents = append(auditEvents, auditEvent)
+		}
+	}
+
+	return auditEvents, nil
+}
+
+// SQLiteAuditRepository stores the audit events in a SQLite database.
+type SQLiteAuditRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteAuditRepository(db *sql.DB) *SQLiteAuditRepository {
+	return &SQLiteAuditRepository{db: db}
+}
+
+func (ar *SQLiteAuditRepository) Insert(newAuditEvent model.AuditEvent) error {
+	_, err := ar.db.Exec(
+		`INSERT INTO audit_events (id, action, user_id, api_token_id, password_card_id, remote_ip, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		newAuditEvent.ID,
+		newAuditEvent.Action,
+		newAuditEvent.UserID,
+		newAuditEvent.APITokenID,
+		newAuditEvent.PasswordCardID,
+		newAuditEvent.RemoteIP,
+		newAuditEvent.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("error saving audit event: %w", err)
+	}
+
+	return nil
+}
+
+func (ar *SQLiteAuditRepository) ListByPasswordCardID(passwordCardID string) ([]model.AuditEvent, error) {
+	rows, err := ar.db.Query(
+		`SELECT id, action, user_id, api_token_id, password_card_id, remote_ip, created_at FROM audit_events WHERE password_card_id = ? ORDER BY rowid`,
+		passwordCardID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error listing audit events: %w", err)
+	}
+	defer rows.Close()
+
+	auditEvents := make([]model.AuditEvent, 0)
+	for rows.Next() {
+		var auditEvent model.AuditEvent
+		err := rows.Scan(
+			&auditEvent.ID,
+			&auditEvent.Action,
+			&auditEvent.UserID,
+			&auditEvent.APITokenID,
+			&auditEvent.PasswordCardID,
+			&auditEvent.RemoteIP,
+			&auditEvent.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning audit event: %w", err)
+		}
+
+		// the driver returns the times in the local time zone, they are stored in UTC
+		auditEvent.CreatedAt = auditEvent.CreatedAt.UTC()
+
+		auditEvents = append(auditEvents, auditEvent)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing audit events: %w", err)
+	}
+
+	return auditEvents, nil
+}
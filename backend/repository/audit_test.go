@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditStores(t *testing.T) {
+	now := time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+	firstReveal := model.AuditEvent{
+		ID:             "event-id-1",
+		Action:         model.AuditActionRevealPassword,
+		UserID:         "user-id-1",
+		PasswordCardID: "card-id-1",
+		RemoteIP:       "10.0.0.1",
+		CreatedAt:      now,
+	}
+	secondReveal := model.AuditEvent{
+		ID:             "event-id-2",
+		Action:         model.AuditActionRevealPassword,
+		UserID:         "user-id-1",
+		APITokenID:     "token-id-1",
+		PasswordCardID: "card-id-1",
+		RemoteIP:       "10.0.0.2",
+		CreatedAt:      now.Add(time.Minute),
+	}
+	otherCardReveal := model.AuditEvent{
+		ID:             "event-id-3",
+		Action:         model.AuditActionRevealPassword,
+		PasswordCardID: "card-id-2",
+		RemoteIP:       "10.0.0.1",
+		CreatedAt:      now,
+	}
+
+	testCases := []struct {
+		name string
+		open func(t *testing.T, path string) AuditStore
+	}{
+		{
+			name: "memory",
+			open: func(t *testing.T, path string) AuditStore {
+				return NewAuditRepository()
+			},
+		},
+		{
+			name: "file",
+			open: func(t *testing.T, path string) AuditStore {
+				s, err := OpenFileStorage(path)
+				require.NoError(t, err)
+
+				ar, err := NewFileAuditRepository(s)
+				require.NoError(t, err)
+				return ar
+			},
+		},
+		{
+			name: "sqlite",
+			open: func(t *testing.T, path string) AuditStore {
+				db, err := OpenSQLite(path)
+				require.NoError(t, err)
+				t.Cleanup(func() { db.Close() })
+
+				return NewSQLiteAuditRepository(db)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ar := tc.open(t, filepath.Join(t.TempDir(), "vault"))
+
+			auditEvents, err := ar.ListByPasswordCardID("card-id-1")
+			require.NoError(t, err)
+			assert.Empty(t, auditEvents)
+
+			require.NoError(t, ar.Insert(firstReveal))
+			require.NoError(t, ar.Insert(otherCardReveal))
+			require.NoError(t, ar.Insert(secondReveal))
+
+			auditEvents, err = ar.ListByPasswordCardID("card-id-1")
+			require.NoError(t, err)
+			assert.Equal(t, []model.AuditEvent{firstReveal, secondReveal}, auditEvents)
+		})
+	}
+}
+
+func TestFileAuditRepositoryReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.json")
+	auditEvent := model.AuditEvent{
+		ID:             "event-id-1",
+		Action:         model.AuditActionRevealPassword,
+		PasswordCardID: "card-id-1",
+		RemoteIP:       "10.0.0.1",
+		CreatedAt:      time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC),
+	}
+
+	s, err := OpenFileStorage(path)
+	require.NoError(t, err)
+	ar, err := NewFileAuditRepository(s)
+	require.NoError(t, err)
+	require.NoError(t, ar.Insert(auditEvent))
+
+	s, err = OpenFileStorage(path)
+	require.NoError(t, err)
+	ar, err = NewFileAuditRepository(s)
+	require.NoError(t, err)
+
+	auditEvents, err := ar.ListByPasswordCardID("card-id-1")
+	require.NoError(t, err)
+	assert.Equal(t, []model.AuditEvent{auditEvent}, auditEvents)
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+)
+
+const (
+	fileStorageVersion = 1
+
+	passwordCardsSection = "password_cards"
+)
+
+// FileStorage keeps the whole vault in a single JSON file. The file is split
+// in named sections so every repository can persist its own data without
+// knowing about the others.
+//
+// Writes never touch the vault file directly: the new content is written to a
+// temporary file in the same directory, fsynced and then renamed over the old
+// one, so a crash leaves either the previous or the new vault on disk.
+type FileStorage struct {
+	path     string
+	mu       sync.Mutex
+	sections map[string]json.RawMessage
+}
+
+type fileDocument struct {
+	Version  int                        `json:"version"`
+	Sections map[string]json.RawMessage `json:"sections"`
+}
+
+// OpenFileStorage loads the vault stored at path. A missing file is not an
+// error, it is created on the first write.
+func OpenFileStorage(path string) (*FileStorage, error) {
+	s := &FileStorage{path: path, sections: make(map[string]json.RawMessage)}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("error reading vault file: %w", err)
+	}
+
+	var doc fileDocument
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("error decoding vault file %q: %w", path, err)
+	}
+
+	if doc.Version != fileStorageVersion {
+		return nil, fmt.Errorf("unsupported vault file version %d", doc.Version)
+	}
+
+	if doc.Sections != nil {
+		s.sections = doc.Sections
+	}
+
+	return s, nil
+}
+
+// Path returns the location of the vault file.
+func (s *FileStorage) Path() string {
+	return s.path
+}
+
+// Load decodes the given section into v. It returns false when the section
+// was never saved.
+func (s *FileStorage) Load(section string, v any) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	raw, ok := s.sections[section]
+	if !ok {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return false, fmt.Errorf("error decoding section %q: %w", section, err)
+	}
+
+	return true, nil
+}
+
+// Save replaces the given section with v and writes the vault file to disk.
+// The in-memory copy is only changed when the write succeeds.
+func (s *FileStorage) Save(section string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("error encoding section %q: %w", section, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sections := make(map[string]json.RawMessage, len(s.sections)+1)
+	for name, content := range s.sections {
+		sections[name] = content
+	}
+	sections[section] = raw
+
+	content, err := json.Marshal(fileDocument{Version: fileStorageVersion, Sections: sections})
+	if err != nil {
+		return fmt.Errorf("error encoding vault file: %w", err)
+	}
+
+	if err := writeFileAtomic(s.path, content, 0o600); err != nil {
+		return fmt.Errorf("error writing vault file: %w", err)
+	}
+
+	s.sections = sections
+
+	return nil
+}
+
+// writeFileAtomic writes content to a temporary file next to path and renames
+// it over path once it is safely on disk.
+func writeFileAtomic(path string, content []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	// the temporary file is useless if anything below fails
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// fsync the directory so the rename itself survives a crash
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
+
+// NewFilePasswordCardRepository returns a repository whose password cards are
+// persisted in the given file storage.
+func NewFilePasswordCardRepository(storage *FileStorage) (*PasswordCardRepository, error) {
+	passwordCards := make([]model.PasswordCard, 0)
+	if _, err := storage.Load(passwordCardsSection, &passwordCards); err != nil {
+		return nil, fmt.Errorf("error loading password cards: %w", err)
+	}
+
+	pr := CustomPasswordCardRepository(passwordCards)
+	pr.storage = storage
+
+	return pr, nil
+}
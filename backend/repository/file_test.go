@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStorage(t *testing.T) {
+	t.Run("opens a missing vault file as empty", func(t *testing.T) {
+		s, err := OpenFileStorage(filepath.Join(t.TempDir(), "vault.json"))
+		require.NoError(t, err)
+
+		var v []string
+		ok, err := s.Load("section", &v)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		_, err = os.Stat(s.Path())
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("returns error for a corrupted vault file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "vault.json")
+		require.NoError(t, os.WriteFile(path, []byte("corrupted"), 0o600))
+
+		_, err := OpenFileStorage(path)
+		assert.ErrorContains(t, err, "error decoding vault file")
+	})
+
+	t.Run("returns error for an unsupported version", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "vault.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"version": 42}`), 0o600))
+
+		_, err := OpenFileStorage(path)
+		assert.EqualError(t, err, "unsupported vault file version 42")
+	})
+
+	t.Run("🎉 saves sections atomically", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "vault.json")
+
+		s, err := OpenFileStorage(path)
+		require.NoError(t, err)
+
+		require.NoError(t, s.Save("first", []string{"a", "b"}))
+		require.NoError(t, s.Save("second", map[string]int{"c": 1}))
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1, "temporary files must not be left behind")
+		assert.Equal(t, "vault.json", entries[0].Name())
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+		reopened, err := OpenFileStorage(path)
+		require.NoError(t, err)
+
+		var first []string
+		ok, err := reopened.Load("first", &first)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"a", "b"}, first)
+
+		var second map[string]int
+		ok, err = reopened.Load("second", &second)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, map[string]int{"c": 1}, second)
+	})
+
+	t.Run("keeps the previous state when the write fails", func(t *testing.T) {
+		s, err := OpenFileStorage(filepath.Join(t.TempDir(), "missing-dir", "vault.json"))
+		require.NoError(t, err)
+
+		err = s.Save("first", []string{"a"})
+		assert.ErrorContains(t, err, "error writing vault file")
+
+		var first []string
+		ok, err := s.Load("first", &first)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestFilePasswordCardRepository(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.json")
+
+	s, err := OpenFileStorage(path)
+	require.NoError(t, err)
+
+	r, err := NewFilePasswordCardRepository(s)
+	require.NoError(t, err)
+	passwordCards, err := r.GetAll()
+	require.NoError(t, err)
+	assert.Empty(t, passwordCards)
+
+	require.NoError(t, r.Insert(model.PasswordCard{
+		ID:       "card-id-1",
+		Name:     "AWS",
+		Username: "username",
+		Password: "supersecret",
+		URL:      "https://aws.com/login",
+	}))
+	require.NoError(t, r.Insert(model.PasswordCard{
+		ID:       "card-id-2",
+		Name:     "Google Cloud Platform",
+		Username: "username",
+		Password: "supersecret",
+		URL:      "https://cloud.google.com/",
+	}))
+	require.NoError(t, r.Update(model.PasswordCard{
+		ID:       "card-id-2",
+		Name:     "Google Cloud Platform - GCP",
+		Username: "username",
+		Password: "supersecret",
+		URL:      "https://cloud.google.com/",
+	}))
+	require.NoError(t, r.Delete("card-id-1"))
+
+	s, err = OpenFileStorage(path)
+	require.NoError(t, err)
+
+	r, err = NewFilePasswordCardRepository(s)
+	require.NoError(t, err)
+
+	passwordCards, err = r.GetAll()
+	require.NoError(t, err)
+	assert.Equal(t, []model.PasswordCard{
+		{
+			ID:       "card-id-2",
+			Name:     "Google Cloud Platform - GCP",
+			Username: "username",
+			Password: "supersecret",
+			URL:      "https://cloud.google.com/",
+		},
+	}, passwordCards)
+
+	t.Run("keeps the repository untouched when the vault can't be written", func(t *testing.T) {
+		require.NoError(t, os.Chmod(filepath.Dir(path), 0o500))
+		t.Cleanup(func() { os.Chmod(filepath.Dir(path), 0o700) })
+
+		if f, err := os.CreateTemp(filepath.Dir(path), "probe"); err == nil {
+			f.Close()
+			t.Skip("directory permissions are not enforced for this user")
+		}
+
+		err := r.Insert(model.PasswordCard{
+			ID:       "card-id-3",
+			Name:     "GitHub",
+			Username: "username",
+			Password: "supersecret",
+			URL:      "https://github.com/login",
+		})
+		assert.ErrorContains(t, err, "error saving password cards")
+		assert.Len(t, r.passwordCards, 1)
+	})
+}
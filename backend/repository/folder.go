@@ -0,0 +1,318 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+const foldersSection = "folders"
+
+// ErrFolderAlreadyExists is returned when the parent folder already has a
+// folder with the same name.
+type ErrFolderAlreadyExists struct {
+	Name string
+}
+
+// Error implements error type interface.
+func (e ErrFolderAlreadyExists) Error() string {
+	return fmt.Sprintf("folder %q already exists", e.Name)
+}
+
+// ErrFolderNotFound is returned for unknown folders.
+type ErrFolderNotFound struct {
+	ID string
+}
+
+// Error implements error type interface.
+func (e ErrFolderNotFound) Error() string {
+	return fmt.Sprintf("folder with ID %q not found", e.ID)
+}
+
+// FolderStore is implemented by every folder storage backend.
+type FolderStore interface {
+	// Insert stores a new folder. The names are unique among the folders of
+	// the same owner and parent.
+	Insert(newFolder model.Folder) error
+	// Update replaces the folder with the same ID.
+	Update(updatedFolder model.Folder) error
+	// Delete removes the folder with the given ID, its content is left as it
+	// is.
+	Delete(folderID string) error
+	GetByID(folderID string) (*model.Folder, error)
+	// ListByOwnerID returns the folders of the owner in creation order.
+	ListByOwnerID(ownerID string) ([]model.Folder, error)
+}
+
+var (
+	_ FolderStore = (*FolderRepository)(nil)
+	_ FolderStore = (*SQLiteFolderRepository)(nil)
+)
+
+// FolderRepository stores the folders in memory and, optionally, in a vault
+// file.
+type FolderRepository struct {
+	folders []model.Folder
+	mu      sync.Mutex
+
+	// storage is nil for repositories that only live in memory.
+	storage *FileStorage
+}
+
+func NewFolderRepository() *FolderRepository {
+	return &FolderRepository{folders: make([]model.Folder, 0)}
+}
+
+// NewFileFolderRepository returns a repository whose folders are persisted in
+// the given file storage.
+func NewFileFolderRepository(storage *FileStorage) (*FolderRepository, error) {
+	fr := NewFolderRepository()
+	if _, err := storage.Load(foldersSection, &fr.folders); err != nil {
+		return nil, fmt.Errorf("error loading folders: %w", err)
+	}
+
+	fr.storage = storage
+
+	return fr, nil
+}
+
+func (fr *FolderRepository) Insert(newFolder model.Folder) error {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	for _, folder := range fr.folders {
+		if folder.ID == newFolder.ID || sameFolderName(folder, newFolder) {
+			return ErrFolderAlreadyExists{Name: newFolder.Name}
+		}
+	}
+
+	folders := make([]model.Folder, 0, len(fr.folders)+1)
+	folders = append(folders, fr.folders...)
+	folders = append(folders, newFolder)
+
+	return fr.save(folders)
+}
+
+func (fr *FolderRepository) Update(updatedFolder model.Folder) error {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	index := -1
+	for i, folder := range fr.folders {
+		if folder.ID == updatedFolder.ID {
+			index = i
+		}
+	}
+
+	if index < 0 {
+		return ErrFolderNotFound{ID: updatedFolder.ID}
+	}
+
+	for _, folder := range fr.folders {
+		if folder.ID != updatedFolder.ID && sameFolderName(folder, updatedFolder) {
+			return ErrFolderAlreadyExists{Name: updatedFolder.Name}
+		}
+	}
+
+	folders := make([]model.Folder, len(fr.folders))
+	copy(folders, fr.folders)
+	folders[index] = updatedFolder
+
+	return fr.save(folders)
+}
+
+func (fr *FolderRepository) Delete(folderID string) error {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	folders := make([]model.Folder, 0, len(fr.folders))
+	for _, folder := range fr.folders {
+		if folder.ID != folderID {
+			folders = append(folders, folder)
+		}
+	}
+
+	if len(folders) == len(fr.folders) {
+		return ErrFolderNotFound{ID: folderID}
+	}
+
+	return fr.save(folders)
+}
+
+func (fr *FolderRepository) GetByID(folderID string) (*model.Folder, error) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	for _, folder := range fr.folders {
+		if folder.ID == folderID {
+			return &folder, nil
+		}
+	}
+
+	return nil, ErrFolderNotFound{ID: folderID}
+}
+
+func (fr *FolderRepository) ListByOwnerID(ownerID string) ([]model.Folder, error) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	folders := make([]model.Folder, 0)
+	for _, folder := range fr.folders {
+		if folder.OwnerID == ownerID {
+			folders = append(folders, folder)
+		}
+	}
+
+	return folders, nil
+}
+
+// save must be called with the lock held.
+func (fr *FolderRepository) save(folders []model.Folder) error {
+	if fr.storage != nil {
+		if err := fr.storage.Save(foldersSection, folders); err != nil {
+			return fmt.Errorf("error saving folders: %w", err)
+		}
+	}
+
+	fr.folders = folders
+
+	return nil
+}
+
+// sameFolderName tells whether two folders would have the same name in the
+// same parent folder.
+func sameFolderName(a, b model.Folder) bool {
+	return a.OwnerID == b.OwnerID && a.ParentID == b.ParentID && a.Name == b.Name
+}
+
+// SQLiteFolderRepository stores the folders in a SQLite database.
+type SQLiteFolderRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteFolderRepository(db *sql.DB) *SQLiteFolderRepository {
+	return &SQLiteFolderRepository{db: db}
+}
+
+// folderColumns are the folders columns in the order used by the queries and
+// by scanFolder.
+const folderColumns = `id, owner_id, parent_id, name, created_at, updated_at`
+
+func (fr *SQLiteFolderRepository) Insert(newFolder model.Folder) error {
+	_, err := fr.db.Exec(
+		`INSERT INTO folders (`+folderColumns+`) VALUES (?, ?, ?, ?, ?, ?)`,
+		newFolder.ID,
+		newFolder.OwnerID,
+		newFolder.ParentID,
+		newFolder.Name,
+		newFolder.CreatedAt.UTC(),
+		newFolder.UpdatedAt.UTC(),
+	)
+	if err != nil {
+		return folderConstraintError(err, newFolder)
+	}
+
+	return nil
+}
+
+func (fr *SQLiteFolderRepository) Update(updatedFolder model.Folder) error {
+	result, err := fr.db.Exec(
+		`UPDATE folders SET owner_id = ?, parent_id = ?, name = ?, created_at = ?, updated_at = ? WHERE id = ?`,
+		updatedFolder.OwnerID,
+		updatedFolder.ParentID,
+		updatedFolder.Name,
+		updatedFolder.CreatedAt.UTC(),
+		updatedFolder.UpdatedAt.UTC(),
+		updatedFolder.ID,
+	)
+	if err != nil {
+		return folderConstraintError(err, updatedFolder)
+	}
+
+	return expectAffected(result, ErrFolderNotFound{ID: updatedFolder.ID})
+}
+
+func (fr *SQLiteFolderRepository) Delete(folderID string) error {
+	result, err := fr.db.Exec(`DELETE FROM folders WHERE id = ?`, folderID)
+	if err != nil {
+		return fmt.Errorf("error deleting folder: %w", err)
+	}
+
+	return expectAffected(result, ErrFolderNotFound{ID: folderID})
+}
+
+func (fr *SQLiteFolderRepository) GetByID(folderID string) (*model.Folder, error) {
+	folder, err := scanFolder(fr.db.QueryRow(`SELECT `+folderColumns+` FROM folders WHERE id = ?`, folderID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrFolderNotFound{ID: folderID}
+		}
+		return nil, fmt.Errorf("error getting folder: %w", err)
+	}
+
+	return folder, nil
+}
+
+func (fr *SQLiteFolderRepository) ListByOwnerID(ownerID string) ([]model.Folder, error) {
+	rows, err := fr.db.Query(`SELECT `+folderColumns+` FROM folders WHERE owner_id = ? ORDER BY rowid`, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing folders: %w", err)
+	}
+	defer rows.Close()
+
+	folders := make([]model.Folder, 0)
+	for rows.Next() {
+		folder, err := scanFolder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning folder: %w", err)
+		}
+
+		folders = append(folders, *folder)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing folders: %w", err)
+	}
+
+	return folders, nil
+}
+
+func scanFolder(row scanner) (*model.Folder, error) {
+	var folder model.Folder
+	err := row.Scan(
+		&folder.ID,
+		&folder.OwnerID,
+		&folder.ParentID,
+		&folder.Name,
+		&folder.CreatedAt,
+		&folder.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// the driver returns the times in the local time zone, they are stored in UTC
+	folder.CreatedAt = folder.CreatedAt.UTC()
+	folder.UpdatedAt = folder.UpdatedAt.UTC()
+
+	return &folder, nil
+}
+
+// folderConstraintError maps the unique constraint violations to
+// ErrFolderAlreadyExists.
+func folderConstraintError(err error, folder model.Folder) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY, sqlite3.SQLITE_CONSTRAINT_UNIQUE:
+			return ErrFolderAlreadyExists{Name: folder.Name}
+		}
+	}
+
+	return fmt.Errorf("error saving folder: %w", err)
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFolderStores(t *testing.T) {
+	now := time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+	work := model.Folder{ID: "folder-id-1", Name: "Work", OwnerID: "user-id-1", CreatedAt: now, UpdatedAt: now}
+	cloud := model.Folder{ID: "folder-id-2", Name: "Cloud", ParentID: "folder-id-1", OwnerID: "user-id-1", CreatedAt: now, UpdatedAt: now}
+
+	testCases := []struct {
+		name string
+		open func(t *testing.T, path string) FolderStore
+	}{
+		{
+			name: "memory",
+			open: func(t *testing.T, path string) FolderStore {
+				return NewFolderRepository()
+			},
+		},
+		{
+			name: "file",
+			open: func(t *testing.T, path string) FolderStore {
+				s, err := OpenFileStorage(path)
+				require.NoError(t, err)
+
+				fr, err := NewFileFolderRepository(s)
+				require.NoError(t, err)
+				return fr
+			},
+		},
+		{
+			name: "sqlite",
+			open: func(t *testing.T, path string) FolderStore {
+				db, err := OpenSQLite(path)
+				require.NoError(t, err)
+				t.Cleanup(func() { db.Close() })
+
+				return NewSQLiteFolderRepository(db)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fr := tc.open(t, filepath.Join(t.TempDir(), "vault"))
+
+			_, err := fr.GetByID(work.ID)
+			assert.ErrorIs(t, err, ErrFolderNotFound{ID: work.ID})
+
+			require.NoError(t, fr.Insert(work))
+			require.NoError(t, fr.Insert(cloud))
+
+			duplicated := cloud
+			duplicated.ID = "folder-id-3"
+			assert.ErrorIs(t, fr.Insert(duplicated), ErrFolderAlreadyExists{Name: "Cloud"})
+
+			// the names are only unique per parent and owner
+			duplicated.ParentID = ""
+			require.NoError(t, fr.Insert(duplicated))
+			other := work
+			other.ID = "folder-id-4"
+			other.OwnerID = "user-id-2"
+			require.NoError(t, fr.Insert(other))
+
+			loaded, err := fr.GetByID(cloud.ID)
+			require.NoError(t, err)
+			assert.Equal(t, &cloud, loaded)
+
+			folders, err := fr.ListByOwnerID("user-id-1")
+			require.NoError(t, err)
+			assert.Equal(t, []model.Folder{work, cloud, duplicated}, folders)
+
+			// moving the folder to the root conflicts with the other Cloud
+			moved := cloud
+			moved.ParentID = ""
+			assert.ErrorIs(t, fr.Update(moved), ErrFolderAlreadyExists{Name: "Cloud"})
+
+			moved.Name = "Cloud providers"
+			moved.UpdatedAt = now.Add(time.Hour)
+			require.NoError(t, fr.Update(moved))
+
+			loaded, err = fr.GetByID(cloud.ID)
+			require.NoError(t, err)
+			assert.Equal(t, &moved, loaded)
+
+			unknown := work
+			unknown.ID = "folder-id-5"
+			assert.ErrorIs(t, fr.Update(unknown), ErrFolderNotFound{ID: "folder-id-5"})
+
+			require.NoError(t, fr.Delete(work.ID))
+			assert.ErrorIs(t, fr.Delete(work.ID), ErrFolderNotFound{ID: work.ID})
+
+			folders, err = fr.ListByOwnerID("user-id-1")
+			require.NoError(t, err)
+			assert.Equal(t, []model.Folder{moved, duplicated}, folders)
+		})
+	}
+}
@@ -0,0 +1,7 @@
+CREATE TABLE password_cards (
+    id       TEXT NOT NULL PRIMARY KEY,
+    name     TEXT NOT NULL,
+    username TEXT NOT NULL,
+    password TEXT NOT NULL,
+    url      TEXT NOT NULL UNIQUE
+);
@@ -0,0 +1,6 @@
+-- The vault header holds the salt and KDF parameters of the master password.
+-- There is a single vault per database.
+CREATE TABLE vault_header (
+    id     INTEGER NOT NULL PRIMARY KEY CHECK (id = 1),
+    header TEXT    NOT NULL
+);
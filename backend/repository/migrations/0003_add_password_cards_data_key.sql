@@ -0,0 +1,2 @@
+-- The wrapped data key encrypting the secrets of each card.
+ALTER TABLE password_cards ADD COLUMN data_key TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,35 @@
+CREATE TABLE users (
+    id            TEXT      NOT NULL PRIMARY KEY,
+    username      TEXT      NOT NULL UNIQUE COLLATE NOCASE,
+    password_hash TEXT      NOT NULL,
+    created_at    TIMESTAMP NOT NULL
+);
+
+CREATE TABLE sessions (
+    token_hash TEXT      NOT NULL PRIMARY KEY,
+    user_id    TEXT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX sessions_expires_at ON sessions (expires_at);
+
+-- Cards belong to a user now and the URLs are only unique per owner. SQLite
+-- can't change a constraint in place, so the table is rebuilt. Cards created
+-- before the accounts existed have an empty owner.
+CREATE TABLE password_cards_new (
+    id       TEXT NOT NULL PRIMARY KEY,
+    name     TEXT NOT NULL,
+    username TEXT NOT NULL,
+    password TEXT NOT NULL,
+    url      TEXT NOT NULL,
+    data_key TEXT NOT NULL DEFAULT '',
+    owner_id TEXT NOT NULL DEFAULT '',
+    UNIQUE (owner_id, url)
+);
+
+INSERT INTO password_cards_new (rowid, id, name, username, password, url, data_key)
+SELECT rowid, id, name, username, password, url, data_key FROM password_cards;
+
+DROP TABLE password_cards;
+
+ALTER TABLE password_cards_new RENAME TO password_cards;
@@ -0,0 +1,10 @@
+CREATE TABLE api_tokens (
+    id         TEXT      NOT NULL PRIMARY KEY,
+    user_id    TEXT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name       TEXT      NOT NULL,
+    scope      TEXT      NOT NULL,
+    token_hash TEXT      NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    UNIQUE (user_id, name)
+);
@@ -0,0 +1,3 @@
+-- The cards stored before the timestamps were recorded get the zero time.
+ALTER TABLE password_cards ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00+00:00';
+ALTER TABLE password_cards ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00+00:00';
@@ -0,0 +1,12 @@
+-- The events outlive the cards they refer to, so there's no foreign key.
+CREATE TABLE audit_events (
+    id               TEXT      NOT NULL PRIMARY KEY,
+    action           TEXT      NOT NULL,
+    user_id          TEXT      NOT NULL,
+    api_token_id     TEXT      NOT NULL,
+    password_card_id TEXT      NOT NULL,
+    remote_ip        TEXT      NOT NULL,
+    created_at       TIMESTAMP NOT NULL
+);
+
+CREATE INDEX audit_events_password_card_id ON audit_events (password_card_id);
@@ -0,0 +1,4 @@
+-- The estimated strength of the password, NULL until it's estimated.
+ALTER TABLE password_cards ADD COLUMN strength_score INTEGER;
+ALTER TABLE password_cards ADD COLUMN strength_crack_time_seconds REAL;
+ALTER TABLE password_cards ADD COLUMN strength_crack_time_display TEXT;
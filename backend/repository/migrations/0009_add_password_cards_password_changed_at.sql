@@ -0,0 +1,3 @@
+-- When the password last changed, the cards stored before it was recorded get
+-- the zero time.
+ALTER TABLE password_cards ADD COLUMN password_changed_at TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00+00:00';
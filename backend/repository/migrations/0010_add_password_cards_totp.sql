@@ -0,0 +1,2 @@
+-- The sealed TOTP key of the card, empty for the cards without one.
+ALTER TABLE password_cards ADD COLUMN totp TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- The sealed HOTP key of the card, empty for the cards without one, and the
+-- counter of its next code.
+ALTER TABLE password_cards ADD COLUMN hotp TEXT NOT NULL DEFAULT '';
+ALTER TABLE password_cards ADD COLUMN hotp_counter INTEGER NOT NULL DEFAULT 0;
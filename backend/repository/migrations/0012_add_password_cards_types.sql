@@ -0,0 +1,37 @@
+-- The cards can be other items than logins now, like secure notes, which may
+-- have no URL. The URLs are only unique among the cards that have one, which
+-- takes a partial index, and SQLite can't change a constraint in place, so
+-- the table is rebuilt. The details of the items are JSON, NULL for the items
+-- of the other types.
+CREATE TABLE password_cards_new (
+    id                          TEXT      NOT NULL PRIMARY KEY,
+    type                        TEXT      NOT NULL DEFAULT '',
+    name                        TEXT      NOT NULL,
+    username                    TEXT      NOT NULL,
+    password                    TEXT      NOT NULL,
+    url                         TEXT      NOT NULL,
+    totp                        TEXT      NOT NULL DEFAULT '',
+    hotp                        TEXT      NOT NULL DEFAULT '',
+    hotp_counter                INTEGER   NOT NULL DEFAULT 0,
+    notes                       TEXT      NOT NULL DEFAULT '',
+    credit_card                 TEXT,
+    identity                    TEXT,
+    ssh_key                     TEXT,
+    data_key                    TEXT      NOT NULL DEFAULT '',
+    owner_id                    TEXT      NOT NULL DEFAULT '',
+    created_at                  TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00+00:00',
+    updated_at                  TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00+00:00',
+    password_changed_at         TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00+00:00',
+    strength_score              INTEGER,
+    strength_crack_time_seconds REAL,
+    strength_crack_time_display TEXT
+);
+
+INSERT INTO password_cards_new (rowid, id, name, username, password, url, totp, hotp, hotp_counter, data_key, owner_id, created_at, updated_at, password_changed_at, strength_score, strength_crack_time_seconds, strength_crack_time_display)
+SELECT rowid, id, name, username, password, url, totp, hotp, hotp_counter, data_key, owner_id, created_at, updated_at, password_changed_at, strength_score, strength_crack_time_seconds, strength_crack_time_display FROM password_cards;
+
+DROP TABLE password_cards;
+
+ALTER TABLE password_cards_new RENAME TO password_cards;
+
+CREATE UNIQUE INDEX password_cards_owner_id_url ON password_cards (owner_id, url) WHERE url != '';
@@ -0,0 +1,3 @@
+-- The custom fields of the card as a JSON array, NULL for the cards without
+-- any. The values of the hidden fields are sealed.
+ALTER TABLE password_cards ADD COLUMN custom_fields TEXT;
@@ -0,0 +1,16 @@
+-- The folders of the cards. The root folders have an empty parent_id, so the
+-- names are unique among the folders of the same parent.
+CREATE TABLE folders (
+    id         TEXT      NOT NULL PRIMARY KEY,
+    owner_id   TEXT      NOT NULL DEFAULT '',
+    parent_id  TEXT      NOT NULL DEFAULT '',
+    name       TEXT      NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    UNIQUE (owner_id, parent_id, name)
+);
+
+-- The folder of the card, empty for the cards out of any folder, and its tags
+-- as a JSON array, NULL for the cards without any.
+ALTER TABLE password_cards ADD COLUMN folder_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE password_cards ADD COLUMN tags TEXT;
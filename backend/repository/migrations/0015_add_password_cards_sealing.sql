@@ -0,0 +1,3 @@
+-- How the secrets of each card are stored, empty for the cards stored before
+-- it was recorded.
+ALTER TABLE password_cards ADD COLUMN sealing TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,7 @@
+-- The strength tells how easy a password is to guess, it's sealed like the
+-- secrets. The plaintext columns are dropped, the cards are estimated again on
+-- unlock.
+ALTER TABLE password_cards DROP COLUMN strength_score;
+ALTER TABLE password_cards DROP COLUMN strength_crack_time_seconds;
+ALTER TABLE password_cards DROP COLUMN strength_crack_time_display;
+ALTER TABLE password_cards ADD COLUMN sealed_strength TEXT NOT NULL DEFAULT '';
@@ -7,11 +7,18 @@ import (
 	"github.com/CaioTeixeira95/password-manager/backend/model"
 )
 
+// PasswordCardRepository stores the password cards in memory and, optionally,
+// in a vault file.
 type PasswordCardRepository struct {
 	passwordCards []model.PasswordCard
 	mu            sync.Mutex
+
+	// storage is nil for repositories that only live in memory.
+	storage *FileStorage
 }
 
+var _ PasswordCardStore = (*PasswordCardRepository)(nil)
+
 func NewPasswordCardRepository() *PasswordCardRepository {
 	return CustomPasswordCardRepository(make([]model.PasswordCard, 0))
 }
@@ -20,25 +27,27 @@ func CustomPasswordCardRepository(passwordCards []model.PasswordCard) *PasswordC
 	return &PasswordCardRepository{passwordCards: passwordCards}
 }
 
+// ErrPasswordCardAlreadyExists is returned when either the ID or the URL of a
+// password card is already taken. Only one of the fields is set.
 type ErrPasswordCardAlreadyExists struct {
-	id, url string
+	ID, URL string
 }
 
 // Error implements error type interface.
 func (e ErrPasswordCardAlreadyExists) Error() string {
-	if e.id != "" {
-		return fmt.Sprintf("password with ID %q already exists", e.id)
+	if e.ID != "" {
+		return fmt.Sprintf("password with ID %q already exists", e.ID)
 	}
-	return fmt.Sprintf("password with URL %q already exists", e.url)
+	return fmt.Sprintf("password with URL %q already exists", e.URL)
 }
 
 type ErrPasswordCardNotFound struct {
-	id string
+	ID string
 }
 
 // Error implements error type interface.
 func (e ErrPasswordCardNotFound) Error() string {
-	return fmt.Sprintf("password with ID %q not found", e.id)
+	return fmt.Sprintf("password with ID %q not found", e.ID)
 }
 
 func (pr *PasswordCardRepository) Insert(newPasswordCard model.PasswordCard) error {
@@ -47,35 +56,43 @@ func (pr *PasswordCardRepository) Insert(newPasswordCard model.PasswordCard) err
 
 	for _, passwordCard := range pr.passwordCards {
 		if passwordCard.ID == newPasswordCard.ID {
-			return ErrPasswordCardAlreadyExists{id: newPasswordCard.ID}
+			return ErrPasswordCardAlreadyExists{ID: newPasswordCard.ID}
 		}
-		if passwordCard.URL == newPasswordCard.URL {
-			return ErrPasswordCardAlreadyExists{url: newPasswordCard.URL}
+		if sameURL(passwordCard, newPasswordCard) {
+			return ErrPasswordCardAlreadyExists{URL: newPasswordCard.URL}
 		}
 	}
 
-	pr.passwordCards = append(pr.passwordCards, newPasswordCard)
-
-	return nil
+	return pr.save(append(pr.passwordCards, newPasswordCard.Clone()))
 }
 
 func (pr *PasswordCardRepository) Update(updatedPasswordCard model.PasswordCard) error {
 	pr.mu.Lock()
 	defer pr.mu.Unlock()
 
+	index := -1
 	for i, passwordCard := range pr.passwordCards {
-		// verify if the updated URL already exists for other cards
-		if passwordCard.ID != updatedPasswordCard.ID && passwordCard.URL == updatedPasswordCard.URL {
-			return ErrPasswordCardAlreadyExists{url: updatedPasswordCard.URL}
+		// verify if the updated URL already exists for other cards of the
+		// owner, before or after the updated one
+		if passwordCard.ID != updatedPasswordCard.ID && sameURL(passwordCard, updatedPasswordCard) {
+			return ErrPasswordCardAlreadyExists{URL: updatedPasswordCard.URL}
 		}
 
 		if passwordCard.ID == updatedPasswordCard.ID {
-			pr.passwordCards[i] = updatedPasswordCard
-			return nil
+			index = i
 		}
 	}
 
-	return ErrPasswordCardNotFound{id: updatedPasswordCard.ID}
+	if index < 0 {
+		return ErrPasswordCardNotFound{ID: updatedPasswordCard.ID}
+	}
+
+	passwordCards := make([]model.PasswordCard, len(pr.passwordCards))
+	copy(passwordCards, pr.passwordCards)
+	passwordCards[index] = updatedPasswordCard.Clone()
+	passwordCards[index].HOTPCounter = pr.passwordCards[index].HOTPCounter
+
+	return pr.save(passwordCards)
 }
 
 func (pr *PasswordCardRepository) Delete(passwordCardID string) error {
@@ -84,14 +101,94 @@ func (pr *PasswordCardRepository) Delete(passwordCardID string) error {
 
 	for i, passwordCard := range pr.passwordCards {
 		if passwordCard.ID == passwordCardID {
-			pr.passwordCards = append(pr.passwordCards[:i], pr.passwordCards[i+1:]...)
-			return nil
+			passwordCards := make([]model.PasswordCard, 0, len(pr.passwordCards)-1)
+			passwordCards = append(passwordCards, pr.passwordCards[:i]...)
+			passwordCards = append(passwordCards, pr.passwordCards[i+1:]...)
+			return pr.save(passwordCards)
+		}
+	}
+
+	return ErrPasswordCardNotFound{ID: passwordCardID}
+}
+
+func (pr *PasswordCardRepository) GetByID(passwordCardID string) (*model.PasswordCard, error) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	for _, passwordCard := range pr.passwordCards {
+		if passwordCard.ID == passwordCardID {
+			passwordCard = passwordCard.Clone()
+			return &passwordCard, nil
+		}
+	}
+
+	return nil, ErrPasswordCardNotFound{ID: passwordCardID}
+}
+
+func (pr *PasswordCardRepository) GetAll() ([]model.PasswordCard, error) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	passwordCards := make([]model.PasswordCard, 0, len(pr.passwordCards))
+	for _, passwordCard := range pr.passwordCards {
+		passwordCards = append(passwordCards, passwordCard.Clone())
+	}
+
+	return passwordCards, nil
+}
+
+func (pr *PasswordCardRepository) IncrementHOTPCounter(passwordCardID string) (uint64, error) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	for i, passwordCard := range pr.passwordCards {
+		if passwordCard.ID == passwordCardID {
+			passwordCards := make([]model.PasswordCard, len(pr.passwordCards))
+			copy(passwordCards, pr.passwordCards)
+			passwordCards[i].HOTPCounter++
+			if err := pr.save(passwordCards); err != nil {
+				return 0, err
+			}
+			return passwordCard.HOTPCounter, nil
+		}
+	}
+
+	return 0, ErrPasswordCardNotFound{ID: passwordCardID}
+}
+
+func (pr *PasswordCardRepository) SetHOTPCounter(passwordCardID string, counter uint64) error {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	for i, passwordCard := range pr.passwordCards {
+		if passwordCard.ID == passwordCardID {
+			passwordCards := make([]model.PasswordCard, len(pr.passwordCards))
+			copy(passwordCards, pr.passwordCards)
+			passwordCards[i].HOTPCounter = counter
+			return pr.save(passwordCards)
 		}
 	}
 
-	return ErrPasswordCardNotFound{id: passwordCardID}
+	return ErrPasswordCardNotFound{ID: passwordCardID}
 }
 
-func (pr *PasswordCardRepository) GetAll() []model.PasswordCard {
-	return pr.passwordCards
+// sameURL tells whether the cards of the same owner have the same URL. The
+// items without URL, like most secure notes, never conflict.
+func sameURL(a, b model.PasswordCard) bool {
+	return a.URL != "" && a.OwnerID == b.OwnerID && a.URL == b.URL
+}
+
+// save persists passwordCards before making them the repository state, so a
+// failed write leaves the repository untouched. It must be called with the
+// lock held.
+func (pr *PasswordCardRepository) save(passwordCards []model.PasswordCard) error {
+	if pr.storage != nil {
+		if err := pr.storage.Save(passwordCardsSection, passwordCards); err != nil {
+			return fmt.Errorf("error saving password cards: %w", err)
+		}
+	}
+
+	pr.passwordCards = passwordCards
+
+	return nil
 }
@@ -30,7 +30,7 @@ func TestPasswordCardRepositoryInsert(t *testing.T) {
 		})
 
 		assert.Error(t, err)
-		assert.ErrorIs(t, err, ErrPasswordCardAlreadyExists{id: "card-id-1"})
+		assert.ErrorIs(t, err, ErrPasswordCardAlreadyExists{ID: "card-id-1"})
 	})
 
 	t.Run("returns error when tries to insert a new password card with an existant URL", func(t *testing.T) {
@@ -43,7 +43,7 @@ func TestPasswordCardRepositoryInsert(t *testing.T) {
 		})
 
 		assert.Error(t, err)
-		assert.ErrorIs(t, err, ErrPasswordCardAlreadyExists{url: "https://aws.com/login"})
+		assert.ErrorIs(t, err, ErrPasswordCardAlreadyExists{URL: "https://aws.com/login"})
 	})
 
 	t.Run("🎉 inserts a new password card successfully", func(t *testing.T) {
@@ -152,7 +152,7 @@ func TestPasswordCardRepositoryUpdate(t *testing.T) {
 		})
 
 		assert.Error(t, err)
-		assert.ErrorIs(t, err, ErrPasswordCardAlreadyExists{url: "https://aws.com/login"})
+		assert.ErrorIs(t, err, ErrPasswordCardAlreadyExists{URL: "https://aws.com/login"})
 	})
 
 	t.Run("returns error when password card is not found", func(t *testing.T) {
@@ -165,7 +165,7 @@ func TestPasswordCardRepositoryUpdate(t *testing.T) {
 		})
 
 		assert.Error(t, err)
-		assert.ErrorIs(t, err, ErrPasswordCardNotFound{id: "card-id-3"})
+		assert.ErrorIs(t, err, ErrPasswordCardNotFound{ID: "card-id-3"})
 	})
 
 	t.Run("🎉 updates a password card successfully", func(t *testing.T) {
@@ -219,7 +219,7 @@ func TestPasswordCardRepositoryDelete(t *testing.T) {
 	t.Run("returns error when password card is not found", func(t *testing.T) {
 		err := r.Delete("card-id-3")
 		assert.Error(t, err)
-		assert.ErrorIs(t, err, ErrPasswordCardNotFound{id: "card-id-3"})
+		assert.ErrorIs(t, err, ErrPasswordCardNotFound{ID: "card-id-3"})
 	})
 
 	t.Run("🎉 deletes a password card successfully", func(t *testing.T) {
@@ -279,9 +279,11 @@ func TestPasswordCardRepositoryDelete(t *testing.T) {
 func TestGetAll(t *testing.T) {
 	r := NewPasswordCardRepository()
 
-	assert.Empty(t, r.GetAll())
+	passwordCards, err := r.GetAll()
+	require.NoError(t, err)
+	assert.Empty(t, passwordCards)
 
-	err := r.Insert(model.PasswordCard{
+	err = r.Insert(model.PasswordCard{
 		ID:       "card-id-1",
 		Name:     "AWS",
 		Username: "username",
@@ -0,0 +1,478 @@
+package repository
+
+import (
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// OpenSQLite opens the SQLite database at path, creating it when needed, and
+// applies every pending schema migration.
+func OpenSQLite(path string) (*sql.DB, error) {
+	// times are written in the SQLite format, in UTC they sort as strings
+	dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_time_format=sqlite", path)
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+
+	// SQLite serializes writers anyway, a single connection avoids busy errors
+	// between connections of the same process.
+	db.SetMaxOpenConns(1)
+
+	if err := Migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+type migration struct {
+	version int
+	name    string
+	query   string
+}
+
+// Migrate applies the embedded migrations that were not applied yet. Each
+// migration runs in its own transaction together with the bump of the schema
+// version, so a failing migration leaves the database in the previous version.
+func Migrate(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER NOT NULL PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		return fmt.Errorf("error creating schema_migrations table: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("error reading schema version: %w", err)
+	}
+
+	pending, err := loadMigrations(current)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range pending {
+		if err := applyMigration(db, m); err != nil {
+			return fmt.Errorf("error applying migration %s: %w", m.name, err)
+		}
+	}
+
+	return nil
+}
+
+// loadMigrations returns the embedded migrations newer than version sorted by
+// their version. Migration files are named <version>_<description>.sql.
+func loadMigrations(version int) ([]migration, error) {
+	entries, err := fs.ReadDir(migrations, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("error reading migrations: %w", err)
+	}
+
+	var pending []migration
+	for _, entry := range entries {
+		prefix, _, ok := strings.Cut(entry.Name(), "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration name %q", entry.Name())
+		}
+
+		v, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %q: %w", entry.Name(), err)
+		}
+
+		if v <= version {
+			continue
+		}
+
+		query, err := fs.ReadFile(migrations, "migrations/"+entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("error reading migration %q: %w", entry.Name(), err)
+		}
+
+		pending = append(pending, migration{version: v, name: entry.Name(), query: string(query)})
+	}
+
+	sort.Slice(pending, func(i, j int) bool { return pending[i].version < pending[j].version })
+
+	return pending, nil
+}
+
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.query); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, m.version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SQLitePasswordCardRepository stores the password cards in a SQLite database.
+// The uniqueness of IDs and of URLs per owner is enforced by the database
+// constraints.
+type SQLitePasswordCardRepository struct {
+	db *sql.DB
+}
+
+var _ PasswordCardStore = (*SQLitePasswordCardRepository)(nil)
+
+// NewSQLitePasswordCardRepository returns a repository backed by a database
+// opened with OpenSQLite.
+func NewSQLitePasswordCardRepository(db *sql.DB) *SQLitePasswordCardRepository {
+	return &SQLitePasswordCardRepository{db: db}
+}
+
+// passwordCardColumns are the password_cards columns in the order used by the
+// queries and by scanPasswordCard.
+const passwordCardColumns = `id, type, name, username, password, url, totp, hotp, hotp_counter, notes, credit_card, identity, ssh_key, custom_fields, folder_id, tags, data_key, sealing, owner_id, created_at, updated_at, password_changed_at, sealed_strength`
+
+func (pr *SQLitePasswordCardRepository) Insert(newPasswordCard model.PasswordCard) error {
+	details, err := itemDetails(newPasswordCard)
+	if err != nil {
+		return fmt.Errorf("error saving password card: %w", err)
+	}
+
+	_, err = pr.db.Exec(
+		`INSERT INTO password_cards (`+passwordCardColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		newPasswordCard.ID,
+		newPasswordCard.Type,
+		newPasswordCard.Name,
+		newPasswordCard.Username,
+		newPasswordCard.Password,
+		newPasswordCard.URL,
+		newPasswordCard.TOTP,
+		newPasswordCard.HOTP,
+		int64(newPasswordCard.HOTPCounter),
+		newPasswordCard.Notes,
+		details.creditCard,
+		details.identity,
+		details.sshKey,
+		details.customFields,
+		newPasswordCard.FolderID,
+		details.tags,
+		newPasswordCard.DataKey,
+		newPasswordCard.Sealing,
+		newPasswordCard.OwnerID,
+		newPasswordCard.CreatedAt.UTC(),
+		newPasswordCard.UpdatedAt.UTC(),
+		newPasswordCard.PasswordChangedAt.UTC(),
+		newPasswordCard.SealedStrength,
+	)
+	if err != nil {
+		return passwordCardConstraintError(err, newPasswordCard)
+	}
+
+	return nil
+}
+
+func (pr *SQLitePasswordCardRepository) Update(updatedPasswordCard model.PasswordCard) error {
+	details, err := itemDetails(updatedPasswordCard)
+	if err != nil {
+		return fmt.Errorf("error saving password card: %w", err)
+	}
+
+	// hotp_counter is left alone, only the HOTP methods change it
+	result, err := pr.db.Exec(
+		`UPDATE password_cards SET type = ?, name = ?, username = ?, password = ?, url = ?, totp = ?, hotp = ?, notes = ?, credit_card = ?, identity = ?, ssh_key = ?, custom_fields = ?, folder_id = ?, tags = ?, data_key = ?, sealing = ?, owner_id = ?, created_at = ?, updated_at = ?, password_changed_at = ?, sealed_strength = ? WHERE id = ?`,
+		updatedPasswordCard.Type,
+		updatedPasswordCard.Name,
+		updatedPasswordCard.Username,
+		updatedPasswordCard.Password,
+		updatedPasswordCard.URL,
+		updatedPasswordCard.TOTP,
+		updatedPasswordCard.HOTP,
+		updatedPasswordCard.Notes,
+		details.creditCard,
+		details.identity,
+		details.sshKey,
+		details.customFields,
+		updatedPasswordCard.FolderID,
+		details.tags,
+		updatedPasswordCard.DataKey,
+		updatedPasswordCard.Sealing,
+		updatedPasswordCard.OwnerID,
+		updatedPasswordCard.CreatedAt.UTC(),
+		updatedPasswordCard.UpdatedAt.UTC(),
+		updatedPasswordCard.PasswordChangedAt.UTC(),
+		updatedPasswordCard.SealedStrength,
+		updatedPasswordCard.ID,
+	)
+	if err != nil {
+		return passwordCardConstraintError(err, updatedPasswordCard)
+	}
+
+	return expectAffected(result, ErrPasswordCardNotFound{ID: updatedPasswordCard.ID})
+}
+
+func (pr *SQLitePasswordCardRepository) Delete(passwordCardID string) error {
+	result, err := pr.db.Exec(`DELETE FROM password_cards WHERE id = ?`, passwordCardID)
+	if err != nil {
+		return fmt.Errorf("error deleting password card: %w", err)
+	}
+
+	return expectAffected(result, ErrPasswordCardNotFound{ID: passwordCardID})
+}
+
+func (pr *SQLitePasswordCardRepository) GetByID(passwordCardID string) (*model.PasswordCard, error) {
+	row := pr.db.QueryRow(`SELECT `+passwordCardColumns+` FROM password_cards WHERE id = ?`, passwordCardID)
+
+	passwordCard, err := scanPasswordCard(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPasswordCardNotFound{ID: passwordCardID}
+		}
+		return nil, fmt.Errorf("error getting password card: %w", err)
+	}
+
+	return passwordCard, nil
+}
+
+func (pr *SQLitePasswordCardRepository) GetAll() ([]model.PasswordCard, error) {
+	// rowid keeps the insertion order, like the other backends
+	rows, err := pr.db.Query(`SELECT ` + passwordCardColumns + ` FROM password_cards ORDER BY rowid`)
+	if err != nil {
+		return nil, fmt.Errorf("error listing password cards: %w", err)
+	}
+	defer rows.Close()
+
+	passwordCards := make([]model.PasswordCard, 0)
+	for rows.Next() {
+		passwordCard, err := scanPasswordCard(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning password card: %w", err)
+		}
+
+		passwordCards = append(passwordCards, *passwordCard)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing password cards: %w", err)
+	}
+
+	return passwordCards, nil
+}
+
+func (pr *SQLitePasswordCardRepository) IncrementHOTPCounter(passwordCardID string) (uint64, error) {
+	// a single statement, so concurrent increments never see the same value
+	var counter int64
+	err := pr.db.QueryRow(
+		`UPDATE password_cards SET hotp_counter = hotp_counter + 1 WHERE id = ? RETURNING hotp_counter - 1`,
+		passwordCardID,
+	).Scan(&counter)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrPasswordCardNotFound{ID: passwordCardID}
+		}
+		return 0, fmt.Errorf("error incrementing HOTP counter: %w", err)
+	}
+
+	return uint64(counter), nil
+}
+
+func (pr *SQLitePasswordCardRepository) SetHOTPCounter(passwordCardID string, counter uint64) error {
+	result, err := pr.db.Exec(`UPDATE password_cards SET hotp_counter = ? WHERE id = ?`, int64(counter), passwordCardID)
+	if err != nil {
+		return fmt.Errorf("error setting HOTP counter: %w", err)
+	}
+
+	return expectAffected(result, ErrPasswordCardNotFound{ID: passwordCardID})
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPasswordCard(row scanner) (*model.PasswordCard, error) {
+	var (
+		passwordCard model.PasswordCard
+		hotpCounter  int64
+		creditCard   sql.NullString
+		identity     sql.NullString
+		sshKey       sql.NullString
+		customFields sql.NullString
+		tags         sql.NullString
+	)
+	err := row.Scan(
+		&passwordCard.ID,
+		&passwordCard.Type,
+		&passwordCard.Name,
+		&passwordCard.Username,
+		&passwordCard.Password,
+		&passwordCard.URL,
+		&passwordCard.TOTP,
+		&passwordCard.HOTP,
+		&hotpCounter,
+		&passwordCard.Notes,
+		&creditCard,
+		&identity,
+		&sshKey,
+		&customFields,
+		&passwordCard.FolderID,
+		&tags,
+		&passwordCard.DataKey,
+		&passwordCard.Sealing,
+		&passwordCard.OwnerID,
+		&passwordCard.CreatedAt,
+		&passwordCard.UpdatedAt,
+		&passwordCard.PasswordChangedAt,
+		&passwordCard.SealedStrength,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordCard.HOTPCounter = uint64(hotpCounter)
+
+	if passwordCard.CreditCard, err = scanJSON[model.CreditCard](creditCard); err != nil {
+		return nil, err
+	}
+	if passwordCard.Identity, err = scanJSON[model.Identity](identity); err != nil {
+		return nil, err
+	}
+	if passwordCard.SSHKey, err = scanJSON[model.SSHKey](sshKey); err != nil {
+		return nil, err
+	}
+	customFieldsValue, err := scanJSON[[]model.CustomField](customFields)
+	if err != nil {
+		return nil, err
+	}
+	if customFieldsValue != nil {
+		passwordCard.CustomFields = *customFieldsValue
+	}
+	tagsValue, err := scanJSON[[]string](tags)
+	if err != nil {
+		return nil, err
+	}
+	if tagsValue != nil {
+		passwordCard.Tags = *tagsValue
+	}
+
+	// the driver returns the times in the local time zone, they are stored in UTC
+	passwordCard.CreatedAt = passwordCard.CreatedAt.UTC()
+	passwordCard.UpdatedAt = passwordCard.UpdatedAt.UTC()
+	passwordCard.PasswordChangedAt = passwordCard.PasswordChangedAt.UTC()
+
+	return &passwordCard, nil
+}
+
+// details are the values of the JSON columns holding the details of the
+// items, the custom fields and the tags, NULL for the ones the card doesn't
+// have.
+type details struct {
+	creditCard, identity, sshKey, customFields, tags any
+}
+
+func itemDetails(passwordCard model.PasswordCard) (details, error) {
+	var (
+		d   details
+		err error
+	)
+	if d.creditCard, err = jsonColumn(passwordCard.CreditCard); err != nil {
+		return details{}, err
+	}
+	if d.identity, err = jsonColumn(passwordCard.Identity); err != nil {
+		return details{}, err
+	}
+	if d.sshKey, err = jsonColumn(passwordCard.SSHKey); err != nil {
+		return details{}, err
+	}
+	if len(passwordCard.CustomFields) > 0 {
+		if d.customFields, err = jsonColumn(&passwordCard.CustomFields); err != nil {
+			return details{}, err
+		}
+	}
+	if len(passwordCard.Tags) > 0 {
+		if d.tags, err = jsonColumn(&passwordCard.Tags); err != nil {
+			return details{}, err
+		}
+	}
+	return d, nil
+}
+
+// jsonColumn returns the JSON of v, NULL when it's nil.
+func jsonColumn[T any](v *T) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// scanJSON reverts jsonColumn.
+func scanJSON[T any](column sql.NullString) (*T, error) {
+	if !column.Valid {
+		return nil, nil
+	}
+
+	v := new(T)
+	if err := json.Unmarshal([]byte(column.String), v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// passwordCardConstraintError maps the violations of the password_cards
+// constraints to ErrPasswordCardAlreadyExists.
+func passwordCardConstraintError(err error, passwordCard model.PasswordCard) error {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return fmt.Errorf("error saving password card: %w", err)
+	}
+
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY, sqlite3.SQLITE_CONSTRAINT_UNIQUE:
+		// the message names the violated column, e.g.
+		// "UNIQUE constraint failed: password_cards.url"
+		if strings.Contains(sqliteErr.Error(), "password_cards.url") {
+			return ErrPasswordCardAlreadyExists{URL: passwordCard.URL}
+		}
+		return ErrPasswordCardAlreadyExists{ID: passwordCard.ID}
+	}
+
+	return fmt.Errorf("error saving password card: %w", err)
+}
+
+// expectAffected returns notFound when the statement didn't change any row.
+func expectAffected(result sql.Result, notFound error) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error reading affected rows: %w", err)
+	}
+
+	if affected == 0 {
+		return notFound
+	}
+
+	return nil
+}
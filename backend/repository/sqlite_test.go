@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.db")
+
+	db, err := OpenSQLite(path)
+	require.NoError(t, err)
+
+	all, err := loadMigrations(0)
+	require.NoError(t, err)
+	require.NotEmpty(t, all)
+
+	var version, count int
+	require.NoError(t, db.QueryRow(`SELECT MAX(version), COUNT(*) FROM schema_migrations`).Scan(&version, &count))
+	assert.Equal(t, all[len(all)-1].version, version)
+	assert.Equal(t, len(all), count)
+
+	// running the migrations again is a no-op
+	require.NoError(t, Migrate(db))
+	require.NoError(t, db.Close())
+
+	db, err = OpenSQLite(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&count))
+	assert.Equal(t, len(all), count)
+
+	pending, err := loadMigrations(version)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestMigrateKeepsPasswordCardsWithoutOwner(t *testing.T) {
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "vault.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	all, err := loadMigrations(0)
+	require.NoError(t, err)
+
+	// a database created before the users existed
+	_, err = db.Exec(`CREATE TABLE schema_migrations (version INTEGER NOT NULL PRIMARY KEY, applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)`)
+	require.NoError(t, err)
+	for _, m := range all[:3] {
+		require.NoError(t, applyMigration(db, m))
+	}
+
+	_, err = db.Exec(
+		`INSERT INTO password_cards (id, name, username, password, url, data_key) VALUES (?, ?, ?, ?, ?, ?)`,
+		"card-id-1", "AWS", "username", "supersecret", "https://aws.com/login", "",
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, Migrate(db))
+
+	r := NewSQLitePasswordCardRepository(db)
+
+	passwordCards, err := r.GetAll()
+	require.NoError(t, err)
+	assert.Equal(t, []model.PasswordCard{
+		{
+			ID:       "card-id-1",
+			Name:     "AWS",
+			Username: "username",
+			Password: "supersecret",
+			URL:      "https://aws.com/login",
+		},
+	}, passwordCards)
+
+	// the URL is only unique per owner now
+	assert.NoError(t, r.Insert(model.PasswordCard{
+		ID:       "card-id-2",
+		Name:     "AWS",
+		Username: "username",
+		Password: "supersecret",
+		URL:      "https://aws.com/login",
+		OwnerID:  "user-id-1",
+	}))
+}
+
+func TestSQLitePasswordCardRepository(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.db")
+
+	db, err := OpenSQLite(path)
+	require.NoError(t, err)
+
+	r := NewSQLitePasswordCardRepository(db)
+
+	require.NoError(t, r.Insert(model.PasswordCard{
+		ID:       "card-id-1",
+		Name:     "AWS",
+		Username: "username",
+		Password: "supersecret",
+		URL:      "https://aws.com/login",
+	}))
+
+	t.Run("maps constraint violations to ErrPasswordCardAlreadyExists", func(t *testing.T) {
+		err := r.Insert(model.PasswordCard{
+			ID:       "card-id-1",
+			Name:     "AWS",
+			Username: "username",
+			Password: "supersecret",
+			URL:      "https://another.aws.com/login",
+		})
+		assert.Equal(t, ErrPasswordCardAlreadyExists{ID: "card-id-1"}, err)
+
+		err = r.Insert(model.PasswordCard{
+			ID:       "card-id-2",
+			Name:     "AWS",
+			Username: "username",
+			Password: "supersecret",
+			URL:      "https://aws.com/login",
+		})
+		assert.Equal(t, ErrPasswordCardAlreadyExists{URL: "https://aws.com/login"}, err)
+	})
+
+	t.Run("🎉 keeps the password cards after reopening the database", func(t *testing.T) {
+		require.NoError(t, db.Close())
+
+		db, err := OpenSQLite(path)
+		require.NoError(t, err)
+		defer db.Close()
+
+		passwordCards, err := NewSQLitePasswordCardRepository(db).GetAll()
+		require.NoError(t, err)
+		assert.Equal(t, []model.PasswordCard{
+			{
+				ID:       "card-id-1",
+				Name:     "AWS",
+				Username: "username",
+				Password: "supersecret",
+				URL:      "https://aws.com/login",
+			},
+		}, passwordCards)
+	})
+}
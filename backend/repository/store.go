@@ -0,0 +1,30 @@
+package repository
+
+import "github.com/CaioTeixeira95/password-manager/backend/model"
+
+// PasswordCardStore is implemented by every password card storage backend.
+//
+// Implementations must be safe for concurrent use and report conflicts with
+// ErrPasswordCardAlreadyExists and missing cards with ErrPasswordCardNotFound,
+// so callers can handle every backend the same way. The storetest package has
+// a conformance suite any implementation can run against.
+type PasswordCardStore interface {
+	// Insert stores a new password card. The ID must be unique and the URL
+	// must be unique among the cards of the same owner.
+	Insert(newPasswordCard model.PasswordCard) error
+	// Update replaces the password card with the same ID, except its HOTP
+	// counter, which only the HOTP methods change.
+	Update(updatedPasswordCard model.PasswordCard) error
+	// Delete removes the password card with the given ID.
+	Delete(passwordCardID string) error
+	// GetByID returns the password card with the given ID.
+	GetByID(passwordCardID string) (*model.PasswordCard, error)
+	// GetAll returns every password card in insertion order.
+	GetAll() ([]model.PasswordCard, error)
+	// IncrementHOTPCounter atomically increments the HOTP counter of the
+	// password card and returns its previous value, the counter of the code
+	// to issue. Concurrent calls never return the same counter.
+	IncrementHOTPCounter(passwordCardID string) (uint64, error)
+	// SetHOTPCounter sets the HOTP counter of the password card.
+	SetHOTPCounter(passwordCardID string, counter uint64) error
+}
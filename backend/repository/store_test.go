@@ -0,0 +1,50 @@
+package repository_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/repository/storetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordCardStoreConformance(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		storetest.Run(t, func(t *testing.T, passwordCards []model.PasswordCard) repository.PasswordCardStore {
+			return repository.CustomPasswordCardRepository(append([]model.PasswordCard(nil), passwordCards...))
+		})
+	})
+
+	t.Run("file", func(t *testing.T) {
+		storetest.Run(t, func(t *testing.T, passwordCards []model.PasswordCard) repository.PasswordCardStore {
+			fileStorage, err := repository.OpenFileStorage(filepath.Join(t.TempDir(), "vault.json"))
+			require.NoError(t, err)
+
+			r, err := repository.NewFilePasswordCardRepository(fileStorage)
+			require.NoError(t, err)
+
+			for _, passwordCard := range passwordCards {
+				require.NoError(t, r.Insert(passwordCard))
+			}
+
+			return r
+		})
+	})
+
+	t.Run("sqlite", func(t *testing.T) {
+		storetest.Run(t, func(t *testing.T, passwordCards []model.PasswordCard) repository.PasswordCardStore {
+			db, err := repository.OpenSQLite(filepath.Join(t.TempDir(), "vault.db"))
+			require.NoError(t, err)
+			t.Cleanup(func() { db.Close() })
+
+			r := repository.NewSQLitePasswordCardRepository(db)
+			for _, passwordCard := range passwordCards {
+				require.NoError(t, r.Insert(passwordCard))
+			}
+
+			return r
+		})
+	})
+}
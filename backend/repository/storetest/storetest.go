@@ -0,0 +1,398 @@
+// Package storetest provides a conformance suite for implementations of
+// repository.PasswordCardStore.
+package storetest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// NewStoreFunc returns a new store already holding the given password cards.
+// Every call must return an independent store.
+type NewStoreFunc func(t *testing.T, passwordCards []model.PasswordCard) repository.PasswordCardStore
+
+// Run runs the conformance suite against the stores returned by newStore.
+func Run(t *testing.T, newStore NewStoreFunc) {
+	t.Run("Insert", func(t *testing.T) { testInsert(t, newStore) })
+	t.Run("Update", func(t *testing.T) { testUpdate(t, newStore) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, newStore) })
+	t.Run("GetByID", func(t *testing.T) { testGetByID(t, newStore) })
+	t.Run("GetAll", func(t *testing.T) { testGetAll(t, newStore) })
+	t.Run("HOTPCounter", func(t *testing.T) { testHOTPCounter(t, newStore) })
+}
+
+var (
+	awsCard = model.PasswordCard{
+		ID:       "card-id-1",
+		Name:     "AWS",
+		Username: "username",
+		Password: "supersecret",
+		URL:      "https://aws.com/login",
+	}
+	gcpCard = model.PasswordCard{
+		ID:                "card-id-2",
+		Name:              "Google Cloud Platform",
+		Username:          "username",
+		Password:          "supersecret",
+		URL:               "https://cloud.google.com/",
+		TOTP:              "sealed-totp-key",
+		HOTP:              "sealed-hotp-key",
+		HOTPCounter:       5,
+		DataKey:           "wrapped-data-key",
+		Sealing:           model.SealingDataKey,
+		CreatedAt:         time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC),
+		UpdatedAt:         time.Date(2023, 8, 2, 10, 30, 0, 0, time.UTC),
+		PasswordChangedAt: time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC),
+		SealedStrength:    "sealed-strength",
+	}
+)
+
+func testInsert(t *testing.T, newStore NewStoreFunc) {
+	s := newStore(t, []model.PasswordCard{awsCard})
+
+	t.Run("returns error when try to insert a new password card with an existant ID", func(t *testing.T) {
+		err := s.Insert(model.PasswordCard{
+			ID:       "card-id-1",
+			Name:     "AWS",
+			Username: "username",
+			Password: "supersecret",
+			URL:      "https://another.aws.com/login",
+		})
+
+		assert.ErrorIs(t, err, repository.ErrPasswordCardAlreadyExists{ID: "card-id-1"})
+	})
+
+	t.Run("returns error when tries to insert a new password card with an existant URL", func(t *testing.T) {
+		err := s.Insert(model.PasswordCard{
+			ID:       "card-id-2",
+			Name:     "AWS",
+			Username: "username",
+			Password: "supersecret",
+			URL:      "https://aws.com/login",
+		})
+
+		assert.ErrorIs(t, err, repository.ErrPasswordCardAlreadyExists{URL: "https://aws.com/login"})
+	})
+
+	t.Run("🎉 inserts a password card with an existant URL of another owner", func(t *testing.T) {
+		s := newStore(t, []model.PasswordCard{awsCard})
+
+		ownedCard := awsCard
+		ownedCard.ID = "card-id-3"
+		ownedCard.OwnerID = "user-id-1"
+
+		err := s.Insert(ownedCard)
+		require.NoError(t, err)
+
+		passwordCards, err := s.GetAll()
+		require.NoError(t, err)
+		assert.Equal(t, []model.PasswordCard{awsCard, ownedCard}, passwordCards)
+
+		ownedCard.ID = "card-id-4"
+		err = s.Insert(ownedCard)
+		assert.ErrorIs(t, err, repository.ErrPasswordCardAlreadyExists{URL: "https://aws.com/login"})
+	})
+
+	t.Run("🎉 inserts a new password card successfully", func(t *testing.T) {
+		err := s.Insert(gcpCard)
+		require.NoError(t, err)
+
+		passwordCards, err := s.GetAll()
+		require.NoError(t, err)
+		assert.Equal(t, []model.PasswordCard{awsCard, gcpCard}, passwordCards)
+	})
+
+	t.Run("🎉 inserts items without URL and with details, custom fields, folder and tags", func(t *testing.T) {
+		s := newStore(t, nil)
+
+		items := []model.PasswordCard{
+			{ID: "note-id-1", Type: model.ItemTypeSecureNote, Name: "Wi-Fi", Notes: "sealed-notes", FolderID: "folder-id-1", Tags: []string{"home", "network"}},
+			{ID: "note-id-2", Type: model.ItemTypeSecureNote, Name: "Alarm", Notes: "sealed-notes"},
+			{
+				ID:         "card-id-3",
+				Type:       model.ItemTypeCreditCard,
+				Name:       "Visa",
+				CreditCard: &model.CreditCard{CardholderName: "Jane Doe", Number: "sealed-number", ExpiryMonth: 12, ExpiryYear: 2030},
+			},
+			{
+				ID:       "card-id-4",
+				Type:     model.ItemTypeIdentity,
+				Name:     "Me",
+				Identity: &model.Identity{FirstName: "Jane", SSN: "sealed-ssn"},
+				CustomFields: []model.CustomField{
+					{Name: "Member ID", Type: model.CustomFieldText, Value: "42"},
+					{Name: "PIN", Type: model.CustomFieldHidden, Value: "sealed-pin"},
+				},
+			},
+			{ID: "card-id-5", Type: model.ItemTypeSSHKey, Name: "Deploy key", SSHKey: &model.SSHKey{PrivateKey: "sealed-private-key", PublicKey: "ssh-ed25519 AAAA"}},
+		}
+		for _, item := range items {
+			require.NoError(t, s.Insert(item))
+		}
+
+		passwordCards, err := s.GetAll()
+		require.NoError(t, err)
+		assert.Equal(t, items, passwordCards)
+
+		// changing the details of a returned card must not change the store
+		passwordCards[2].CreditCard.Number = "changed"
+		passwordCards[3].CustomFields[1].Value = "changed"
+		passwordCards[0].Tags[0] = "changed"
+
+		passwordCard, err := s.GetByID("card-id-3")
+		require.NoError(t, err)
+		assert.Equal(t, items[2], *passwordCard)
+
+		passwordCard, err = s.GetByID("card-id-4")
+		require.NoError(t, err)
+		assert.Equal(t, items[3], *passwordCard)
+
+		passwordCard, err = s.GetByID("note-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, items[0], *passwordCard)
+	})
+
+	t.Run("ensures no race condition", func(t *testing.T) {
+		s := newStore(t, nil)
+
+		var wg sync.WaitGroup
+		for _, passwordCard := range []model.PasswordCard{awsCard, gcpCard} {
+			wg.Add(1)
+			go func(passwordCard model.PasswordCard) {
+				defer wg.Done()
+				assert.NoError(t, s.Insert(passwordCard))
+			}(passwordCard)
+		}
+		wg.Wait()
+
+		passwordCards, err := s.GetAll()
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []model.PasswordCard{awsCard, gcpCard}, passwordCards)
+	})
+}
+
+func testUpdate(t *testing.T, newStore NewStoreFunc) {
+	s := newStore(t, []model.PasswordCard{awsCard, gcpCard})
+
+	t.Run("returns error when tries to update a password card with an existant URL", func(t *testing.T) {
+		err := s.Update(model.PasswordCard{
+			ID:       "card-id-2",
+			Name:     "Google Cloud Platform",
+			Username: "username",
+			Password: "supersecret",
+			URL:      "https://aws.com/login",
+		})
+
+		assert.ErrorIs(t, err, repository.ErrPasswordCardAlreadyExists{URL: "https://aws.com/login"})
+	})
+
+	t.Run("returns error when tries to update a password card with the URL of a later card", func(t *testing.T) {
+		err := s.Update(model.PasswordCard{
+			ID:       "card-id-1",
+			Name:     "Amazon Web Services",
+			Username: "username",
+			Password: "supersecret",
+			URL:      gcpCard.URL,
+		})
+
+		assert.ErrorIs(t, err, repository.ErrPasswordCardAlreadyExists{URL: gcpCard.URL})
+	})
+
+	t.Run("returns error when password card is not found", func(t *testing.T) {
+		err := s.Update(model.PasswordCard{
+			ID:       "card-id-3",
+			Name:     "Google Cloud Platform",
+			Username: "username",
+			Password: "supersecret",
+			URL:      "https://another.google.com/login",
+		})
+
+		assert.ErrorIs(t, err, repository.ErrPasswordCardNotFound{ID: "card-id-3"})
+	})
+
+	t.Run("🎉 updates a password card successfully", func(t *testing.T) {
+		updatedCard := model.PasswordCard{
+			ID:       "card-id-2",
+			Name:     "Google Cloud Platform - GCP",
+			Username: "username",
+			Password: "supersecret",
+			URL:      "https://another.google.com/login",
+			// the counter isn't changed by Update
+			HOTPCounter: gcpCard.HOTPCounter,
+		}
+
+		err := s.Update(model.PasswordCard{
+			ID:       "card-id-2",
+			Name:     "Google Cloud Platform - GCP",
+			Username: "username",
+			Password: "supersecret",
+			URL:      "https://another.google.com/login",
+		})
+		require.NoError(t, err)
+
+		passwordCards, err := s.GetAll()
+		require.NoError(t, err)
+		assert.Equal(t, []model.PasswordCard{awsCard, updatedCard}, passwordCards)
+	})
+
+	t.Run("🎉 keeps the URL of the updated password card", func(t *testing.T) {
+		updatedCard := awsCard
+		updatedCard.Password = "newsupersecret"
+
+		err := s.Update(updatedCard)
+		require.NoError(t, err)
+
+		passwordCard, err := s.GetByID(awsCard.ID)
+		require.NoError(t, err)
+		assert.Equal(t, updatedCard, *passwordCard)
+	})
+}
+
+func testDelete(t *testing.T, newStore NewStoreFunc) {
+	s := newStore(t, []model.PasswordCard{awsCard, gcpCard})
+
+	t.Run("returns error when password card is not found", func(t *testing.T) {
+		err := s.Delete("card-id-3")
+		assert.ErrorIs(t, err, repository.ErrPasswordCardNotFound{ID: "card-id-3"})
+	})
+
+	t.Run("🎉 deletes a password card successfully", func(t *testing.T) {
+		err := s.Delete("card-id-1")
+		require.NoError(t, err)
+
+		passwordCards, err := s.GetAll()
+		require.NoError(t, err)
+		assert.Equal(t, []model.PasswordCard{gcpCard}, passwordCards)
+	})
+
+	t.Run("ensures no race condition", func(t *testing.T) {
+		s := newStore(t, []model.PasswordCard{awsCard, gcpCard})
+
+		var wg sync.WaitGroup
+		for _, passwordCardID := range []string{"card-id-2", "card-id-1"} {
+			wg.Add(1)
+			go func(passwordCardID string) {
+				defer wg.Done()
+				assert.NoError(t, s.Delete(passwordCardID))
+			}(passwordCardID)
+		}
+		wg.Wait()
+
+		passwordCards, err := s.GetAll()
+		require.NoError(t, err)
+		assert.Empty(t, passwordCards)
+	})
+}
+
+func testGetByID(t *testing.T, newStore NewStoreFunc) {
+	s := newStore(t, []model.PasswordCard{awsCard, gcpCard})
+
+	t.Run("returns error when password card is not found", func(t *testing.T) {
+		passwordCard, err := s.GetByID("card-id-3")
+		assert.ErrorIs(t, err, repository.ErrPasswordCardNotFound{ID: "card-id-3"})
+		assert.Nil(t, passwordCard)
+	})
+
+	t.Run("🎉 gets a password card successfully", func(t *testing.T) {
+		passwordCard, err := s.GetByID("card-id-2")
+		require.NoError(t, err)
+		assert.Equal(t, gcpCard, *passwordCard)
+	})
+}
+
+func testGetAll(t *testing.T, newStore NewStoreFunc) {
+	s := newStore(t, nil)
+
+	passwordCards, err := s.GetAll()
+	require.NoError(t, err)
+	assert.Empty(t, passwordCards)
+
+	require.NoError(t, s.Insert(awsCard))
+	require.NoError(t, s.Insert(gcpCard))
+
+	passwordCards, err = s.GetAll()
+	require.NoError(t, err)
+	assert.Equal(t, []model.PasswordCard{awsCard, gcpCard}, passwordCards)
+
+	// changing the returned slice must not change the store
+	passwordCards[0].Name = "changed"
+
+	passwordCards, err = s.GetAll()
+	require.NoError(t, err)
+	assert.Equal(t, []model.PasswordCard{awsCard, gcpCard}, passwordCards)
+}
+
+func testHOTPCounter(t *testing.T, newStore NewStoreFunc) {
+	s := newStore(t, []model.PasswordCard{awsCard, gcpCard})
+
+	t.Run("returns error when password card is not found", func(t *testing.T) {
+		_, err := s.IncrementHOTPCounter("card-id-3")
+		assert.ErrorIs(t, err, repository.ErrPasswordCardNotFound{ID: "card-id-3"})
+
+		err = s.SetHOTPCounter("card-id-3", 1)
+		assert.ErrorIs(t, err, repository.ErrPasswordCardNotFound{ID: "card-id-3"})
+	})
+
+	t.Run("🎉 increments the counter", func(t *testing.T) {
+		counter, err := s.IncrementHOTPCounter("card-id-2")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(5), counter)
+
+		counter, err = s.IncrementHOTPCounter("card-id-2")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(6), counter)
+
+		passwordCard, err := s.GetByID("card-id-2")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(7), passwordCard.HOTPCounter)
+	})
+
+	t.Run("🎉 sets the counter", func(t *testing.T) {
+		require.NoError(t, s.SetHOTPCounter("card-id-2", 42))
+
+		counter, err := s.IncrementHOTPCounter("card-id-2")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(42), counter)
+	})
+
+	t.Run("ensures no race condition", func(t *testing.T) {
+		s := newStore(t, []model.PasswordCard{gcpCard})
+
+		const increments = 20
+
+		var (
+			wg       sync.WaitGroup
+			mu       sync.Mutex
+			counters []uint64
+		)
+		for i := 0; i < increments; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				counter, err := s.IncrementHOTPCounter("card-id-2")
+				assert.NoError(t, err)
+
+				mu.Lock()
+				counters = append(counters, counter)
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+
+		expected := make([]uint64, 0, increments)
+		for i := uint64(0); i < increments; i++ {
+			expected = append(expected, gcpCard.HOTPCounter+i)
+		}
+		assert.ElementsMatch(t, expected, counters)
+
+		passwordCard, err := s.GetByID("card-id-2")
+		require.NoError(t, err)
+		assert.Equal(t, gcpCard.HOTPCounter+increments, passwordCard.HOTPCounter)
+	})
+}
@@ -0,0 +1,384 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+const (
+	usersSection    = "users"
+	sessionsSection = "sessions"
+)
+
+// ErrUserAlreadyExists is returned when the username is taken, regardless of
+// its case.
+type ErrUserAlreadyExists struct {
+	Username string
+}
+
+// Error implements error type interface.
+func (e ErrUserAlreadyExists) Error() string {
+	return fmt.Sprintf("user %q already exists", e.Username)
+}
+
+// ErrUserNotFound is returned when no user matches the ID or the username,
+// only one of them is set.
+type ErrUserNotFound struct {
+	ID, Username string
+}
+
+// Error implements error type interface.
+func (e ErrUserNotFound) Error() string {
+	if e.ID != "" {
+		return fmt.Sprintf("user with ID %q not found", e.ID)
+	}
+	return fmt.Sprintf("user %q not found", e.Username)
+}
+
+// ErrSessionNotFound is returned for unknown session tokens.
+var ErrSessionNotFound = errors.New("session not found")
+
+// UserStore is implemented by every user storage backend. Usernames are unique
+// regardless of their case.
+type UserStore interface {
+	Insert(newUser model.User) error
+	GetByID(userID string) (*model.User, error)
+	GetByUsername(username string) (*model.User, error)
+	Count() (int, error)
+}
+
+// SessionStore is implemented by every session storage backend.
+type SessionStore interface {
+	Insert(newSession model.Session) error
+	GetByTokenHash(tokenHash string) (*model.Session, error)
+	Delete(tokenHash string) error
+	// DeleteExpired removes the sessions expired before now.
+	DeleteExpired(now time.Time) error
+}
+
+var (
+	_ UserStore    = (*UserRepository)(nil)
+	_ UserStore    = (*SQLiteUserRepository)(nil)
+	_ SessionStore = (*SessionRepository)(nil)
+	_ SessionStore = (*SQLiteSessionRepository)(nil)
+)
+
+// UserRepository stores the users in memory and, optionally, in a vault file.
+type UserRepository struct {
+	users []model.User
+	mu    sync.Mutex
+
+	// storage is nil for repositories that only live in memory.
+	storage *FileStorage
+}
+
+func NewUserRepository() *UserRepository {
+	return &UserRepository{users: make([]model.User, 0)}
+}
+
+// NewFileUserRepository returns a repository whose users are persisted in the
+// given file storage.
+func NewFileUserRepository(storage *FileStorage) (*UserRepository, error) {
+	ur := NewUserRepository()
+	if _, err := storage.Load(usersSection, &ur.users); err != nil {
+		return nil, fmt.Errorf("error loading users: %w", err)
+	}
+
+	ur.storage = storage
+
+	return ur, nil
+}
+
+func (ur *UserRepository) Insert(newUser model.User) error {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	for _, user := range ur.users {
+		if strings.EqualFold(user.Username, newUser.Username) {
+			return ErrUserAlreadyExists{Username: newUser.Username}
+		}
+	}
+
+	users := append(ur.users, newUser)
+
+	if ur.storage != nil {
+		if err := ur.storage.Save(usersSection, users); err != nil {
+			return fmt.Errorf("error saving users: %w", err)
+		}
+	}
+
+	ur.users = users
+
+	return nil
+}
+
+func (ur *UserRepository) GetByID(userID string) (*model.User, error) {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	for _, user := range ur.users {
+		if user.ID == userID {
+			return &user, nil
+		}
+	}
+
+	return nil, ErrUserNotFound{ID: userID}
+}
+
+func (ur *UserRepository) GetByUsername(username string) (*model.User, error) {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	for _, user := range ur.users {
+		if strings.EqualFold(user.Username, username) {
+			return &user, nil
+		}
+	}
+
+	return nil, ErrUserNotFound{Username: username}
+}
+
+func (ur *UserRepository) Count() (int, error) {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	return len(ur.users), nil
+}
+
+// SessionRepository stores the sessions in memory and, optionally, in a vault
+// file.
+type SessionRepository struct {
+	sessions map[string]model.Session
+	mu       sync.Mutex
+
+	// storage is nil for repositories that only live in memory.
+	storage *FileStorage
+}
+
+func NewSessionRepository() *SessionRepository {
+	return &SessionRepository{sessions: make(map[string]model.Session)}
+}
+
+// NewFileSessionRepository returns a repository whose sessions are persisted
+// in the given file storage, so restarting the server doesn't log users out.
+func NewFileSessionRepository(storage *FileStorage) (*SessionRepository, error) {
+	sr := NewSessionRepository()
+	if _, err := storage.Load(sessionsSection, &sr.sessions); err != nil {
+		return nil, fmt.Errorf("error loading sessions: %w", err)
+	}
+
+	sr.storage = storage
+
+	return sr, nil
+}
+
+func (sr *SessionRepository) Insert(newSession model.Session) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	sessions := sr.copySessions()
+	sessions[newSession.TokenHash] = newSession
+
+	return sr.save(sessions)
+}
+
+func (sr *SessionRepository) GetByTokenHash(tokenHash string) (*model.Session, error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	session, ok := sr.sessions[tokenHash]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	return &session, nil
+}
+
+func (sr *SessionRepository) Delete(tokenHash string) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	if _, ok := sr.sessions[tokenHash]; !ok {
+		return ErrSessionNotFound
+	}
+
+	sessions := sr.copySessions()
+	delete(sessions, tokenHash)
+
+	return sr.save(sessions)
+}
+
+func (sr *SessionRepository) DeleteExpired(now time.Time) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	sessions := sr.copySessions()
+	for tokenHash, session := range sessions {
+		if session.ExpiresAt.Before(now) {
+			delete(sessions, tokenHash)
+		}
+	}
+
+	if len(sessions) == len(sr.sessions) {
+		return nil
+	}
+
+	return sr.save(sessions)
+}
+
+func (sr *SessionRepository) copySessions() map[string]model.Session {
+	sessions := make(map[string]model.Session, len(sr.sessions)+1)
+	for tokenHash, session := range sr.sessions {
+		sessions[tokenHash] = session
+	}
+	return sessions
+}
+
+// save must be called with the lock held.
+func (sr *SessionRepository) save(sessions map[string]model.Session) error {
+	if sr.storage != nil {
+		if err := sr.storage.Save(sessionsSection, sessions); err != nil {
+			return fmt.Errorf("error saving sessions: %w", err)
+		}
+	}
+
+	sr.sessions = sessions
+
+	return nil
+}
+
+// SQLiteUserRepository stores the users in a SQLite database.
+type SQLiteUserRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteUserRepository(db *sql.DB) *SQLiteUserRepository {
+	return &SQLiteUserRepository{db: db}
+}
+
+func (ur *SQLiteUserRepository) Insert(newUser model.User) error {
+	_, err := ur.db.Exec(
+		`INSERT INTO users (id, username, password_hash, created_at) VALUES (?, ?, ?, ?)`,
+		newUser.ID,
+		newUser.Username,
+		newUser.PasswordHash,
+		newUser.CreatedAt.UTC(),
+	)
+	if err != nil {
+		var sqliteErr *sqlite.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+			return ErrUserAlreadyExists{Username: newUser.Username}
+		}
+		return fmt.Errorf("error saving user: %w", err)
+	}
+
+	return nil
+}
+
+func (ur *SQLiteUserRepository) GetByID(userID string) (*model.User, error) {
+	user, err := scanUser(ur.db.QueryRow(`SELECT id, username, password_hash, created_at FROM users WHERE id = ?`, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound{ID: userID}
+	}
+
+	return user, err
+}
+
+func (ur *SQLiteUserRepository) GetByUsername(username string) (*model.User, error) {
+	user, err := scanUser(ur.db.QueryRow(`SELECT id, username, password_hash, created_at FROM users WHERE username = ?`, username))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound{Username: username}
+	}
+
+	return user, err
+}
+
+func (ur *SQLiteUserRepository) Count() (int, error) {
+	var count int
+	if err := ur.db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("error counting users: %w", err)
+	}
+
+	return count, nil
+}
+
+func scanUser(row scanner) (*model.User, error) {
+	var user model.User
+	if err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error getting user: %w", err)
+	}
+
+	// the driver returns the times in the local time zone, they are stored in UTC
+	user.CreatedAt = user.CreatedAt.UTC()
+
+	return &user, nil
+}
+
+// SQLiteSessionRepository stores the sessions in a SQLite database.
+type SQLiteSessionRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteSessionRepository(db *sql.DB) *SQLiteSessionRepository {
+	return &SQLiteSessionRepository{db: db}
+}
+
+func (sr *SQLiteSessionRepository) Insert(newSession model.Session) error {
+	_, err := sr.db.Exec(
+		`INSERT INTO sessions (token_hash, user_id, expires_at) VALUES (?, ?, ?)`,
+		newSession.TokenHash,
+		newSession.UserID,
+		newSession.ExpiresAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("error saving session: %w", err)
+	}
+
+	return nil
+}
+
+func (sr *SQLiteSessionRepository) GetByTokenHash(tokenHash string) (*model.Session, error) {
+	var session model.Session
+	err := sr.db.QueryRow(
+		`SELECT token_hash, user_id, expires_at FROM sessions WHERE token_hash = ?`,
+		tokenHash,
+	).Scan(&session.TokenHash, &session.UserID, &session.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("error getting session: %w", err)
+	}
+
+	session.ExpiresAt = session.ExpiresAt.UTC()
+
+	return &session, nil
+}
+
+func (sr *SQLiteSessionRepository) Delete(tokenHash string) error {
+	result, err := sr.db.Exec(`DELETE FROM sessions WHERE token_hash = ?`, tokenHash)
+	if err != nil {
+		return fmt.Errorf("error deleting session: %w", err)
+	}
+
+	return expectAffected(result, ErrSessionNotFound)
+}
+
+func (sr *SQLiteSessionRepository) DeleteExpired(now time.Time) error {
+	if _, err := sr.db.Exec(`DELETE FROM sessions WHERE expires_at < ?`, now.UTC()); err != nil {
+		return fmt.Errorf("error deleting expired sessions: %w", err)
+	}
+
+	return nil
+}
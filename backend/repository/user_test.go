@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserStores(t *testing.T) {
+	user := model.User{
+		ID:           "user-id-1",
+		Username:     "Alice",
+		PasswordHash: "$argon2id$hash",
+		CreatedAt:    time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC),
+	}
+
+	testCases := []struct {
+		name string
+		open func(t *testing.T, path string) UserStore
+	}{
+		{
+			name: "memory",
+			open: func(t *testing.T, path string) UserStore {
+				return NewUserRepository()
+			},
+		},
+		{
+			name: "file",
+			open: func(t *testing.T, path string) UserStore {
+				s, err := OpenFileStorage(path)
+				require.NoError(t, err)
+
+				ur, err := NewFileUserRepository(s)
+				require.NoError(t, err)
+				return ur
+			},
+		},
+		{
+			name: "sqlite",
+			open: func(t *testing.T, path string) UserStore {
+				db, err := OpenSQLite(path)
+				require.NoError(t, err)
+				t.Cleanup(func() { db.Close() })
+
+				return NewSQLiteUserRepository(db)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ur := tc.open(t, filepath.Join(t.TempDir(), "vault"))
+
+			count, err := ur.Count()
+			require.NoError(t, err)
+			assert.Zero(t, count)
+
+			_, err = ur.GetByUsername("alice")
+			assert.ErrorIs(t, err, ErrUserNotFound{Username: "alice"})
+
+			require.NoError(t, ur.Insert(user))
+
+			duplicated := user
+			duplicated.ID = "user-id-2"
+			duplicated.Username = "ALICE"
+			err = ur.Insert(duplicated)
+			assert.ErrorIs(t, err, ErrUserAlreadyExists{Username: "ALICE"})
+
+			loaded, err := ur.GetByUsername("alice")
+			require.NoError(t, err)
+			assert.Equal(t, &user, loaded)
+
+			loaded, err = ur.GetByID("user-id-1")
+			require.NoError(t, err)
+			assert.Equal(t, &user, loaded)
+
+			_, err = ur.GetByID("user-id-2")
+			assert.ErrorIs(t, err, ErrUserNotFound{ID: "user-id-2"})
+
+			count, err = ur.Count()
+			require.NoError(t, err)
+			assert.Equal(t, 1, count)
+		})
+	}
+}
+
+func TestSessionStores(t *testing.T) {
+	now := time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+	user := model.User{ID: "user-id-1", Username: "alice", PasswordHash: "$argon2id$hash", CreatedAt: now}
+	session := model.Session{TokenHash: "token-hash-1", UserID: user.ID, ExpiresAt: now.Add(time.Hour)}
+	expired := model.Session{TokenHash: "token-hash-2", UserID: user.ID, ExpiresAt: now.Add(-time.Hour)}
+
+	testCases := []struct {
+		name string
+		open func(t *testing.T, path string) SessionStore
+	}{
+		{
+			name: "memory",
+			open: func(t *testing.T, path string) SessionStore {
+				return NewSessionRepository()
+			},
+		},
+		{
+			name: "file",
+			open: func(t *testing.T, path string) SessionStore {
+				s, err := OpenFileStorage(path)
+				require.NoError(t, err)
+
+				sr, err := NewFileSessionRepository(s)
+				require.NoError(t, err)
+				return sr
+			},
+		},
+		{
+			name: "sqlite",
+			open: func(t *testing.T, path string) SessionStore {
+				db, err := OpenSQLite(path)
+				require.NoError(t, err)
+				t.Cleanup(func() { db.Close() })
+
+				// the sessions reference their user
+				require.NoError(t, NewSQLiteUserRepository(db).Insert(user))
+
+				return NewSQLiteSessionRepository(db)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sr := tc.open(t, filepath.Join(t.TempDir(), "vault"))
+
+			_, err := sr.GetByTokenHash(session.TokenHash)
+			assert.ErrorIs(t, err, ErrSessionNotFound)
+
+			require.NoError(t, sr.Insert(session))
+			require.NoError(t, sr.Insert(expired))
+
+			loaded, err := sr.GetByTokenHash(session.TokenHash)
+			require.NoError(t, err)
+			assert.Equal(t, &session, loaded)
+
+			require.NoError(t, sr.DeleteExpired(now))
+
+			_, err = sr.GetByTokenHash(expired.TokenHash)
+			assert.ErrorIs(t, err, ErrSessionNotFound)
+
+			require.NoError(t, sr.Delete(session.TokenHash))
+			assert.ErrorIs(t, sr.Delete(session.TokenHash), ErrSessionNotFound)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/CaioTeixeira95/password-manager/backend/vault"
+)
+
+const vaultHeaderSection = "vault_header"
+
+// VaultHeaderStore keeps the vault header next to the encrypted data.
+type VaultHeaderStore interface {
+	// LoadVaultHeader returns nil when no vault was created yet.
+	LoadVaultHeader() (*vault.Header, error)
+	SaveVaultHeader(header vault.Header) error
+}
+
+// VaultHeaderRepository keeps the vault header in memory and, optionally, in
+// a vault file.
+type VaultHeaderRepository struct {
+	header *vault.Header
+	mu     sync.Mutex
+
+	// storage is nil for repositories that only live in memory.
+	storage *FileStorage
+}
+
+var (
+	_ VaultHeaderStore = (*VaultHeaderRepository)(nil)
+	_ VaultHeaderStore = (*SQLiteVaultHeaderRepository)(nil)
+)
+
+func NewVaultHeaderRepository() *VaultHeaderRepository {
+	return &VaultHeaderRepository{}
+}
+
+// NewFileVaultHeaderRepository returns a repository whose header is persisted
+// in the given file storage.
+func NewFileVaultHeaderRepository(storage *FileStorage) (*VaultHeaderRepository, error) {
+	var header vault.Header
+	ok, err := storage.Load(vaultHeaderSection, &header)
+	if err != nil {
+		return nil, fmt.Errorf("error loading vault header: %w", err)
+	}
+
+	hr := &VaultHeaderRepository{storage: storage}
+	if ok {
+		hr.header = &header
+	}
+
+	return hr, nil
+}
+
+func (hr *VaultHeaderRepository) LoadVaultHeader() (*vault.Header, error) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	if hr.header == nil {
+		return nil, nil
+	}
+
+	header := *hr.header
+	return &header, nil
+}
+
+func (hr *VaultHeaderRepository) SaveVaultHeader(header vault.Header) error {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	if hr.storage != nil {
+		if err := hr.storage.Save(vaultHeaderSection, header); err != nil {
+			return fmt.Errorf("error saving vault header: %w", err)
+		}
+	}
+
+	hr.header = &header
+
+	return nil
+}
+
+// SQLiteVaultHeaderRepository keeps the vault header in a SQLite database.
+type SQLiteVaultHeaderRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteVaultHeaderRepository(db *sql.DB) *SQLiteVaultHeaderRepository {
+	return &SQLiteVaultHeaderRepository{db: db}
+}
+
+func (hr *SQLiteVaultHeaderRepository) LoadVaultHeader() (*vault.Header, error) {
+	var raw string
+	err := hr.db.QueryRow(`SELECT header FROM vault_header WHERE id = 1`).Scan(&raw)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error loading vault header: %w", err)
+	}
+
+	var header vault.Header
+	if err := json.Unmarshal([]byte(raw), &header); err != nil {
+		return nil, fmt.Errorf("error decoding vault header: %w", err)
+	}
+
+	return &header, nil
+}
+
+func (hr *SQLiteVaultHeaderRepository) SaveVaultHeader(header vault.Header) error {
+	raw, err := json.Marshal(header)
+	if err != nil {
+		return fmt.Errorf("error encoding vault header: %w", err)
+	}
+
+	_, err = hr.db.Exec(
+		`INSERT INTO vault_header (id, header) VALUES (1, ?) ON CONFLICT (id) DO UPDATE SET header = excluded.header`,
+		string(raw),
+	)
+	if err != nil {
+		return fmt.Errorf("error saving vault header: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/CaioTeixeira95/password-manager/backend/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultHeaderStores(t *testing.T) {
+	header := vault.Header{
+		Version: vault.HeaderVersion,
+		KDF:     vault.KDFParams{Salt: []byte("salt"), Time: 1, Memory: 64, Threads: 1},
+		Check:   []byte("check"),
+	}
+
+	testCases := []struct {
+		name string
+		open func(t *testing.T, path string) VaultHeaderStore
+	}{
+		{
+			name: "file",
+			open: func(t *testing.T, path string) VaultHeaderStore {
+				s, err := OpenFileStorage(path)
+				require.NoError(t, err)
+
+				hr, err := NewFileVaultHeaderRepository(s)
+				require.NoError(t, err)
+				return hr
+			},
+		},
+		{
+			name: "sqlite",
+			open: func(t *testing.T, path string) VaultHeaderStore {
+				db, err := OpenSQLite(path)
+				require.NoError(t, err)
+				t.Cleanup(func() { db.Close() })
+
+				return NewSQLiteVaultHeaderRepository(db)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "vault")
+			hr := tc.open(t, path)
+
+			loaded, err := hr.LoadVaultHeader()
+			require.NoError(t, err)
+			assert.Nil(t, loaded)
+
+			require.NoError(t, hr.SaveVaultHeader(header))
+
+			updated := header
+			updated.Check = []byte("new check")
+			require.NoError(t, hr.SaveVaultHeader(updated))
+
+			loaded, err = tc.open(t, path).LoadVaultHeader()
+			require.NoError(t, err)
+			assert.Equal(t, &updated, loaded)
+		})
+	}
+
+	t.Run("memory", func(t *testing.T) {
+		hr := NewVaultHeaderRepository()
+
+		loaded, err := hr.LoadVaultHeader()
+		require.NoError(t, err)
+		assert.Nil(t, loaded)
+
+		require.NoError(t, hr.SaveVaultHeader(header))
+
+		loaded, err = hr.LoadVaultHeader()
+		require.NoError(t, err)
+		assert.Equal(t, &header, loaded)
+	})
+}
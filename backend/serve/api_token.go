@@ -0,0 +1,168 @@
+package serve
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type APITokenResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Scope     string    `json:"scope"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CreateAPITokenResponse is the only response holding the token itself.
+type CreateAPITokenResponse struct {
+	APITokenResponse
+	Token string `json:"token"`
+}
+
+func newAPITokenResponse(apiToken model.APIToken) APITokenResponse {
+	return APITokenResponse{
+		ID:        apiToken.ID,
+		Name:      apiToken.Name,
+		Scope:     apiToken.Scope,
+		CreatedAt: apiToken.CreatedAt,
+		ExpiresAt: apiToken.ExpiresAt,
+	}
+}
+
+// requestAPIToken returns the API token the request was authenticated with, it's
+// nil for the requests authenticated with a session.
+func requestAPIToken(c *fiber.Ctx) *model.APIToken {
+	apiToken, _ := c.Locals(apiTokenLocalsKey).(*model.APIToken)
+	return apiToken
+}
+
+// requireWriteScope rejects the requests changing data when they are
+// authenticated with a read-only API token.
+func requireWriteScope(c *fiber.Ctx) error {
+	apiToken := requestAPIToken(c)
+	if apiToken == nil || apiToken.CanWrite() {
+		return c.Next()
+	}
+
+	switch c.Method() {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return c.Next()
+	}
+
+	return forbiddenResponse(c, "API token is read-only")
+}
+
+// requireSession rejects the requests authenticated with an API token, so a
+// leaked token can't be used to create new ones.
+func requireSession(c *fiber.Ctx) error {
+	if requestAPIToken(c) != nil {
+		return forbiddenResponse(c, "API tokens can't manage API tokens")
+	}
+	return c.Next()
+}
+
+func forbiddenResponse(c *fiber.Ctx, reason string) error {
+	return c.Status(http.StatusForbidden).JSON(ErrorResponse{
+		Status:  http.StatusForbidden,
+		Message: "Forbidden.",
+		Error:   reason,
+	})
+}
+
+func handleGetAPITokens(ts *service.APITokenService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		apiTokens, err := ts.ListAPITokens(currentUserID(c))
+		if err != nil {
+			log.Printf("error listing API tokens: %s", err.Error())
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		apiTokenResponses := make([]APITokenResponse, 0, len(apiTokens))
+		for _, apiToken := range apiTokens {
+			apiTokenResponses = append(apiTokenResponses, newAPITokenResponse(apiToken))
+		}
+
+		return c.JSON(apiTokenResponses)
+	}
+}
+
+func handlePostAPITokens(ts *service.APITokenService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		var apiTokenRequest model.APITokenRequest
+		if err := c.BodyParser(&apiTokenRequest); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "The request is invalid in some way.",
+				Error:   err.Error(),
+			})
+		}
+
+		if err := apiTokenRequest.Validate(); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "Validation error.",
+				Error:   err.Error(),
+			})
+		}
+
+		token, apiToken, err := ts.CreateAPIToken(currentUserID(c), apiTokenRequest)
+		if err != nil {
+			log.Printf("error creating API token: %s", err.Error())
+
+			var errExists repository.ErrAPITokenAlreadyExists
+			if errors.As(err, &errExists) {
+				return c.Status(http.StatusConflict).JSON(ErrorResponse{
+					Status:  http.StatusConflict,
+					Message: "Conflict.",
+					Error:   errExists.Error(),
+				})
+			}
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		return c.Status(http.StatusCreated).JSON(CreateAPITokenResponse{
+			APITokenResponse: newAPITokenResponse(*apiToken),
+			Token:            token,
+		})
+	}
+}
+
+func handleDeleteAPITokens(ts *service.APITokenService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		err := ts.RevokeAPIToken(currentUserID(c), c.Params("id"))
+		if err != nil {
+			log.Printf("error revoking API token: %s", err.Error())
+
+			var errNotFound repository.ErrAPITokenNotFound
+			if errors.As(err, &errNotFound) {
+				return c.Status(http.StatusNotFound).JSON(ErrorResponse{
+					Status:  http.StatusNotFound,
+					Message: "API token not found.",
+					Error:   errNotFound.Error(),
+				})
+			}
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		return c.SendStatus(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,135 @@
+package serve
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPITokens(t *testing.T) {
+	app := fiber.New()
+	userRepository := repository.NewUserRepository()
+	userService := newTestUserService(userRepository)
+	apiTokenService := service.NewAPITokenService(repository.NewAPITokenRepository(), userRepository)
+
+	s := NewServe(
+		app,
+		service.NewPasswordCardService(repository.NewPasswordCardRepository()),
+		WithUserService(userService),
+		WithAPITokenService(apiTokenService),
+	)
+	s.initHandlers()
+
+	do := func(method, url, token, body string) (int, string) {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(respBody)
+	}
+
+	status, body := do(http.MethodPost, "/auth/register", "", `{"username": "alice", "password": "supersecret"}`)
+	require.Equal(t, http.StatusCreated, status, body)
+
+	status, body = do(http.MethodPost, "/auth/login", "", `{"username": "alice", "password": "supersecret"}`)
+	require.Equal(t, http.StatusOK, status, body)
+
+	var loginResponse LoginResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &loginResponse))
+	sessionToken := loginResponse.Token
+
+	createToken := func(body string) CreateAPITokenResponse {
+		status, respBody := do(http.MethodPost, "/auth/tokens", sessionToken, body)
+		require.Equal(t, http.StatusCreated, status, respBody)
+
+		var createResponse CreateAPITokenResponse
+		require.NoError(t, json.Unmarshal([]byte(respBody), &createResponse))
+		return createResponse
+	}
+
+	t.Run("return BadRequest for an invalid scope", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/auth/tokens", sessionToken, `{"name": "ci", "scope": "admin"}`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":"scope must be \"read\" or \"read-write\"", "message":"Validation error.", "status":400}`, body)
+	})
+
+	readToken := createToken(`{"name": "ci", "scope": "read"}`)
+	writeToken := createToken(`{"name": "deploy", "scope": "read-write", "expires_in_days": 7}`)
+
+	t.Run("return Conflict for a duplicated name", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/auth/tokens", sessionToken, `{"name": "ci", "scope": "read"}`)
+		assert.Equal(t, http.StatusConflict, status)
+		assert.JSONEq(t, `{"error":"API token \"ci\" already exists", "message":"Conflict.", "status":409}`, body)
+	})
+
+	t.Run("🎉 lists the API tokens without the tokens themselves", func(t *testing.T) {
+		status, body := do(http.MethodGet, "/auth/tokens", sessionToken, "")
+		assert.Equal(t, http.StatusOK, status)
+
+		var apiTokens []APITokenResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &apiTokens))
+		assert.Equal(t, []APITokenResponse{readToken.APITokenResponse, writeToken.APITokenResponse}, apiTokens)
+		assert.NotContains(t, body, readToken.Token)
+	})
+
+	t.Run("return Forbidden for writes with a read-only token", func(t *testing.T) {
+		status, _ := do(http.MethodGet, "/password-cards", readToken.Token, "")
+		assert.Equal(t, http.StatusOK, status)
+
+		status, body := do(http.MethodPost, "/password-cards", readToken.Token, `{"id": "card-id-1", "name": "AWS", "username": "ci", "password": "supersecret", "url": "https://aws.com/login"}`)
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.JSONEq(t, `{"error":"API token is read-only", "message":"Forbidden.", "status":403}`, body)
+
+		// issuing an HOTP code moves its counter
+		status, body = do(http.MethodPost, "/password-cards/card-id-1/hotp", readToken.Token, "")
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.JSONEq(t, `{"error":"API token is read-only", "message":"Forbidden.", "status":403}`, body)
+	})
+
+	t.Run("🎉 writes with a read-write token", func(t *testing.T) {
+		status, _ := do(http.MethodPost, "/password-cards", writeToken.Token, `{"id": "card-id-1", "name": "AWS", "username": "ci", "password": "supersecret", "url": "https://aws.com/login"}`)
+		assert.Equal(t, http.StatusCreated, status)
+
+		// the card belongs to the owner of the token
+		status, body := do(http.MethodGet, "/password-cards", sessionToken, "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, `"id":"card-id-1"`)
+	})
+
+	t.Run("return Forbidden when an API token manages API tokens", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/auth/tokens", writeToken.Token, `{"name": "another", "scope": "read"}`)
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.JSONEq(t, `{"error":"API tokens can't manage API tokens", "message":"Forbidden.", "status":403}`, body)
+	})
+
+	t.Run("🎉 revokes an API token", func(t *testing.T) {
+		status, _ := do(http.MethodDelete, "/auth/tokens/"+readToken.ID, sessionToken, "")
+		assert.Equal(t, http.StatusNoContent, status)
+
+		status, body := do(http.MethodGet, "/password-cards", readToken.Token, "")
+		assert.Equal(t, http.StatusUnauthorized, status)
+		assert.JSONEq(t, `{"error":"invalid, expired or revoked API token", "message":"Unauthorized.", "status":401}`, body)
+
+		status, _ = do(http.MethodDelete, "/auth/tokens/"+readToken.ID, sessionToken, "")
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+}
@@ -0,0 +1,240 @@
+package serve
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// userLocalsKey is the fiber.Ctx locals key holding the authenticated user.
+	userLocalsKey = "user"
+	// apiTokenLocalsKey holds the API token of the request, it's unset for
+	// the requests authenticated with a session.
+	apiTokenLocalsKey = "api_token"
+)
+
+type UserResponse struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LoginResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func newUserResponse(user *model.User) UserResponse {
+	return UserResponse{ID: user.ID, Username: user.Username, CreatedAt: user.CreatedAt}
+}
+
+// requireAuthentication rejects the requests without a valid session or API
+// token in the Authorization header and stores the user of the token in the
+// context. ts is nil when the API tokens are disabled.
+func requireAuthentication(us *service.UserService, ts *service.APITokenService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		token, ok := bearerToken(c)
+		if !ok {
+			return unauthorizedResponse(c, "missing bearer token")
+		}
+
+		var (
+			user *model.User
+			err  error
+		)
+		if ts != nil && service.IsAPIToken(token) {
+			var apiToken *model.APIToken
+			user, apiToken, err = ts.Authenticate(token)
+			if err == nil {
+				c.Locals(apiTokenLocalsKey, apiToken)
+			}
+		} else {
+			user, err = us.Authenticate(token)
+		}
+
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidSession) || errors.Is(err, service.ErrInvalidAPIToken) {
+				return unauthorizedResponse(c, err.Error())
+			}
+
+			log.Printf("error authenticating: %s", err.Error())
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		c.Locals(userLocalsKey, user)
+
+		return c.Next()
+	}
+}
+
+// requireAdmin rejects the requests of the users without the admin rights. It
+// must run after requireAuthentication.
+func requireAdmin(us *service.UserService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		user, ok := c.Locals(userLocalsKey).(*model.User)
+		if !ok || !us.IsAdmin(user) {
+			return forbiddenResponse(c, "admin rights required")
+		}
+		return c.Next()
+	}
+}
+
+// currentUserID returns the ID of the authenticated user, it's empty when the
+// server runs without accounts.
+func currentUserID(c *fiber.Ctx) string {
+	if user, ok := c.Locals(userLocalsKey).(*model.User); ok {
+		return user.ID
+	}
+	return ""
+}
+
+func bearerToken(c *fiber.Ctx) (string, bool) {
+	scheme, token, ok := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+func unauthorizedResponse(c *fiber.Ctx, reason string) error {
+	return c.Status(http.StatusUnauthorized).JSON(ErrorResponse{
+		Status:  http.StatusUnauthorized,
+		Message: "Unauthorized.",
+		Error:   reason,
+	})
+}
+
+func handlePostAuthRegister(us *service.UserService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		var credentials model.Credentials
+		if err := c.BodyParser(&credentials); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "The request is invalid in some way.",
+				Error:   err.Error(),
+			})
+		}
+
+		if err := credentials.Validate(); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "Validation error.",
+				Error:   err.Error(),
+			})
+		}
+
+		user, err := us.Register(credentials)
+		if err != nil {
+			log.Printf("error registering user: %s", err.Error())
+
+			var errExists repository.ErrUserAlreadyExists
+			if errors.As(err, &errExists) {
+				return c.Status(http.StatusConflict).JSON(ErrorResponse{
+					Status:  http.StatusConflict,
+					Message: "Conflict.",
+					Error:   errExists.Error(),
+				})
+			}
+
+			if errors.Is(err, service.ErrAdminMasterPassword) {
+				return forbiddenResponse(c, service.ErrAdminMasterPassword.Error())
+			}
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		return c.Status(http.StatusCreated).JSON(newUserResponse(user))
+	}
+}
+
+// handlePostPasswordCardsClaim gives the password cards and folders created
+// before the accounts were enabled to the current user, and reports the cards
+// whose URL it already uses. It's restricted to the admins: anyone can
+// register.
+func handlePostPasswordCardsClaim(ps *service.PasswordCardService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		report, err := ps.ClaimUnownedPasswordCards(currentUserID(c))
+		if err != nil {
+			log.Printf("error claiming password cards: %s", err.Error())
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		return c.JSON(report)
+	}
+}
+
+func handlePostAuthLogin(us *service.UserService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		var credentials model.Credentials
+		if err := c.BodyParser(&credentials); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "The request is invalid in some way.",
+				Error:   err.Error(),
+			})
+		}
+
+		token, session, err := us.Login(credentials)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidCredentials) {
+				return unauthorizedResponse(c, err.Error())
+			}
+
+			log.Printf("error logging in: %s", err.Error())
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		return c.JSON(LoginResponse{Token: token, ExpiresAt: session.ExpiresAt})
+	}
+}
+
+func handlePostAuthLogout(us *service.UserService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		token, _ := bearerToken(c)
+
+		if err := us.Logout(token); err != nil {
+			if errors.Is(err, service.ErrInvalidSession) {
+				return unauthorizedResponse(c, err.Error())
+			}
+
+			log.Printf("error logging out: %s", err.Error())
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		return c.SendStatus(http.StatusNoContent)
+	}
+}
+
+func handleGetAuthMe() func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		user := c.Locals(userLocalsKey).(*model.User)
+		return c.JSON(newUserResponse(user))
+	}
+}
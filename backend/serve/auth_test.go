@@ -0,0 +1,168 @@
+package serve
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/service"
+	"github.com/CaioTeixeira95/password-manager/backend/vault"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestUserService returns a user service using cheap KDF parameters.
+func newTestUserService(userRepository repository.UserStore) *service.UserService {
+	return service.CustomUserService(userRepository, repository.NewSessionRepository(), func() (vault.KDFParams, error) {
+		return vault.KDFParams{Time: 1, Memory: 64, Threads: 1}, nil
+	})
+}
+
+func TestAuth(t *testing.T) {
+	app := fiber.New()
+	r := repository.CustomPasswordCardRepository([]model.PasswordCard{
+		{
+			ID:       "card-id-1",
+			Name:     "AWS",
+			Username: "username",
+			Password: "supersecret",
+			URL:      "https://aws.com/login",
+		},
+	})
+
+	vaultService := newTestVaultService()
+	require.NoError(t, vaultService.Unlock([]byte("master")))
+
+	userService := newTestUserService(repository.NewUserRepository())
+	userService.SetAdmins([]string{"alice"}, vaultService)
+
+	s := NewServe(app, service.NewPasswordCardService(r), WithUserService(userService))
+	s.initHandlers()
+
+	do := func(method, url, token, body string) (int, string) {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(respBody)
+	}
+
+	login := func(username string) string {
+		status, body := do(http.MethodPost, "/auth/login", "", `{"username": "`+username+`", "password": "supersecret"}`)
+		require.Equal(t, http.StatusOK, status, body)
+
+		var loginResponse LoginResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &loginResponse))
+		return loginResponse.Token
+	}
+
+	t.Run("return Unauthorized for the password cards routes without a session", func(t *testing.T) {
+		status, body := do(http.MethodGet, "/password-cards", "", "")
+		assert.Equal(t, http.StatusUnauthorized, status)
+		assert.JSONEq(t, `{"error":"missing bearer token", "message":"Unauthorized.", "status":401}`, body)
+
+		status, body = do(http.MethodGet, "/password-cards", "unknown-token", "")
+		assert.Equal(t, http.StatusUnauthorized, status)
+		assert.JSONEq(t, `{"error":"invalid or expired session", "message":"Unauthorized.", "status":401}`, body)
+	})
+
+	t.Run("return BadRequest for invalid credentials", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/auth/register", "", `{"username": "alice", "password": "short"}`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":"password must have at least 8 characters", "message":"Validation error.", "status":400}`, body)
+	})
+
+	t.Run("return Forbidden for admins registered without the master password", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/auth/register", "", `{"username": "alice", "password": "supersecret", "master_password": "wrong"}`)
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.JSONEq(t, `{"error":"admin accounts are registered with the vault master password", "message":"Forbidden.", "status":403}`, body)
+	})
+
+	t.Run("🎉 registers users", func(t *testing.T) {
+		for _, username := range []string{"alice", "bob"} {
+			status, body := do(http.MethodPost, "/auth/register", "", `{"username": "`+username+`", "password": "supersecret", "master_password": "master"}`)
+			assert.Equal(t, http.StatusCreated, status)
+			assert.Contains(t, body, `"username":"`+username+`"`)
+			assert.NotContains(t, body, "password")
+		}
+
+		status, body := do(http.MethodPost, "/auth/register", "", `{"username": "Bob", "password": "supersecret"}`)
+		assert.Equal(t, http.StatusConflict, status)
+		assert.JSONEq(t, `{"error":"user \"Bob\" already exists", "message":"Conflict.", "status":409}`, body)
+	})
+
+	t.Run("return Unauthorized for a wrong password", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/auth/login", "", `{"username": "alice", "password": "wrongsecret"}`)
+		assert.Equal(t, http.StatusUnauthorized, status)
+		assert.JSONEq(t, `{"error":"invalid username or password", "message":"Unauthorized.", "status":401}`, body)
+	})
+
+	t.Run("🎉 scopes the password cards to their owner", func(t *testing.T) {
+		aliceToken := login("alice")
+		bobToken := login("bob")
+
+		status, body := do(http.MethodGet, "/auth/me", aliceToken, "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, `"username":"alice"`)
+
+		// registering first doesn't give the existing password cards, an
+		// admin claims them
+		status, body = do(http.MethodGet, "/password-cards", aliceToken, "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.JSONEq(t, `[]`, body)
+
+		status, body = do(http.MethodPost, "/password-cards/claim", bobToken, "")
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.JSONEq(t, `{"error":"admin rights required", "message":"Forbidden.", "status":403}`, body)
+
+		status, body = do(http.MethodPost, "/password-cards/claim", aliceToken, "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.JSONEq(t, `{"claimed":[{"row":1, "id":"card-id-1", "name":"AWS", "url":"https://aws.com/login"}], "folders":0, "conflicts":[]}`, body)
+
+		status, body = do(http.MethodGet, "/password-cards", aliceToken, "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, `"id":"card-id-1"`)
+
+		status, body = do(http.MethodGet, "/password-cards", bobToken, "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.JSONEq(t, `[]`, body)
+
+		status, _ = do(http.MethodDelete, "/password-cards/card-id-1", bobToken, "")
+		assert.Equal(t, http.StatusNotFound, status)
+
+		// the same URL can be stored by different users
+		status, _ = do(http.MethodPost, "/password-cards", bobToken, `{"id": "card-id-2", "name": "AWS", "username": "bob", "password": "supersecret", "url": "https://aws.com/login"}`)
+		assert.Equal(t, http.StatusCreated, status)
+
+		status, body = do(http.MethodGet, "/password-cards", bobToken, "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, `"id":"card-id-2"`)
+		assert.NotContains(t, body, `"id":"card-id-1"`)
+	})
+
+	t.Run("🎉 logs out", func(t *testing.T) {
+		token := login("alice")
+
+		status, _ := do(http.MethodPost, "/auth/logout", token, "")
+		assert.Equal(t, http.StatusNoContent, status)
+
+		status, _ = do(http.MethodGet, "/password-cards", token, "")
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+}
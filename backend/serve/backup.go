@@ -0,0 +1,182 @@
+package serve
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/backup"
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/service"
+	"github.com/CaioTeixeira95/password-manager/backend/vault"
+	"github.com/gofiber/fiber/v2"
+)
+
+// backupPassphraseHeader holds the passphrase of the backups, the export is a
+// GET request without a body.
+const backupPassphraseHeader = "X-Backup-Passphrase"
+
+// handleGetVaultExport sends a backup of the password cards and folders of the
+// current user, encrypted with the passphrase of the X-Backup-Passphrase
+// header. Every backed up card is recorded like a reveal before the backup is
+// sent.
+func handleGetVaultExport(s *service.PasswordCardService, as *service.AuditService, newKDFParams func() (vault.KDFParams, error)) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		passphrase := c.Get(backupPassphraseHeader)
+		if err := validateBackupPassphrase(passphrase); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "Validation error.",
+				Error:   err.Error(),
+			})
+		}
+
+		passwordCards, err := s.ListPasswordCards(currentUserID(c))
+		if err != nil {
+			log.Printf("error exporting vault: %s", err.Error())
+
+			if errors.Is(err, service.ErrVaultLocked) {
+				return vaultLockedResponse(c)
+			}
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		for _, passwordCard := range passwordCards {
+			if err := recordAuditEvent(c, as, model.AuditActionExportPassword, passwordCard.ID); err != nil {
+				log.Printf("error exporting vault: %s", err.Error())
+
+				return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+					Status:  http.StatusInternalServerError,
+					Message: "Internal Server Error.",
+				})
+			}
+		}
+
+		folders, err := s.ListFolders(currentUserID(c))
+		if err != nil {
+			log.Printf("error exporting vault: %s", err.Error())
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		params, err := newKDFParams()
+		if err != nil {
+			log.Printf("error exporting vault: %s", err.Error())
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		var buf bytes.Buffer
+		if err := backup.Write(&buf, passphrase, passwordCards, folders, time.Now(), params); err != nil {
+			log.Printf("error exporting vault: %s", err.Error())
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		c.Attachment("vault-backup.json")
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Send(buf.Bytes())
+	}
+}
+
+// handlePostVaultImport restores a backup made by the export. It's either the
+// body of the request, with the passphrase in the X-Backup-Passphrase header,
+// or the "file" field of a multipart form along with the "passphrase" field.
+// The mode query parameter, merge by default, tells whether the cards of the
+// current user are kept or replaced. The cards that can't be restored are
+// reported, they don't fail the request.
+func handlePostVaultImport(s *service.PasswordCardService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		query := model.DefaultRestoreQuery()
+		if err := c.QueryParser(&query); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "The request is invalid in some way.",
+				Error:   err.Error(),
+			})
+		}
+
+		if err := query.Validate(); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "Validation error.",
+				Error:   err.Error(),
+			})
+		}
+
+		data, passphrase, err := restoreRequest(c)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "The request is invalid in some way.",
+				Error:   err.Error(),
+			})
+		}
+
+		restored, err := backup.Read(bytes.NewReader(data), passphrase)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "Validation error.",
+				Error:   err.Error(),
+			})
+		}
+
+		report, err := s.RestorePasswordCards(currentUserID(c), query.Mode, restored.PasswordCards, restored.Folders)
+		if err != nil {
+			log.Printf("error importing vault: %s", err.Error())
+
+			if errors.Is(err, service.ErrVaultLocked) {
+				return vaultLockedResponse(c)
+			}
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		return c.JSON(report)
+	}
+}
+
+// restoreRequest returns the restored backup and its passphrase.
+func restoreRequest(c *fiber.Ctx) ([]byte, string, error) {
+	if !isMultipartForm(c) {
+		return c.Body(), c.Get(backupPassphraseHeader), nil
+	}
+
+	data, err := formFile(c, "file")
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, c.FormValue("passphrase"), nil
+}
+
+// validateBackupPassphrase rejects the passphrases shorter than the account
+// passwords, the backups can be copied anywhere.
+func validateBackupPassphrase(passphrase string) error {
+	if len(passphrase) < model.MinPasswordLength {
+		return fmt.Errorf("passphrase must have at least %d characters", model.MinPasswordLength)
+	}
+
+	return nil
+}
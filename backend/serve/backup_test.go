@@ -0,0 +1,275 @@
+package serve
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/CaioTeixeira95/password-manager/backend/backup"
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/service"
+	"github.com/CaioTeixeira95/password-manager/backend/vault"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultBackup(t *testing.T) {
+	app := fiber.New()
+	r := repository.CustomPasswordCardRepository([]model.PasswordCard{
+		{ID: "card-id-1", Name: "AWS", Username: "admin", Password: "supersecret", URL: "https://aws.com/login"},
+	})
+	auditRepository := repository.NewAuditRepository()
+	ps := service.NewPasswordCardService(r)
+	folder, err := ps.CreateFolder("", model.FolderRequest{Name: "Cloud"})
+	require.NoError(t, err)
+	_, err = ps.MovePasswordCard("", "card-id-1", folder.ID)
+	require.NoError(t, err)
+
+	s := NewServe(app, ps, WithAuditService(service.NewAuditService(auditRepository)))
+	s.newBackupKDFParams = func() (vault.KDFParams, error) {
+		return vault.KDFParams{Salt: []byte("0123456789abcdef"), Time: 1, Memory: 64, Threads: 1}, nil
+	}
+	s.initHandlers()
+
+	do := func(req *http.Request) (*http.Response, []byte) {
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		return resp, respBody
+	}
+
+	export := func(passphrase string) (*http.Response, []byte) {
+		req, err := http.NewRequest(http.MethodGet, "/vault/export", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Backup-Passphrase", passphrase)
+
+		return do(req)
+	}
+
+	t.Run("return BadRequest for short passphrases", func(t *testing.T) {
+		resp, body := export("short")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.JSONEq(t, `{"error":"passphrase must have at least 8 characters", "message":"Validation error.", "status":400}`, string(body))
+	})
+
+	var archive []byte
+
+	t.Run("🎉 exports an encrypted backup", func(t *testing.T) {
+		resp, body := export("correct horse")
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		assert.Equal(t, `attachment; filename="vault-backup.json"`, resp.Header.Get("Content-Disposition"))
+		assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+		assert.NotContains(t, string(body), "supersecret")
+		archive = body
+
+		restored, err := backup.Read(bytes.NewReader(body), "correct horse")
+		require.NoError(t, err)
+		require.Len(t, restored.PasswordCards, 1)
+		assert.Equal(t, "supersecret", restored.PasswordCards[0].Password)
+		require.Len(t, restored.Folders, 1)
+		assert.Equal(t, "Cloud", restored.Folders[0].Name)
+
+		auditEvents, err := auditRepository.ListByPasswordCardID("card-id-1")
+		require.NoError(t, err)
+		require.Len(t, auditEvents, 1)
+		assert.Equal(t, model.AuditActionExportPassword, auditEvents[0].Action)
+	})
+
+	restore := func(mode, passphrase string) (*http.Response, []byte) {
+		target := "/vault/import"
+		if mode != "" {
+			target += "?mode=" + mode
+		}
+
+		req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(archive))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Backup-Passphrase", passphrase)
+
+		return do(req)
+	}
+
+	t.Run("return BadRequest for unknown modes", func(t *testing.T) {
+		resp, body := restore("overwrite", "correct horse")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.JSONEq(t, `{"error":"mode must be \"merge\" or \"replace\"", "message":"Validation error.", "status":400}`, string(body))
+	})
+
+	t.Run("return BadRequest for wrong passphrases", func(t *testing.T) {
+		resp, body := restore("merge", "wrong passphrase")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Contains(t, string(body), "invalid backup or passphrase")
+	})
+
+	t.Run("🎉 merges a backup and reports the conflicts", func(t *testing.T) {
+		_, err := ps.UpdatePasswordCard("", model.PasswordCard{ID: "card-id-1", Name: "AWS", Username: "admin", Password: "changedsecret", URL: "https://aws.com/login"})
+		require.NoError(t, err)
+
+		resp, body := restore("", "correct horse")
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+
+		var report model.RestoreReport
+		require.NoError(t, json.Unmarshal(body, &report))
+		assert.Equal(t, model.RestoreModeMerge, report.Mode)
+		assert.Empty(t, report.Restored)
+		assert.Equal(t, []model.ImportRow{
+			{Row: 1, Name: "AWS", URL: "https://aws.com/login", Error: `password with ID "card-id-1" already exists`},
+		}, report.Conflicts)
+
+		passwordCard, err := ps.GetPasswordCard("", "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, "changedsecret", passwordCard.Password)
+	})
+
+	t.Run("🎉 replaces the password cards with a backup", func(t *testing.T) {
+		var form bytes.Buffer
+		writer := multipart.NewWriter(&form)
+		file, err := writer.CreateFormFile("file", "vault-backup.json")
+		require.NoError(t, err)
+		_, err = file.Write(archive)
+		require.NoError(t, err)
+		require.NoError(t, writer.WriteField("passphrase", "correct horse"))
+		require.NoError(t, writer.Close())
+
+		req, err := http.NewRequest(http.MethodPost, "/vault/import?mode=replace", &form)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+
+		resp, body := do(req)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		assert.JSONEq(t, `{
+			"mode": "replace",
+			"deleted": 1,
+			"restored": [{"row": 1, "id": "card-id-1", "name": "AWS", "url": "https://aws.com/login"}],
+			"folders": 1,
+			"conflicts": [],
+			"invalid": []
+		}`, string(body))
+
+		passwordCard, err := ps.GetPasswordCard("", "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, "supersecret", passwordCard.Password)
+
+		// the folders are replaced too, the card is back in the restored one
+		folders, err := ps.ListFolders("")
+		require.NoError(t, err)
+		require.Len(t, folders, 1)
+		assert.Equal(t, "Cloud", folders[0].Name)
+		assert.Equal(t, folders[0].ID, passwordCard.FolderID)
+	})
+}
+
+func TestVaultBackupWithAccounts(t *testing.T) {
+	app := fiber.New()
+	vaultService := newTestVaultService()
+	require.NoError(t, vaultService.Unlock([]byte("master")))
+
+	userRepository := repository.NewUserRepository()
+	userService := newTestUserService(userRepository)
+	userService.SetAdmins([]string{"alice"}, vaultService)
+	apiTokenService := service.NewAPITokenService(repository.NewAPITokenRepository(), userRepository)
+
+	bob, err := userService.Register(model.Credentials{Username: "bob", Password: "supersecret"})
+	require.NoError(t, err)
+	sessionToken, _, err := userService.Login(model.Credentials{Username: "bob", Password: "supersecret"})
+	require.NoError(t, err)
+	readToken, _, err := apiTokenService.CreateAPIToken(bob.ID, model.APITokenRequest{Name: "ci", Scope: model.ScopeRead})
+	require.NoError(t, err)
+	writeToken, _, err := apiTokenService.CreateAPIToken(bob.ID, model.APITokenRequest{Name: "deploy", Scope: model.ScopeReadWrite})
+	require.NoError(t, err)
+
+	ps := service.NewPasswordCardService(repository.NewPasswordCardRepository())
+	_, err = ps.CreatePasswordCard(bob.ID, model.PasswordCard{ID: "card-id-1", Name: "AWS", Username: "admin", Password: "kX9#mQ2$vL7!pR4@", URL: "https://aws.com/login"})
+	require.NoError(t, err)
+	_, err = ps.CreatePasswordCard("", model.PasswordCard{ID: "card-id-2", Name: "GCP", Username: "ops", Password: "kX9#mQ2$vL7!pR4@", URL: "https://cloud.google.com/"})
+	require.NoError(t, err)
+
+	s := NewServe(app, ps,
+		WithVaultService(vaultService),
+		WithUserService(userService),
+		WithAPITokenService(apiTokenService),
+	)
+	s.newBackupKDFParams = func() (vault.KDFParams, error) {
+		return vault.KDFParams{Salt: []byte("0123456789abcdef"), Time: 1, Memory: 64, Threads: 1}, nil
+	}
+	s.initHandlers()
+
+	do := func(method, url, token string, body []byte) (int, []byte) {
+		req, err := http.NewRequest(method, url, bytes.NewReader(body))
+		require.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Backup-Passphrase", "correct horse")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		return resp.StatusCode, respBody
+	}
+
+	t.Run("return Unauthorized for anonymous requests", func(t *testing.T) {
+		status, body := do(http.MethodGet, "/vault/export", "", nil)
+		assert.Equal(t, http.StatusUnauthorized, status)
+		assert.JSONEq(t, `{"error":"missing bearer token", "message":"Unauthorized.", "status":401}`, string(body))
+	})
+
+	var archive []byte
+
+	t.Run("🎉 lets the users without admin rights export their backup", func(t *testing.T) {
+		for _, token := range []string{sessionToken, readToken} {
+			status, body := do(http.MethodGet, "/vault/export", token, nil)
+			require.Equal(t, http.StatusOK, status, string(body))
+			archive = body
+
+			restored, err := backup.Read(bytes.NewReader(body), "correct horse")
+			require.NoError(t, err)
+			require.Len(t, restored.PasswordCards, 1)
+			assert.Equal(t, "card-id-1", restored.PasswordCards[0].ID)
+		}
+	})
+
+	t.Run("return Forbidden for read-only API tokens restoring a backup", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/vault/import", readToken, archive)
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.JSONEq(t, `{"error":"API token is read-only", "message":"Forbidden.", "status":403}`, string(body))
+	})
+
+	t.Run("🎉 lets the users without admin rights restore their backup", func(t *testing.T) {
+		for _, token := range []string{sessionToken, writeToken} {
+			status, body := do(http.MethodPost, "/vault/import?mode=replace", token, archive)
+			require.Equal(t, http.StatusOK, status, string(body))
+
+			var report model.RestoreReport
+			require.NoError(t, json.Unmarshal(body, &report))
+			assert.Equal(t, 1, report.Deleted)
+			assert.Len(t, report.Restored, 1)
+		}
+
+		// the cards of the other users are left alone
+		_, err := ps.GetPasswordCard("", "card-id-2")
+		assert.NoError(t, err)
+	})
+
+	t.Run("return Forbidden for the users without admin rights managing the vault", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/vault/lock", sessionToken, nil)
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.JSONEq(t, `{"error":"admin rights required", "message":"Forbidden.", "status":403}`, string(body))
+		assert.False(t, vaultService.IsLocked())
+	})
+}
@@ -0,0 +1,192 @@
+package serve
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// handleGetFolders returns the folders of the current user, flat: the clients
+// build the tree from their parents.
+func handleGetFolders(s *service.PasswordCardService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		folders, err := s.ListFolders(currentUserID(c))
+		if err != nil {
+			log.Printf("error listing folders: %s", err.Error())
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		return c.JSON(folders)
+	}
+}
+
+func handleGetFolder(s *service.PasswordCardService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		folder, err := s.GetFolder(currentUserID(c), c.Params("id"))
+		if err != nil {
+			log.Printf("error getting folder: %s", err.Error())
+
+			return folderErrorResponse(c, err)
+		}
+
+		return c.JSON(folder)
+	}
+}
+
+func handlePostFolders(s *service.PasswordCardService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		var folderRequest model.FolderRequest
+		if err := c.BodyParser(&folderRequest); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "The request is invalid in some way.",
+				Error:   err.Error(),
+			})
+		}
+
+		if err := folderRequest.Validate(); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "Validation error.",
+				Error:   err.Error(),
+			})
+		}
+
+		folder, err := s.CreateFolder(currentUserID(c), folderRequest)
+		if err != nil {
+			log.Printf("error creating folder: %s", err.Error())
+
+			return folderErrorResponse(c, err)
+		}
+
+		return c.Status(http.StatusCreated).JSON(folder)
+	}
+}
+
+// handlePutFolders renames a folder and moves it, with its content, to the
+// parent folder of the request.
+func handlePutFolders(s *service.PasswordCardService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		var folderRequest model.FolderRequest
+		if err := c.BodyParser(&folderRequest); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "The request is invalid in some way.",
+				Error:   err.Error(),
+			})
+		}
+
+		if err := folderRequest.Validate(); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "Validation error.",
+				Error:   err.Error(),
+			})
+		}
+
+		folder, err := s.UpdateFolder(currentUserID(c), c.Params("id"), folderRequest)
+		if err != nil {
+			log.Printf("error updating folder: %s", err.Error())
+
+			return folderErrorResponse(c, err)
+		}
+
+		return c.JSON(folder)
+	}
+}
+
+// handleDeleteFolders deletes a folder. Its subfolders and cards move to its
+// parent folder.
+func handleDeleteFolders(s *service.PasswordCardService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		if err := s.DeleteFolder(currentUserID(c), c.Params("id")); err != nil {
+			log.Printf("error deleting folder: %s", err.Error())
+
+			return folderErrorResponse(c, err)
+		}
+
+		return c.SendStatus(http.StatusNoContent)
+	}
+}
+
+// handlePostPasswordCardMove moves a card to the folder of the request, or out
+// of any folder when it's empty.
+func handlePostPasswordCardMove(s *service.PasswordCardService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		var moveRequest model.MovePasswordCardRequest
+		if err := c.BodyParser(&moveRequest); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "The request is invalid in some way.",
+				Error:   err.Error(),
+			})
+		}
+
+		passwordCard, err := s.MovePasswordCard(currentUserID(c), c.Params("id"), moveRequest.FolderID)
+		if err != nil {
+			log.Printf("error moving password card: %s", err.Error())
+
+			var errNotFound repository.ErrPasswordCardNotFound
+			if errors.As(err, &errNotFound) {
+				return c.Status(http.StatusNotFound).JSON(ErrorResponse{
+					Status:  http.StatusNotFound,
+					Message: "Password Card not found.",
+					Error:   errNotFound.Error(),
+				})
+			}
+
+			if errors.Is(err, service.ErrVaultLocked) {
+				return vaultLockedResponse(c)
+			}
+
+			return folderErrorResponse(c, err)
+		}
+
+		return c.JSON(passwordCard.Masked())
+	}
+}
+
+// folderErrorResponse responds to the errors of the folder operations.
+func folderErrorResponse(c *fiber.Ctx, err error) error {
+	for _, errValidation := range []error{service.ErrUnknownFolder, service.ErrFolderCycle} {
+		if errors.Is(err, errValidation) {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "Validation error.",
+				Error:   errValidation.Error(),
+			})
+		}
+	}
+
+	var errNotFound repository.ErrFolderNotFound
+	if errors.As(err, &errNotFound) {
+		return c.Status(http.StatusNotFound).JSON(ErrorResponse{
+			Status:  http.StatusNotFound,
+			Message: "Folder not found.",
+			Error:   errNotFound.Error(),
+		})
+	}
+
+	var errExists repository.ErrFolderAlreadyExists
+	if errors.As(err, &errExists) {
+		return c.Status(http.StatusConflict).JSON(ErrorResponse{
+			Status:  http.StatusConflict,
+			Message: "Conflict.",
+			Error:   errExists.Error(),
+		})
+	}
+
+	return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+		Status:  http.StatusInternalServerError,
+		Message: "Internal Server Error.",
+	})
+}
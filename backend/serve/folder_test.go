@@ -0,0 +1,199 @@
+package serve
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFolders(t *testing.T) {
+	app := fiber.New()
+	s := NewServe(app, service.NewPasswordCardService(repository.NewPasswordCardRepository()))
+	s.initHandlers()
+
+	do := func(method, url, body string) (int, string) {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(respBody)
+	}
+
+	createFolder := func(body string) model.Folder {
+		status, respBody := do(http.MethodPost, "/folders", body)
+		require.Equal(t, http.StatusCreated, status, respBody)
+
+		var folder model.Folder
+		require.NoError(t, json.Unmarshal([]byte(respBody), &folder))
+
+		return folder
+	}
+
+	work := createFolder(`{"name": "Work"}`)
+	cloud := createFolder(`{"name": "Cloud", "parent_id": "` + work.ID + `"}`)
+
+	status, body := do(http.MethodPost, "/password-cards", `
+		{
+			"id": "card-id-1",
+			"name": "AWS",
+			"username": "username",
+			"password": "supersecret",
+			"url": "https://aws.com/login",
+			"folder_id": "`+cloud.ID+`",
+			"tags": ["cloud", "2fa"]
+		}
+	`)
+	require.Equal(t, http.StatusCreated, status, body)
+
+	status, body = do(http.MethodPost, "/password-cards", `
+		{
+			"id": "card-id-2",
+			"name": "Bank",
+			"username": "username",
+			"password": "supersecret",
+			"url": "https://bank.com/login",
+			"tags": ["2FA"]
+		}
+	`)
+	require.Equal(t, http.StatusCreated, status, body)
+
+	listNames := func(query string) []string {
+		status, body := do(http.MethodGet, "/password-cards?"+query, "")
+		require.Equal(t, http.StatusOK, status, body)
+
+		var passwordCards []model.PasswordCard
+		require.NoError(t, json.Unmarshal([]byte(body), &passwordCards))
+
+		names := make([]string, 0, len(passwordCards))
+		for _, passwordCard := range passwordCards {
+			names = append(names, passwordCard.Name)
+		}
+
+		return names
+	}
+
+	t.Run("return BadRequest for invalid folders", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/folders", `{"name": " "}`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":"name can't be empty", "message":"Validation error.", "status":400}`, body)
+
+		status, body = do(http.MethodPost, "/folders", `{"name": "Banks", "parent_id": "folder-id"}`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":"the folder doesn't exist", "message":"Validation error.", "status":400}`, body)
+	})
+
+	t.Run("return Conflict for a name taken in the parent folder", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/folders", `{"name": "Cloud", "parent_id": "`+work.ID+`"}`)
+		assert.Equal(t, http.StatusConflict, status)
+		assert.JSONEq(t, `{"error":"folder \"Cloud\" already exists", "message":"Conflict.", "status":409}`, body)
+	})
+
+	t.Run("return NotFound for unknown folders", func(t *testing.T) {
+		status, body := do(http.MethodGet, "/folders/folder-id", "")
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.JSONEq(t, `{"error":"folder with ID \"folder-id\" not found", "message":"Folder not found.", "status":404}`, body)
+	})
+
+	t.Run("return BadRequest for cards in unknown folders", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/password-cards", `
+			{
+				"id": "card-id-3",
+				"name": "GitLab",
+				"username": "username",
+				"password": "supersecret",
+				"url": "https://gitlab.com/",
+				"folder_id": "folder-id"
+			}
+		`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":"the folder doesn't exist", "message":"Validation error.", "status":400}`, body)
+
+		status, body = do(http.MethodGet, "/password-cards?folder=folder-id", "")
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":"the folder doesn't exist", "message":"Validation error.", "status":400}`, body)
+	})
+
+	t.Run("🎉 lists the folders", func(t *testing.T) {
+		status, body := do(http.MethodGet, "/folders", "")
+		require.Equal(t, http.StatusOK, status)
+
+		var folders []model.Folder
+		require.NoError(t, json.Unmarshal([]byte(body), &folders))
+		assert.Equal(t, []model.Folder{work, cloud}, folders)
+	})
+
+	t.Run("🎉 filters the cards by folder and tag", func(t *testing.T) {
+		assert.Equal(t, []string{"AWS"}, listNames("folder="+work.ID))
+		assert.Equal(t, []string{"AWS", "Bank"}, listNames("tag=2fa"))
+		assert.Equal(t, []string{"AWS"}, listNames("tag=cloud"))
+	})
+
+	t.Run("🎉 moves the cards", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/password-cards/card-id-2/move", `{"folder_id": "`+work.ID+`"}`)
+		require.Equal(t, http.StatusOK, status, body)
+
+		var passwordCard model.PasswordCard
+		require.NoError(t, json.Unmarshal([]byte(body), &passwordCard))
+		assert.Equal(t, work.ID, passwordCard.FolderID)
+		assert.Equal(t, model.MaskedPassword, passwordCard.Password)
+
+		assert.Equal(t, []string{"AWS", "Bank"}, listNames("folder="+work.ID))
+
+		status, body = do(http.MethodPost, "/password-cards/card-id-3/move", `{"folder_id": ""}`)
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.JSONEq(t, `{"error":"password with ID \"card-id-3\" not found", "message":"Password Card not found.", "status":404}`, body)
+	})
+
+	t.Run("return BadRequest for a folder moved into its subfolder", func(t *testing.T) {
+		status, body := do(http.MethodPut, "/folders/"+work.ID, `{"name": "Work", "parent_id": "`+cloud.ID+`"}`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":"a folder can't be moved into itself or its subfolders", "message":"Validation error.", "status":400}`, body)
+	})
+
+	t.Run("🎉 renames the folders", func(t *testing.T) {
+		status, body := do(http.MethodPut, "/folders/"+cloud.ID, `{"name": "AWS", "parent_id": "`+work.ID+`"}`)
+		require.Equal(t, http.StatusOK, status, body)
+
+		var folder model.Folder
+		require.NoError(t, json.Unmarshal([]byte(body), &folder))
+		assert.Equal(t, "AWS", folder.Name)
+		assert.Equal(t, work.ID, folder.ParentID)
+	})
+
+	t.Run("🎉 deletes the folders and keeps their content", func(t *testing.T) {
+		status, _ := do(http.MethodDelete, "/folders/"+work.ID, "")
+		require.Equal(t, http.StatusNoContent, status)
+
+		status, body := do(http.MethodGet, "/folders/"+cloud.ID, "")
+		require.Equal(t, http.StatusOK, status, body)
+
+		var folder model.Folder
+		require.NoError(t, json.Unmarshal([]byte(body), &folder))
+		assert.Empty(t, folder.ParentID)
+
+		assert.Equal(t, []string{"AWS"}, listNames("folder="+cloud.ID))
+
+		status, body = do(http.MethodGet, "/password-cards/card-id-2", "")
+		require.Equal(t, http.StatusOK, status, body)
+
+		var passwordCard model.PasswordCard
+		require.NoError(t, json.Unmarshal([]byte(body), &passwordCard))
+		assert.Empty(t, passwordCard.FolderID)
+	})
+}
@@ -0,0 +1,100 @@
+package serve
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/CaioTeixeira95/password-manager/backend/generator"
+	"github.com/gofiber/fiber/v2"
+)
+
+type GeneratedPasswordResponse struct {
+	Password string `json:"password"`
+}
+
+type GeneratedPassphraseResponse struct {
+	Passphrase string `json:"passphrase"`
+	// EntropyBits measures how hard the passphrase is to guess for an attacker
+	// who knows how it was generated.
+	EntropyBits float64 `json:"entropy_bits"`
+}
+
+// handleGetPasswordGenerator generates a password. The query parameters
+// override the fields of generator.DefaultOptions, e.g.
+// "?length=32&symbols=false".
+func handleGetPasswordGenerator() func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		options := generator.DefaultOptions()
+		if err := c.QueryParser(&options); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "The request is invalid in some way.",
+				Error:   err.Error(),
+			})
+		}
+
+		if err := options.Validate(); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "Validation error.",
+				Error:   err.Error(),
+			})
+		}
+
+		password, err := generator.Generate(options)
+		if err != nil {
+			log.Printf("error generating password: %s", err.Error())
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		// the password isn't stored anywhere, it mustn't be cached either
+		c.Set(fiber.HeaderCacheControl, "no-store")
+
+		return c.JSON(GeneratedPasswordResponse{Password: password})
+	}
+}
+
+// handleGetPassphraseGenerator generates a diceware passphrase. The query
+// parameters override the fields of generator.DefaultPassphraseOptions, e.g.
+// "?words=8&separator=.".
+func handleGetPassphraseGenerator() func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		options := generator.DefaultPassphraseOptions()
+		if err := c.QueryParser(&options); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "The request is invalid in some way.",
+				Error:   err.Error(),
+			})
+		}
+
+		if err := options.Validate(); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "Validation error.",
+				Error:   err.Error(),
+			})
+		}
+
+		passphrase, err := generator.GeneratePassphrase(options)
+		if err != nil {
+			log.Printf("error generating passphrase: %s", err.Error())
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		c.Set(fiber.HeaderCacheControl, "no-store")
+
+		return c.JSON(GeneratedPassphraseResponse{
+			Passphrase:  passphrase,
+			EntropyBits: options.Entropy(),
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package serve
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/CaioTeixeira95/password-manager/backend/generator"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPasswordGenerator(t *testing.T) {
+	app := fiber.New()
+	s := NewServe(app, service.NewPasswordCardService(repository.NewPasswordCardRepository()))
+	s.initHandlers()
+
+	do := func(url string) (int, string) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(respBody)
+	}
+
+	t.Run("return BadRequest for invalid options", func(t *testing.T) {
+		status, body := do("/password-generator?length=2")
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":"length must be between 4 and 128", "message":"Validation error.", "status":400}`, body)
+
+		status, _ = do("/password-generator?length=long")
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("🎉 generates a password with the default options", func(t *testing.T) {
+		status, body := do("/password-generator")
+		assert.Equal(t, http.StatusOK, status)
+
+		var response GeneratedPasswordResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &response))
+		assert.Len(t, response.Password, generator.DefaultLength)
+	})
+
+	t.Run("🎉 overrides the default options", func(t *testing.T) {
+		status, body := do("/password-generator?length=32&uppercase=false&symbols=false&min_digits=4")
+		assert.Equal(t, http.StatusOK, status)
+
+		var response GeneratedPasswordResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &response))
+		assert.Len(t, response.Password, 32)
+
+		digits := 0
+		for _, r := range response.Password {
+			assert.True(t, strings.ContainsRune(generator.Lowercase+generator.Digits, r), response.Password)
+			if strings.ContainsRune(generator.Digits, r) {
+				digits++
+			}
+		}
+		assert.GreaterOrEqual(t, digits, 4, response.Password)
+	})
+}
+
+func TestGetPassphraseGenerator(t *testing.T) {
+	app := fiber.New()
+	s := NewServe(app, service.NewPasswordCardService(repository.NewPasswordCardRepository()))
+	s.initHandlers()
+
+	do := func(url string) (int, string) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(respBody)
+	}
+
+	t.Run("return BadRequest for invalid options", func(t *testing.T) {
+		status, body := do("/passphrase-generator?words=1")
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":"words must be between 3 and 20", "message":"Validation error.", "status":400}`, body)
+	})
+
+	t.Run("🎉 generates a passphrase with its entropy", func(t *testing.T) {
+		status, body := do("/passphrase-generator?words=4&separator=.&capitalize=true&append_digit=true")
+		assert.Equal(t, http.StatusOK, status)
+
+		var response GeneratedPassphraseResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &response))
+		assert.Len(t, strings.Split(response.Passphrase, "."), 4)
+		assert.InDelta(t, 55.02, response.EntropyBits, 0.01)
+	})
+}
@@ -0,0 +1,203 @@
+package serve
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/CaioTeixeira95/password-manager/backend/exporter"
+	"github.com/CaioTeixeira95/password-manager/backend/importer"
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ImportQuery struct {
+	// DryRun reports what the import would do without storing anything.
+	DryRun bool `query:"dry_run"`
+}
+
+// ImportErrorResponse is sent when an import stops partway, the report lists
+// the rows handled before, whose cards are kept.
+type ImportErrorResponse struct {
+	ErrorResponse
+	Report *model.ImportReport `json:"report,omitempty"`
+}
+
+type ExportRequest struct {
+	// Password encrypts the exported database.
+	Password string `json:"password"`
+}
+
+// handlePostPasswordCardsImport imports an export of another password manager.
+// It's either the body of the request, or the "file" field of a multipart
+// form along with the "password" field for the encrypted formats. The rows
+// that can't be imported are reported along with the created cards, they
+// don't fail the request. The errors that do fail it come with the report of
+// the rows imported before. With dry_run=true nothing is stored.
+func handlePostPasswordCardsImport(s *service.PasswordCardService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		var query ImportQuery
+		if err := c.QueryParser(&query); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "The request is invalid in some way.",
+				Error:   err.Error(),
+			})
+		}
+
+		data, password, err := importRequest(c)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "The request is invalid in some way.",
+				Error:   err.Error(),
+			})
+		}
+
+		format, entries, err := importer.Parse(data, password)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "Validation error.",
+				Error:   err.Error(),
+			})
+		}
+
+		report, err := s.ImportPasswordCards(currentUserID(c), format, entries, query.DryRun)
+		if err != nil {
+			log.Printf("error importing password cards: %s", err.Error())
+
+			response := ImportErrorResponse{
+				ErrorResponse: ErrorResponse{
+					Status:  http.StatusInternalServerError,
+					Message: "Internal Server Error.",
+				},
+				Report: report,
+			}
+			if errors.Is(err, service.ErrVaultLocked) {
+				response.ErrorResponse = ErrorResponse{
+					Status:  http.StatusLocked,
+					Message: "Vault is locked.",
+					Error:   service.ErrVaultLocked.Error(),
+				}
+			}
+
+			return c.Status(response.Status).JSON(response)
+		}
+
+		return c.JSON(report)
+	}
+}
+
+// importRequest returns the imported file and its password.
+func importRequest(c *fiber.Ctx) ([]byte, string, error) {
+	if !isMultipartForm(c) {
+		return c.Body(), "", nil
+	}
+
+	data, err := formFile(c, "file")
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, c.FormValue("password"), nil
+}
+
+func isMultipartForm(c *fiber.Ctx) bool {
+	return strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm)
+}
+
+// formFile returns the content of a file of a multipart form.
+func formFile(c *fiber.Ctx, name string) ([]byte, error) {
+	fileHeader, err := c.FormFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+// handlePostPasswordCardsExport exports the password cards of the current
+// user, in the groups of their folders, as a KeePass database encrypted with
+// the password of the request. Every
+// exported card is recorded like a reveal before the database is sent.
+func handlePostPasswordCardsExport(s *service.PasswordCardService, as *service.AuditService, params exporter.KDBXParams) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		var exportRequest ExportRequest
+		if err := c.BodyParser(&exportRequest); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "The request is invalid in some way.",
+				Error:   err.Error(),
+			})
+		}
+
+		if exportRequest.Password == "" {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "Validation error.",
+				Error:   "password can't be empty",
+			})
+		}
+
+		passwordCards, err := s.ListPasswordCards(currentUserID(c))
+		if err != nil {
+			log.Printf("error exporting password cards: %s", err.Error())
+
+			if errors.Is(err, service.ErrVaultLocked) {
+				return vaultLockedResponse(c)
+			}
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		folders, err := s.ListFolders(currentUserID(c))
+		if err != nil {
+			log.Printf("error exporting password cards: %s", err.Error())
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		for _, passwordCard := range passwordCards {
+			if err := recordAuditEvent(c, as, model.AuditActionExportPassword, passwordCard.ID); err != nil {
+				log.Printf("error exporting password cards: %s", err.Error())
+
+				return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+					Status:  http.StatusInternalServerError,
+					Message: "Internal Server Error.",
+				})
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := exporter.WriteKDBX(&buf, exportRequest.Password, passwordCards, folders, params); err != nil {
+			log.Printf("error exporting password cards: %s", err.Error())
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		c.Attachment("passwords.kdbx")
+		c.Set(fiber.HeaderContentType, fiber.MIMEOctetStream)
+		return c.Send(buf.Bytes())
+	}
+}
@@ -0,0 +1,258 @@
+package serve
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/CaioTeixeira95/password-manager/backend/exporter"
+	"github.com/CaioTeixeira95/password-manager/backend/importer"
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingInsertRepository fails the inserts once the first ones succeeded.
+type failingInsertRepository struct {
+	repository.PasswordCardStore
+	inserts int
+}
+
+func (r *failingInsertRepository) Insert(newPasswordCard model.PasswordCard) error {
+	if r.inserts == 0 {
+		return errors.New("disk is full")
+	}
+	r.inserts--
+	return r.PasswordCardStore.Insert(newPasswordCard)
+}
+
+func TestPostPasswordCardsImport(t *testing.T) {
+	app := fiber.New()
+	r := repository.CustomPasswordCardRepository([]model.PasswordCard{
+		{ID: "card-id-1", Name: "AWS", Username: "admin", Password: "supersecret", URL: "https://aws.com/login"},
+	})
+	s := NewServe(app, service.NewPasswordCardService(r))
+	s.initHandlers()
+
+	do := func(body string) (int, string) {
+		req, err := http.NewRequest(http.MethodPost, "/password-cards/import", strings.NewReader(body))
+		require.NoError(t, err)
+
+		req.Header.Set("Content-Type", "text/csv")
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(respBody)
+	}
+
+	t.Run("return BadRequest for unknown formats", func(t *testing.T) {
+		status, body := do("title,login,secret\n")
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":"unknown export format", "message":"Validation error.", "status":400}`, body)
+	})
+
+	t.Run("🎉 previews a Bitwarden export with dry_run", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "/password-cards/import?dry_run=true", strings.NewReader(`
+			{
+				"encrypted": false,
+				"items": [
+					{"type": 1, "name": "GCP", "login": {"uris": [{"uri": "https://cloud.google.com/"}], "username": "ops", "password": "anothersecret"}},
+					{"type": 2, "name": "Wifi", "notes": "fridge"},
+					{"type": 9, "name": "Passkey"}
+				]
+			}
+		`))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.JSONEq(t, `
+			{
+				"format": "bitwarden_json",
+				"dry_run": true,
+				"created": [{"row": 1, "name": "GCP", "url": "https://cloud.google.com/"}, {"row": 2, "name": "Wifi", "url": ""}],
+				"skipped": [{"row": 3, "name": "Passkey", "url": "", "error": "items of type 9 aren't supported"}],
+				"conflicts": [],
+				"invalid": []
+			}
+		`, string(respBody))
+
+		passwordCards, err := r.GetAll()
+		require.NoError(t, err)
+		assert.Len(t, passwordCards, 1)
+	})
+
+	t.Run("🎉 imports a CSV export and reports the rows that weren't imported", func(t *testing.T) {
+		status, body := do("name,url,username,password\n" +
+			"GCP,https://cloud.google.com/,ops,anothersecret\n" +
+			"AWS,https://aws.com/login,root,supersecret\n" +
+			"GitHub,https://github.com/,,secret\n")
+		assert.Equal(t, http.StatusOK, status)
+		assert.NotContains(t, body, "anothersecret")
+
+		var report model.ImportReport
+		require.NoError(t, json.Unmarshal([]byte(body), &report))
+		assert.Equal(t, "chrome", report.Format)
+
+		require.Len(t, report.Created, 1)
+		assert.Equal(t, 2, report.Created[0].Row)
+		assert.Equal(t, "https://cloud.google.com/", report.Created[0].URL)
+
+		assert.Equal(t, []model.ImportRow{
+			{Row: 3, Name: "AWS", URL: "https://aws.com/login", Error: `password with URL "https://aws.com/login" already exists`},
+		}, report.Conflicts)
+		assert.Equal(t, []model.ImportRow{
+			{Row: 4, Name: "GitHub", URL: "https://github.com/", Error: "username can't be empty"},
+		}, report.Invalid)
+
+		passwordCards, err := r.GetAll()
+		require.NoError(t, err)
+		require.Len(t, passwordCards, 2)
+		assert.Equal(t, report.Created[0].ID, passwordCards[1].ID)
+		assert.Equal(t, "anothersecret", passwordCards[1].Password)
+	})
+}
+
+func TestPasswordCardsKDBX(t *testing.T) {
+	app := fiber.New()
+	r := repository.CustomPasswordCardRepository([]model.PasswordCard{
+		{ID: "card-id-1", Name: "AWS", Username: "admin", Password: "supersecret", URL: "https://aws.com/login"},
+	})
+	auditRepository := repository.NewAuditRepository()
+
+	s := NewServe(app, service.NewPasswordCardService(r), WithAuditService(service.NewAuditService(auditRepository)))
+	s.kdbxParams = exporter.KDBXParams{Iterations: 1, Memory: 64 * 1024, Parallelism: 1}
+	s.initHandlers()
+
+	do := func(req *http.Request) (*http.Response, []byte) {
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		return resp, respBody
+	}
+
+	export := func(body string) (*http.Response, []byte) {
+		req, err := http.NewRequest(http.MethodPost, "/password-cards/export", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		return do(req)
+	}
+
+	t.Run("return BadRequest without a password", func(t *testing.T) {
+		resp, body := export(`{}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.JSONEq(t, `{"error":"password can't be empty", "message":"Validation error.", "status":400}`, string(body))
+	})
+
+	var kdbx []byte
+
+	t.Run("🎉 exports the password cards as a KeePass database", func(t *testing.T) {
+		resp, body := export(`{"password": "master"}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		assert.Equal(t, `attachment; filename="passwords.kdbx"`, resp.Header.Get("Content-Disposition"))
+		assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+		assert.True(t, importer.IsKDBX(body))
+		kdbx = body
+
+		entries, err := importer.ParseKDBX(bytes.NewReader(body), "master")
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "supersecret", entries[0].PasswordCard.Password)
+
+		auditEvents, err := auditRepository.ListByPasswordCardID("card-id-1")
+		require.NoError(t, err)
+		require.Len(t, auditEvents, 1)
+		assert.Equal(t, model.AuditActionExportPassword, auditEvents[0].Action)
+	})
+
+	importKDBX := func(password string) (*http.Response, []byte) {
+		var form bytes.Buffer
+		writer := multipart.NewWriter(&form)
+		file, err := writer.CreateFormFile("file", "passwords.kdbx")
+		require.NoError(t, err)
+		_, err = file.Write(kdbx)
+		require.NoError(t, err)
+		require.NoError(t, writer.WriteField("password", password))
+		require.NoError(t, writer.Close())
+
+		req, err := http.NewRequest(http.MethodPost, "/password-cards/import", &form)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+
+		return do(req)
+	}
+
+	t.Run("return BadRequest for wrong passwords", func(t *testing.T) {
+		resp, body := importKDBX("wrong")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Contains(t, string(body), "invalid KeePass database or password")
+	})
+
+	t.Run("🎉 imports a KeePass database", func(t *testing.T) {
+		require.NoError(t, r.Delete("card-id-1"))
+
+		resp, body := importKDBX("master")
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+
+		var report model.ImportReport
+		require.NoError(t, json.Unmarshal(body, &report))
+		assert.Equal(t, "keepass", report.Format)
+		require.Len(t, report.Created, 1)
+		assert.Equal(t, model.ImportRow{Row: 1, ID: report.Created[0].ID, Name: "AWS", URL: "https://aws.com/login"}, report.Created[0])
+		assert.Empty(t, report.Conflicts)
+		assert.Empty(t, report.Invalid)
+	})
+}
+
+func TestPostPasswordCardsImportPartially(t *testing.T) {
+	app := fiber.New()
+	r := &failingInsertRepository{PasswordCardStore: repository.NewPasswordCardRepository(), inserts: 1}
+	s := NewServe(app, service.NewPasswordCardService(r))
+	s.initHandlers()
+
+	req, err := http.NewRequest(http.MethodPost, "/password-cards/import", strings.NewReader(
+		"name,url,username,password\n"+
+			"AWS,https://aws.com/login,admin,supersecret\n"+
+			"GCP,https://cloud.google.com/,ops,anothersecret\n",
+	))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "text/csv")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	// the card created before the error is reported
+	var response ImportErrorResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.Equal(t, "Internal Server Error.", response.Message)
+	require.NotNil(t, response.Report)
+	require.Len(t, response.Report.Created, 1)
+	assert.Equal(t, "AWS", response.Report.Created[0].Name)
+}
@@ -0,0 +1,174 @@
+package serve
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// handleGetPasswordCardTOTP returns the current TOTP code of a card and how
+// long it stays valid. Like a reveal, the code is only sent once it's recorded.
+func handleGetPasswordCardTOTP(s *service.PasswordCardService, as *service.AuditService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		passwordCard, err := getOwnedPasswordCard(c, s)
+		if err != nil {
+			return err
+		}
+		if passwordCard == nil {
+			return nil
+		}
+
+		if passwordCard.TOTP == "" {
+			return c.Status(http.StatusNotFound).JSON(ErrorResponse{
+				Status:  http.StatusNotFound,
+				Message: "TOTP not found.",
+				Error:   service.ErrNoTOTP.Error(),
+			})
+		}
+
+		if err := recordAuditEvent(c, as, model.AuditActionGenerateTOTP, passwordCard.ID); err != nil {
+			log.Printf("error generating TOTP code: %s", err.Error())
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		code, err := s.GenerateTOTP(currentUserID(c), passwordCard.ID)
+		if err != nil {
+			log.Printf("error generating TOTP code: %s", err.Error())
+
+			if errors.Is(err, service.ErrVaultLocked) {
+				return vaultLockedResponse(c)
+			}
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		return c.JSON(code)
+	}
+}
+
+// handlePostPasswordCardHOTP issues the next HOTP code of a card. Every call
+// moves the counter forward, a write the read-only API tokens can't do, and
+// like a reveal, the code is only issued once it's recorded.
+func handlePostPasswordCardHOTP(s *service.PasswordCardService, as *service.AuditService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		passwordCard, err := getOwnedPasswordCard(c, s)
+		if err != nil {
+			return err
+		}
+		if passwordCard == nil {
+			return nil
+		}
+
+		if passwordCard.HOTP == "" {
+			return hotpNotFoundResponse(c)
+		}
+
+		if err := recordAuditEvent(c, as, model.AuditActionGenerateHOTP, passwordCard.ID); err != nil {
+			log.Printf("error generating HOTP code: %s", err.Error())
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		code, err := s.GenerateHOTP(currentUserID(c), passwordCard.ID)
+		if err != nil {
+			log.Printf("error generating HOTP code: %s", err.Error())
+
+			if errors.Is(err, service.ErrVaultLocked) {
+				return vaultLockedResponse(c)
+			}
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		return c.JSON(code)
+	}
+}
+
+// HOTPResyncResponse holds the counter of the next HOTP code after a resync.
+type HOTPResyncResponse struct {
+	Counter uint64 `json:"counter"`
+}
+
+// handlePostPasswordCardHOTPResync moves the HOTP counter of a card past two
+// consecutive codes of its token and returns the new counter.
+func handlePostPasswordCardHOTPResync(s *service.PasswordCardService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		var resyncRequest model.HOTPResync
+		if err := c.BodyParser(&resyncRequest); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "The request is invalid in some way.",
+				Error:   err.Error(),
+			})
+		}
+
+		if err := resyncRequest.Validate(); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "Validation error.",
+				Error:   err.Error(),
+			})
+		}
+
+		passwordCard, err := getOwnedPasswordCard(c, s)
+		if err != nil {
+			return err
+		}
+		if passwordCard == nil {
+			return nil
+		}
+
+		if passwordCard.HOTP == "" {
+			return hotpNotFoundResponse(c)
+		}
+
+		counter, err := s.ResyncHOTP(currentUserID(c), passwordCard.ID, resyncRequest.Codes[0], resyncRequest.Codes[1])
+		if err != nil {
+			log.Printf("error resynchronizing HOTP counter: %s", err.Error())
+
+			if errors.Is(err, service.ErrHOTPResync) {
+				return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+					Status:  http.StatusBadRequest,
+					Message: "Validation error.",
+					Error:   service.ErrHOTPResync.Error(),
+				})
+			}
+
+			if errors.Is(err, service.ErrVaultLocked) {
+				return vaultLockedResponse(c)
+			}
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		return c.JSON(HOTPResyncResponse{Counter: counter})
+	}
+}
+
+func hotpNotFoundResponse(c *fiber.Ctx) error {
+	return c.Status(http.StatusNotFound).JSON(ErrorResponse{
+		Status:  http.StatusNotFound,
+		Message: "HOTP not found.",
+		Error:   service.ErrNoHOTP.Error(),
+	})
+}
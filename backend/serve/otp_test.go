@@ -0,0 +1,240 @@
+package serve
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordCardTOTP(t *testing.T) {
+	app := fiber.New()
+	auditRepository := repository.NewAuditRepository()
+
+	s := NewServe(
+		app,
+		service.NewPasswordCardService(repository.NewPasswordCardRepository()),
+		WithAuditService(service.NewAuditService(auditRepository)),
+	)
+	s.initHandlers()
+
+	do := func(method, url, body string) (int, string) {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(respBody)
+	}
+
+	t.Run("return BadRequest for invalid TOTP keys", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/password-cards", `
+			{
+				"id": "card-id-1",
+				"name": "AWS",
+				"username": "username",
+				"password": "supersecret",
+				"url": "https://aws.com/login",
+				"totp": "otpauth://totp/AWS:username?secret=JBSWY3DPEHPK3PXP&algorithm=MD5"
+			}
+		`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":"invalid TOTP: algorithm must be one of SHA1, SHA256 or SHA512", "message":"Validation error.", "status":400}`, body)
+	})
+
+	t.Run("🎉 masks the TOTP key", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/password-cards", `
+			{
+				"id": "card-id-1",
+				"name": "AWS",
+				"username": "username",
+				"password": "supersecret",
+				"url": "https://aws.com/login",
+				"totp": "otpauth://totp/AWS:username?secret=JBSWY3DPEHPK3PXP&digits=8&period=60"
+			}
+		`)
+		require.Equal(t, http.StatusCreated, status, body)
+
+		var passwordCard model.PasswordCard
+		require.NoError(t, json.Unmarshal([]byte(body), &passwordCard))
+		assert.Equal(t, model.MaskedPassword, passwordCard.TOTP)
+
+		status, body = do(http.MethodPost, "/password-cards/card-id-1/reveal", "")
+		require.Equal(t, http.StatusOK, status, body)
+		assert.JSONEq(t, `{"id": "card-id-1", "password": "supersecret", "totp": "otpauth://totp/AWS:username?secret=JBSWY3DPEHPK3PXP&digits=8&period=60"}`, body)
+	})
+
+	t.Run("🎉 generates the current code", func(t *testing.T) {
+		status, body := do(http.MethodGet, "/password-cards/card-id-1/totp", "")
+		require.Equal(t, http.StatusOK, status, body)
+
+		var code model.TOTPCode
+		require.NoError(t, json.Unmarshal([]byte(body), &code))
+		assert.Regexp(t, `^[0-9]{8}$`, code.Code)
+		assert.Equal(t, 60, code.Period)
+		assert.True(t, code.SecondsRemaining >= 1 && code.SecondsRemaining <= 60, code.SecondsRemaining)
+
+		auditEvents, err := auditRepository.ListByPasswordCardID("card-id-1")
+		require.NoError(t, err)
+		require.Len(t, auditEvents, 2)
+		assert.Equal(t, model.AuditActionGenerateTOTP, auditEvents[1].Action)
+	})
+
+	t.Run("return NotFound for cards without TOTP key", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/password-cards", `
+			{
+				"id": "card-id-2",
+				"name": "GCP",
+				"username": "username",
+				"password": "supersecret",
+				"url": "https://cloud.google.com/"
+			}
+		`)
+		require.Equal(t, http.StatusCreated, status, body)
+
+		status, body = do(http.MethodGet, "/password-cards/card-id-2/totp", "")
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.JSONEq(t, `{"error":"the password card has no TOTP key", "message":"TOTP not found.", "status":404}`, body)
+	})
+
+	t.Run("return NotFound for unknown cards", func(t *testing.T) {
+		status, _ := do(http.MethodGet, "/password-cards/card-id-3/totp", "")
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+}
+
+func TestPasswordCardHOTP(t *testing.T) {
+	app := fiber.New()
+	auditRepository := repository.NewAuditRepository()
+
+	s := NewServe(
+		app,
+		service.NewPasswordCardService(repository.NewPasswordCardRepository()),
+		WithAuditService(service.NewAuditService(auditRepository)),
+	)
+	s.initHandlers()
+
+	do := func(method, url, body string) (int, string) {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(respBody)
+	}
+
+	t.Run("return BadRequest for invalid HOTP keys", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/password-cards", `
+			{
+				"id": "card-id-1",
+				"name": "VPN",
+				"username": "admin",
+				"password": "supersecret",
+				"url": "https://vpn.example.com/",
+				"hotp": "otpauth://hotp/VPN:admin?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&counter=-1"
+			}
+		`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":"invalid HOTP: counter must be a positive integer", "message":"Validation error.", "status":400}`, body)
+	})
+
+	t.Run("🎉 masks the HOTP key", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/password-cards", `
+			{
+				"id": "card-id-1",
+				"name": "VPN",
+				"username": "admin",
+				"password": "supersecret",
+				"url": "https://vpn.example.com/",
+				"hotp": "otpauth://hotp/VPN:admin?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&counter=1"
+			}
+		`)
+		require.Equal(t, http.StatusCreated, status, body)
+
+		var passwordCard model.PasswordCard
+		require.NoError(t, json.Unmarshal([]byte(body), &passwordCard))
+		assert.Equal(t, model.MaskedPassword, passwordCard.HOTP)
+		assert.Equal(t, uint64(1), passwordCard.HOTPCounter)
+
+		status, body = do(http.MethodPost, "/password-cards/card-id-1/reveal", "")
+		require.Equal(t, http.StatusOK, status, body)
+		assert.JSONEq(t, `{"id": "card-id-1", "password": "supersecret", "hotp": "otpauth://hotp/VPN:admin?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&counter=1"}`, body)
+	})
+
+	t.Run("🎉 issues the codes of the following counters", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/password-cards/card-id-1/hotp", "")
+		require.Equal(t, http.StatusOK, status, body)
+		assert.JSONEq(t, `{"code": "287082", "counter": 1}`, body)
+
+		status, body = do(http.MethodPost, "/password-cards/card-id-1/hotp", "")
+		require.Equal(t, http.StatusOK, status, body)
+		assert.JSONEq(t, `{"code": "359152", "counter": 2}`, body)
+
+		auditEvents, err := auditRepository.ListByPasswordCardID("card-id-1")
+		require.NoError(t, err)
+		require.Len(t, auditEvents, 3)
+		assert.Equal(t, model.AuditActionGenerateHOTP, auditEvents[2].Action)
+	})
+
+	t.Run("return BadRequest for a single resync code", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/password-cards/card-id-1/hotp/resync", `{"codes": ["287922"]}`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":"two consecutive codes are required", "message":"Validation error.", "status":400}`, body)
+	})
+
+	t.Run("return BadRequest for codes that don't match", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/password-cards/card-id-1/hotp/resync", `{"codes": ["162583", "287922"]}`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":"the codes don't match the HOTP key", "message":"Validation error.", "status":400}`, body)
+	})
+
+	t.Run("🎉 resynchronizes the counter", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/password-cards/card-id-1/hotp/resync", `{"codes": ["287922", "162583"]}`)
+		require.Equal(t, http.StatusOK, status, body)
+		assert.JSONEq(t, `{"counter": 8}`, body)
+
+		status, body = do(http.MethodPost, "/password-cards/card-id-1/hotp", "")
+		require.Equal(t, http.StatusOK, status, body)
+		assert.JSONEq(t, `{"code": "399871", "counter": 8}`, body)
+	})
+
+	t.Run("return NotFound for cards without HOTP key", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/password-cards", `
+			{
+				"id": "card-id-2",
+				"name": "GCP",
+				"username": "username",
+				"password": "supersecret",
+				"url": "https://cloud.google.com/"
+			}
+		`)
+		require.Equal(t, http.StatusCreated, status, body)
+
+		status, body = do(http.MethodPost, "/password-cards/card-id-2/hotp", "")
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.JSONEq(t, `{"error":"the password card has no HOTP key", "message":"HOTP not found.", "status":404}`, body)
+
+		status, _ = do(http.MethodPost, "/password-cards/card-id-2/hotp/resync", `{"codes": ["287922", "162583"]}`)
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+}
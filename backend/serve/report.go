@@ -0,0 +1,51 @@
+package serve
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// handleGetHealthReport reports the reused, weak and old passwords and the
+// URLs without TLS of the current user. The max_age_days and min_score query
+// parameters override the default thresholds.
+func handleGetHealthReport(s *service.PasswordCardService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		query := model.DefaultHealthReportQuery()
+		if err := c.QueryParser(&query); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "The request is invalid in some way.",
+				Error:   err.Error(),
+			})
+		}
+
+		if err := query.Validate(); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "Validation error.",
+				Error:   err.Error(),
+			})
+		}
+
+		report, err := s.HealthReport(currentUserID(c), query)
+		if err != nil {
+			log.Printf("error reporting health: %s", err.Error())
+
+			if errors.Is(err, service.ErrVaultLocked) {
+				return vaultLockedResponse(c)
+			}
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		return c.JSON(report)
+	}
+}
@@ -0,0 +1,87 @@
+package serve
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetHealthReport(t *testing.T) {
+	app := fiber.New()
+	s := NewServe(app, service.NewPasswordCardService(
+		repository.CustomPasswordCardRepository([]model.PasswordCard{
+			{
+				ID:       "card-id-1",
+				Name:     "AWS",
+				Username: "username",
+				Password: "supersecret",
+				URL:      "http://aws.com/login",
+			},
+			{
+				ID:       "card-id-2",
+				Name:     "GCP",
+				Username: "username",
+				Password: "supersecret",
+				URL:      "https://cloud.google.com/login",
+			},
+		}),
+	))
+	s.initHandlers()
+
+	do := func(url string) (int, string) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(respBody)
+	}
+
+	t.Run("return BadRequest for invalid thresholds", func(t *testing.T) {
+		status, body := do("/reports/health?min_score=7")
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":"min_score must be between 0 and 4", "message":"Validation error.", "status":400}`, body)
+	})
+
+	t.Run("🎉 reports the vault health without the passwords", func(t *testing.T) {
+		status, body := do("/reports/health")
+		assert.Equal(t, http.StatusOK, status)
+		assert.NotContains(t, body, "supersecret")
+		assert.JSONEq(t, `
+			{
+				"score": 0,
+				"total_cards": 2,
+				"reused": [
+					[
+						{"id": "card-id-1", "name": "AWS", "url": "http://aws.com/login"},
+						{"id": "card-id-2", "name": "GCP", "url": "https://cloud.google.com/login"}
+					]
+				],
+				"weak": [
+					{"id": "card-id-1", "name": "AWS", "url": "http://aws.com/login", "score": 0},
+					{"id": "card-id-2", "name": "GCP", "url": "https://cloud.google.com/login", "score": 0}
+				],
+				"old": [
+					{"id": "card-id-1", "name": "AWS", "url": "http://aws.com/login"},
+					{"id": "card-id-2", "name": "GCP", "url": "https://cloud.google.com/login"}
+				],
+				"insecure_urls": [
+					{"id": "card-id-1", "name": "AWS", "url": "http://aws.com/login"}
+				],
+				"breached": []
+			}
+		`, body)
+	})
+}
@@ -0,0 +1,141 @@
+package serve
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RevealResponse holds the secrets of a card. The details of the items and
+// the custom fields are returned whole, with their secrets.
+type RevealResponse struct {
+	ID         string            `json:"id"`
+	Password   string            `json:"password"`
+	TOTP       string            `json:"totp,omitempty"`
+	HOTP       string            `json:"hotp,omitempty"`
+	Notes      string            `json:"notes,omitempty"`
+	CreditCard *model.CreditCard `json:"credit_card,omitempty"`
+	Identity   *model.Identity   `json:"identity,omitempty"`
+	SSHKey     *model.SSHKey     `json:"ssh_key,omitempty"`
+
+	CustomFields []model.CustomField `json:"custom_fields,omitempty"`
+}
+
+// handlePostPasswordCardReveal returns the secrets of a card: the password,
+// the one-time password keys, the notes, the details and the custom fields.
+// The reveal is recorded before the password is sent, when it can't be
+// recorded the password isn't sent either.
+func handlePostPasswordCardReveal(s *service.PasswordCardService, as *service.AuditService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		passwordCard, err := getOwnedPasswordCard(c, s)
+		if err != nil {
+			return err
+		}
+		if passwordCard == nil {
+			return nil
+		}
+
+		if err := recordAuditEvent(c, as, model.AuditActionRevealPassword, passwordCard.ID); err != nil {
+			log.Printf("error revealing password card: %s", err.Error())
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		return c.JSON(RevealResponse{
+			ID:         passwordCard.ID,
+			Password:   passwordCard.Password,
+			TOTP:       passwordCard.TOTP,
+			HOTP:       passwordCard.HOTP,
+			Notes:      passwordCard.Notes,
+			CreditCard: passwordCard.CreditCard,
+			Identity:   passwordCard.Identity,
+			SSHKey:     passwordCard.SSHKey,
+
+			CustomFields: passwordCard.CustomFields,
+		})
+	}
+}
+
+// recordAuditEvent records the action of the current user on a password card.
+// It does nothing when the audit service is nil.
+func recordAuditEvent(c *fiber.Ctx, as *service.AuditService, action, passwordCardID string) error {
+	if as == nil {
+		return nil
+	}
+
+	auditEvent := model.AuditEvent{
+		Action:         action,
+		UserID:         currentUserID(c),
+		PasswordCardID: passwordCardID,
+		RemoteIP:       c.IP(),
+	}
+	if apiToken := requestAPIToken(c); apiToken != nil {
+		auditEvent.APITokenID = apiToken.ID
+	}
+
+	_, err := as.Record(auditEvent)
+	return err
+}
+
+// handleGetPasswordCardReveals lists who revealed or exported the password of
+// a card, or generated its one-time codes, and when, oldest first.
+func handleGetPasswordCardReveals(s *service.PasswordCardService, as *service.AuditService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		passwordCard, err := getOwnedPasswordCard(c, s)
+		if err != nil {
+			return err
+		}
+		if passwordCard == nil {
+			return nil
+		}
+
+		auditEvents, err := as.ListPasswordCardEvents(passwordCard.ID)
+		if err != nil {
+			log.Printf("error listing password card reveals: %s", err.Error())
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		return c.JSON(auditEvents)
+	}
+}
+
+// getOwnedPasswordCard returns the card of the id param. When it can't, it
+// sends the error response and returns a nil card.
+func getOwnedPasswordCard(c *fiber.Ctx, s *service.PasswordCardService) (*model.PasswordCard, error) {
+	passwordCard, err := s.GetPasswordCard(currentUserID(c), c.Params("id"))
+	if err == nil {
+		return passwordCard, nil
+	}
+
+	log.Printf("error getting password card: %s", err.Error())
+
+	var errNotFound repository.ErrPasswordCardNotFound
+	if errors.As(err, &errNotFound) {
+		return nil, c.Status(http.StatusNotFound).JSON(ErrorResponse{
+			Status:  http.StatusNotFound,
+			Message: "Password Card not found.",
+			Error:   errNotFound.Error(),
+		})
+	}
+
+	if errors.Is(err, service.ErrVaultLocked) {
+		return nil, vaultLockedResponse(c)
+	}
+
+	return nil, c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+		Status:  http.StatusInternalServerError,
+		Message: "Internal Server Error.",
+	})
+}
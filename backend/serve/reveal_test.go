@@ -0,0 +1,151 @@
+package serve
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevealPasswordCard(t *testing.T) {
+	app := fiber.New()
+	userRepository := repository.NewUserRepository()
+	auditRepository := repository.NewAuditRepository()
+
+	s := NewServe(
+		app,
+		service.NewPasswordCardService(repository.NewPasswordCardRepository()),
+		WithUserService(newTestUserService(userRepository)),
+		WithAPITokenService(service.NewAPITokenService(repository.NewAPITokenRepository(), userRepository)),
+		WithAuditService(service.NewAuditService(auditRepository)),
+	)
+	s.initHandlers()
+
+	do := func(method, url, token, body string) (int, string) {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(respBody)
+	}
+
+	login := func(username string) string {
+		credentials := `{"username": "` + username + `", "password": "supersecret"}`
+
+		status, body := do(http.MethodPost, "/auth/register", "", credentials)
+		require.Equal(t, http.StatusCreated, status, body)
+
+		status, body = do(http.MethodPost, "/auth/login", "", credentials)
+		require.Equal(t, http.StatusOK, status, body)
+
+		var loginResponse LoginResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &loginResponse))
+		return loginResponse.Token
+	}
+
+	aliceToken := login("alice")
+	bobToken := login("bob")
+
+	status, body := do(http.MethodPost, "/password-cards", aliceToken, `
+		{
+			"id": "card-id-1",
+			"name": "AWS",
+			"username": "username",
+			"password": "supersecret",
+			"url": "https://aws.com/login"
+		}
+	`)
+	require.Equal(t, http.StatusCreated, status, body)
+
+	status, body = do(http.MethodPost, "/auth/tokens", aliceToken, `{"name": "ci", "scope": "read"}`)
+	require.Equal(t, http.StatusCreated, status, body)
+
+	var readToken CreateAPITokenResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &readToken))
+
+	t.Run("return NotFound for the cards of another user", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/password-cards/card-id-1/reveal", bobToken, "")
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.JSONEq(t, `{"error":"password with ID \"card-id-1\" not found", "message":"Password Card not found.", "status":404}`, body)
+
+		status, _ = do(http.MethodGet, "/password-cards/card-id-1/reveals", bobToken, "")
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("masks the passwords in the list", func(t *testing.T) {
+		status, body := do(http.MethodGet, "/password-cards", aliceToken, "")
+		assert.Equal(t, http.StatusOK, status)
+
+		var passwordCards []model.PasswordCard
+		require.NoError(t, json.Unmarshal([]byte(body), &passwordCards))
+		require.Len(t, passwordCards, 1)
+		assert.Equal(t, model.MaskedPassword, passwordCards[0].Password)
+	})
+
+	t.Run("🎉 reveals the password and records who revealed it", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/password-cards/card-id-1/reveal", aliceToken, "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.JSONEq(t, `{"id":"card-id-1", "password":"supersecret"}`, body)
+
+		// revealing is allowed to the read-only tokens
+		status, body = do(http.MethodPost, "/password-cards/card-id-1/reveal", readToken.Token, "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.JSONEq(t, `{"id":"card-id-1", "password":"supersecret"}`, body)
+
+		status, body = do(http.MethodGet, "/password-cards/card-id-1/reveals", aliceToken, "")
+		assert.Equal(t, http.StatusOK, status)
+
+		var auditEvents []model.AuditEvent
+		require.NoError(t, json.Unmarshal([]byte(body), &auditEvents))
+		require.Len(t, auditEvents, 2)
+
+		for _, auditEvent := range auditEvents {
+			assert.Equal(t, model.AuditActionRevealPassword, auditEvent.Action)
+			assert.Equal(t, "card-id-1", auditEvent.PasswordCardID)
+			assert.NotEmpty(t, auditEvent.UserID)
+			assert.NotEmpty(t, auditEvent.RemoteIP)
+			assert.False(t, auditEvent.CreatedAt.IsZero())
+		}
+		assert.Empty(t, auditEvents[0].APITokenID)
+		assert.Equal(t, readToken.ID, auditEvents[1].APITokenID)
+	})
+
+	t.Run("🎉 keeps the password when the masked one is sent back", func(t *testing.T) {
+		status, body := do(http.MethodPut, "/password-cards/card-id-1", aliceToken, `
+			{
+				"name": "Amazon Web Services",
+				"username": "username",
+				"password": "********",
+				"url": "https://aws.com/login"
+			}
+		`)
+		require.Equal(t, http.StatusOK, status, body)
+
+		var passwordCard model.PasswordCard
+		require.NoError(t, json.Unmarshal([]byte(body), &passwordCard))
+		assert.Equal(t, model.MaskedPassword, passwordCard.Password)
+
+		status, body = do(http.MethodPost, "/password-cards/card-id-1/reveal", aliceToken, "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.JSONEq(t, `{"id":"card-id-1", "password":"supersecret"}`, body)
+	})
+}
@@ -5,14 +5,24 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 
+	"github.com/CaioTeixeira95/password-manager/backend/exporter"
 	"github.com/CaioTeixeira95/password-manager/backend/model"
 	"github.com/CaioTeixeira95/password-manager/backend/repository"
 	"github.com/CaioTeixeira95/password-manager/backend/service"
+	"github.com/CaioTeixeira95/password-manager/backend/vault"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Pagination headers of the password cards list.
+const (
+	totalCountHeader = "X-Total-Count"
+	nextCursorHeader = "X-Next-Cursor"
 )
 
 type ErrorResponse struct {
@@ -24,13 +34,73 @@ type ErrorResponse struct {
 type Serve struct {
 	app                 *fiber.App
 	passwordCardService *service.PasswordCardService
+
+	// vaultService is nil when the vault isn't protected by a master password.
+	vaultService *service.VaultService
+	// userService is nil when the server runs without accounts, every caller
+	// shares the same password cards then.
+	userService *service.UserService
+	// apiTokenService is nil when the API tokens are disabled. It requires the
+	// user service.
+	apiTokenService *service.APITokenService
+	// auditService is nil when the reveals aren't recorded.
+	auditService *service.AuditService
+
+	// kdbxParams derive the key of the exported KeePass databases.
+	kdbxParams exporter.KDBXParams
+	// newBackupKDFParams returns the parameters deriving the key of every new
+	// backup.
+	newBackupKDFParams func() (vault.KDFParams, error)
+}
+
+// Option enables optional features of the server.
+type Option func(*Serve)
+
+// WithVaultService enables the lock and unlock endpoints. While the vault is
+// locked the password card routes answer 423 Locked.
+func WithVaultService(vaultService *service.VaultService) Option {
+	return func(s *Serve) {
+		s.vaultService = vaultService
+	}
+}
+
+// WithUserService enables the accounts. The password card routes require a
+// session token and only give access to the cards of its user.
+func WithUserService(userService *service.UserService) Option {
+	return func(s *Serve) {
+		s.userService = userService
+	}
+}
+
+// WithAPITokenService lets the users create API tokens, accepted as bearer
+// tokens like the session ones. It has no effect without WithUserService.
+func WithAPITokenService(apiTokenService *service.APITokenService) Option {
+	return func(s *Serve) {
+		s.apiTokenService = apiTokenService
+	}
+}
+
+// WithAuditService records every password reveal and export, and every TOTP
+// and HOTP code generated, and enables the endpoint listing them.
+func WithAuditService(auditService *service.AuditService) Option {
+	return func(s *Serve) {
+		s.auditService = auditService
+	}
 }
 
-func NewServe(app *fiber.App, passwordCardService *service.PasswordCardService) *Serve {
-	return &Serve{
+func NewServe(app *fiber.App, passwordCardService *service.PasswordCardService, options ...Option) *Serve {
+	s := &Serve{
 		app:                 app,
 		passwordCardService: passwordCardService,
+		kdbxParams:          exporter.DefaultKDBXParams(),
+		newBackupKDFParams:  vault.NewKDFParams,
 	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
 }
 
 func (s *Serve) Run(port int) error {
@@ -45,22 +115,229 @@ func (s *Serve) Run(port int) error {
 func (s *Serve) initHandlers() {
 	s.app.Use(recover.New())
 	s.app.Use(logger.New())
-	s.app.Use(cors.New())
+	s.app.Use(cors.New(cors.Config{ExposeHeaders: totalCountHeader + "," + nextCursorHeader}))
+
+	if s.vaultService != nil {
+		// the backups are under /vault too, so the guards are set on each
+		// route rather than on the whole prefix
+		s.app.Route("/vault", func(router fiber.Router) {
+			router.Post("/unlock", append(s.vaultAccess(), handlePostVaultUnlock(s.vaultService, s.passwordCardService))...)
+			router.Post("/lock", append(s.vaultAccess(), handlePostVaultLock(s.vaultService))...)
+			router.Post("/rotate-master-key", append(s.vaultAccess(), handlePostVaultRotateMasterKey(s.passwordCardService))...)
+		})
+	}
+
+	if s.userService != nil {
+		s.app.Route("/auth", func(router fiber.Router) {
+			router.Post("/register", handlePostAuthRegister(s.userService))
+			router.Post("/login", handlePostAuthLogin(s.userService))
+			router.Post("/logout", requireAuthentication(s.userService, nil), handlePostAuthLogout(s.userService))
+			router.Get("/me", requireAuthentication(s.userService, s.apiTokenService), handleGetAuthMe())
+
+			if s.apiTokenService != nil {
+				router.Route("/tokens", func(router fiber.Router) {
+					router.Use(requireAuthentication(s.userService, s.apiTokenService))
+					router.Use(requireSession)
+
+					router.Get("/", handleGetAPITokens(s.apiTokenService))
+					router.Post("/", handlePostAPITokens(s.apiTokenService))
+					router.Delete("/:id", handleDeleteAPITokens(s.apiTokenService))
+				})
+			}
+		})
+	}
+
+	// the backups hold the password cards, they are protected like them
+	s.app.Get("/vault/export", append(s.passwordCardsAccess(), handleGetVaultExport(s.passwordCardService, s.auditService, s.newBackupKDFParams))...)
+	s.app.Post("/vault/import", append(s.passwordCardsAccess(), requireWriteScope, handlePostVaultImport(s.passwordCardService))...)
+
+	s.app.Route("/reports", func(router fiber.Router) {
+		if s.userService != nil {
+			router.Use(requireAuthentication(s.userService, s.apiTokenService))
+		}
+
+		if s.vaultService != nil {
+			router.Use(touchVault(s.vaultService), requireUnlockedVault(s.vaultService))
+		}
+
+		router.Get("/health", handleGetHealthReport(s.passwordCardService))
+	})
+
+	s.app.Route("/folders", func(router fiber.Router) {
+		if s.userService != nil {
+			router.Use(requireAuthentication(s.userService, s.apiTokenService))
+		}
+
+		if s.vaultService != nil {
+			router.Use(touchVault(s.vaultService), requireUnlockedVault(s.vaultService))
+		}
+
+		router.Get("/", handleGetFolders(s.passwordCardService))
+		router.Post("/", requireWriteScope, handlePostFolders(s.passwordCardService))
+		router.Get("/:id", handleGetFolder(s.passwordCardService))
+		router.Put("/:id", requireWriteScope, handlePutFolders(s.passwordCardService))
+		router.Delete("/:id", requireWriteScope, handleDeleteFolders(s.passwordCardService))
+	})
+
+	// generating passwords doesn't touch the vault, it's open to everyone
+	s.app.Get("/password-generator", handleGetPasswordGenerator())
+	s.app.Get("/passphrase-generator", handleGetPassphraseGenerator())
 
 	s.app.Route("/password-cards", func(router fiber.Router) {
+		if s.userService != nil {
+			router.Use(requireAuthentication(s.userService, s.apiTokenService))
+		}
+
+		if s.vaultService != nil {
+			router.Use(touchVault(s.vaultService), requireUnlockedVault(s.vaultService))
+		}
+
+		// revealing, exporting and generating codes only read, so they are
+		// allowed to the read-only API tokens
 		router.Get("/", handleGetPasswordCards(s.passwordCardService))
-		router.Post("/", handlePostPasswordCards(s.passwordCardService))
+		router.Post("/", requireWriteScope, handlePostPasswordCards(s.passwordCardService))
+		router.Post("/import", requireWriteScope, handlePostPasswordCardsImport(s.passwordCardService))
+		router.Post("/export", handlePostPasswordCardsExport(s.passwordCardService, s.auditService, s.kdbxParams))
+
+		// the cards created before the accounts were enabled go to the admin
+		// claiming them, not to whoever registers first
+		if s.userService != nil {
+			router.Post("/claim", requireWriteScope, requireAdmin(s.userService), handlePostPasswordCardsClaim(s.passwordCardService))
+		}
 
 		router.Route("/:id", func(router fiber.Router) {
-			router.Put("/", handlePutPasswordCards(s.passwordCardService))
-			router.Delete("/", handleDeletePasswordCards(s.passwordCardService))
+			router.Get("/", handleGetPasswordCard(s.passwordCardService))
+			router.Put("/", requireWriteScope, handlePutPasswordCards(s.passwordCardService))
+			router.Delete("/", requireWriteScope, handleDeletePasswordCards(s.passwordCardService))
+			router.Post("/reveal", handlePostPasswordCardReveal(s.passwordCardService, s.auditService))
+			router.Get("/totp", handleGetPasswordCardTOTP(s.passwordCardService, s.auditService))
+			router.Post("/hotp", requireWriteScope, handlePostPasswordCardHOTP(s.passwordCardService, s.auditService))
+			router.Post("/hotp/resync", requireWriteScope, handlePostPasswordCardHOTPResync(s.passwordCardService))
+			router.Post("/move", requireWriteScope, handlePostPasswordCardMove(s.passwordCardService))
+
+			if s.auditService != nil {
+				router.Get("/reveals", handleGetPasswordCardReveals(s.passwordCardService, s.auditService))
+			}
 		})
 	})
 }
 
+// vaultAccess returns the middlewares guarding the routes managing the vault.
+// With accounts, only the admins manage it, with a session: a leaked API token
+// can't lock it.
+func (s *Serve) vaultAccess() []fiber.Handler {
+	var handlers []fiber.Handler
+	if s.userService != nil {
+		handlers = append(handlers, requireAuthentication(s.userService, nil), requireAdmin(s.userService))
+	}
+
+	return append(handlers, touchVault(s.vaultService))
+}
+
+// passwordCardsAccess returns the middlewares guarding the password cards
+// outside of their routes: the authentication and the unlocked vault. Only the
+// authenticated requests postpone the auto-lock.
+func (s *Serve) passwordCardsAccess() []fiber.Handler {
+	var handlers []fiber.Handler
+	if s.userService != nil {
+		handlers = append(handlers, requireAuthentication(s.userService, s.apiTokenService))
+	}
+
+	if s.vaultService != nil {
+		handlers = append(handlers, touchVault(s.vaultService), requireUnlockedVault(s.vaultService))
+	}
+
+	return handlers
+}
+
+// handleGetPasswordCards returns a page of the password cards as a JSON array.
+// The total of matching cards and the cursor of the next page are sent in the
+// X-Total-Count and X-Next-Cursor headers, so clients that don't paginate keep
+// working. The passwords are masked, they are only sent by the reveal endpoint.
 func handleGetPasswordCards(s *service.PasswordCardService) func(*fiber.Ctx) error {
 	return func(c *fiber.Ctx) error {
-		return c.JSON(s.ListPasswordCards())
+		var query model.PasswordCardQuery
+		if err := c.QueryParser(&query); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "The request is invalid in some way.",
+				Error:   err.Error(),
+			})
+		}
+
+		if err := query.Validate(); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "Validation error.",
+				Error:   err.Error(),
+			})
+		}
+
+		page, err := s.SearchPasswordCards(currentUserID(c), query)
+		if err != nil {
+			log.Printf("error listing password cards: %s", err.Error())
+
+			if errors.Is(err, service.ErrInvalidCursor) || errors.Is(err, service.ErrBreachCheckDisabled) ||
+				errors.Is(err, service.ErrUnknownFolder) {
+				return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+					Status:  http.StatusBadRequest,
+					Message: "Validation error.",
+					Error:   err.Error(),
+				})
+			}
+
+			if errors.Is(err, service.ErrVaultLocked) {
+				return vaultLockedResponse(c)
+			}
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		c.Set(totalCountHeader, strconv.Itoa(page.Total))
+		if page.NextCursor != "" {
+			c.Set(nextCursorHeader, page.NextCursor)
+		}
+
+		passwordCards := make([]model.PasswordCard, 0, len(page.PasswordCards))
+		for _, passwordCard := range page.PasswordCards {
+			passwordCards = append(passwordCards, passwordCard.Masked())
+		}
+
+		return c.JSON(passwordCards)
+	}
+}
+
+func handleGetPasswordCard(s *service.PasswordCardService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		passwordCardID := c.Params("id")
+
+		passwordCard, err := s.GetPasswordCard(currentUserID(c), passwordCardID)
+		if err != nil {
+			log.Printf("error getting password card: %s", err.Error())
+
+			var errNotFound repository.ErrPasswordCardNotFound
+			if errors.As(err, &errNotFound) {
+				return c.Status(http.StatusNotFound).JSON(ErrorResponse{
+					Status:  http.StatusNotFound,
+					Message: "Password Card not found.",
+					Error:   errNotFound.Error(),
+				})
+			}
+
+			if errors.Is(err, service.ErrVaultLocked) {
+				return vaultLockedResponse(c)
+			}
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		return c.JSON(passwordCard.Masked())
 	}
 }
 
@@ -83,10 +360,27 @@ func handlePostPasswordCards(s *service.PasswordCardService) func(*fiber.Ctx) er
 			})
 		}
 
-		_, err := s.CreatePasswordCard(passwordCardRequest)
+		passwordCard, err := s.CreatePasswordCard(currentUserID(c), passwordCardRequest)
 		if err != nil {
 			log.Printf("error creating password card: %s", err.Error())
 
+			var errWeak service.ErrWeakPassword
+			if errors.As(err, &errWeak) {
+				return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+					Status:  http.StatusBadRequest,
+					Message: "Validation error.",
+					Error:   errWeak.Error(),
+				})
+			}
+
+			if errors.Is(err, service.ErrUnknownFolder) {
+				return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+					Status:  http.StatusBadRequest,
+					Message: "Validation error.",
+					Error:   service.ErrUnknownFolder.Error(),
+				})
+			}
+
 			var errExists repository.ErrPasswordCardAlreadyExists
 			if errors.As(err, &errExists) {
 				return c.Status(http.StatusConflict).JSON(ErrorResponse{
@@ -96,19 +390,24 @@ func handlePostPasswordCards(s *service.PasswordCardService) func(*fiber.Ctx) er
 				})
 			}
 
+			if errors.Is(err, service.ErrVaultLocked) {
+				return vaultLockedResponse(c)
+			}
+
 			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
 				Status:  http.StatusInternalServerError,
 				Message: "Internal Server Error.",
 			})
 		}
 
-		return c.Status(http.StatusCreated).JSON(passwordCardRequest)
+		return c.Status(http.StatusCreated).JSON(passwordCard.Masked())
 	}
 }
 
 func handlePutPasswordCards(s *service.PasswordCardService) func(*fiber.Ctx) error {
 	return func(c *fiber.Ctx) error {
-		passwordCardID := c.Params("id")
+		// the params are only valid during the request, the ID is stored
+		passwordCardID := utils.CopyString(c.Params("id"))
 
 		var passwordCardRequest model.PasswordCard
 		if err := c.BodyParser(&passwordCardRequest); err != nil {
@@ -128,10 +427,28 @@ func handlePutPasswordCards(s *service.PasswordCardService) func(*fiber.Ctx) err
 			})
 		}
 
-		_, err := s.UpdatePasswordCard(passwordCardRequest)
+		passwordCard, err := s.UpdatePasswordCard(currentUserID(c), passwordCardRequest)
 		if err != nil {
 			log.Printf("error creating password card: %s", err.Error())
 
+			var errWeak service.ErrWeakPassword
+			if errors.As(err, &errWeak) {
+				return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+					Status:  http.StatusBadRequest,
+					Message: "Validation error.",
+					Error:   errWeak.Error(),
+				})
+			}
+
+			var errMasked service.ErrUnknownMaskedSecret
+			if errors.As(err, &errMasked) {
+				return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+					Status:  http.StatusBadRequest,
+					Message: "Validation error.",
+					Error:   errMasked.Error(),
+				})
+			}
+
 			var errExists repository.ErrPasswordCardAlreadyExists
 			if errors.As(err, &errExists) {
 				return c.Status(http.StatusConflict).JSON(ErrorResponse{
@@ -150,13 +467,17 @@ func handlePutPasswordCards(s *service.PasswordCardService) func(*fiber.Ctx) err
 				})
 			}
 
+			if errors.Is(err, service.ErrVaultLocked) {
+				return vaultLockedResponse(c)
+			}
+
 			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
 				Status:  http.StatusInternalServerError,
 				Message: "Internal Server Error.",
 			})
 		}
 
-		return c.JSON(passwordCardRequest)
+		return c.JSON(passwordCard.Masked())
 	}
 }
 
@@ -164,7 +485,7 @@ func handleDeletePasswordCards(s *service.PasswordCardService) func(*fiber.Ctx)
 	return func(c *fiber.Ctx) error {
 		passwordCardID := c.Params("id")
 
-		err := s.DeletePasswordCard(passwordCardID)
+		err := s.DeletePasswordCard(currentUserID(c), passwordCardID)
 		if err != nil {
 			log.Printf("error deleting password card: %s", err.Error())
 
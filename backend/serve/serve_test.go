@@ -1,12 +1,14 @@
 package serve
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"testing"
 
+	"github.com/CaioTeixeira95/password-manager/backend/breach"
 	"github.com/CaioTeixeira95/password-manager/backend/model"
 	"github.com/CaioTeixeira95/password-manager/backend/repository"
 	"github.com/CaioTeixeira95/password-manager/backend/service"
@@ -137,7 +139,282 @@ func TestPostPasswordCards(t *testing.T) {
 		resp.Body.Close()
 
 		assert.Equal(t, http.StatusCreated, resp.StatusCode)
-		assert.JSONEq(t, reqBody, string(respBody))
+
+		var passwordCard model.PasswordCard
+		require.NoError(t, json.Unmarshal(respBody, &passwordCard))
+		assert.False(t, passwordCard.CreatedAt.IsZero())
+		assert.Equal(t, model.PasswordCard{
+			ID:                "card-id-2",
+			Name:              "Google Cloud Platform",
+			Username:          "username",
+			Password:          model.MaskedPassword,
+			URL:               "https://cloud.google.com/login",
+			CreatedAt:         passwordCard.CreatedAt,
+			UpdatedAt:         passwordCard.CreatedAt,
+			PasswordChangedAt: passwordCard.CreatedAt,
+			Strength:          &model.PasswordStrength{Score: 0, CrackTimeSeconds: 1.297, CrackTimeDisplay: "instant"},
+		}, passwordCard)
+	})
+}
+
+func TestPostPasswordCardsMinPasswordScore(t *testing.T) {
+	app := fiber.New()
+	service := service.NewPasswordCardService(repository.NewPasswordCardRepository())
+	service.SetMinPasswordScore(3)
+
+	s := NewServe(app, service)
+	s.initHandlers()
+
+	reqBody := `
+		{
+			"id": "card-id-1",
+			"name": "AWS",
+			"username": "username",
+			"password": "supersecret",
+			"url": "https://aws.com/login"
+		}
+	`
+	req, err := http.NewRequest(http.MethodPost, "/password-cards", strings.NewReader(reqBody))
+	require.NoError(t, err)
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.JSONEq(t, `{"error":"password is too weak: its strength score is 0, the minimum is 3", "message":"Validation error.", "status":400}`, string(respBody))
+}
+
+func TestPasswordCardItemTypes(t *testing.T) {
+	app := fiber.New()
+	s := NewServe(app, service.NewPasswordCardService(repository.NewPasswordCardRepository()))
+	s.initHandlers()
+
+	do := func(method, url, body string) (int, string) {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(respBody)
+	}
+
+	t.Run("return BadRequest for invalid card numbers", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/password-cards", `
+			{
+				"id": "card-id-1",
+				"type": "credit_card",
+				"name": "Visa",
+				"credit_card": {"number": "4111 1111 1111 1112", "expiry_month": 12, "expiry_year": 2030}
+			}
+		`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":"invalid card number", "message":"Validation error.", "status":400}`, body)
+	})
+
+	t.Run("return BadRequest for the details of another type", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/password-cards", `
+			{
+				"id": "card-id-1",
+				"type": "secure_note",
+				"name": "Visa",
+				"notes": "the PIN is 1234",
+				"credit_card": {"number": "4111 1111 1111 1111", "expiry_month": 12, "expiry_year": 2030}
+			}
+		`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":"credit_card is only allowed on \"credit_card\" items", "message":"Validation error.", "status":400}`, body)
+	})
+
+	t.Run("🎉 creates a credit card with masked secrets", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/password-cards", `
+			{
+				"id": "card-id-1",
+				"type": "credit_card",
+				"name": "Visa",
+				"notes": "the PIN is 1234",
+				"credit_card": {"cardholder_name": "Jane Doe", "number": "4111 1111 1111 1111", "expiry_month": 12, "expiry_year": 2030, "code": "123"}
+			}
+		`)
+		require.Equal(t, http.StatusCreated, status, body)
+
+		var passwordCard model.PasswordCard
+		require.NoError(t, json.Unmarshal([]byte(body), &passwordCard))
+		assert.Equal(t, model.ItemTypeCreditCard, passwordCard.Type)
+		assert.Equal(t, "", passwordCard.Password)
+		assert.Equal(t, model.MaskedPassword, passwordCard.Notes)
+		assert.Equal(t, &model.CreditCard{CardholderName: "Jane Doe", Number: model.MaskedPassword, ExpiryMonth: 12, ExpiryYear: 2030, Code: model.MaskedPassword}, passwordCard.CreditCard)
+	})
+
+	t.Run("🎉 keeps the secrets sent back masked", func(t *testing.T) {
+		status, body := do(http.MethodPut, "/password-cards/card-id-1", `
+			{
+				"type": "credit_card",
+				"name": "Visa Gold",
+				"notes": "********",
+				"credit_card": {"cardholder_name": "Jane Doe", "number": "********", "expiry_month": 1, "expiry_year": 2031, "code": "********"}
+			}
+		`)
+		require.Equal(t, http.StatusOK, status, body)
+
+		status, body = do(http.MethodPost, "/password-cards/card-id-1/reveal", "")
+		require.Equal(t, http.StatusOK, status, body)
+		assert.JSONEq(t, `{
+			"id": "card-id-1",
+			"password": "",
+			"notes": "the PIN is 1234",
+			"credit_card": {"cardholder_name": "Jane Doe", "number": "4111 1111 1111 1111", "expiry_month": 1, "expiry_year": 2031, "code": "123"}
+		}`, body)
+	})
+
+	t.Run("🎉 lists the items of a type", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/password-cards", `
+			{
+				"id": "card-id-2",
+				"name": "AWS",
+				"username": "username",
+				"password": "supersecret",
+				"url": "https://aws.com/login"
+			}
+		`)
+		require.Equal(t, http.StatusCreated, status, body)
+
+		status, body = do(http.MethodGet, "/password-cards?type=credit_card", "")
+		require.Equal(t, http.StatusOK, status, body)
+
+		var passwordCards []model.PasswordCard
+		require.NoError(t, json.Unmarshal([]byte(body), &passwordCards))
+		require.Len(t, passwordCards, 1)
+		assert.Equal(t, "Visa Gold", passwordCards[0].Name)
+
+		status, body = do(http.MethodGet, "/password-cards?type=wifi", "")
+		assert.Equal(t, http.StatusBadRequest, status, body)
+	})
+}
+
+func TestPasswordCardCustomFields(t *testing.T) {
+	app := fiber.New()
+	s := NewServe(app, service.NewPasswordCardService(repository.NewPasswordCardRepository()))
+	s.initHandlers()
+
+	do := func(method, url, body string) (int, string) {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(respBody)
+	}
+
+	t.Run("return BadRequest for duplicate custom fields", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/password-cards", `
+			{
+				"id": "card-id-1",
+				"name": "AWS",
+				"username": "username",
+				"password": "supersecret",
+				"url": "https://aws.com/login",
+				"custom_fields": [
+					{"name": "PIN", "type": "hidden", "value": "4321"},
+					{"name": "PIN", "type": "text", "value": "8765"}
+				]
+			}
+		`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":"duplicate custom field \"PIN\"", "message":"Validation error.", "status":400}`, body)
+	})
+
+	t.Run("🎉 masks the hidden fields", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/password-cards", `
+			{
+				"id": "card-id-1",
+				"name": "AWS",
+				"username": "username",
+				"password": "supersecret",
+				"url": "https://aws.com/login",
+				"custom_fields": [
+					{"name": "Account ID", "type": "text", "value": "123456789012"},
+					{"name": "PIN", "type": "hidden", "value": "4321"}
+				]
+			}
+		`)
+		require.Equal(t, http.StatusCreated, status, body)
+
+		var passwordCard model.PasswordCard
+		require.NoError(t, json.Unmarshal([]byte(body), &passwordCard))
+		assert.Equal(t, []model.CustomField{
+			{Name: "Account ID", Type: model.CustomFieldText, Value: "123456789012"},
+			{Name: "PIN", Type: model.CustomFieldHidden, Value: model.MaskedPassword},
+		}, passwordCard.CustomFields)
+	})
+
+	t.Run("🎉 keeps the hidden fields sent back masked", func(t *testing.T) {
+		status, body := do(http.MethodPut, "/password-cards/card-id-1", `
+			{
+				"name": "AWS",
+				"username": "username",
+				"password": "********",
+				"url": "https://aws.com/login",
+				"custom_fields": [
+					{"name": "PIN", "type": "hidden", "value": "********"},
+					{"name": "MFA enforced", "type": "boolean", "value": "true"}
+				]
+			}
+		`)
+		require.Equal(t, http.StatusOK, status, body)
+
+		status, body = do(http.MethodPost, "/password-cards/card-id-1/reveal", "")
+		require.Equal(t, http.StatusOK, status, body)
+		assert.JSONEq(t, `{
+			"id": "card-id-1",
+			"password": "supersecret",
+			"custom_fields": [
+				{"name": "PIN", "type": "hidden", "value": "4321"},
+				{"name": "MFA enforced", "type": "boolean", "value": "true"}
+			]
+		}`, body)
+	})
+
+	t.Run("return BadRequest for a renamed hidden field sent back masked", func(t *testing.T) {
+		status, body := do(http.MethodPut, "/password-cards/card-id-1", `
+			{
+				"name": "AWS",
+				"username": "username",
+				"password": "********",
+				"url": "https://aws.com/login",
+				"custom_fields": [
+					{"name": "Card PIN", "type": "hidden", "value": "********"}
+				]
+			}
+		`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":"custom_fields.Card PIN is masked but the card has no value to keep", "message":"Validation error.", "status":400}`, body)
+	})
+
+	t.Run("🎉 searches the custom fields", func(t *testing.T) {
+		status, body := do(http.MethodGet, "/password-cards?q=mfa", "")
+		require.Equal(t, http.StatusOK, status, body)
+
+		var passwordCards []model.PasswordCard
+		require.NoError(t, json.Unmarshal([]byte(body), &passwordCards))
+		require.Len(t, passwordCards, 1)
+		assert.Equal(t, "card-id-1", passwordCards[0].ID)
 	})
 }
 
@@ -267,15 +544,86 @@ func TestPutPasswordCards(t *testing.T) {
 		resp.Body.Close()
 
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
-		assert.JSONEq(t, `
-			{
-				"id": "card-id-2",
-				"name": "Google Cloud Platform - GCP",
-				"username": "username",
-				"password": "mynewsupersecret",
-				"url": "https://another.google.com/login"
+
+		var passwordCard model.PasswordCard
+		require.NoError(t, json.Unmarshal(respBody, &passwordCard))
+		assert.False(t, passwordCard.UpdatedAt.IsZero())
+		assert.Equal(t, model.PasswordCard{
+			ID:                "card-id-2",
+			Name:              "Google Cloud Platform - GCP",
+			Username:          "username",
+			Password:          model.MaskedPassword,
+			URL:               "https://another.google.com/login",
+			UpdatedAt:         passwordCard.UpdatedAt,
+			PasswordChangedAt: passwordCard.UpdatedAt,
+			Strength:          &model.PasswordStrength{Score: 1, CrackTimeSeconds: 5423.341, CrackTimeDisplay: "3.0 hours"},
+		}, passwordCard)
+	})
+
+	t.Run("🎉 keeps the fields sent back as they were listed", func(t *testing.T) {
+		_, err := service.CreatePasswordCard("", model.PasswordCard{
+			ID:       "card-id-3",
+			Name:     "VPN",
+			Username: "username",
+			Password: "supersecret",
+			URL:      "https://vpn.example.com/",
+			TOTP:     "otpauth://totp/VPN:username?secret=JBSWY3DPEHPK3PXP",
+			HOTP:     "otpauth://hotp/VPN:username?secret=JBSWY3DPEHPK3PXP&counter=7",
+			Notes:    "ask the IT team",
+			CustomFields: []model.CustomField{
+				{Name: "Region", Type: model.CustomFieldText, Value: "eu-west-1"},
+				{Name: "PIN", Type: model.CustomFieldHidden, Value: "1234"},
+			},
+			Tags: []string{"work"},
+		})
+		require.NoError(t, err)
+
+		// the web app sends back the card it listed with the edited fields
+		req, err := http.NewRequest(http.MethodGet, "/password-cards", nil)
+		require.NoError(t, err)
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		var listed []map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&listed))
+		resp.Body.Close()
+
+		var reqBody []byte
+		for _, passwordCard := range listed {
+			if passwordCard["id"] == "card-id-3" {
+				passwordCard["name"] = "Office VPN"
+				reqBody, err = json.Marshal(passwordCard)
+				require.NoError(t, err)
 			}
-		`, string(respBody))
+		}
+		require.NotNil(t, reqBody)
+
+		req, err = http.NewRequest(http.MethodPut, fmt.Sprintf(url, "card-id-3"), strings.NewReader(string(reqBody)))
+		require.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err = app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(respBody))
+
+		passwordCard, err := service.GetPasswordCard("", "card-id-3")
+		require.NoError(t, err)
+		assert.Equal(t, "Office VPN", passwordCard.Name)
+		assert.Equal(t, "supersecret", passwordCard.Password)
+		assert.Equal(t, "otpauth://totp/VPN:username?secret=JBSWY3DPEHPK3PXP", passwordCard.TOTP)
+		assert.Equal(t, "otpauth://hotp/VPN:username?secret=JBSWY3DPEHPK3PXP&counter=7", passwordCard.HOTP)
+		assert.Equal(t, uint64(7), passwordCard.HOTPCounter)
+		assert.Equal(t, "ask the IT team", passwordCard.Notes)
+		assert.Equal(t, []model.CustomField{
+			{Name: "Region", Type: model.CustomFieldText, Value: "eu-west-1"},
+			{Name: "PIN", Type: model.CustomFieldHidden, Value: "1234"},
+		}, passwordCard.CustomFields)
+		assert.Equal(t, []string{"work"}, passwordCard.Tags)
 	})
 }
 
@@ -330,6 +678,65 @@ func TestDeletePasswordCards(t *testing.T) {
 	})
 }
 
+func TestGetPasswordCard(t *testing.T) {
+	app := fiber.New()
+	service := service.NewPasswordCardService(
+		repository.CustomPasswordCardRepository([]model.PasswordCard{
+			{
+				ID:       "card-id-1",
+				Name:     "AWS",
+				Username: "username",
+				Password: "supersecret",
+				URL:      "https://aws.com/login",
+			},
+		}),
+	)
+
+	s := NewServe(app, service)
+	s.initHandlers()
+
+	url := "/password-cards/%s"
+
+	t.Run("return NotFound when a non-existent is used", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(url, "card-id-2"), nil)
+		require.NoError(t, err)
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.JSONEq(t, `{"error":"password with ID \"card-id-2\" not found", "message":"Password Card not found.", "status":404}`, string(respBody))
+	})
+
+	t.Run("gets a password card successfully", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(url, "card-id-1"), nil)
+		require.NoError(t, err)
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.JSONEq(t, `
+			{
+				"id": "card-id-1",
+				"name": "AWS",
+				"username": "username",
+				"password": "********",
+				"url": "https://aws.com/login",
+				"created_at": "0001-01-01T00:00:00Z",
+				"updated_at": "0001-01-01T00:00:00Z",
+				"password_changed_at": "0001-01-01T00:00:00Z"
+			}
+		`, string(respBody))
+	})
+}
+
 func TestGetPasswordCards(t *testing.T) {
 	app := fiber.New()
 	r := repository.NewPasswordCardRepository()
@@ -387,17 +794,161 @@ func TestGetPasswordCards(t *testing.T) {
 					"id": "card-id-1",
 					"name": "AWS",
 					"username": "username",
-					"password": "supersecret",
-					"url": "https://aws.com/login"
+					"password": "********",
+					"url": "https://aws.com/login",
+					"created_at": "0001-01-01T00:00:00Z",
+					"updated_at": "0001-01-01T00:00:00Z",
+					"password_changed_at": "0001-01-01T00:00:00Z"
 				},
 				{
 					"id": "card-id-2",
 					"name": "GCP",
 					"username": "username",
-					"password": "supersecret",
-					"url": "https://cloud.google.com/login"
+					"password": "********",
+					"url": "https://cloud.google.com/login",
+					"created_at": "0001-01-01T00:00:00Z",
+					"updated_at": "0001-01-01T00:00:00Z",
+					"password_changed_at": "0001-01-01T00:00:00Z"
 				}
 			]
 		`, string(respBody))
 	})
+	t.Run("return BadRequest for invalid queries", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, url+"?sort=password", nil)
+		require.NoError(t, err)
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.JSONEq(t, `{"error":"sort must be one of \"name\", \"created_at\" or \"updated_at\", optionally prefixed by \"-\"", "message":"Validation error.", "status":400}`, string(respBody))
+
+		req, err = http.NewRequest(http.MethodGet, url+"?cursor=invalid", nil)
+		require.NoError(t, err)
+
+		resp, err = app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.JSONEq(t, `{"error":"invalid cursor", "message":"Validation error.", "status":400}`, string(respBody))
+	})
+
+	t.Run("searches and paginates the password cards", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, url+"?q=username&sort=-name&limit=1", nil)
+		require.NoError(t, err)
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "2", resp.Header.Get("X-Total-Count"))
+		assert.Contains(t, string(respBody), `"id":"card-id-2"`)
+
+		cursor := resp.Header.Get("X-Next-Cursor")
+		require.NotEmpty(t, cursor)
+
+		req, err = http.NewRequest(http.MethodGet, url+"?q=username&sort=-name&limit=1&cursor="+cursor, nil)
+		require.NoError(t, err)
+
+		resp, err = app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, string(respBody), `"id":"card-id-1"`)
+		assert.Empty(t, resp.Header.Get("X-Next-Cursor"))
+
+		req, err = http.NewRequest(http.MethodGet, url+"?host=google.com", nil)
+		require.NoError(t, err)
+
+		resp, err = app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "1", resp.Header.Get("X-Total-Count"))
+		assert.Contains(t, string(respBody), `"id":"card-id-2"`)
+		assert.NotContains(t, string(respBody), `"id":"card-id-1"`)
+	})
+}
+
+func TestGetPasswordCardsBreached(t *testing.T) {
+	app := fiber.New()
+	r := repository.CustomPasswordCardRepository([]model.PasswordCard{
+		{ID: "card-id-1", Name: "AWS", Username: "username", Password: "supersecret", URL: "https://aws.com/login"},
+		{ID: "card-id-2", Name: "GCP", Username: "username", Password: "anothersecret", URL: "https://cloud.google.com/login"},
+	})
+	service := service.NewPasswordCardService(r)
+
+	s := NewServe(app, service)
+	s.initHandlers()
+
+	do := func(url string) (int, string) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(respBody)
+	}
+
+	t.Run("return BadRequest without a breached passwords list", func(t *testing.T) {
+		status, body := do("/password-cards?breached=true")
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":"the breached passwords list isn't configured", "message":"Validation error.", "status":400}`, body)
+	})
+
+	// the SHA-1 hash of "supersecret"
+	list := "A761CE3A45D97E41840A788495E85A70D1BB3815:42\n"
+	hashFile, err := breach.NewHashFile(strings.NewReader(list), int64(len(list)))
+	require.NoError(t, err)
+	service.SetBreachChecker(hashFile)
+
+	t.Run("🎉 flags and filters the breached passwords", func(t *testing.T) {
+		status, body := do("/password-cards?breached=true")
+		assert.Equal(t, http.StatusOK, status)
+		assert.JSONEq(t, `
+			[
+				{
+					"id": "card-id-1",
+					"name": "AWS",
+					"username": "username",
+					"password": "********",
+					"url": "https://aws.com/login",
+					"breached": true,
+					"created_at": "0001-01-01T00:00:00Z",
+					"updated_at": "0001-01-01T00:00:00Z",
+					"password_changed_at": "0001-01-01T00:00:00Z"
+				}
+			]
+		`, body)
+
+		status, body = do("/password-cards?breached=false")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, `"id":"card-id-2"`)
+		assert.Contains(t, body, `"breached":false`)
+		assert.NotContains(t, body, `"id":"card-id-1"`)
+
+		status, body = do("/password-cards/card-id-1")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, `"breached":true`)
+	})
 }
@@ -0,0 +1,153 @@
+package serve
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/CaioTeixeira95/password-manager/backend/service"
+	"github.com/CaioTeixeira95/password-manager/backend/vault"
+	"github.com/gofiber/fiber/v2"
+)
+
+type UnlockVaultRequest struct {
+	MasterPassword string `json:"master_password"`
+}
+
+type RotateMasterKeyRequest struct {
+	MasterPassword string `json:"master_password"`
+	// NewMasterPassword is optional, the master key is rotated with the same
+	// master password when it's empty.
+	NewMasterPassword string `json:"new_master_password"`
+}
+
+// touchVault postpones the auto-lock of the vault. It runs after the
+// authentication, so anonymous requests don't keep the vault unlocked.
+func touchVault(vs *service.VaultService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		vs.Touch()
+		return c.Next()
+	}
+}
+
+// requireUnlockedVault rejects the requests while the vault is locked.
+func requireUnlockedVault(vs *service.VaultService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		if vs.IsLocked() {
+			return vaultLockedResponse(c)
+		}
+		return c.Next()
+	}
+}
+
+func vaultLockedResponse(c *fiber.Ctx) error {
+	return c.Status(http.StatusLocked).JSON(ErrorResponse{
+		Status:  http.StatusLocked,
+		Message: "Vault is locked.",
+		Error:   service.ErrVaultLocked.Error(),
+	})
+}
+
+func handlePostVaultUnlock(vs *service.VaultService, ps *service.PasswordCardService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		var unlockRequest UnlockVaultRequest
+		if err := c.BodyParser(&unlockRequest); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "The request is invalid in some way.",
+				Error:   err.Error(),
+			})
+		}
+
+		if strings.TrimSpace(unlockRequest.MasterPassword) == "" {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "Validation error.",
+				Error:   "master password can't be empty",
+			})
+		}
+
+		if err := vs.Unlock([]byte(unlockRequest.MasterPassword)); err != nil {
+			if errors.Is(err, vault.ErrWrongMasterPassword) {
+				return c.Status(http.StatusUnauthorized).JSON(ErrorResponse{
+					Status:  http.StatusUnauthorized,
+					Message: "Unauthorized.",
+					Error:   vault.ErrWrongMasterPassword.Error(),
+				})
+			}
+
+			log.Printf("error unlocking vault: %s", err.Error())
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		// secrets stored in plaintext, or left behind by an interrupted master
+		// key rotation, are migrated as soon as the key is available
+		if err := ps.MigrateSecrets(); err != nil {
+			log.Printf("error migrating secrets: %s", err.Error())
+		}
+
+		return c.SendStatus(http.StatusNoContent)
+	}
+}
+
+func handlePostVaultLock(vs *service.VaultService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		vs.Lock()
+		return c.SendStatus(http.StatusNoContent)
+	}
+}
+
+func handlePostVaultRotateMasterKey(ps *service.PasswordCardService) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		var rotateRequest RotateMasterKeyRequest
+		if err := c.BodyParser(&rotateRequest); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "The request is invalid in some way.",
+				Error:   err.Error(),
+			})
+		}
+
+		if strings.TrimSpace(rotateRequest.MasterPassword) == "" {
+			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "Validation error.",
+				Error:   "master password can't be empty",
+			})
+		}
+
+		newMasterPassword := rotateRequest.NewMasterPassword
+		if strings.TrimSpace(newMasterPassword) == "" {
+			newMasterPassword = rotateRequest.MasterPassword
+		}
+
+		err := ps.RotateMasterKey([]byte(rotateRequest.MasterPassword), []byte(newMasterPassword))
+		if err != nil {
+			if errors.Is(err, vault.ErrWrongMasterPassword) {
+				return c.Status(http.StatusUnauthorized).JSON(ErrorResponse{
+					Status:  http.StatusUnauthorized,
+					Message: "Unauthorized.",
+					Error:   vault.ErrWrongMasterPassword.Error(),
+				})
+			}
+
+			if errors.Is(err, service.ErrVaultLocked) {
+				return vaultLockedResponse(c)
+			}
+
+			log.Printf("error rotating master key: %s", err.Error())
+
+			return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Internal Server Error.",
+			})
+		}
+
+		return c.SendStatus(http.StatusNoContent)
+	}
+}
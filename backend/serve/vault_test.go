@@ -0,0 +1,260 @@
+package serve
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/service"
+	"github.com/CaioTeixeira95/password-manager/backend/vault"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestVaultService returns a vault service using cheap KDF parameters.
+func newTestVaultService() *service.VaultService {
+	return service.CustomVaultService(repository.NewVaultHeaderRepository(), func() (vault.KDFParams, error) {
+		return vault.KDFParams{Salt: []byte("0123456789abcdef"), Time: 1, Memory: 64, Threads: 1}, nil
+	})
+}
+
+func TestVaultLockAndUnlock(t *testing.T) {
+	app := fiber.New()
+	r := repository.CustomPasswordCardRepository([]model.PasswordCard{
+		{
+			ID:       "card-id-1",
+			Name:     "AWS",
+			Username: "username",
+			Password: "supersecret",
+			URL:      "https://aws.com/login",
+		},
+	})
+	vaultService := newTestVaultService()
+	require.NoError(t, vaultService.Unlock([]byte("master")))
+	vaultService.Lock()
+
+	s := NewServe(app, service.NewEncryptedPasswordCardService(r, vaultService), WithVaultService(vaultService))
+	s.initHandlers()
+
+	do := func(method, url, body string) (int, string) {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(respBody)
+	}
+
+	t.Run("return Locked for the password cards routes while the vault is locked", func(t *testing.T) {
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			status, body := do(method, "/password-cards", `{}`)
+			assert.Equal(t, http.StatusLocked, status)
+			assert.JSONEq(t, `{"error":"vault is locked", "message":"Vault is locked.", "status":423}`, body)
+		}
+
+		for _, method := range []string{http.MethodPut, http.MethodDelete} {
+			status, _ := do(method, "/password-cards/card-id-1", `{}`)
+			assert.Equal(t, http.StatusLocked, status)
+		}
+	})
+
+	t.Run("return BadRequest for an empty master password", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/vault/unlock", `{"master_password": ""}`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":"master password can't be empty", "message":"Validation error.", "status":400}`, body)
+	})
+
+	t.Run("return Unauthorized for a wrong master password", func(t *testing.T) {
+		status, body := do(http.MethodPost, "/vault/unlock", `{"master_password": "wrong"}`)
+		assert.Equal(t, http.StatusUnauthorized, status)
+		assert.JSONEq(t, `{"error":"wrong master password", "message":"Unauthorized.", "status":401}`, body)
+		assert.True(t, vaultService.IsLocked())
+	})
+
+	t.Run("🎉 unlocks and locks the vault", func(t *testing.T) {
+		status, _ := do(http.MethodPost, "/vault/unlock", `{"master_password": "master"}`)
+		assert.Equal(t, http.StatusNoContent, status)
+		assert.False(t, vaultService.IsLocked())
+
+		// the card stored in plaintext was encrypted and its strength
+		// estimated and sealed on unlock
+		stored, err := r.GetByID("card-id-1")
+		require.NoError(t, err)
+		assert.True(t, vault.IsEncrypted(stored.Password))
+		assert.Nil(t, stored.Strength)
+		assert.True(t, vault.IsEncrypted(stored.SealedStrength))
+
+		status, body := do(http.MethodGet, "/password-cards", "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.JSONEq(t, `[{"id":"card-id-1", "name":"AWS", "username":"username", "password":"********", "url":"https://aws.com/login", "created_at":"0001-01-01T00:00:00Z", "updated_at":"0001-01-01T00:00:00Z", "password_changed_at":"0001-01-01T00:00:00Z", "strength":{"score":0, "crack_time_seconds":1.297, "crack_time_display":"instant"}}]`, body)
+
+		status, body = do(http.MethodPost, "/password-cards/card-id-1/reveal", "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.JSONEq(t, `{"id":"card-id-1", "password":"supersecret"}`, body)
+
+		status, _ = do(http.MethodPost, "/vault/lock", "")
+		assert.Equal(t, http.StatusNoContent, status)
+		assert.True(t, vaultService.IsLocked())
+
+		status, _ = do(http.MethodGet, "/password-cards", "")
+		assert.Equal(t, http.StatusLocked, status)
+	})
+}
+
+func TestVaultRotateMasterKey(t *testing.T) {
+	app := fiber.New()
+	vaultService := newTestVaultService()
+	require.NoError(t, vaultService.Unlock([]byte("master")))
+
+	passwordCardService := service.NewEncryptedPasswordCardService(repository.NewPasswordCardRepository(), vaultService)
+	_, err := passwordCardService.CreatePasswordCard("", model.PasswordCard{
+		ID:       "card-id-1",
+		Name:     "AWS",
+		Username: "username",
+		Password: "supersecret",
+		URL:      "https://aws.com/login",
+	})
+	require.NoError(t, err)
+
+	s := NewServe(app, passwordCardService, WithVaultService(vaultService))
+	s.initHandlers()
+
+	do := func(body string) (int, string) {
+		req, err := http.NewRequest(http.MethodPost, "/vault/rotate-master-key", strings.NewReader(body))
+		require.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(respBody)
+	}
+
+	t.Run("return BadRequest without the master password", func(t *testing.T) {
+		status, body := do(`{"new_master_password": "new master"}`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":"master password can't be empty", "message":"Validation error.", "status":400}`, body)
+	})
+
+	t.Run("return Unauthorized for a wrong master password", func(t *testing.T) {
+		status, body := do(`{"master_password": "wrong", "new_master_password": "new master"}`)
+		assert.Equal(t, http.StatusUnauthorized, status)
+		assert.JSONEq(t, `{"error":"wrong master password", "message":"Unauthorized.", "status":401}`, body)
+	})
+
+	t.Run("🎉 rotates the master key keeping the master password", func(t *testing.T) {
+		status, _ := do(`{"master_password": "master"}`)
+		assert.Equal(t, http.StatusNoContent, status)
+
+		vaultService.Lock()
+		require.NoError(t, vaultService.Unlock([]byte("master")))
+	})
+
+	t.Run("🎉 changes the master password", func(t *testing.T) {
+		status, _ := do(`{"master_password": "master", "new_master_password": "new master"}`)
+		assert.Equal(t, http.StatusNoContent, status)
+
+		passwordCards, err := passwordCardService.ListPasswordCards("")
+		require.NoError(t, err)
+		assert.Equal(t, "supersecret", passwordCards[0].Password)
+
+		vaultService.Lock()
+		require.NoError(t, vaultService.Unlock([]byte("new master")))
+	})
+
+	t.Run("return Locked while the vault is locked", func(t *testing.T) {
+		vaultService.Lock()
+
+		status, _ := do(`{"master_password": "new master"}`)
+		assert.Equal(t, http.StatusLocked, status)
+	})
+}
+
+func TestVaultAdmin(t *testing.T) {
+	app := fiber.New()
+	vaultService := newTestVaultService()
+	require.NoError(t, vaultService.Unlock([]byte("master")))
+
+	userService := newTestUserService(repository.NewUserRepository())
+	userService.SetAdmins([]string{"Alice"}, vaultService)
+
+	s := NewServe(app, service.NewEncryptedPasswordCardService(repository.NewPasswordCardRepository(), vaultService),
+		WithVaultService(vaultService),
+		WithUserService(userService),
+	)
+	s.initHandlers()
+
+	do := func(url, token, body string) (int, string) {
+		req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		require.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(respBody)
+	}
+
+	login := func(username string) string {
+		_, err := userService.Register(model.Credentials{Username: username, Password: "supersecret", MasterPassword: "master"})
+		require.NoError(t, err)
+
+		token, _, err := userService.Login(model.Credentials{Username: username, Password: "supersecret"})
+		require.NoError(t, err)
+
+		return token
+	}
+
+	aliceToken := login("alice")
+	bobToken := login("bob")
+
+	t.Run("return Unauthorized for anonymous requests", func(t *testing.T) {
+		for _, url := range []string{"/vault/lock", "/vault/unlock", "/vault/rotate-master-key"} {
+			status, body := do(url, "", `{"master_password": "master"}`)
+			assert.Equal(t, http.StatusUnauthorized, status)
+			assert.JSONEq(t, `{"error":"missing bearer token", "message":"Unauthorized.", "status":401}`, body)
+		}
+		assert.False(t, vaultService.IsLocked())
+	})
+
+	t.Run("return Forbidden for the users without admin rights", func(t *testing.T) {
+		status, body := do("/vault/lock", bobToken, "")
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.JSONEq(t, `{"error":"admin rights required", "message":"Forbidden.", "status":403}`, body)
+		assert.False(t, vaultService.IsLocked())
+	})
+
+	t.Run("🎉 lets the admins lock and unlock the vault", func(t *testing.T) {
+		status, _ := do("/vault/lock", aliceToken, "")
+		assert.Equal(t, http.StatusNoContent, status)
+		assert.True(t, vaultService.IsLocked())
+
+		status, _ = do("/vault/unlock", aliceToken, `{"master_password": "master"}`)
+		assert.Equal(t, http.StatusNoContent, status)
+		assert.False(t, vaultService.IsLocked())
+	})
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+)
+
+// APITokenPrefix starts every API token, it tells them apart from the session
+// tokens and makes them easy to spot in leaked files.
+const APITokenPrefix = "pm_"
+
+// ErrInvalidAPIToken is returned for unknown, expired or revoked API tokens.
+var ErrInvalidAPIToken = errors.New("invalid, expired or revoked API token")
+
+// APITokenService manages the API tokens used by scripts.
+type APITokenService struct {
+	apiTokenRepository repository.APITokenStore
+	userRepository     repository.UserStore
+	now                func() time.Time
+}
+
+func NewAPITokenService(apiTokenRepository repository.APITokenStore, userRepository repository.UserStore) *APITokenService {
+	return &APITokenService{
+		apiTokenRepository: apiTokenRepository,
+		userRepository:     userRepository,
+		now:                time.Now,
+	}
+}
+
+// IsAPIToken reports whether a bearer token is an API token rather than a
+// session token.
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// CreateAPIToken creates a new API token for the user. The token itself is
+// only returned here, just its hash is stored.
+func (s *APITokenService) CreateAPIToken(userID string, apiTokenRequest model.APITokenRequest) (token string, apiToken *model.APIToken, err error) {
+	if err := apiTokenRequest.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	expiresInDays := apiTokenRequest.ExpiresInDays
+	if expiresInDays == 0 {
+		expiresInDays = model.DefaultAPITokenTTLDays
+	}
+
+	apiTokenID, err := randomToken(16, hex.EncodeToString)
+	if err != nil {
+		return "", nil, fmt.Errorf("error creating API token: %w", err)
+	}
+
+	secret, err := randomToken(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", nil, fmt.Errorf("error creating API token: %w", err)
+	}
+	token = APITokenPrefix + secret
+
+	now := s.now().UTC()
+	apiToken = &model.APIToken{
+		ID:        apiTokenID,
+		UserID:    userID,
+		Name:      strings.TrimSpace(apiTokenRequest.Name),
+		Scope:     apiTokenRequest.Scope,
+		TokenHash: hashToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.AddDate(0, 0, expiresInDays),
+	}
+
+	if err := s.apiTokenRepository.Insert(*apiToken); err != nil {
+		return "", nil, fmt.Errorf("error creating API token: %w", err)
+	}
+
+	return token, apiToken, nil
+}
+
+// ListAPITokens returns the API tokens of the user, including the expired
+// ones.
+func (s *APITokenService) ListAPITokens(userID string) ([]model.APIToken, error) {
+	apiTokens, err := s.apiTokenRepository.ListByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing API tokens: %w", err)
+	}
+
+	return apiTokens, nil
+}
+
+// RevokeAPIToken deletes an API token of the user.
+func (s *APITokenService) RevokeAPIToken(userID, apiTokenID string) error {
+	if err := s.apiTokenRepository.Delete(userID, apiTokenID); err != nil {
+		return fmt.Errorf("error revoking API token: %w", err)
+	}
+
+	return nil
+}
+
+// Authenticate returns the user and the API token matching token.
+func (s *APITokenService) Authenticate(token string) (*model.User, *model.APIToken, error) {
+	apiToken, err := s.apiTokenRepository.GetByTokenHash(hashToken(token))
+	if err != nil {
+		var errNotFound repository.ErrAPITokenNotFound
+		if errors.As(err, &errNotFound) {
+			return nil, nil, ErrInvalidAPIToken
+		}
+		return nil, nil, fmt.Errorf("error authenticating: %w", err)
+	}
+
+	if !s.now().Before(apiToken.ExpiresAt) {
+		return nil, nil, ErrInvalidAPIToken
+	}
+
+	user, err := s.userRepository.GetByID(apiToken.UserID)
+	if err != nil {
+		var errNotFound repository.ErrUserNotFound
+		if errors.As(err, &errNotFound) {
+			return nil, nil, ErrInvalidAPIToken
+		}
+		return nil, nil, fmt.Errorf("error authenticating: %w", err)
+	}
+
+	return user, apiToken, nil
+}
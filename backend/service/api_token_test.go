@@ -0,0 +1,69 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPITokenService(t *testing.T) {
+	now := time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+	user := model.User{ID: "user-id-1", Username: "alice", CreatedAt: now}
+
+	ur := repository.NewUserRepository()
+	require.NoError(t, ur.Insert(user))
+
+	s := NewAPITokenService(repository.NewAPITokenRepository(), ur)
+	s.now = func() time.Time { return now }
+
+	_, _, err := s.CreateAPIToken(user.ID, model.APITokenRequest{Name: "ci", Scope: "admin"})
+	assert.EqualError(t, err, `scope must be "read" or "read-write"`)
+
+	token, apiToken, err := s.CreateAPIToken(user.ID, model.APITokenRequest{Name: "ci", Scope: model.ScopeRead})
+	require.NoError(t, err)
+	assert.True(t, IsAPIToken(token))
+	assert.NotContains(t, apiToken.TokenHash, token)
+	assert.Equal(t, now.AddDate(0, 0, model.DefaultAPITokenTTLDays), apiToken.ExpiresAt)
+
+	_, _, err = s.CreateAPIToken(user.ID, model.APITokenRequest{Name: "ci", Scope: model.ScopeRead})
+	assert.ErrorIs(t, err, repository.ErrAPITokenAlreadyExists{Name: "ci"})
+
+	t.Run("🎉 authenticates with the token", func(t *testing.T) {
+		authenticated, authenticatedToken, err := s.Authenticate(token)
+		require.NoError(t, err)
+		assert.Equal(t, &user, authenticated)
+		assert.Equal(t, apiToken, authenticatedToken)
+
+		_, _, err = s.Authenticate(APITokenPrefix + "unknown")
+		assert.ErrorIs(t, err, ErrInvalidAPIToken)
+	})
+
+	t.Run("returns error for expired tokens", func(t *testing.T) {
+		expiringToken, _, err := s.CreateAPIToken(user.ID, model.APITokenRequest{Name: "expiring", Scope: model.ScopeRead, ExpiresInDays: 1})
+		require.NoError(t, err)
+
+		s.now = func() time.Time { return now.AddDate(0, 0, 1) }
+		defer func() { s.now = func() time.Time { return now } }()
+
+		_, _, err = s.Authenticate(expiringToken)
+		assert.ErrorIs(t, err, ErrInvalidAPIToken)
+	})
+
+	t.Run("🎉 revokes the token", func(t *testing.T) {
+		apiTokens, err := s.ListAPITokens(user.ID)
+		require.NoError(t, err)
+		assert.Len(t, apiTokens, 2)
+
+		require.NoError(t, s.RevokeAPIToken(user.ID, apiToken.ID))
+
+		_, _, err = s.Authenticate(token)
+		assert.ErrorIs(t, err, ErrInvalidAPIToken)
+
+		err = s.RevokeAPIToken(user.ID, apiToken.ID)
+		assert.ErrorIs(t, err, repository.ErrAPITokenNotFound{ID: apiToken.ID})
+	})
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+)
+
+// AuditService records the accesses to the secrets.
+type AuditService struct {
+	auditRepository repository.AuditStore
+	now             func() time.Time
+}
+
+func NewAuditService(auditRepository repository.AuditStore) *AuditService {
+	return &AuditService{auditRepository: auditRepository, now: time.Now}
+}
+
+// Record stores the event, filling its ID and time.
+func (s *AuditService) Record(auditEvent model.AuditEvent) (*model.AuditEvent, error) {
+	auditEventID, err := randomToken(16, hex.EncodeToString)
+	if err != nil {
+		return nil, fmt.Errorf("error recording audit event: %w", err)
+	}
+
+	auditEvent.ID = auditEventID
+	auditEvent.CreatedAt = s.now().UTC()
+
+	if err := s.auditRepository.Insert(auditEvent); err != nil {
+		return nil, fmt.Errorf("error recording audit event: %w", err)
+	}
+
+	return &auditEvent, nil
+}
+
+// ListPasswordCardEvents returns the events of a card, oldest first. The
+// caller must check the card belongs to the user.
+func (s *AuditService) ListPasswordCardEvents(passwordCardID string) ([]model.AuditEvent, error) {
+	auditEvents, err := s.auditRepository.ListByPasswordCardID(passwordCardID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing audit events: %w", err)
+	}
+
+	return auditEvents, nil
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditService(t *testing.T) {
+	s := NewAuditService(repository.NewAuditRepository())
+
+	now := time.Date(2023, 8, 1, 10, 0, 0, 0, time.FixedZone("BRT", -3*60*60))
+	s.now = func() time.Time { return now }
+
+	t.Run("🎉 records the events with their ID and time", func(t *testing.T) {
+		auditEvent, err := s.Record(model.AuditEvent{
+			Action:         model.AuditActionRevealPassword,
+			UserID:         "user-id-1",
+			PasswordCardID: "card-id-1",
+			RemoteIP:       "10.0.0.1",
+		})
+		require.NoError(t, err)
+		assert.NotEmpty(t, auditEvent.ID)
+		assert.Equal(t, now.UTC(), auditEvent.CreatedAt)
+
+		_, err = s.Record(model.AuditEvent{
+			Action:         model.AuditActionRevealPassword,
+			PasswordCardID: "card-id-2",
+			RemoteIP:       "10.0.0.1",
+		})
+		require.NoError(t, err)
+
+		auditEvents, err := s.ListPasswordCardEvents("card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, []model.AuditEvent{*auditEvent}, auditEvents)
+	})
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/google/uuid"
+)
+
+// RestorePasswordCards stores the password cards and folders of a backup for
+// ownerID. The cards keep their IDs and dates, and their passwords even when
+// they are weak: it restores what was stored before. The cards whose ID or URL
+// is already taken are reported as conflicts, the invalid ones are reported
+// too. The folders are merged by path into the folders of ownerID, the
+// missing ones are created with new IDs, and the cards are put back in them.
+// The cards of the folders that are neither in the backup nor owned by
+// ownerID are restored out of any folder.
+//
+// In the replace mode the cards and folders of ownerID are deleted first. It
+// only happens once every card of the backup is sealed, so a locked vault
+// doesn't lose them.
+func (s *PasswordCardService) RestorePasswordCards(ownerID string, mode model.RestoreMode, passwordCards []model.PasswordCard, folders []model.Folder) (*model.RestoreReport, error) {
+	report := &model.RestoreReport{
+		Mode:      mode,
+		Restored:  make([]model.ImportRow, 0),
+		Conflicts: make([]model.ImportRow, 0),
+		Invalid:   make([]model.ImportRow, 0),
+	}
+
+	// the estimates are slow, they are done before taking the lock
+	rows := make([]model.ImportRow, 0, len(passwordCards))
+	validPasswordCards := make([]model.PasswordCard, 0, len(passwordCards))
+	for i, passwordCard := range passwordCards {
+		row := model.ImportRow{
+			Row:  i + 1,
+			Name: passwordCard.Name,
+			URL:  passwordCard.URL,
+		}
+
+		if err := passwordCard.Validate(); err != nil {
+			row.Error = err.Error()
+			report.Invalid = append(report.Invalid, row)
+			continue
+		}
+
+		if err := s.estimateStrength(&passwordCard, false); err != nil {
+			return nil, fmt.Errorf("error restoring password cards: %w", err)
+		}
+
+		rows = append(rows, row)
+		validPasswordCards = append(validPasswordCards, passwordCard)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sealedPasswordCards := make([]model.PasswordCard, 0, len(validPasswordCards))
+	for _, passwordCard := range validPasswordCards {
+		passwordCard.OwnerID = ownerID
+		if passwordCard.CreatedAt.IsZero() {
+			passwordCard.CreatedAt = s.now().UTC()
+		}
+		if passwordCard.UpdatedAt.IsZero() {
+			passwordCard.UpdatedAt = passwordCard.CreatedAt
+		}
+		if passwordCard.PasswordChangedAt.IsZero() {
+			passwordCard.PasswordChangedAt = passwordCard.UpdatedAt
+		}
+
+		sealedPasswordCard, err := s.seal(passwordCard)
+		if err != nil {
+			return nil, fmt.Errorf("error restoring password cards: %w", err)
+		}
+
+		sealedPasswordCards = append(sealedPasswordCards, sealedPasswordCard)
+	}
+
+	if mode == model.RestoreModeReplace {
+		storedPasswordCards, err := s.passwordCardRepository.GetAll()
+		if err != nil {
+			return nil, fmt.Errorf("error restoring password cards: %w", err)
+		}
+
+		for _, passwordCard := range storedPasswordCards {
+			if passwordCard.OwnerID != ownerID {
+				continue
+			}
+
+			if err := s.passwordCardRepository.Delete(passwordCard.ID); err != nil {
+				return nil, fmt.Errorf("error restoring password cards: %w", err)
+			}
+			report.Deleted++
+		}
+
+		storedFolders, err := s.folderRepository.ListByOwnerID(ownerID)
+		if err != nil {
+			return nil, fmt.Errorf("error restoring password cards: %w", err)
+		}
+
+		for _, folder := range storedFolders {
+			if err := s.folderRepository.Delete(folder.ID); err != nil {
+				return nil, fmt.Errorf("error restoring password cards: %w", err)
+			}
+		}
+	}
+
+	folderIDs, err := s.restoreFolders(ownerID, folders, report)
+	if err != nil {
+		return nil, fmt.Errorf("error restoring password cards: %w", err)
+	}
+
+	for i, sealedPasswordCard := range sealedPasswordCards {
+		row := rows[i]
+
+		// the folder isn't sealed, it's set once the folders are restored
+		folderID, ok := folderIDs[sealedPasswordCard.FolderID]
+		if !ok {
+			if err := s.checkFolder(ownerID, sealedPasswordCard.FolderID); err != nil {
+				if !errors.Is(err, ErrUnknownFolder) {
+					return nil, fmt.Errorf("error restoring password cards: %w", err)
+				}
+				folderID = ""
+			} else {
+				folderID = sealedPasswordCard.FolderID
+			}
+		}
+		sealedPasswordCard.FolderID = folderID
+
+		err := s.passwordCardRepository.Insert(sealedPasswordCard)
+
+		var errExists repository.ErrPasswordCardAlreadyExists
+		switch {
+		case errors.As(err, &errExists):
+			row.Error = errExists.Error()
+			report.Conflicts = append(report.Conflicts, row)
+		case err != nil:
+			return nil, fmt.Errorf("error restoring password cards: %w", err)
+		default:
+			row.ID = sealedPasswordCard.ID
+			report.Restored = append(report.Restored, row)
+		}
+	}
+
+	return report, nil
+}
+
+// restoreFolders stores the folders of a backup for ownerID, parents first,
+// and returns the IDs they got by their ID in the backup. The folders of
+// ownerID with the same name in the same parent are reused, the others are
+// created with new IDs, the invalid ones are left out with their subfolders.
+// It must be called with the lock held.
+func (s *PasswordCardService) restoreFolders(ownerID string, folders []model.Folder, report *model.RestoreReport) (map[string]string, error) {
+	folderIDs := make(map[string]string, len(folders))
+	if len(folders) == 0 {
+		return folderIDs, nil
+	}
+
+	storedFolders, err := s.folderRepository.ListByOwnerID(ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	storedFolderIDs := make(map[string]string, len(storedFolders))
+	for _, folder := range storedFolders {
+		storedFolderIDs[folderKey(folder.ParentID, folder.Name)] = folder.ID
+	}
+
+	backupFolderIDs := make(map[string]bool, len(folders))
+	for _, folder := range folders {
+		backupFolderIDs[folder.ID] = true
+	}
+
+	// the folders whose parent isn't in the backup are restored at the root,
+	// the ones in a cycle are never reached
+	subfolders := make(map[string][]model.Folder, len(folders))
+	for _, folder := range folders {
+		parentID := folder.ParentID
+		if !backupFolderIDs[parentID] {
+			parentID = ""
+		}
+		subfolders[parentID] = append(subfolders[parentID], folder)
+	}
+
+	pending := subfolders[""]
+	for len(pending) > 0 {
+		folder := pending[0]
+		pending = pending[1:]
+
+		if _, ok := folderIDs[folder.ID]; ok {
+			continue
+		}
+
+		folderRequest := model.FolderRequest{Name: folder.Name, ParentID: folderIDs[folder.ParentID]}
+		if err := folderRequest.Validate(); err != nil {
+			continue
+		}
+
+		name := strings.TrimSpace(folder.Name)
+		key := folderKey(folderRequest.ParentID, name)
+		id, ok := storedFolderIDs[key]
+		if !ok {
+			newID, err := uuid.NewRandom()
+			if err != nil {
+				return nil, err
+			}
+
+			restoredFolder := model.Folder{
+				ID:        newID.String(),
+				Name:      name,
+				ParentID:  folderRequest.ParentID,
+				OwnerID:   ownerID,
+				CreatedAt: folder.CreatedAt,
+				UpdatedAt: folder.UpdatedAt,
+			}
+			if restoredFolder.CreatedAt.IsZero() {
+				restoredFolder.CreatedAt = s.now().UTC()
+			}
+			if restoredFolder.UpdatedAt.IsZero() {
+				restoredFolder.UpdatedAt = restoredFolder.CreatedAt
+			}
+
+			if err := s.folderRepository.Insert(restoredFolder); err != nil {
+				return nil, err
+			}
+			id = restoredFolder.ID
+			storedFolderIDs[key] = id
+			report.Folders++
+		}
+
+		folderIDs[folder.ID] = id
+		pending = append(pending, subfolders[folder.ID]...)
+	}
+
+	return folderIDs, nil
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestorePasswordCards(t *testing.T) {
+	createdAt := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	backup := []model.PasswordCard{
+		{ID: "card-id-1", Name: "AWS", Username: "admin", Password: "restoredsecret", URL: "https://aws.com/login", CreatedAt: createdAt, UpdatedAt: createdAt, PasswordChangedAt: createdAt},
+		{ID: "card-id-3", Name: "GCP", Username: "ops", Password: "password", URL: "https://cloud.google.com/", CreatedAt: createdAt, UpdatedAt: createdAt, PasswordChangedAt: createdAt},
+		{ID: "card-id-4", Name: "GitHub", Password: "anothersecret", URL: "https://github.com/"},
+		{ID: "card-id-5", Name: "GitLab", Username: "dev", Password: "anothersecret", URL: "https://gitlab.com/", CreatedAt: createdAt, UpdatedAt: createdAt, PasswordChangedAt: createdAt},
+	}
+
+	newService := func() (*repository.PasswordCardRepository, *PasswordCardService) {
+		r := repository.CustomPasswordCardRepository([]model.PasswordCard{
+			{ID: "card-id-1", Name: "AWS", Username: "admin", Password: "supersecret", URL: "https://aws.com/login", OwnerID: "user-id-1"},
+			{ID: "card-id-2", Name: "GitLab", Username: "dev", Password: "supersecret", URL: "https://gitlab.com/", OwnerID: "user-id-1"},
+			{ID: "card-id-6", Name: "Other", Username: "other", Password: "supersecret", URL: "https://other.com/", OwnerID: "user-id-2"},
+		})
+		s := NewPasswordCardService(r)
+		// weak passwords are restored anyway
+		s.SetMinPasswordScore(4)
+
+		return r, s
+	}
+
+	t.Run("🎉 merges the backup and reports the conflicts", func(t *testing.T) {
+		r, s := newService()
+
+		report, err := s.RestorePasswordCards("user-id-1", model.RestoreModeMerge, backup, nil)
+		require.NoError(t, err)
+		assert.Equal(t, &model.RestoreReport{
+			Mode: model.RestoreModeMerge,
+			Restored: []model.ImportRow{
+				{Row: 2, ID: "card-id-3", Name: "GCP", URL: "https://cloud.google.com/"},
+			},
+			Conflicts: []model.ImportRow{
+				{Row: 1, Name: "AWS", URL: "https://aws.com/login", Error: `password with ID "card-id-1" already exists`},
+				{Row: 4, Name: "GitLab", URL: "https://gitlab.com/", Error: `password with URL "https://gitlab.com/" already exists`},
+			},
+			Invalid: []model.ImportRow{
+				{Row: 3, Name: "GitHub", URL: "https://github.com/", Error: "username can't be empty"},
+			},
+		}, report)
+
+		passwordCard, err := s.GetPasswordCard("user-id-1", "card-id-3")
+		require.NoError(t, err)
+		assert.Equal(t, "password", passwordCard.Password)
+		assert.Equal(t, createdAt, passwordCard.CreatedAt)
+		assert.Equal(t, createdAt, passwordCard.PasswordChangedAt)
+		assert.NotNil(t, passwordCard.Strength)
+
+		passwordCard, err = s.GetPasswordCard("user-id-1", "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, "supersecret", passwordCard.Password)
+
+		passwordCards, err := r.GetAll()
+		require.NoError(t, err)
+		assert.Len(t, passwordCards, 4)
+	})
+
+	t.Run("🎉 replaces the cards of the owner", func(t *testing.T) {
+		r, s := newService()
+
+		report, err := s.RestorePasswordCards("user-id-1", model.RestoreModeReplace, backup, nil)
+		require.NoError(t, err)
+		assert.Equal(t, &model.RestoreReport{
+			Mode:    model.RestoreModeReplace,
+			Deleted: 2,
+			Restored: []model.ImportRow{
+				{Row: 1, ID: "card-id-1", Name: "AWS", URL: "https://aws.com/login"},
+				{Row: 2, ID: "card-id-3", Name: "GCP", URL: "https://cloud.google.com/"},
+				{Row: 4, ID: "card-id-5", Name: "GitLab", URL: "https://gitlab.com/"},
+			},
+			Conflicts: []model.ImportRow{},
+			Invalid: []model.ImportRow{
+				{Row: 3, Name: "GitHub", URL: "https://github.com/", Error: "username can't be empty"},
+			},
+		}, report)
+
+		passwordCard, err := s.GetPasswordCard("user-id-1", "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, "restoredsecret", passwordCard.Password)
+
+		_, err = s.GetPasswordCard("user-id-1", "card-id-2")
+		assert.ErrorAs(t, err, &repository.ErrPasswordCardNotFound{})
+
+		// the cards of the other owners are kept
+		passwordCard, err = s.GetPasswordCard("user-id-2", "card-id-6")
+		require.NoError(t, err)
+		assert.Equal(t, "Other", passwordCard.Name)
+
+		passwordCards, err := r.GetAll()
+		require.NoError(t, err)
+		assert.Len(t, passwordCards, 4)
+	})
+
+	t.Run("🎉 reports the IDs taken by other owners", func(t *testing.T) {
+		_, s := newService()
+
+		report, err := s.RestorePasswordCards("user-id-2", model.RestoreModeReplace, backup[:1], nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Deleted)
+		assert.Empty(t, report.Restored)
+		assert.Equal(t, []model.ImportRow{
+			{Row: 1, Name: "AWS", URL: "https://aws.com/login", Error: `password with ID "card-id-1" already exists`},
+		}, report.Conflicts)
+	})
+
+	t.Run("🎉 restores the cards of missing folders out of any folder", func(t *testing.T) {
+		_, s := newService()
+		folder, err := s.CreateFolder("user-id-1", model.FolderRequest{Name: "Cloud"})
+		require.NoError(t, err)
+
+		gcp, other := backup[1], backup[1]
+		gcp.FolderID = folder.ID
+		other.ID, other.URL, other.FolderID = "card-id-7", "https://cloud.google.com/other", "folder-id"
+
+		report, err := s.RestorePasswordCards("user-id-1", model.RestoreModeMerge, []model.PasswordCard{gcp, other}, nil)
+		require.NoError(t, err)
+		assert.Len(t, report.Restored, 2)
+
+		passwordCard, err := s.GetPasswordCard("user-id-1", "card-id-3")
+		require.NoError(t, err)
+		assert.Equal(t, folder.ID, passwordCard.FolderID)
+
+		passwordCard, err = s.GetPasswordCard("user-id-1", "card-id-7")
+		require.NoError(t, err)
+		assert.Empty(t, passwordCard.FolderID)
+	})
+
+	t.Run("🎉 restores the folders of the backup", func(t *testing.T) {
+		_, s := newService()
+		work, err := s.CreateFolder("user-id-1", model.FolderRequest{Name: "Work"})
+		require.NoError(t, err)
+
+		folders := []model.Folder{
+			{ID: "folder-id-2", Name: "Cloud", ParentID: "folder-id-1"},
+			{ID: "folder-id-1", Name: "Work"},
+		}
+		gcp := backup[1]
+		gcp.FolderID = "folder-id-2"
+
+		report, err := s.RestorePasswordCards("user-id-1", model.RestoreModeMerge, []model.PasswordCard{gcp}, folders)
+		require.NoError(t, err)
+		assert.Len(t, report.Restored, 1)
+		// the Work folder was already there
+		assert.Equal(t, 1, report.Folders)
+
+		storedFolders, err := s.ListFolders("user-id-1")
+		require.NoError(t, err)
+		require.Len(t, storedFolders, 2)
+		cloud := storedFolders[1]
+		assert.Equal(t, "Cloud", cloud.Name)
+		assert.Equal(t, work.ID, cloud.ParentID)
+
+		passwordCard, err := s.GetPasswordCard("user-id-1", "card-id-3")
+		require.NoError(t, err)
+		assert.Equal(t, cloud.ID, passwordCard.FolderID)
+	})
+
+	t.Run("🎉 replaces the folders of the owner", func(t *testing.T) {
+		_, s := newService()
+		_, err := s.CreateFolder("user-id-1", model.FolderRequest{Name: "Personal"})
+		require.NoError(t, err)
+
+		report, err := s.RestorePasswordCards("user-id-1", model.RestoreModeReplace, backup[:1], []model.Folder{{ID: "folder-id-1", Name: "Work"}})
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Folders)
+
+		storedFolders, err := s.ListFolders("user-id-1")
+		require.NoError(t, err)
+		require.Len(t, storedFolders, 1)
+		assert.Equal(t, "Work", storedFolders[0].Name)
+	})
+
+	t.Run("keeps the cards while the vault is locked", func(t *testing.T) {
+		r := repository.CustomPasswordCardRepository([]model.PasswordCard{
+			{ID: "card-id-1", Name: "AWS", Username: "admin", Password: "supersecret", URL: "https://aws.com/login"},
+		})
+		vs := newTestVaultService(repository.NewVaultHeaderRepository())
+		s := NewEncryptedPasswordCardService(r, vs)
+
+		_, err := s.RestorePasswordCards("", model.RestoreModeReplace, backup[:2], nil)
+		assert.ErrorIs(t, err, ErrVaultLocked)
+
+		passwordCards, err := r.GetAll()
+		require.NoError(t, err)
+		assert.Len(t, passwordCards, 1)
+
+		require.NoError(t, vs.Unlock([]byte("master")))
+
+		report, err := s.RestorePasswordCards("", model.RestoreModeReplace, backup[:2], nil)
+		require.NoError(t, err)
+		assert.Len(t, report.Restored, 2)
+
+		stored, err := r.GetByID("card-id-3")
+		require.NoError(t, err)
+		assert.True(t, vault.IsEncrypted(stored.Password))
+	})
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrUnknownFolder is returned when a card or a folder is put in a
+	// folder that doesn't exist, or that belongs to another owner.
+	ErrUnknownFolder = errors.New("the folder doesn't exist")
+	// ErrFolderCycle is returned when a folder is moved into itself or one
+	// of its subfolders.
+	ErrFolderCycle = errors.New("a folder can't be moved into itself or its subfolders")
+)
+
+// SetFolderRepository stores the folders in folderRepository. The service
+// keeps them in memory otherwise.
+func (s *PasswordCardService) SetFolderRepository(folderRepository repository.FolderStore) {
+	s.folderRepository = folderRepository
+}
+
+// CreateFolder stores a new folder owned by ownerID, in the parent folder of
+// the request or at the root.
+func (s *PasswordCardService) CreateFolder(ownerID string, folderRequest model.FolderRequest) (*model.Folder, error) {
+	if err := folderRequest.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkFolder(ownerID, folderRequest.ParentID); err != nil {
+		return nil, fmt.Errorf("error creating folder: %w", err)
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, fmt.Errorf("error creating folder: %w", err)
+	}
+
+	now := s.now().UTC()
+	folder := &model.Folder{
+		ID:        id.String(),
+		Name:      strings.TrimSpace(folderRequest.Name),
+		ParentID:  folderRequest.ParentID,
+		OwnerID:   ownerID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.folderRepository.Insert(*folder); err != nil {
+		return nil, fmt.Errorf("error creating folder: %w", err)
+	}
+
+	return folder, nil
+}
+
+// ListFolders returns the folders owned by ownerID in creation order, the
+// clients build the tree from their parents.
+func (s *PasswordCardService) ListFolders(ownerID string) ([]model.Folder, error) {
+	folders, err := s.folderRepository.ListByOwnerID(ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing folders: %w", err)
+	}
+
+	return folders, nil
+}
+
+// GetFolder returns a folder owned by ownerID. The folders of other owners
+// are reported as not found.
+func (s *PasswordCardService) GetFolder(ownerID, folderID string) (*model.Folder, error) {
+	folder, err := s.getOwnedFolder(ownerID, folderID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting folder: %w", err)
+	}
+
+	return folder, nil
+}
+
+// UpdateFolder renames a folder owned by ownerID and moves it, with its
+// subfolders and cards, to the parent folder of the request.
+func (s *PasswordCardService) UpdateFolder(ownerID, folderID string, folderRequest model.FolderRequest) (*model.Folder, error) {
+	if err := folderRequest.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	folder, err := s.getOwnedFolder(ownerID, folderID)
+	if err != nil {
+		return nil, fmt.Errorf("error updating folder: %w", err)
+	}
+
+	if folderRequest.ParentID != folder.ParentID {
+		if err := s.checkFolder(ownerID, folderRequest.ParentID); err != nil {
+			return nil, fmt.Errorf("error updating folder: %w", err)
+		}
+
+		folders, err := s.folderRepository.ListByOwnerID(ownerID)
+		if err != nil {
+			return nil, fmt.Errorf("error updating folder: %w", err)
+		}
+		if folderRequest.ParentID != "" && subfolderIDs(folders, folderID)[folderRequest.ParentID] {
+			return nil, fmt.Errorf("error updating folder: %w", ErrFolderCycle)
+		}
+	}
+
+	folder.Name = strings.TrimSpace(folderRequest.Name)
+	folder.ParentID = folderRequest.ParentID
+	folder.UpdatedAt = s.now().UTC()
+
+	if err := s.folderRepository.Update(*folder); err != nil {
+		return nil, fmt.Errorf("error updating folder: %w", err)
+	}
+
+	return folder, nil
+}
+
+// DeleteFolder deletes a folder owned by ownerID. Its subfolders and cards
+// aren't deleted, they are moved to its parent folder. When a subfolder has
+// the name of a folder of the parent, nothing changes and
+// ErrFolderAlreadyExists is returned.
+func (s *PasswordCardService) DeleteFolder(ownerID, folderID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	folder, err := s.getOwnedFolder(ownerID, folderID)
+	if err != nil {
+		return fmt.Errorf("error deleting folder: %w", err)
+	}
+
+	folders, err := s.folderRepository.ListByOwnerID(ownerID)
+	if err != nil {
+		return fmt.Errorf("error deleting folder: %w", err)
+	}
+
+	// the names are checked before anything changes, the deleted folder
+	// itself frees its name
+	takenNames := make(map[string]bool)
+	for _, sibling := range folders {
+		if sibling.ParentID == folder.ParentID && sibling.ID != folderID {
+			takenNames[sibling.Name] = true
+		}
+	}
+
+	subfolders := make([]model.Folder, 0)
+	for _, subfolder := range folders {
+		if subfolder.ParentID != folderID {
+			continue
+		}
+
+		if takenNames[subfolder.Name] {
+			return fmt.Errorf("error deleting folder: %w", repository.ErrFolderAlreadyExists{Name: subfolder.Name})
+		}
+		subfolders = append(subfolders, subfolder)
+	}
+
+	passwordCards, err := s.passwordCardRepository.GetAll()
+	if err != nil {
+		return fmt.Errorf("error deleting folder: %w", err)
+	}
+
+	for _, passwordCard := range passwordCards {
+		if passwordCard.OwnerID != ownerID || passwordCard.FolderID != folderID {
+			continue
+		}
+
+		// the folder isn't sealed, the secrets stay as they are
+		passwordCard.FolderID = folder.ParentID
+		if err := s.passwordCardRepository.Update(passwordCard); err != nil {
+			return fmt.Errorf("error deleting folder: %w", err)
+		}
+	}
+
+	if err := s.folderRepository.Delete(folderID); err != nil {
+		return fmt.Errorf("error deleting folder: %w", err)
+	}
+
+	for _, subfolder := range subfolders {
+		subfolder.ParentID = folder.ParentID
+		if err := s.folderRepository.Update(subfolder); err != nil {
+			return fmt.Errorf("error deleting folder: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// MovePasswordCard moves a password card owned by ownerID to the folder, or
+// out of any folder when folderID is empty.
+func (s *PasswordCardService) MovePasswordCard(ownerID, passwordCardID, folderID string) (*model.PasswordCard, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	passwordCard, err := s.getOwned(ownerID, passwordCardID)
+	if err != nil {
+		return nil, fmt.Errorf("error moving password card: %w", err)
+	}
+	if passwordCard == nil {
+		return nil, fmt.Errorf("error moving password card: %w", repository.ErrPasswordCardNotFound{ID: passwordCardID})
+	}
+
+	if err := s.checkFolder(ownerID, folderID); err != nil {
+		return nil, fmt.Errorf("error moving password card: %w", err)
+	}
+
+	passwordCard.FolderID = folderID
+	passwordCard.UpdatedAt = s.now().UTC()
+	if err := s.passwordCardRepository.Update(*passwordCard); err != nil {
+		return nil, fmt.Errorf("error moving password card: %w", err)
+	}
+
+	unsealedPasswordCard, err := s.unseal(*passwordCard)
+	if err != nil {
+		return nil, fmt.Errorf("error moving password card: %w", err)
+	}
+
+	return &unsealedPasswordCard, nil
+}
+
+// checkFolder returns ErrUnknownFolder when folderID isn't a folder owned by
+// ownerID. The empty ID, out of any folder, is valid.
+func (s *PasswordCardService) checkFolder(ownerID, folderID string) error {
+	if folderID == "" {
+		return nil
+	}
+
+	_, err := s.getOwnedFolder(ownerID, folderID)
+	var errNotFound repository.ErrFolderNotFound
+	if errors.As(err, &errNotFound) {
+		return ErrUnknownFolder
+	}
+
+	return err
+}
+
+func (s *PasswordCardService) getOwnedFolder(ownerID, folderID string) (*model.Folder, error) {
+	folder, err := s.folderRepository.GetByID(folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if folder.OwnerID != ownerID {
+		return nil, repository.ErrFolderNotFound{ID: folderID}
+	}
+
+	return folder, nil
+}
+
+// subfolderIDs returns the IDs of the folder and of every folder nested in it.
+func subfolderIDs(folders []model.Folder, folderID string) map[string]bool {
+	children := make(map[string][]string, len(folders))
+	for _, folder := range folders {
+		children[folder.ParentID] = append(children[folder.ParentID], folder.ID)
+	}
+
+	ids := map[string]bool{folderID: true}
+	pending := []string{folderID}
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		for _, child := range children[id] {
+			if !ids[child] {
+				ids[child] = true
+				pending = append(pending, child)
+			}
+		}
+	}
+
+	return ids
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFolders(t *testing.T) {
+	r := repository.NewPasswordCardRepository()
+	vs := newTestVaultService(repository.NewVaultHeaderRepository())
+	s := NewEncryptedPasswordCardService(r, vs)
+	require.NoError(t, vs.Unlock([]byte("master")))
+
+	work, err := s.CreateFolder("user-id-1", model.FolderRequest{Name: " Work "})
+	require.NoError(t, err)
+	cloud, err := s.CreateFolder("user-id-1", model.FolderRequest{Name: "Cloud", ParentID: work.ID})
+	require.NoError(t, err)
+	personal, err := s.CreateFolder("user-id-1", model.FolderRequest{Name: "Personal"})
+	require.NoError(t, err)
+
+	newCard := func(id, name, folderID string, tags ...string) model.PasswordCard {
+		return model.PasswordCard{
+			ID:       id,
+			Name:     name,
+			Username: "admin",
+			Password: "supersecret",
+			URL:      "https://" + id + ".com/login",
+			FolderID: folderID,
+			Tags:     tags,
+		}
+	}
+
+	_, err = s.CreatePasswordCard("user-id-1", newCard("aws", "AWS", cloud.ID, "2fa"))
+	require.NoError(t, err)
+	_, err = s.CreatePasswordCard("user-id-1", newCard("jira", "Jira", work.ID, "Work"))
+	require.NoError(t, err)
+	_, err = s.CreatePasswordCard("user-id-1", newCard("bank", "Bank", personal.ID, "2FA"))
+	require.NoError(t, err)
+
+	t.Run("🎉 creates the folders", func(t *testing.T) {
+		assert.Equal(t, "Work", work.Name)
+		assert.Equal(t, "user-id-1", cloud.OwnerID)
+		assert.Equal(t, work.ID, cloud.ParentID)
+
+		folders, err := s.ListFolders("user-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, []model.Folder{*work, *cloud, *personal}, folders)
+
+		folders, err = s.ListFolders("user-id-2")
+		require.NoError(t, err)
+		assert.Empty(t, folders)
+	})
+
+	t.Run("doesn't create cards or folders in unknown folders", func(t *testing.T) {
+		_, err := s.CreatePasswordCard("user-id-1", newCard("gitlab", "GitLab", "folder-id"))
+		assert.ErrorIs(t, err, ErrUnknownFolder)
+
+		_, err = s.CreateFolder("user-id-1", model.FolderRequest{Name: "Banks", ParentID: "folder-id"})
+		assert.ErrorIs(t, err, ErrUnknownFolder)
+	})
+
+	t.Run("hides the folders of other owners", func(t *testing.T) {
+		_, err := s.GetFolder("user-id-2", work.ID)
+		assert.ErrorAs(t, err, &repository.ErrFolderNotFound{})
+
+		_, err = s.CreatePasswordCard("user-id-2", newCard("gitlab", "GitLab", work.ID))
+		assert.ErrorIs(t, err, ErrUnknownFolder)
+	})
+
+	t.Run("doesn't repeat names in the same folder", func(t *testing.T) {
+		_, err := s.CreateFolder("user-id-1", model.FolderRequest{Name: "Cloud", ParentID: work.ID})
+		assert.ErrorAs(t, err, &repository.ErrFolderAlreadyExists{})
+
+		_, err = s.CreateFolder("user-id-2", model.FolderRequest{Name: "Work"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("🎉 filters the cards by folder, with the subfolders", func(t *testing.T) {
+		page, err := s.SearchPasswordCards("user-id-1", model.PasswordCardQuery{Folder: work.ID})
+		require.NoError(t, err)
+		require.Equal(t, 2, page.Total)
+		assert.Equal(t, "AWS", page.PasswordCards[0].Name)
+		assert.Equal(t, "Jira", page.PasswordCards[1].Name)
+
+		page, err = s.SearchPasswordCards("user-id-1", model.PasswordCardQuery{Folder: cloud.ID})
+		require.NoError(t, err)
+		assert.Equal(t, 1, page.Total)
+
+		_, err = s.SearchPasswordCards("user-id-1", model.PasswordCardQuery{Folder: "folder-id"})
+		assert.ErrorIs(t, err, ErrUnknownFolder)
+	})
+
+	t.Run("🎉 filters the cards by tag, ignoring the case", func(t *testing.T) {
+		page, err := s.SearchPasswordCards("user-id-1", model.PasswordCardQuery{Tag: "2fa"})
+		require.NoError(t, err)
+		require.Equal(t, 2, page.Total)
+		assert.Equal(t, "AWS", page.PasswordCards[0].Name)
+		assert.Equal(t, "Bank", page.PasswordCards[1].Name)
+	})
+
+	t.Run("🎉 keeps the folder on updates", func(t *testing.T) {
+		jira := newCard("jira", "Jira Cloud", "")
+		_, err := s.UpdatePasswordCard("user-id-1", jira)
+		require.NoError(t, err)
+
+		passwordCard, err := s.GetPasswordCard("user-id-1", "jira")
+		require.NoError(t, err)
+		assert.Equal(t, work.ID, passwordCard.FolderID)
+	})
+
+	t.Run("🎉 moves the cards", func(t *testing.T) {
+		passwordCard, err := s.MovePasswordCard("user-id-1", "bank", "")
+		require.NoError(t, err)
+		assert.Empty(t, passwordCard.FolderID)
+		assert.Equal(t, "supersecret", passwordCard.Password)
+
+		passwordCard, err = s.MovePasswordCard("user-id-1", "bank", personal.ID)
+		require.NoError(t, err)
+		assert.Equal(t, personal.ID, passwordCard.FolderID)
+
+		_, err = s.MovePasswordCard("user-id-1", "bank", "folder-id")
+		assert.ErrorIs(t, err, ErrUnknownFolder)
+
+		_, err = s.MovePasswordCard("user-id-2", "bank", "")
+		assert.ErrorAs(t, err, &repository.ErrPasswordCardNotFound{})
+
+		_, err = s.MovePasswordCard("user-id-1", "card-id", "")
+		assert.ErrorAs(t, err, &repository.ErrPasswordCardNotFound{})
+	})
+
+	t.Run("doesn't move a folder into itself or its subfolders", func(t *testing.T) {
+		_, err := s.UpdateFolder("user-id-1", work.ID, model.FolderRequest{Name: "Work", ParentID: work.ID})
+		assert.ErrorIs(t, err, ErrFolderCycle)
+
+		_, err = s.UpdateFolder("user-id-1", work.ID, model.FolderRequest{Name: "Work", ParentID: cloud.ID})
+		assert.ErrorIs(t, err, ErrFolderCycle)
+	})
+
+	t.Run("🎉 renames and moves the folders", func(t *testing.T) {
+		folder, err := s.UpdateFolder("user-id-1", cloud.ID, model.FolderRequest{Name: "AWS", ParentID: personal.ID})
+		require.NoError(t, err)
+		assert.Equal(t, "AWS", folder.Name)
+		assert.Equal(t, personal.ID, folder.ParentID)
+
+		page, err := s.SearchPasswordCards("user-id-1", model.PasswordCardQuery{Folder: personal.ID})
+		require.NoError(t, err)
+		assert.Equal(t, 2, page.Total)
+	})
+
+	t.Run("doesn't delete a folder whose subfolders have the name of a folder of its parent", func(t *testing.T) {
+		banks, err := s.CreateFolder("user-id-1", model.FolderRequest{Name: "Banks"})
+		require.NoError(t, err)
+		_, err = s.CreateFolder("user-id-1", model.FolderRequest{Name: "Banks", ParentID: personal.ID})
+		require.NoError(t, err)
+
+		err = s.DeleteFolder("user-id-1", personal.ID)
+		assert.ErrorIs(t, err, repository.ErrFolderAlreadyExists{Name: "Banks"})
+
+		// nothing moved, not even the subfolders before the taken name
+		folder, err := s.GetFolder("user-id-1", cloud.ID)
+		require.NoError(t, err)
+		assert.Equal(t, personal.ID, folder.ParentID)
+		_, err = s.GetFolder("user-id-1", personal.ID)
+		require.NoError(t, err)
+
+		require.NoError(t, s.DeleteFolder("user-id-1", banks.ID))
+	})
+
+	t.Run("🎉 moves the content of a deleted folder to its parent", func(t *testing.T) {
+		require.NoError(t, s.DeleteFolder("user-id-1", personal.ID))
+
+		folder, err := s.GetFolder("user-id-1", cloud.ID)
+		require.NoError(t, err)
+		assert.Empty(t, folder.ParentID)
+
+		passwordCard, err := s.GetPasswordCard("user-id-1", "bank")
+		require.NoError(t, err)
+		assert.Empty(t, passwordCard.FolderID)
+		assert.Equal(t, "supersecret", passwordCard.Password)
+
+		err = s.DeleteFolder("user-id-1", personal.ID)
+		assert.ErrorAs(t, err, &repository.ErrFolderNotFound{})
+	})
+}
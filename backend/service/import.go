@@ -0,0 +1,180 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/CaioTeixeira95/password-manager/backend/importer"
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+)
+
+// ImportPasswordCards creates the password cards of the imported entries for
+// ownerID. The entries that aren't logins are skipped, the invalid ones and
+// those whose URL is already taken are reported, the others are still
+// created. It only stops on the errors every entry would hit, like a locked
+// vault, keeping the cards created so far: the report of the entries handled
+// before is returned along with the error.
+//
+// The cards are put in the folders of their entries, created along with them
+// unless ownerID already has folders at the same path.
+//
+// A dry run stores nothing and reports what a real import would do.
+func (s *PasswordCardService) ImportPasswordCards(ownerID string, format importer.Format, entries []importer.Entry, dryRun bool) (*model.ImportReport, error) {
+	report := &model.ImportReport{
+		Format:    string(format),
+		DryRun:    dryRun,
+		Created:   make([]model.ImportRow, 0),
+		Skipped:   make([]model.ImportRow, 0),
+		Conflicts: make([]model.ImportRow, 0),
+		Invalid:   make([]model.ImportRow, 0),
+	}
+
+	create := s.CreatePasswordCard
+	if dryRun {
+		var err error
+		create, err = s.dryRunCreate(ownerID)
+		if err != nil {
+			return nil, fmt.Errorf("error importing password cards: %w", err)
+		}
+	}
+
+	// the folders of ownerID by parent ID and name, read on the first entry
+	// in a folder
+	var folderIDs map[string]string
+
+	for _, entry := range entries {
+		row := model.ImportRow{
+			Row:  entry.Row,
+			Name: entry.PasswordCard.Name,
+			URL:  entry.PasswordCard.URL,
+		}
+
+		var errUnsupported importer.ErrUnsupportedItem
+		switch {
+		case errors.As(entry.Err, &errUnsupported):
+			row.Error = errUnsupported.Error()
+			report.Skipped = append(report.Skipped, row)
+			continue
+		case entry.Err != nil:
+			row.Error = entry.Err.Error()
+			report.Invalid = append(report.Invalid, row)
+			continue
+		}
+
+		if len(entry.Folder) > 0 && !dryRun {
+			if folderIDs == nil {
+				var err error
+				folderIDs, err = s.folderIDsByKey(ownerID)
+				if err != nil {
+					return report, fmt.Errorf("error importing password cards: %w", err)
+				}
+			}
+
+			folderID, err := s.importFolder(ownerID, entry.Folder, folderIDs)
+			if err != nil {
+				return report, fmt.Errorf("error importing password cards: %w", err)
+			}
+			entry.PasswordCard.FolderID = folderID
+		}
+
+		passwordCard, err := create(ownerID, entry.PasswordCard)
+
+		var errExists repository.ErrPasswordCardAlreadyExists
+		var errWeak ErrWeakPassword
+		switch {
+		case errors.As(err, &errExists):
+			row.Error = errExists.Error()
+			report.Conflicts = append(report.Conflicts, row)
+		case errors.As(err, &errWeak):
+			row.Error = errWeak.Error()
+			report.Invalid = append(report.Invalid, row)
+		case err != nil:
+			return report, fmt.Errorf("error importing password cards: %w", err)
+		default:
+			if !dryRun {
+				row.ID = passwordCard.ID
+			}
+			report.Created = append(report.Created, row)
+		}
+	}
+
+	return report, nil
+}
+
+// folderIDsByKey returns the IDs of the folders of ownerID by the key of their
+// parent and name, see folderKey.
+func (s *PasswordCardService) folderIDsByKey(ownerID string) (map[string]string, error) {
+	folders, err := s.ListFolders(ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	folderIDs := make(map[string]string, len(folders))
+	for _, folder := range folders {
+		folderIDs[folderKey(folder.ParentID, folder.Name)] = folder.ID
+	}
+
+	return folderIDs, nil
+}
+
+// importFolder returns the ID of the folder of ownerID at path, creating the
+// missing folders of the path. folderIDs gets the created folders.
+func (s *PasswordCardService) importFolder(ownerID string, path []string, folderIDs map[string]string) (string, error) {
+	parentID := ""
+	for _, name := range path {
+		key := folderKey(parentID, name)
+		id, ok := folderIDs[key]
+		if !ok {
+			folder, err := s.CreateFolder(ownerID, model.FolderRequest{Name: name, ParentID: parentID})
+			if err != nil {
+				return "", err
+			}
+			id = folder.ID
+			folderIDs[key] = id
+		}
+		parentID = id
+	}
+
+	return parentID, nil
+}
+
+// folderKey identifies a folder by its parent and name, which are unique
+// together.
+func folderKey(parentID, name string) string {
+	return parentID + "/" + name
+}
+
+// dryRunCreate returns a replacement of CreatePasswordCard for the dry runs.
+// It fails like CreatePasswordCard would, without storing anything, taking
+// into account the cards it already accepted.
+func (s *PasswordCardService) dryRunCreate(ownerID string) (func(string, model.PasswordCard) (*model.PasswordCard, error), error) {
+	passwordCards, err := s.passwordCardRepository.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	// the cards without a URL, like the secure notes, never conflict
+	takenURLs := make(map[string]bool)
+	for _, passwordCard := range passwordCards {
+		if passwordCard.OwnerID == ownerID && passwordCard.URL != "" {
+			takenURLs[passwordCard.URL] = true
+		}
+	}
+
+	return func(_ string, newPasswordCard model.PasswordCard) (*model.PasswordCard, error) {
+		if takenURLs[newPasswordCard.URL] {
+			return nil, repository.ErrPasswordCardAlreadyExists{URL: newPasswordCard.URL}
+		}
+
+		if err := s.estimateStrength(&newPasswordCard, true); err != nil {
+			return nil, err
+		}
+
+		if newPasswordCard.URL != "" {
+			takenURLs[newPasswordCard.URL] = true
+		}
+
+		return &newPasswordCard, nil
+	}, nil
+}
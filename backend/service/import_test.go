@@ -0,0 +1,161 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/CaioTeixeira95/password-manager/backend/importer"
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingInsertRepository fails the inserts once the first ones succeeded.
+type failingInsertRepository struct {
+	repository.PasswordCardStore
+	inserts int
+}
+
+func (r *failingInsertRepository) Insert(newPasswordCard model.PasswordCard) error {
+	if r.inserts == 0 {
+		return errors.New("disk is full")
+	}
+	r.inserts--
+	return r.PasswordCardStore.Insert(newPasswordCard)
+}
+
+func TestImportPasswordCards(t *testing.T) {
+	r := repository.CustomPasswordCardRepository([]model.PasswordCard{
+		{ID: "card-id-1", Name: "AWS", Username: "admin", Password: "supersecret", URL: "https://aws.com/login"},
+	})
+	s := NewPasswordCardService(r)
+	s.SetMinPasswordScore(1)
+
+	entries := []importer.Entry{
+		{Row: 2, PasswordCard: model.PasswordCard{ID: "card-id-2", Name: "GCP", Username: "ops", Password: "kX9#mQ2$vL7!pR4@", URL: "https://cloud.google.com/"}},
+		{Row: 3, PasswordCard: model.PasswordCard{ID: "card-id-3", Name: "AWS", Username: "root", Password: "kX9#mQ2$vL7!pR4@", URL: "https://aws.com/login"}},
+		{Row: 4, PasswordCard: model.PasswordCard{ID: "card-id-4", Name: "GitHub", Password: "kX9#mQ2$vL7!pR4@", URL: "https://github.com/"}, Err: errors.New("username can't be empty")},
+		{Row: 6, PasswordCard: model.PasswordCard{ID: "card-id-5", Name: "Wifi", Username: "me", Password: "password", URL: "https://router.local/"}},
+		{Row: 7, PasswordCard: model.PasswordCard{ID: "card-id-6", Name: "Notes"}, Err: importer.ErrUnsupportedItem{Reason: "secure notes aren't supported"}},
+		{Row: 8, PasswordCard: model.PasswordCard{ID: "card-id-7", Name: "GCP again", Username: "ops", Password: "kX9#mQ2$vL7!pR4@", URL: "https://cloud.google.com/"}},
+	}
+
+	t.Run("🎉 reports what would be imported without storing anything", func(t *testing.T) {
+		report, err := s.ImportPasswordCards("", importer.FormatChrome, entries, true)
+		require.NoError(t, err)
+		assert.Equal(t, &model.ImportReport{
+			Format: "chrome",
+			DryRun: true,
+			Created: []model.ImportRow{
+				{Row: 2, Name: "GCP", URL: "https://cloud.google.com/"},
+			},
+			Skipped: []model.ImportRow{
+				{Row: 7, Name: "Notes", Error: "secure notes aren't supported"},
+			},
+			Conflicts: []model.ImportRow{
+				{Row: 3, Name: "AWS", URL: "https://aws.com/login", Error: `password with URL "https://aws.com/login" already exists`},
+				{Row: 8, Name: "GCP again", URL: "https://cloud.google.com/", Error: `password with URL "https://cloud.google.com/" already exists`},
+			},
+			Invalid: []model.ImportRow{
+				{Row: 4, Name: "GitHub", URL: "https://github.com/", Error: "username can't be empty"},
+				{Row: 6, Name: "Wifi", URL: "https://router.local/", Error: "password is too weak: its strength score is 0, the minimum is 1"},
+			},
+		}, report)
+
+		passwordCards, err := r.GetAll()
+		require.NoError(t, err)
+		assert.Len(t, passwordCards, 1)
+	})
+
+	t.Run("🎉 imports the valid entries and reports the others", func(t *testing.T) {
+		report, err := s.ImportPasswordCards("", importer.FormatChrome, entries, false)
+		require.NoError(t, err)
+		assert.Equal(t, &model.ImportReport{
+			Format: "chrome",
+			Created: []model.ImportRow{
+				{Row: 2, ID: "card-id-2", Name: "GCP", URL: "https://cloud.google.com/"},
+			},
+			Skipped: []model.ImportRow{
+				{Row: 7, Name: "Notes", Error: "secure notes aren't supported"},
+			},
+			Conflicts: []model.ImportRow{
+				{Row: 3, Name: "AWS", URL: "https://aws.com/login", Error: `password with URL "https://aws.com/login" already exists`},
+				{Row: 8, Name: "GCP again", URL: "https://cloud.google.com/", Error: `password with URL "https://cloud.google.com/" already exists`},
+			},
+			Invalid: []model.ImportRow{
+				{Row: 4, Name: "GitHub", URL: "https://github.com/", Error: "username can't be empty"},
+				{Row: 6, Name: "Wifi", URL: "https://router.local/", Error: "password is too weak: its strength score is 0, the minimum is 1"},
+			},
+		}, report)
+
+		passwordCard, err := s.GetPasswordCard("", "card-id-2")
+		require.NoError(t, err)
+		assert.Equal(t, "kX9#mQ2$vL7!pR4@", passwordCard.Password)
+
+		_, err = s.GetPasswordCard("", "card-id-3")
+		assert.ErrorIs(t, err, repository.ErrPasswordCardNotFound{ID: "card-id-3"})
+	})
+
+	t.Run("🎉 doesn't report the items without a URL as conflicts in dry runs", func(t *testing.T) {
+		s := NewPasswordCardService(repository.CustomPasswordCardRepository([]model.PasswordCard{
+			{ID: "card-id-1", Type: model.ItemTypeSecureNote, Name: "Wifi", Notes: "fridge"},
+		}))
+
+		report, err := s.ImportPasswordCards("", importer.FormatBitwardenJSON, []importer.Entry{
+			{Row: 1, PasswordCard: model.PasswordCard{ID: "card-id-2", Type: model.ItemTypeSecureNote, Name: "Alarm", Notes: "1234"}},
+			{Row: 2, PasswordCard: model.PasswordCard{ID: "card-id-3", Type: model.ItemTypeSecureNote, Name: "Safe", Notes: "4321"}},
+		}, true)
+		require.NoError(t, err)
+		assert.Equal(t, []model.ImportRow{{Row: 1, Name: "Alarm"}, {Row: 2, Name: "Safe"}}, report.Created)
+		assert.Empty(t, report.Conflicts)
+	})
+
+	t.Run("🎉 puts the cards in the folders of the entries", func(t *testing.T) {
+		s := NewPasswordCardService(repository.NewPasswordCardRepository())
+		work, err := s.CreateFolder("user-id-1", model.FolderRequest{Name: "Work"})
+		require.NoError(t, err)
+
+		report, err := s.ImportPasswordCards("user-id-1", importer.FormatKeePass, []importer.Entry{
+			{Row: 1, PasswordCard: model.PasswordCard{ID: "card-id-2", Name: "GCP", Username: "ops", Password: "kX9#mQ2$vL7!pR4@", URL: "https://cloud.google.com/"}, Folder: []string{"Work", "Cloud"}},
+			{Row: 2, PasswordCard: model.PasswordCard{ID: "card-id-3", Name: "AWS", Username: "ops", Password: "kX9#mQ2$vL7!pR4@", URL: "https://aws.com/"}, Folder: []string{"Work", "Cloud"}},
+			{Row: 3, PasswordCard: model.PasswordCard{ID: "card-id-4", Name: "Wifi", Username: "me", Password: "kX9#mQ2$vL7!pR4@", URL: "https://router.local/"}},
+		}, false)
+		require.NoError(t, err)
+		assert.Len(t, report.Created, 3)
+
+		folders, err := s.ListFolders("user-id-1")
+		require.NoError(t, err)
+		require.Len(t, folders, 2)
+		assert.Equal(t, "Cloud", folders[1].Name)
+		assert.Equal(t, work.ID, folders[1].ParentID)
+
+		for id, folderID := range map[string]string{"card-id-2": folders[1].ID, "card-id-3": folders[1].ID, "card-id-4": ""} {
+			passwordCard, err := s.GetPasswordCard("user-id-1", id)
+			require.NoError(t, err)
+			assert.Equal(t, folderID, passwordCard.FolderID)
+		}
+	})
+
+	t.Run("returns the report of the entries imported before an error", func(t *testing.T) {
+		r := &failingInsertRepository{PasswordCardStore: repository.NewPasswordCardRepository(), inserts: 1}
+		s := NewPasswordCardService(r)
+
+		report, err := s.ImportPasswordCards("", importer.FormatChrome, []importer.Entry{entries[0], entries[1]}, false)
+		assert.EqualError(t, err, "error importing password cards: error creating a new password card: disk is full")
+		require.NotNil(t, report)
+		assert.Equal(t, []model.ImportRow{{Row: 2, ID: "card-id-2", Name: "GCP", URL: "https://cloud.google.com/"}}, report.Created)
+
+		passwordCards, err := r.GetAll()
+		require.NoError(t, err)
+		assert.Len(t, passwordCards, 1)
+	})
+
+	t.Run("returns error when the vault is locked", func(t *testing.T) {
+		vaultService := NewVaultService(repository.NewVaultHeaderRepository())
+		s := NewEncryptedPasswordCardService(repository.NewPasswordCardRepository(), vaultService)
+
+		_, err := s.ImportPasswordCards("", importer.FormatChrome, entries[:1], false)
+		assert.ErrorIs(t, err, ErrVaultLocked)
+	})
+}
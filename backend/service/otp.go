@@ -0,0 +1,137 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/otp"
+)
+
+var (
+	// ErrNoTOTP is returned when generating a code for a card without TOTP
+	// key.
+	ErrNoTOTP = errors.New("the password card has no TOTP key")
+	// ErrNoHOTP is returned when generating a code for, or resynchronizing,
+	// a card without HOTP key.
+	ErrNoHOTP = errors.New("the password card has no HOTP key")
+	// ErrHOTPResync is returned when the codes given to resynchronize the
+	// HOTP counter aren't consecutive codes of the key.
+	ErrHOTPResync = errors.New("the codes don't match the HOTP key")
+)
+
+// HOTPResyncWindow is how many counters past the stored one are searched for
+// the codes of a token being resynchronized.
+const HOTPResyncWindow = 100
+
+// GenerateTOTP returns the current code of the TOTP key of a password card
+// owned by ownerID.
+func (s *PasswordCardService) GenerateTOTP(ownerID, passwordCardID string) (*model.TOTPCode, error) {
+	passwordCard, err := s.GetPasswordCard(ownerID, passwordCardID)
+	if err != nil {
+		return nil, fmt.Errorf("error generating TOTP code: %w", err)
+	}
+
+	if passwordCard.TOTP == "" {
+		return nil, fmt.Errorf("error generating TOTP code: %w", ErrNoTOTP)
+	}
+
+	key, err := otp.ParseTOTP(passwordCard.TOTP)
+	if err != nil {
+		return nil, fmt.Errorf("error generating TOTP code: %w", err)
+	}
+
+	now := s.now()
+	code, err := key.Code(now)
+	if err != nil {
+		return nil, fmt.Errorf("error generating TOTP code: %w", err)
+	}
+
+	return &model.TOTPCode{
+		Code:             code,
+		SecondsRemaining: int(key.Remaining(now).Seconds()),
+		Period:           int(key.Period.Seconds()),
+	}, nil
+}
+
+// GenerateHOTP returns the next code of the HOTP key of a password card owned
+// by ownerID. The counter is incremented by the repository, so every code is
+// only issued once.
+func (s *PasswordCardService) GenerateHOTP(ownerID, passwordCardID string) (*model.HOTPCode, error) {
+	key, err := s.hotpKey(ownerID, passwordCardID)
+	if err != nil {
+		return nil, fmt.Errorf("error generating HOTP code: %w", err)
+	}
+
+	counter, err := s.passwordCardRepository.IncrementHOTPCounter(passwordCardID)
+	if err != nil {
+		return nil, fmt.Errorf("error generating HOTP code: %w", err)
+	}
+
+	code, err := key.CounterCode(counter)
+	if err != nil {
+		return nil, fmt.Errorf("error generating HOTP code: %w", err)
+	}
+
+	return &model.HOTPCode{Code: code, Counter: counter}, nil
+}
+
+// ResyncHOTP moves the HOTP counter of a password card owned by ownerID past
+// two consecutive codes of its token, for tokens that generated codes the
+// server never saw. It returns the new counter.
+func (s *PasswordCardService) ResyncHOTP(ownerID, passwordCardID, code1, code2 string) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, err := s.hotpKey(ownerID, passwordCardID)
+	if err != nil {
+		return 0, fmt.Errorf("error resynchronizing HOTP counter: %w", err)
+	}
+
+	passwordCard, err := s.passwordCardRepository.GetByID(passwordCardID)
+	if err != nil {
+		return 0, fmt.Errorf("error resynchronizing HOTP counter: %w", err)
+	}
+
+	counter, ok, err := key.Resync(code1, code2, passwordCard.HOTPCounter, HOTPResyncWindow)
+	if err != nil {
+		return 0, fmt.Errorf("error resynchronizing HOTP counter: %w", err)
+	}
+	if !ok {
+		return 0, fmt.Errorf("error resynchronizing HOTP counter: %w", ErrHOTPResync)
+	}
+
+	if err := s.passwordCardRepository.SetHOTPCounter(passwordCardID, counter); err != nil {
+		return 0, fmt.Errorf("error resynchronizing HOTP counter: %w", err)
+	}
+
+	return counter, nil
+}
+
+func (s *PasswordCardService) hotpKey(ownerID, passwordCardID string) (*otp.Key, error) {
+	passwordCard, err := s.GetPasswordCard(ownerID, passwordCardID)
+	if err != nil {
+		return nil, err
+	}
+
+	if passwordCard.HOTP == "" {
+		return nil, ErrNoHOTP
+	}
+
+	return otp.ParseHOTP(passwordCard.HOTP)
+}
+
+// initialHOTPCounter returns the counter of a new HOTP key, 0 for the cards
+// without one.
+func initialHOTPCounter(hotp string) (uint64, error) {
+	if hotp == "" {
+		return 0, nil
+	}
+
+	key, err := otp.ParseHOTP(hotp)
+	if err != nil {
+		return 0, fmt.Errorf("invalid HOTP: %w", err)
+	}
+
+	return key.Counter, nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTOTP(t *testing.T) {
+	r := repository.NewPasswordCardRepository()
+	vs := newTestVaultService(repository.NewVaultHeaderRepository())
+	s := NewEncryptedPasswordCardService(r, vs)
+	require.NoError(t, vs.Unlock([]byte("master")))
+
+	now := time.Unix(59, 0)
+	s.now = func() time.Time { return now }
+
+	// the RFC 6238 SHA1 test key
+	totp := "otpauth://totp/AWS:admin?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&digits=8"
+
+	_, err := s.CreatePasswordCard("user-id-1", model.PasswordCard{ID: "card-id-1", Name: "AWS", Username: "admin", Password: "supersecret", URL: "https://aws.com/login", TOTP: totp})
+	require.NoError(t, err)
+	_, err = s.CreatePasswordCard("user-id-1", model.PasswordCard{ID: "card-id-2", Name: "GCP", Username: "ops", Password: "supersecret", URL: "https://cloud.google.com/"})
+	require.NoError(t, err)
+
+	t.Run("🎉 seals the TOTP key", func(t *testing.T) {
+		stored, err := r.GetByID("card-id-1")
+		require.NoError(t, err)
+		assert.True(t, vault.IsEncrypted(stored.TOTP))
+
+		passwordCard, err := s.GetPasswordCard("user-id-1", "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, totp, passwordCard.TOTP)
+	})
+
+	t.Run("🎉 generates the current code", func(t *testing.T) {
+		code, err := s.GenerateTOTP("user-id-1", "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, &model.TOTPCode{Code: "94287082", SecondsRemaining: 1, Period: 30}, code)
+	})
+
+	t.Run("🎉 keeps the TOTP key when it's sent back masked", func(t *testing.T) {
+		_, err := s.UpdatePasswordCard("user-id-1", model.PasswordCard{ID: "card-id-1", Name: "AWS", Username: "root", Password: model.MaskedPassword, URL: "https://aws.com/login", TOTP: model.MaskedPassword})
+		require.NoError(t, err)
+
+		passwordCard, err := s.GetPasswordCard("user-id-1", "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, "root", passwordCard.Username)
+		assert.Equal(t, "supersecret", passwordCard.Password)
+		assert.Equal(t, totp, passwordCard.TOTP)
+	})
+
+	t.Run("returns error for cards without TOTP key", func(t *testing.T) {
+		_, err := s.GenerateTOTP("user-id-1", "card-id-2")
+		assert.ErrorIs(t, err, ErrNoTOTP)
+	})
+
+	t.Run("returns error for the cards of other owners", func(t *testing.T) {
+		_, err := s.GenerateTOTP("user-id-2", "card-id-1")
+		assert.ErrorAs(t, err, &repository.ErrPasswordCardNotFound{})
+	})
+}
+
+func TestGenerateHOTP(t *testing.T) {
+	r := repository.NewPasswordCardRepository()
+	vs := newTestVaultService(repository.NewVaultHeaderRepository())
+	s := NewEncryptedPasswordCardService(r, vs)
+	require.NoError(t, vs.Unlock([]byte("master")))
+
+	// the RFC 4226 test key
+	hotp := "otpauth://hotp/VPN:admin?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&counter=1"
+
+	passwordCard, err := s.CreatePasswordCard("user-id-1", model.PasswordCard{ID: "card-id-1", Name: "VPN", Username: "admin", Password: "supersecret", URL: "https://vpn.example.com/", HOTP: hotp})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), passwordCard.HOTPCounter)
+
+	_, err = s.CreatePasswordCard("user-id-1", model.PasswordCard{ID: "card-id-2", Name: "GCP", Username: "ops", Password: "supersecret", URL: "https://cloud.google.com/"})
+	require.NoError(t, err)
+
+	t.Run("🎉 seals the HOTP key", func(t *testing.T) {
+		stored, err := r.GetByID("card-id-1")
+		require.NoError(t, err)
+		assert.True(t, vault.IsEncrypted(stored.HOTP))
+
+		passwordCard, err := s.GetPasswordCard("user-id-1", "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, hotp, passwordCard.HOTP)
+	})
+
+	t.Run("🎉 generates the codes of the following counters", func(t *testing.T) {
+		code, err := s.GenerateHOTP("user-id-1", "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, &model.HOTPCode{Code: "287082", Counter: 1}, code)
+
+		code, err = s.GenerateHOTP("user-id-1", "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, &model.HOTPCode{Code: "359152", Counter: 2}, code)
+	})
+
+	t.Run("🎉 keeps the counter when the HOTP key is sent back", func(t *testing.T) {
+		passwordCard, err := s.UpdatePasswordCard("user-id-1", model.PasswordCard{ID: "card-id-1", Name: "VPN", Username: "root", Password: model.MaskedPassword, URL: "https://vpn.example.com/", HOTP: model.MaskedPassword})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(3), passwordCard.HOTPCounter)
+
+		passwordCard, err = s.UpdatePasswordCard("user-id-1", model.PasswordCard{ID: "card-id-1", Name: "VPN", Username: "root", Password: model.MaskedPassword, URL: "https://vpn.example.com/", HOTP: hotp})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(3), passwordCard.HOTPCounter)
+
+		passwordCard, err = s.GetPasswordCard("user-id-1", "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, "root", passwordCard.Username)
+		assert.Equal(t, hotp, passwordCard.HOTP)
+		assert.Equal(t, uint64(3), passwordCard.HOTPCounter)
+	})
+
+	t.Run("returns error when the codes aren't consecutive", func(t *testing.T) {
+		_, err := s.ResyncHOTP("user-id-1", "card-id-1", "162583", "254676")
+		assert.ErrorIs(t, err, ErrHOTPResync)
+	})
+
+	t.Run("🎉 resynchronizes the counter", func(t *testing.T) {
+		// the codes of the counters 6 and 7
+		counter, err := s.ResyncHOTP("user-id-1", "card-id-1", "287922", "162583")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(8), counter)
+
+		code, err := s.GenerateHOTP("user-id-1", "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, &model.HOTPCode{Code: "399871", Counter: 8}, code)
+	})
+
+	t.Run("🎉 resets the counter when the HOTP key changes", func(t *testing.T) {
+		passwordCard, err := s.UpdatePasswordCard("user-id-1", model.PasswordCard{ID: "card-id-1", Name: "VPN", Username: "root", Password: model.MaskedPassword, URL: "https://vpn.example.com/", HOTP: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), passwordCard.HOTPCounter)
+
+		code, err := s.GenerateHOTP("user-id-1", "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, &model.HOTPCode{Code: "755224", Counter: 0}, code)
+	})
+
+	t.Run("returns error for cards without HOTP key", func(t *testing.T) {
+		_, err := s.GenerateHOTP("user-id-1", "card-id-2")
+		assert.ErrorIs(t, err, ErrNoHOTP)
+
+		_, err = s.ResyncHOTP("user-id-1", "card-id-2", "755224", "287082")
+		assert.ErrorIs(t, err, ErrNoHOTP)
+	})
+
+	t.Run("returns error for the cards of other owners", func(t *testing.T) {
+		_, err := s.GenerateHOTP("user-id-2", "card-id-1")
+		assert.ErrorAs(t, err, &repository.ErrPasswordCardNotFound{})
+	})
+}
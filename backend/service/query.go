@@ -0,0 +1,261 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+)
+
+// ErrInvalidCursor is returned for cursors that weren't returned by a previous
+// page of the same query.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursor is the position after the last card of a page. It holds the sort key
+// rather than an offset, so cards added or removed meanwhile don't shift the
+// following pages.
+type cursor struct {
+	Sort string    `json:"s"`
+	Name string    `json:"n,omitempty"`
+	Time time.Time `json:"t"`
+	ID   string    `json:"i"`
+}
+
+// SearchPasswordCards returns the page of the password cards owned by ownerID
+// selected by the query.
+func (s *PasswordCardService) SearchPasswordCards(ownerID string, query model.PasswordCardQuery) (*model.PasswordCardPage, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	if query.Breached != nil && s.breachChecker == nil {
+		return nil, ErrBreachCheckDisabled
+	}
+
+	// the folder filter includes the subfolders
+	var folderIDs map[string]bool
+	if query.Folder != "" {
+		if err := s.checkFolder(ownerID, query.Folder); err != nil {
+			return nil, err
+		}
+
+		folders, err := s.folderRepository.ListByOwnerID(ownerID)
+		if err != nil {
+			return nil, fmt.Errorf("error searching password cards: %w", err)
+		}
+		folderIDs = subfolderIDs(folders, query.Folder)
+	}
+
+	passwordCards, err := s.passwordCardRepository.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("error searching password cards: %w", err)
+	}
+
+	// only the passwords are sealed, the cards are matched before unsealing
+	matched := make([]model.PasswordCard, 0, len(passwordCards))
+	for _, passwordCard := range passwordCards {
+		if folderIDs != nil && !folderIDs[passwordCard.FolderID] {
+			continue
+		}
+
+		if passwordCard.OwnerID == ownerID && matchesQuery(passwordCard, query) {
+			matched = append(matched, passwordCard)
+		}
+	}
+
+	// except for the breached filter, which needs every matched password
+	unsealed := query.Breached != nil
+	if unsealed {
+		breached := matched[:0]
+		for _, passwordCard := range matched {
+			passwordCard, err = s.unseal(passwordCard)
+			if err != nil {
+				return nil, fmt.Errorf("error searching password cards: %w", err)
+			}
+
+			// only the logins are checked
+			if passwordCard.Breached != nil && *passwordCard.Breached == *query.Breached {
+				breached = append(breached, passwordCard)
+			}
+		}
+		matched = breached
+	}
+
+	field, descending := query.SortField()
+	compare := func(a, b model.PasswordCard) int {
+		c := comparePasswordCards(a, b, field)
+		if descending {
+			return -c
+		}
+		return c
+	}
+
+	sort.Slice(matched, func(i, j int) bool { return compare(matched[i], matched[j]) < 0 })
+
+	start := query.Offset
+	if query.Cursor != "" {
+		after, err := decodeCursor(query.Cursor, query.Sort)
+		if err != nil {
+			return nil, err
+		}
+		start = sort.Search(len(matched), func(i int) bool { return compare(after, matched[i]) < 0 })
+	}
+	if start > len(matched) {
+		start = len(matched)
+	}
+
+	end := len(matched)
+	if query.Limit > 0 && start+query.Limit < end {
+		end = start + query.Limit
+	}
+
+	page := &model.PasswordCardPage{
+		PasswordCards: make([]model.PasswordCard, 0, end-start),
+		Total:         len(matched),
+	}
+
+	for _, passwordCard := range matched[start:end] {
+		if !unsealed {
+			passwordCard, err = s.unseal(passwordCard)
+			if err != nil {
+				return nil, fmt.Errorf("error searching password cards: %w", err)
+			}
+		}
+
+		page.PasswordCards = append(page.PasswordCards, passwordCard)
+	}
+
+	if end < len(matched) {
+		page.NextCursor, err = encodeCursor(matched[end-1], query.Sort, field)
+		if err != nil {
+			return nil, fmt.Errorf("error searching password cards: %w", err)
+		}
+	}
+
+	return page, nil
+}
+
+func matchesQuery(passwordCard model.PasswordCard, query model.PasswordCardQuery) bool {
+	if search := strings.ToLower(strings.TrimSpace(query.Search)); search != "" {
+		if !strings.Contains(strings.ToLower(passwordCard.Name), search) &&
+			!strings.Contains(strings.ToLower(passwordCard.Username), search) &&
+			!strings.Contains(strings.ToLower(passwordCard.URL), search) &&
+			!matchesCustomFields(passwordCard, search) {
+			return false
+		}
+	}
+
+	if query.Type != "" && passwordCard.Kind() != query.Type {
+		return false
+	}
+
+	if tag := strings.TrimSpace(query.Tag); tag != "" && !passwordCard.HasTag(tag) {
+		return false
+	}
+
+	if host := strings.ToLower(strings.TrimSpace(query.Host)); host != "" {
+		cardHost := urlHost(passwordCard.URL)
+		if cardHost != host && !strings.HasSuffix(cardHost, "."+host) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchesCustomFields tells whether the name or the value of a custom field
+// of the card contains search. The values of the hidden fields are secrets,
+// they aren't searched.
+func matchesCustomFields(passwordCard model.PasswordCard, search string) bool {
+	for _, field := range passwordCard.CustomFields {
+		if strings.Contains(strings.ToLower(field.Name), search) {
+			return true
+		}
+		if field.Type != model.CustomFieldHidden && strings.Contains(strings.ToLower(field.Value), search) {
+			return true
+		}
+	}
+	return false
+}
+
+// urlHost returns the lowercase host name of rawURL, which may lack the
+// scheme.
+func urlHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err == nil && u.Host == "" {
+		u, err = url.Parse("//" + rawURL)
+	}
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// comparePasswordCards orders the cards by field, the ID breaks the ties so
+// the order is total and the cursors are stable.
+func comparePasswordCards(a, b model.PasswordCard, field string) int {
+	var c int
+	switch field {
+	case model.SortByName:
+		c = strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	case model.SortByCreatedAt:
+		c = compareTimes(a.CreatedAt, b.CreatedAt)
+	case model.SortByUpdatedAt:
+		c = compareTimes(a.UpdatedAt, b.UpdatedAt)
+	}
+
+	if c != 0 {
+		return c
+	}
+	return strings.Compare(a.ID, b.ID)
+}
+
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
+
+func encodeCursor(last model.PasswordCard, sortOrder, field string) (string, error) {
+	c := cursor{Sort: sortOrder, ID: last.ID}
+	switch field {
+	case model.SortByName:
+		c.Name = last.Name
+	case model.SortByCreatedAt:
+		c.Time = last.CreatedAt
+	case model.SortByUpdatedAt:
+		c.Time = last.UpdatedAt
+	}
+
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeCursor returns a card holding the sort key of the cursor.
+func decodeCursor(encoded, sortOrder string) (model.PasswordCard, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return model.PasswordCard{}, ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.Sort != sortOrder || c.ID == "" {
+		return model.PasswordCard{}, ErrInvalidCursor
+	}
+
+	return model.PasswordCard{ID: c.ID, Name: c.Name, CreatedAt: c.Time, UpdatedAt: c.Time}, nil
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchPasswordCards(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2023, 8, d, 10, 0, 0, 0, time.UTC) }
+
+	awsCard := model.PasswordCard{ID: "card-id-1", Name: "AWS", Username: "admin", Password: "secret", URL: "https://aws.amazon.com/login", CreatedAt: day(1), UpdatedAt: day(5)}
+	gcpCard := model.PasswordCard{ID: "card-id-2", Name: "Google Cloud", Username: "ops", Password: "secret", URL: "https://cloud.google.com/", CreatedAt: day(2), UpdatedAt: day(3)}
+	gmailCard := model.PasswordCard{ID: "card-id-3", Name: "gmail", Username: "me@gmail.com", Password: "secret", URL: "mail.google.com", CreatedAt: day(3), UpdatedAt: day(4)}
+	otherCard := model.PasswordCard{ID: "card-id-4", Name: "AWS", Username: "admin", Password: "secret", URL: "https://aws.amazon.com/login", OwnerID: "user-id-2"}
+
+	s := NewPasswordCardService(repository.CustomPasswordCardRepository([]model.PasswordCard{gmailCard, awsCard, otherCard, gcpCard}))
+
+	search := func(t *testing.T, query model.PasswordCardQuery) *model.PasswordCardPage {
+		page, err := s.SearchPasswordCards("", query)
+		require.NoError(t, err)
+		return page
+	}
+
+	t.Run("🎉 returns every card of the owner in creation order", func(t *testing.T) {
+		page := search(t, model.PasswordCardQuery{})
+		assert.Equal(t, &model.PasswordCardPage{PasswordCards: []model.PasswordCard{awsCard, gcpCard, gmailCard}, Total: 3}, page)
+	})
+
+	t.Run("🎉 searches the name, username and URL", func(t *testing.T) {
+		assert.Equal(t, []model.PasswordCard{gcpCard}, search(t, model.PasswordCardQuery{Search: "CLOUD"}).PasswordCards)
+		assert.Equal(t, []model.PasswordCard{awsCard}, search(t, model.PasswordCardQuery{Search: "admin"}).PasswordCards)
+		assert.Equal(t, []model.PasswordCard{gcpCard, gmailCard}, search(t, model.PasswordCardQuery{Search: "google"}).PasswordCards)
+	})
+
+	t.Run("🎉 filters by host and subdomains", func(t *testing.T) {
+		assert.Equal(t, []model.PasswordCard{gcpCard, gmailCard}, search(t, model.PasswordCardQuery{Host: "google.com"}).PasswordCards)
+		assert.Equal(t, []model.PasswordCard{gmailCard}, search(t, model.PasswordCardQuery{Host: "Mail.Google.com"}).PasswordCards)
+		assert.Empty(t, search(t, model.PasswordCardQuery{Host: "gle.com"}).PasswordCards)
+	})
+
+	t.Run("🎉 sorts by name and modification date", func(t *testing.T) {
+		assert.Equal(t, []model.PasswordCard{awsCard, gmailCard, gcpCard}, search(t, model.PasswordCardQuery{Sort: "name"}).PasswordCards)
+		assert.Equal(t, []model.PasswordCard{gcpCard, gmailCard, awsCard}, search(t, model.PasswordCardQuery{Sort: "-name"}).PasswordCards)
+		assert.Equal(t, []model.PasswordCard{awsCard, gmailCard, gcpCard}, search(t, model.PasswordCardQuery{Sort: "-updated_at"}).PasswordCards)
+	})
+
+	t.Run("🎉 paginates with offsets", func(t *testing.T) {
+		page := search(t, model.PasswordCardQuery{Limit: 2, Offset: 1})
+		assert.Equal(t, []model.PasswordCard{gcpCard, gmailCard}, page.PasswordCards)
+		assert.Equal(t, 3, page.Total)
+		assert.Empty(t, page.NextCursor)
+
+		page = search(t, model.PasswordCardQuery{Offset: 5})
+		assert.Empty(t, page.PasswordCards)
+		assert.Equal(t, 3, page.Total)
+	})
+
+	t.Run("🎉 paginates with cursors", func(t *testing.T) {
+		page := search(t, model.PasswordCardQuery{Sort: "-updated_at", Limit: 2})
+		assert.Equal(t, []model.PasswordCard{awsCard, gmailCard}, page.PasswordCards)
+		require.NotEmpty(t, page.NextCursor)
+
+		// a card added before the cursor doesn't shift the next page
+		_, err := s.CreatePasswordCard("", model.PasswordCard{ID: "card-id-5", Name: "new", Username: "new", Password: "secret", URL: "https://new.com"})
+		require.NoError(t, err)
+		defer s.DeletePasswordCard("", "card-id-5")
+
+		page = search(t, model.PasswordCardQuery{Sort: "-updated_at", Limit: 2, Cursor: page.NextCursor})
+		assert.Equal(t, []model.PasswordCard{gcpCard}, page.PasswordCards)
+		assert.Equal(t, 4, page.Total)
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("returns error for invalid cursors", func(t *testing.T) {
+		page := search(t, model.PasswordCardQuery{Sort: "name", Limit: 1})
+
+		_, err := s.SearchPasswordCards("", model.PasswordCardQuery{Sort: "-name", Cursor: page.NextCursor})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+
+		_, err = s.SearchPasswordCards("", model.PasswordCardQuery{Cursor: "not a cursor"})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+}
+
+// breachList is a BreachChecker counting the passwords of the map.
+type breachList map[string]int
+
+func (l breachList) Count(password string) (int, error) {
+	return l[password], nil
+}
+
+func TestSearchPasswordCardsBreached(t *testing.T) {
+	awsCard := model.PasswordCard{ID: "card-id-1", Name: "AWS", Username: "admin", Password: "secret", URL: "https://aws.amazon.com/login"}
+	gcpCard := model.PasswordCard{ID: "card-id-2", Name: "Google Cloud", Username: "ops", Password: "n0t-br3ached", URL: "https://cloud.google.com/"}
+
+	s := NewPasswordCardService(repository.CustomPasswordCardRepository([]model.PasswordCard{awsCard, gcpCard}))
+
+	t.Run("returns error without a breached passwords list", func(t *testing.T) {
+		_, err := s.SearchPasswordCards("", model.PasswordCardQuery{Breached: new(bool)})
+		assert.ErrorIs(t, err, ErrBreachCheckDisabled)
+
+		page, err := s.SearchPasswordCards("", model.PasswordCardQuery{})
+		require.NoError(t, err)
+		assert.Nil(t, page.PasswordCards[0].Breached)
+	})
+
+	s.SetBreachChecker(breachList{"secret": 3})
+
+	breached, notBreached := true, false
+	awsCard.Breached = &breached
+	gcpCard.Breached = &notBreached
+
+	t.Run("🎉 flags the breached passwords", func(t *testing.T) {
+		page, err := s.SearchPasswordCards("", model.PasswordCardQuery{})
+		require.NoError(t, err)
+		assert.Equal(t, []model.PasswordCard{awsCard, gcpCard}, page.PasswordCards)
+
+		passwordCard, err := s.GetPasswordCard("", "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, &awsCard, passwordCard)
+
+		passwordCard, err = s.CreatePasswordCard("", model.PasswordCard{ID: "card-id-3", Name: "new", Username: "new", Password: "secret", URL: "https://new.com"})
+		require.NoError(t, err)
+		defer s.DeletePasswordCard("", "card-id-3")
+		assert.Equal(t, &breached, passwordCard.Breached)
+	})
+
+	t.Run("🎉 filters the breached passwords", func(t *testing.T) {
+		page, err := s.SearchPasswordCards("", model.PasswordCardQuery{Breached: &breached, Limit: 1})
+		require.NoError(t, err)
+		assert.Equal(t, &model.PasswordCardPage{PasswordCards: []model.PasswordCard{awsCard}, Total: 1}, page)
+
+		page, err = s.SearchPasswordCards("", model.PasswordCardQuery{Breached: &notBreached})
+		require.NoError(t, err)
+		assert.Equal(t, []model.PasswordCard{gcpCard}, page.PasswordCards)
+	})
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/strength"
+)
+
+// HealthReport analyzes the logins owned by ownerID and reports the reused,
+// weak, old and breached passwords and the URLs without TLS.
+func (s *PasswordCardService) HealthReport(ownerID string, query model.HealthReportQuery) (*model.HealthReport, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	ownedPasswordCards, err := s.ListPasswordCards(ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("error reporting health: %w", err)
+	}
+
+	// only the logins have a password to analyze
+	passwordCards := make([]model.PasswordCard, 0, len(ownedPasswordCards))
+	for _, passwordCard := range ownedPasswordCards {
+		if passwordCard.Kind() == model.ItemTypeLogin {
+			passwordCards = append(passwordCards, passwordCard)
+		}
+	}
+
+	report := &model.HealthReport{
+		TotalCards:   len(passwordCards),
+		Reused:       make([][]model.HealthReportCard, 0),
+		Weak:         make([]model.HealthReportCard, 0),
+		Old:          make([]model.HealthReportCard, 0),
+		InsecureURLs: make([]model.HealthReportCard, 0),
+		Breached:     make([]model.HealthReportCard, 0),
+	}
+
+	// the passwords are only compared in memory, the report holds the cards
+	groups := make(map[string][]model.HealthReportCard)
+	var order []string
+	unhealthy := make(map[string]bool)
+
+	oldBefore := s.now().UTC().AddDate(0, 0, -query.MaxAgeDays)
+	for _, passwordCard := range passwordCards {
+		card := model.HealthReportCard{ID: passwordCard.ID, Name: passwordCard.Name, URL: passwordCard.URL}
+
+		if _, ok := groups[passwordCard.Password]; !ok {
+			order = append(order, passwordCard.Password)
+		}
+		groups[passwordCard.Password] = append(groups[passwordCard.Password], card)
+
+		passwordStrength := passwordCard.Strength
+		if passwordStrength == nil {
+			estimated := strength.EstimateCard(passwordCard)
+			passwordStrength = &estimated
+		}
+		if passwordStrength.Score < query.MinScore {
+			weak := card
+			weak.Score = &passwordStrength.Score
+			report.Weak = append(report.Weak, weak)
+			unhealthy[card.ID] = true
+		}
+
+		if changedAt := passwordChangedAt(passwordCard); changedAt.Before(oldBefore) {
+			old := card
+			if !changedAt.IsZero() {
+				old.PasswordChangedAt = &changedAt
+			}
+			report.Old = append(report.Old, old)
+			unhealthy[card.ID] = true
+		}
+
+		if u, err := url.Parse(passwordCard.URL); err == nil && strings.EqualFold(u.Scheme, "http") {
+			report.InsecureURLs = append(report.InsecureURLs, card)
+			unhealthy[card.ID] = true
+		}
+
+		if passwordCard.Breached != nil && *passwordCard.Breached {
+			report.Breached = append(report.Breached, card)
+			unhealthy[card.ID] = true
+		}
+	}
+
+	for _, password := range order {
+		if cards := groups[password]; len(cards) > 1 {
+			report.Reused = append(report.Reused, cards)
+			for _, card := range cards {
+				unhealthy[card.ID] = true
+			}
+		}
+	}
+
+	report.Score = 100
+	if len(passwordCards) > 0 {
+		report.Score = 100 * (len(passwordCards) - len(unhealthy)) / len(passwordCards)
+	}
+
+	return report, nil
+}
+
+// passwordChangedAt returns when the password last changed. The cards stored
+// before it was recorded fall back to their last update, which is zero, so
+// always old, for the oldest ones.
+func passwordChangedAt(passwordCard model.PasswordCard) time.Time {
+	if passwordCard.PasswordChangedAt.IsZero() {
+		return passwordCard.UpdatedAt
+	}
+	return passwordCard.PasswordChangedAt
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthReport(t *testing.T) {
+	now := time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+	lastYear := now.AddDate(-1, 0, -1)
+
+	r := repository.NewPasswordCardRepository()
+	s := NewPasswordCardService(r)
+
+	intPtr := func(i int) *int { return &i }
+
+	t.Run("🎉 reports a healthy empty vault", func(t *testing.T) {
+		report, err := s.HealthReport("", model.DefaultHealthReportQuery())
+		require.NoError(t, err)
+		assert.Equal(t, &model.HealthReport{
+			Score:        100,
+			Reused:       [][]model.HealthReportCard{},
+			Weak:         []model.HealthReportCard{},
+			Old:          []model.HealthReportCard{},
+			InsecureURLs: []model.HealthReportCard{},
+			Breached:     []model.HealthReportCard{},
+		}, report)
+	})
+
+	s.now = func() time.Time { return lastYear }
+	_, err := s.CreatePasswordCard("", model.PasswordCard{
+		ID:       "card-id-1",
+		Name:     "AWS",
+		Username: "username",
+		Password: "kX9#mQ2$vL7!pR4@",
+		URL:      "https://aws.com/login",
+	})
+	require.NoError(t, err)
+
+	s.now = func() time.Time { return now }
+	for _, passwordCard := range []model.PasswordCard{
+		{ID: "card-id-2", Name: "GCP", Username: "username", Password: "kX9#mQ2$vL7!pR4@", URL: "https://cloud.google.com/login"},
+		{ID: "card-id-3", Name: "Heroku", Username: "username", Password: "password", URL: "https://heroku.com/login"},
+		{ID: "card-id-4", Name: "Intranet", Username: "username", Password: "Zq8&wN3^tB6*yH1!", URL: "http://intranet.local/"},
+		{ID: "card-id-5", Name: "GitHub", Username: "username", Password: "Pf5%jD9(cS2)gK7=", URL: "https://github.com/login"},
+	} {
+		_, err := s.CreatePasswordCard("", passwordCard)
+		require.NoError(t, err)
+	}
+
+	// another owner's cards aren't reported
+	_, err = s.CreatePasswordCard("user-id-2", model.PasswordCard{
+		ID:       "card-id-6",
+		Name:     "AWS",
+		Username: "username",
+		Password: "kX9#mQ2$vL7!pR4@",
+		URL:      "http://aws.com/login",
+	})
+	require.NoError(t, err)
+
+	t.Run("return error for invalid thresholds", func(t *testing.T) {
+		_, err := s.HealthReport("", model.HealthReportQuery{MaxAgeDays: 0, MinScore: 3})
+		assert.EqualError(t, err, "max_age_days must be at least 1")
+
+		_, err = s.HealthReport("", model.HealthReportQuery{MaxAgeDays: 30, MinScore: 5})
+		assert.EqualError(t, err, "min_score must be between 0 and 4")
+	})
+
+	t.Run("🎉 reports the reused, weak and old passwords and the insecure URLs", func(t *testing.T) {
+		report, err := s.HealthReport("", model.DefaultHealthReportQuery())
+		require.NoError(t, err)
+
+		aws := model.HealthReportCard{ID: "card-id-1", Name: "AWS", URL: "https://aws.com/login"}
+		gcp := model.HealthReportCard{ID: "card-id-2", Name: "GCP", URL: "https://cloud.google.com/login"}
+		oldAWS := aws
+		oldAWS.PasswordChangedAt = &lastYear
+
+		assert.Equal(t, &model.HealthReport{
+			Score:      20,
+			TotalCards: 5,
+			Reused:     [][]model.HealthReportCard{{aws, gcp}},
+			Weak: []model.HealthReportCard{
+				{ID: "card-id-3", Name: "Heroku", URL: "https://heroku.com/login", Score: intPtr(0)},
+			},
+			Old: []model.HealthReportCard{oldAWS},
+			InsecureURLs: []model.HealthReportCard{
+				{ID: "card-id-4", Name: "Intranet", URL: "http://intranet.local/"},
+			},
+			Breached: []model.HealthReportCard{},
+		}, report)
+	})
+
+	t.Run("🎉 uses the given thresholds", func(t *testing.T) {
+		report, err := s.HealthReport("", model.HealthReportQuery{MaxAgeDays: 400, MinScore: 0})
+		require.NoError(t, err)
+		assert.Empty(t, report.Weak)
+		assert.Empty(t, report.Old)
+		assert.Equal(t, 40, report.Score)
+	})
+}
@@ -1,44 +1,862 @@
 package service
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/CaioTeixeira95/password-manager/backend/model"
 	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/strength"
+	"github.com/CaioTeixeira95/password-manager/backend/vault"
 )
 
+// ErrBreachCheckDisabled is returned when filtering the breached passwords
+// without a breached passwords list.
+var ErrBreachCheckDisabled = errors.New("the breached passwords list isn't configured")
+
+// BreachChecker tells how many times a password was seen in breaches. It's
+// implemented by breach.HashFile.
+type BreachChecker interface {
+	Count(password string) (int, error)
+}
+
+// ErrWeakPassword is returned when the strength score of a new password is
+// below the minimum.
+type ErrWeakPassword struct {
+	Score    int
+	MinScore int
+}
+
+func (e ErrWeakPassword) Error() string {
+	return fmt.Sprintf("password is too weak: its strength score is %d, the minimum is %d", e.Score, e.MinScore)
+}
+
+// ErrUnknownMaskedSecret is returned when an update sends back a masked
+// secret the card doesn't have, like a hidden custom field renamed without
+// typing its value again.
+type ErrUnknownMaskedSecret struct {
+	Field string
+}
+
+func (e ErrUnknownMaskedSecret) Error() string {
+	return fmt.Sprintf("%s is masked but the card has no value to keep", e.Field)
+}
+
 type PasswordCardService struct {
-	passwordCardRepository *repository.PasswordCardRepository
+	passwordCardRepository repository.PasswordCardStore
+
+	// vaultService is nil when the passwords are stored in plaintext.
+	vaultService *VaultService
+	now          func() time.Time
+
+	// minPasswordScore is the lowest strength score of the new passwords,
+	// zero accepts every password.
+	minPasswordScore int
+	// breachChecker is nil when the passwords aren't checked against a
+	// breached passwords list.
+	breachChecker BreachChecker
+	// folderRepository holds the folders the cards are in.
+	folderRepository repository.FolderStore
+
+	// mu serializes the writes, so rewrapping the data keys never overwrites a
+	// concurrent update.
+	mu sync.Mutex
+}
+
+func NewPasswordCardService(passwordCardRepository repository.PasswordCardStore) *PasswordCardService {
+	return &PasswordCardService{
+		passwordCardRepository: passwordCardRepository,
+		folderRepository:       repository.NewFolderRepository(),
+		now:                    time.Now,
+	}
+}
+
+// NewEncryptedPasswordCardService returns a service that encrypts the
+// passwords with the vault key before handing them to the repository.
+func NewEncryptedPasswordCardService(passwordCardRepository repository.PasswordCardStore, vaultService *VaultService) *PasswordCardService {
+	return &PasswordCardService{
+		passwordCardRepository: passwordCardRepository,
+		folderRepository:       repository.NewFolderRepository(),
+		vaultService:           vaultService,
+		now:                    time.Now,
+	}
 }
 
-func NewPasswordCardService(passwordCardRepository *repository.PasswordCardRepository) *PasswordCardService {
-	return &PasswordCardService{passwordCardRepository: passwordCardRepository}
+// SetMinPasswordScore rejects the new passwords whose strength score is below
+// minScore with ErrWeakPassword. The passwords already stored are kept.
+func (s *PasswordCardService) SetMinPasswordScore(minScore int) {
+	s.minPasswordScore = minScore
 }
 
-func (s *PasswordCardService) CreatePasswordCard(newPasswordCard model.PasswordCard) (*model.PasswordCard, error) {
-	if err := s.passwordCardRepository.Insert(newPasswordCard); err != nil {
+// SetBreachChecker flags the returned password cards whose password was seen
+// in breaches.
+func (s *PasswordCardService) SetBreachChecker(breachChecker BreachChecker) {
+	s.breachChecker = breachChecker
+}
+
+// CreatePasswordCard stores a new password card owned by ownerID. The owner is
+// empty when the server runs without accounts.
+func (s *PasswordCardService) CreatePasswordCard(ownerID string, newPasswordCard model.PasswordCard) (*model.PasswordCard, error) {
+	// the estimate is slow, it's done before taking the lock
+	if err := s.estimateStrength(&newPasswordCard, true); err != nil {
+		return nil, fmt.Errorf("error creating a new password card: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkFolder(ownerID, newPasswordCard.FolderID); err != nil {
+		return nil, fmt.Errorf("error creating a new password card: %w", err)
+	}
+
+	newPasswordCard.OwnerID = ownerID
+	newPasswordCard.CreatedAt = s.now().UTC()
+	newPasswordCard.UpdatedAt = newPasswordCard.CreatedAt
+	newPasswordCard.PasswordChangedAt = newPasswordCard.CreatedAt
+
+	var err error
+	newPasswordCard.HOTPCounter, err = initialHOTPCounter(newPasswordCard.HOTP)
+	if err != nil {
+		return nil, fmt.Errorf("error creating a new password card: %w", err)
+	}
+
+	if newPasswordCard.SSHKey != nil {
+		newPasswordCard.SSHKey.Complete()
+	}
+
+	sealedPasswordCard, err := s.seal(newPasswordCard)
+	if err != nil {
+		return nil, fmt.Errorf("error creating a new password card: %w", err)
+	}
+
+	if err := s.passwordCardRepository.Insert(sealedPasswordCard); err != nil {
+		return nil, fmt.Errorf("error creating a new password card: %w", err)
+	}
+
+	if err := s.checkBreach(&newPasswordCard); err != nil {
 		return nil, fmt.Errorf("error creating a new password card: %w", err)
 	}
 
 	return &newPasswordCard, nil
 }
 
-func (s *PasswordCardService) ListPasswordCards() []model.PasswordCard {
-	return s.passwordCardRepository.GetAll()
+// ListPasswordCards returns the password cards owned by ownerID.
+func (s *PasswordCardService) ListPasswordCards(ownerID string) ([]model.PasswordCard, error) {
+	passwordCards, err := s.passwordCardRepository.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("error listing password cards: %w", err)
+	}
+
+	ownedPasswordCards := make([]model.PasswordCard, 0, len(passwordCards))
+	for _, passwordCard := range passwordCards {
+		if passwordCard.OwnerID != ownerID {
+			continue
+		}
+
+		passwordCard, err = s.unseal(passwordCard)
+		if err != nil {
+			return nil, fmt.Errorf("error listing password cards: %w", err)
+		}
+
+		ownedPasswordCards = append(ownedPasswordCards, passwordCard)
+	}
+
+	return ownedPasswordCards, nil
 }
 
-func (s *PasswordCardService) UpdatePasswordCard(newPasswordCard model.PasswordCard) (*model.PasswordCard, error) {
-	if err := s.passwordCardRepository.Update(newPasswordCard); err != nil {
+// GetPasswordCard returns a password card owned by ownerID. The password cards
+// of other owners are reported as not found.
+func (s *PasswordCardService) GetPasswordCard(ownerID, passwordCardID string) (*model.PasswordCard, error) {
+	passwordCard, err := s.passwordCardRepository.GetByID(passwordCardID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting password card: %w", err)
+	}
+
+	if passwordCard.OwnerID != ownerID {
+		return nil, fmt.Errorf("error getting password card: %w", repository.ErrPasswordCardNotFound{ID: passwordCardID})
+	}
+
+	unsealedPasswordCard, err := s.unseal(*passwordCard)
+	if err != nil {
+		return nil, fmt.Errorf("error getting password card: %w", err)
+	}
+
+	return &unsealedPasswordCard, nil
+}
+
+func (s *PasswordCardService) UpdatePasswordCard(ownerID string, newPasswordCard model.PasswordCard) (*model.PasswordCard, error) {
+	// the estimate is slow, it's done before taking the lock
+	estimate, err := s.estimateUpdate(ownerID, newPasswordCard)
+	if err != nil {
+		return nil, fmt.Errorf("error updating password card: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	currentPasswordCard, err := s.getOwned(ownerID, newPasswordCard.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error updating password card: %w", err)
+	}
+
+	newPasswordCard.OwnerID = ownerID
+	newPasswordCard.UpdatedAt = s.now().UTC()
+	newPasswordCard.PasswordChangedAt = newPasswordCard.UpdatedAt
+	passwordChanged := true
+	// the HOTP counter is only reset when the HOTP key changes
+	hotpChanged := newPasswordCard.HOTP != ""
+	if currentPasswordCard != nil {
+		newPasswordCard.CreatedAt = currentPasswordCard.CreatedAt
+		newPasswordCard.HOTPCounter = currentPasswordCard.HOTPCounter
+		// only MovePasswordCard changes the folder
+		newPasswordCard.FolderID = currentPasswordCard.FolderID
+
+		unsealedPasswordCard, err := s.unseal(*currentPasswordCard)
+		if err != nil {
+			return nil, fmt.Errorf("error updating password card: %w", err)
+		}
+
+		// the clients send back the masked secrets when they weren't changed,
+		// or the password as it was revealed
+		if newPasswordCard.Password == model.MaskedPassword || newPasswordCard.Password == unsealedPasswordCard.Password {
+			newPasswordCard.PasswordChangedAt = currentPasswordCard.PasswordChangedAt
+			passwordChanged = false
+		}
+		if err := unmaskSecrets(&newPasswordCard, &unsealedPasswordCard); err != nil {
+			return nil, fmt.Errorf("error updating password card: %w", err)
+		}
+		hotpChanged = newPasswordCard.HOTP != unsealedPasswordCard.HOTP
+	}
+
+	if newPasswordCard.SSHKey != nil {
+		newPasswordCard.SSHKey.Complete()
+	}
+
+	if hotpChanged {
+		newPasswordCard.HOTPCounter, err = initialHOTPCounter(newPasswordCard.HOTP)
+		if err != nil {
+			return nil, fmt.Errorf("error updating password card: %w", err)
+		}
+	}
+
+	// the stored password can change while the lock isn't held, it's only
+	// estimated again then
+	if err := s.applyEstimate(&newPasswordCard, estimate, passwordChanged); err != nil {
+		return nil, fmt.Errorf("error updating password card: %w", err)
+	}
+
+	sealedPasswordCard, err := s.seal(newPasswordCard)
+	if err != nil {
+		return nil, fmt.Errorf("error updating password card: %w", err)
+	}
+
+	if err := s.passwordCardRepository.Update(sealedPasswordCard); err != nil {
+		return nil, fmt.Errorf("error updating password card: %w", err)
+	}
+
+	// Update keeps the counter of the repository
+	if hotpChanged {
+		if err := s.passwordCardRepository.SetHOTPCounter(newPasswordCard.ID, newPasswordCard.HOTPCounter); err != nil {
+			return nil, fmt.Errorf("error updating password card: %w", err)
+		}
+	}
+
+	if err := s.checkBreach(&newPasswordCard); err != nil {
 		return nil, fmt.Errorf("error updating password card: %w", err)
 	}
 
 	return &newPasswordCard, nil
 }
 
-func (s *PasswordCardService) DeletePasswordCard(passwordCardID string) error {
+func (s *PasswordCardService) DeletePasswordCard(ownerID, passwordCardID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.getOwned(ownerID, passwordCardID); err != nil {
+		return fmt.Errorf("error deleting password card: %w", err)
+	}
+
 	if err := s.passwordCardRepository.Delete(passwordCardID); err != nil {
 		return fmt.Errorf("error deleting password card: %w", err)
 	}
 
 	return nil
 }
+
+// ClaimUnownedPasswordCards gives the password cards and folders created
+// before the accounts were enabled to ownerID. The claimed root folders whose
+// name ownerID already uses get a number, e.g. "Work (2)", the subfolders
+// stay in their claimed parents. The cards whose URL ownerID already uses are
+// reported as conflicts and stay without owner; they are found before
+// anything is claimed.
+func (s *PasswordCardService) ClaimUnownedPasswordCards(ownerID string) (*model.ClaimReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := &model.ClaimReport{
+		Claimed:   make([]model.ImportRow, 0),
+		Conflicts: make([]model.ImportRow, 0),
+	}
+
+	passwordCards, err := s.passwordCardRepository.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("error claiming password cards: %w", err)
+	}
+
+	takenURLs := make(map[string]bool)
+	for _, passwordCard := range passwordCards {
+		if passwordCard.OwnerID == ownerID && passwordCard.URL != "" {
+			takenURLs[passwordCard.URL] = true
+		}
+	}
+
+	claimedPasswordCards := make([]model.PasswordCard, 0)
+	for _, passwordCard := range passwordCards {
+		if passwordCard.OwnerID != "" {
+			continue
+		}
+
+		row := model.ImportRow{
+			Row:  len(report.Claimed) + len(report.Conflicts) + 1,
+			Name: passwordCard.Name,
+			URL:  passwordCard.URL,
+		}
+		if takenURLs[passwordCard.URL] {
+			row.Error = repository.ErrPasswordCardAlreadyExists{URL: passwordCard.URL}.Error()
+			report.Conflicts = append(report.Conflicts, row)
+			continue
+		}
+
+		row.ID = passwordCard.ID
+		report.Claimed = append(report.Claimed, row)
+		claimedPasswordCards = append(claimedPasswordCards, passwordCard)
+	}
+
+	folders, err := s.folderRepository.ListByOwnerID("")
+	if err != nil {
+		return nil, fmt.Errorf("error claiming password cards: %w", err)
+	}
+
+	ownedFolders, err := s.folderRepository.ListByOwnerID(ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming password cards: %w", err)
+	}
+
+	takenNames := make(map[string]bool, len(ownedFolders))
+	for _, folder := range ownedFolders {
+		if folder.ParentID == "" {
+			takenNames[folder.Name] = true
+		}
+	}
+
+	for _, folder := range folders {
+		folder.OwnerID = ownerID
+		if folder.ParentID == "" {
+			name := folder.Name
+			for n := 2; takenNames[folder.Name]; n++ {
+				folder.Name = fmt.Sprintf("%s (%d)", name, n)
+			}
+			takenNames[folder.Name] = true
+		}
+
+		if err := s.folderRepository.Update(folder); err != nil {
+			return nil, fmt.Errorf("error claiming password cards: %w", err)
+		}
+		report.Folders++
+	}
+
+	for _, passwordCard := range claimedPasswordCards {
+		// the secrets are bound to the card ID only, they stay as they are
+		passwordCard.OwnerID = ownerID
+		if err := s.passwordCardRepository.Update(passwordCard); err != nil {
+			return nil, fmt.Errorf("error claiming password cards: %w", err)
+		}
+	}
+
+	return report, nil
+}
+
+// hasMaskedSecrets tells whether a secret of the card is MaskedPassword.
+func hasMaskedSecrets(passwordCard *model.PasswordCard) bool {
+	for _, secret := range passwordCard.Secrets() {
+		if *secret.Value == model.MaskedPassword {
+			return true
+		}
+	}
+	return false
+}
+
+// unmaskSecrets replaces the masked secrets of passwordCard by the ones of
+// the same field of the plaintext current card. It returns
+// ErrUnknownMaskedSecret for the masked secrets the current card doesn't
+// have, rather than losing them.
+func unmaskSecrets(passwordCard, currentPasswordCard *model.PasswordCard) error {
+	current := make(map[string]string)
+	for _, secret := range currentPasswordCard.Secrets() {
+		current[secret.Field] = *secret.Value
+	}
+
+	for _, secret := range passwordCard.Secrets() {
+		if *secret.Value != model.MaskedPassword {
+			continue
+		}
+
+		value, ok := current[secret.Field]
+		if !ok {
+			return ErrUnknownMaskedSecret{Field: secret.Field}
+		}
+		*secret.Value = value
+	}
+
+	return nil
+}
+
+// strengthEstimate is the strength estimated for the inputs of a card, it
+// still holds while they don't change. enforced tells whether the minimum
+// score was checked.
+type strengthEstimate struct {
+	inputs   [4]string
+	strength *model.PasswordStrength
+	enforced bool
+}
+
+// strengthInputs returns the fields of the card its strength depends on.
+func strengthInputs(passwordCard model.PasswordCard) [4]string {
+	return [4]string{passwordCard.Password, passwordCard.Name, passwordCard.Username, passwordCard.URL}
+}
+
+// estimateUpdate estimates the strength of an update. The name, username and
+// URL count too, so it's estimated even when the password didn't change: the
+// stored one replaces the mask then. The minimum score is only enforced when
+// the password differs from the stored one. It must be called without the
+// lock.
+func (s *PasswordCardService) estimateUpdate(ownerID string, newPasswordCard model.PasswordCard) (strengthEstimate, error) {
+	passwordChanged := true
+	if newPasswordCard.Kind() == model.ItemTypeLogin {
+		currentPasswordCard, err := s.GetPasswordCard(ownerID, newPasswordCard.ID)
+
+		var errNotFound repository.ErrPasswordCardNotFound
+		switch {
+		case errors.As(err, &errNotFound) && newPasswordCard.Password != model.MaskedPassword:
+			// the missing cards are reported once the lock is held, only the
+			// masks need the stored password
+		case err != nil:
+			return strengthEstimate{}, err
+		default:
+			if newPasswordCard.Password == model.MaskedPassword {
+				newPasswordCard.Password = currentPasswordCard.Password
+			}
+			passwordChanged = newPasswordCard.Password != currentPasswordCard.Password
+		}
+	}
+
+	if err := s.estimateStrength(&newPasswordCard, passwordChanged); err != nil {
+		return strengthEstimate{}, err
+	}
+
+	return strengthEstimate{inputs: strengthInputs(newPasswordCard), strength: newPasswordCard.Strength, enforced: passwordChanged}, nil
+}
+
+// applyEstimate sets the strength of the plaintext password card from an
+// estimate done before taking the lock, or estimates it again when the inputs
+// changed meanwhile, or when the minimum score must now be enforced.
+func (s *PasswordCardService) applyEstimate(passwordCard *model.PasswordCard, estimate strengthEstimate, enforce bool) error {
+	if passwordCard.Kind() != model.ItemTypeLogin || estimate.inputs != strengthInputs(*passwordCard) || enforce && !estimate.enforced {
+		return s.estimateStrength(passwordCard, enforce)
+	}
+
+	passwordCard.Strength = estimate.strength
+	return nil
+}
+
+// estimateStrength sets the strength of the plaintext password card. When
+// enforce is set, passwords below the minimum score are rejected. The estimate
+// is slow, it should be done without the lock.
+func (s *PasswordCardService) estimateStrength(passwordCard *model.PasswordCard, enforce bool) error {
+	// only the logins have a password
+	if passwordCard.Kind() != model.ItemTypeLogin {
+		passwordCard.Strength = nil
+		return nil
+	}
+
+	estimated := strength.EstimateCard(*passwordCard)
+	if enforce && estimated.Score < s.minPasswordScore {
+		return ErrWeakPassword{Score: estimated.Score, MinScore: s.minPasswordScore}
+	}
+
+	passwordCard.Strength = &estimated
+
+	return nil
+}
+
+// checkBreach sets whether the password of the plaintext password card was
+// breached.
+func (s *PasswordCardService) checkBreach(passwordCard *model.PasswordCard) error {
+	passwordCard.Breached = nil
+	if s.breachChecker == nil || passwordCard.Kind() != model.ItemTypeLogin {
+		return nil
+	}
+
+	count, err := s.breachChecker.Count(passwordCard.Password)
+	if err != nil {
+		return fmt.Errorf("error checking breached password: %w", err)
+	}
+
+	breached := count > 0
+	passwordCard.Breached = &breached
+
+	return nil
+}
+
+// getOwned returns the stored password card, still sealed, and
+// ErrPasswordCardNotFound when it belongs to another user, so callers can't
+// tell it exists. Missing password cards are returned as nil and left to the
+// repository to report. It must be called with the lock held.
+func (s *PasswordCardService) getOwned(ownerID, passwordCardID string) (*model.PasswordCard, error) {
+	passwordCard, err := s.passwordCardRepository.GetByID(passwordCardID)
+	if err != nil {
+		var errNotFound repository.ErrPasswordCardNotFound
+		if errors.As(err, &errNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if passwordCard.OwnerID != ownerID {
+		return nil, repository.ErrPasswordCardNotFound{ID: passwordCardID}
+	}
+
+	return passwordCard, nil
+}
+
+// MigrateSecrets brings the stored secrets up to date with the vault: the
+// passwords stored in plaintext or sealed directly with the master key are
+// sealed with a data key, the data keys wrapped by a previous master key are
+// rewrapped, and the missing strengths are estimated. It's a no-op for
+// services without encryption.
+func (s *PasswordCardService) MigrateSecrets() error {
+	if s.vaultService == nil {
+		return nil
+	}
+
+	// the estimates are slow, they are done before taking the lock
+	estimates, err := s.estimateMissingStrengths()
+	if err != nil {
+		return fmt.Errorf("error migrating secrets: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, err := s.vaultService.Vault()
+	if err != nil {
+		return fmt.Errorf("error migrating secrets: %w", err)
+	}
+
+	passwordCards, err := s.passwordCardRepository.GetAll()
+	if err != nil {
+		return fmt.Errorf("error migrating secrets: %w", err)
+	}
+
+	for _, passwordCard := range passwordCards {
+		migrated := passwordCard
+		switch {
+		// the strength is sealed like the secrets, the cards stored before it
+		// was, or before it was estimated, are sealed again too
+		case sealingOf(passwordCard) != model.SealingDataKey, passwordCard.Strength != nil, lacksStrength(passwordCard):
+			plaintextCard, err := s.unsealWith(v, passwordCard)
+			if err != nil {
+				return fmt.Errorf("error migrating secrets: %w", err)
+			}
+
+			if plaintextCard.Strength == nil {
+				// the cards changed meanwhile are estimated again
+				if err := s.applyEstimate(&plaintextCard, estimates[plaintextCard.ID], false); err != nil {
+					return fmt.Errorf("error migrating secrets: %w", err)
+				}
+			}
+
+			migrated, err = s.sealWith(v, plaintextCard)
+			if err != nil {
+				return fmt.Errorf("error migrating secrets: %w", err)
+			}
+		case !v.IsWrappedByCurrentKey(passwordCard.DataKey):
+			// only the data key changes, the secrets stay as they are
+			migrated.DataKey, err = v.RewrapDataKey(passwordCard.DataKey, passwordCard.ID)
+			if err != nil {
+				return fmt.Errorf("error rewrapping data key of %q: %w", passwordCard.ID, err)
+			}
+			migrated.Sealing = model.SealingDataKey
+		case passwordCard.Sealing == "":
+			// the cards stored before the sealing was recorded get it
+			migrated.Sealing = model.SealingDataKey
+		default:
+			continue
+		}
+
+		if err := s.passwordCardRepository.Update(migrated); err != nil {
+			return fmt.Errorf("error migrating secrets: %w", err)
+		}
+	}
+
+	if err := s.vaultService.FinishRotation(); err != nil {
+		return fmt.Errorf("error migrating secrets: %w", err)
+	}
+
+	return nil
+}
+
+// estimateMissingStrengths estimates the strength of the stored logins that
+// lack one, by card ID. It must be called without the lock.
+func (s *PasswordCardService) estimateMissingStrengths() (map[string]strengthEstimate, error) {
+	v, err := s.vaultService.Vault()
+	if err != nil {
+		return nil, err
+	}
+
+	passwordCards, err := s.passwordCardRepository.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	estimates := make(map[string]strengthEstimate)
+	for _, passwordCard := range passwordCards {
+		if !lacksStrength(passwordCard) {
+			continue
+		}
+
+		plaintextCard, err := s.unsealWith(v, passwordCard)
+		if err != nil {
+			return nil, err
+		}
+
+		estimated := strength.EstimateCard(plaintextCard)
+		estimates[plaintextCard.ID] = strengthEstimate{inputs: strengthInputs(plaintextCard), strength: &estimated}
+	}
+
+	return estimates, nil
+}
+
+// lacksStrength tells whether the stored login was stored before its strength
+// was estimated.
+func lacksStrength(passwordCard model.PasswordCard) bool {
+	return passwordCard.Kind() == model.ItemTypeLogin && passwordCard.Strength == nil && passwordCard.SealedStrength == ""
+}
+
+// RotateMasterKey replaces the master key by one derived from
+// newMasterPassword and rewraps the data keys of every password card. Giving
+// the current master password as the new one only rotates the key. The
+// password cards stay available during the rotation.
+func (s *PasswordCardService) RotateMasterKey(masterPassword, newMasterPassword []byte) error {
+	if s.vaultService == nil {
+		return fmt.Errorf("error rotating master key: vault is not encrypted")
+	}
+
+	// finish any interrupted rotation and upgrade the legacy secrets first,
+	// they can't be read with the new master key
+	if err := s.MigrateSecrets(); err != nil {
+		return err
+	}
+
+	if err := s.vaultService.BeginRotation(masterPassword, newMasterPassword); err != nil {
+		return err
+	}
+
+	return s.MigrateSecrets()
+}
+
+// seal encrypts the secrets of the password card with a new data key wrapped
+// by the master key. The card ID is used as additional data so secrets can't
+// be moved to another card. The other fields are stored in plaintext, they are
+// searched and checked for conflicts without the vault.
+func (s *PasswordCardService) seal(passwordCard model.PasswordCard) (model.PasswordCard, error) {
+	// it's checked again when the card is read, the list can change
+	passwordCard.Breached = nil
+
+	if s.vaultService == nil {
+		passwordCard.DataKey = ""
+		passwordCard.Sealing = model.SealingPlaintext
+		if err := sealStrength(&passwordCard, nil); err != nil {
+			return model.PasswordCard{}, err
+		}
+		return passwordCard, nil
+	}
+
+	v, err := s.vaultService.Vault()
+	if err != nil {
+		return model.PasswordCard{}, err
+	}
+
+	return s.sealWith(v, passwordCard)
+}
+
+func (s *PasswordCardService) sealWith(v *vault.Vault, passwordCard model.PasswordCard) (model.PasswordCard, error) {
+	dataKey, err := vault.NewDataKey()
+	if err != nil {
+		return model.PasswordCard{}, err
+	}
+
+	// the details are sealed in place, they must not be shared with the
+	// plaintext card
+	passwordCard = passwordCard.Clone()
+	for _, secret := range passwordCard.Secrets() {
+		*secret.Value, err = vault.SealString(dataKey, *secret.Value, secretAdditionalData(passwordCard.ID, secret.Field))
+		if err != nil {
+			return model.PasswordCard{}, fmt.Errorf("error encrypting %s: %w", secret.Field, err)
+		}
+	}
+
+	if err := sealStrength(&passwordCard, dataKey); err != nil {
+		return model.PasswordCard{}, err
+	}
+
+	passwordCard.DataKey, err = v.WrapDataKey(dataKey, passwordCard.ID)
+	if err != nil {
+		return model.PasswordCard{}, fmt.Errorf("error wrapping data key: %w", err)
+	}
+	passwordCard.Sealing = model.SealingDataKey
+
+	return passwordCard, nil
+}
+
+// unseal reverts seal and checks whether the password was breached. Passwords
+// stored before the encryption was enabled are returned as they are.
+func (s *PasswordCardService) unseal(passwordCard model.PasswordCard) (model.PasswordCard, error) {
+	if s.vaultService != nil && sealingOf(passwordCard) != model.SealingPlaintext {
+		v, err := s.vaultService.Vault()
+		if err != nil {
+			return model.PasswordCard{}, err
+		}
+
+		passwordCard, err = s.unsealWith(v, passwordCard)
+		if err != nil {
+			return model.PasswordCard{}, err
+		}
+	} else if err := openStrength(&passwordCard, nil); err != nil {
+		return model.PasswordCard{}, err
+	}
+
+	passwordCard.DataKey = ""
+	passwordCard.Sealing = ""
+
+	if err := s.checkBreach(&passwordCard); err != nil {
+		return model.PasswordCard{}, err
+	}
+
+	return passwordCard, nil
+}
+
+func (s *PasswordCardService) unsealWith(v *vault.Vault, passwordCard model.PasswordCard) (model.PasswordCard, error) {
+	var err error
+	switch sealingOf(passwordCard) {
+	case model.SealingPlaintext:
+		if err := openStrength(&passwordCard, nil); err != nil {
+			return model.PasswordCard{}, err
+		}
+	case model.SealingMasterKey:
+		// sealed before the data keys were introduced
+		passwordCard.Password, err = v.DecryptString(passwordCard.Password, passwordCard.ID)
+		if err != nil {
+			return model.PasswordCard{}, fmt.Errorf("error decrypting password of %q: %w", passwordCard.ID, err)
+		}
+	default:
+		dataKey, err := v.UnwrapDataKey(passwordCard.DataKey, passwordCard.ID)
+		if err != nil {
+			return model.PasswordCard{}, fmt.Errorf("error unwrapping data key of %q: %w", passwordCard.ID, err)
+		}
+
+		// the details are opened in place, they must not be shared with the
+		// sealed card
+		passwordCard = passwordCard.Clone()
+		for _, secret := range passwordCard.Secrets() {
+			*secret.Value, err = vault.OpenString(dataKey, *secret.Value, secretAdditionalData(passwordCard.ID, secret.Field))
+			if err != nil {
+				return model.PasswordCard{}, fmt.Errorf("error decrypting %s of %q: %w", secret.Field, passwordCard.ID, err)
+			}
+		}
+
+		if err := openStrength(&passwordCard, dataKey); err != nil {
+			return model.PasswordCard{}, err
+		}
+	}
+
+	passwordCard.DataKey = ""
+	passwordCard.Sealing = ""
+
+	return passwordCard, nil
+}
+
+// sealStrength moves the strength of the card to SealedStrength, sealed with
+// dataKey. It's only encoded without a data key, for the plaintext cards.
+func sealStrength(passwordCard *model.PasswordCard, dataKey []byte) error {
+	passwordCard.SealedStrength = ""
+	if passwordCard.Strength == nil {
+		return nil
+	}
+
+	encoded, err := json.Marshal(passwordCard.Strength)
+	if err != nil {
+		return fmt.Errorf("error encoding strength: %w", err)
+	}
+
+	passwordCard.SealedStrength = string(encoded)
+	if dataKey != nil {
+		passwordCard.SealedStrength, err = vault.SealString(dataKey, passwordCard.SealedStrength, secretAdditionalData(passwordCard.ID, "strength"))
+		if err != nil {
+			return fmt.Errorf("error encrypting strength: %w", err)
+		}
+	}
+	passwordCard.Strength = nil
+
+	return nil
+}
+
+// openStrength reverts sealStrength. The cards stored before the strength was
+// sealed keep their plaintext one.
+func openStrength(passwordCard *model.PasswordCard, dataKey []byte) error {
+	if passwordCard.SealedStrength == "" {
+		return nil
+	}
+
+	encoded := passwordCard.SealedStrength
+	if dataKey != nil {
+		var err error
+		encoded, err = vault.OpenString(dataKey, encoded, secretAdditionalData(passwordCard.ID, "strength"))
+		if err != nil {
+			return fmt.Errorf("error decrypting strength of %q: %w", passwordCard.ID, err)
+		}
+	}
+
+	var passwordStrength model.PasswordStrength
+	if err := json.Unmarshal([]byte(encoded), &passwordStrength); err != nil {
+		return fmt.Errorf("error decoding strength of %q: %w", passwordCard.ID, err)
+	}
+	passwordCard.Strength = &passwordStrength
+	passwordCard.SealedStrength = ""
+
+	return nil
+}
+
+// sealingOf returns how the secrets of a stored card are sealed. The cards
+// stored before it was recorded are told apart by their data key, and the
+// legacy ones by the prefix of their password.
+func sealingOf(passwordCard model.PasswordCard) model.Sealing {
+	switch {
+	case passwordCard.Sealing != "":
+		return passwordCard.Sealing
+	case passwordCard.DataKey != "":
+		return model.SealingDataKey
+	case vault.IsMasterKeyEncrypted(passwordCard.Password):
+		return model.SealingMasterKey
+	default:
+		return model.SealingPlaintext
+	}
+}
+
+// secretAdditionalData binds a secret to its card and field.
+func secretAdditionalData(passwordCardID, field string) string {
+	return passwordCardID + "/" + field
+}
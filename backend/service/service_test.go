@@ -1,19 +1,26 @@
 package service
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/CaioTeixeira95/password-manager/backend/model"
 	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/vault"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
 )
 
 func TestCreatePasswordCard(t *testing.T) {
 	r := repository.NewPasswordCardRepository()
 	s := NewPasswordCardService(r)
 
-	pc, err := s.CreatePasswordCard(model.PasswordCard{
+	pc, err := s.CreatePasswordCard("", model.PasswordCard{
 		ID:       "card-id-1",
 		Name:     "AWS",
 		Username: "username",
@@ -23,7 +30,7 @@ func TestCreatePasswordCard(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(t, pc)
 
-	pc, err = s.CreatePasswordCard(model.PasswordCard{
+	pc, err = s.CreatePasswordCard("", model.PasswordCard{
 		ID:       "card-id-1",
 		Name:     "AWS",
 		Username: "username",
@@ -39,7 +46,9 @@ func TestListPasswordCards(t *testing.T) {
 	r := repository.NewPasswordCardRepository()
 	s := NewPasswordCardService(r)
 
-	assert.Empty(t, s.ListPasswordCards())
+	passwordCards, err := s.ListPasswordCards("")
+	require.NoError(t, err)
+	assert.Empty(t, passwordCards)
 
 	r = repository.CustomPasswordCardRepository([]model.PasswordCard{
 		{
@@ -59,6 +68,8 @@ func TestListPasswordCards(t *testing.T) {
 	})
 	s = NewPasswordCardService(r)
 
+	passwordCards, err = s.ListPasswordCards("")
+	require.NoError(t, err)
 	assert.Equal(t, []model.PasswordCard{
 		{
 			ID:       "card-id-1",
@@ -74,7 +85,7 @@ func TestListPasswordCards(t *testing.T) {
 			Password: "supersecret",
 			URL:      "https://cloud.google.com/login",
 		},
-	}, s.ListPasswordCards())
+	}, passwordCards)
 }
 
 func TestUpdatePasswordCardService(t *testing.T) {
@@ -89,7 +100,7 @@ func TestUpdatePasswordCardService(t *testing.T) {
 	})
 	s := NewPasswordCardService(r)
 
-	pc, err := s.UpdatePasswordCard(model.PasswordCard{
+	pc, err := s.UpdatePasswordCard("", model.PasswordCard{
 		ID:       "card-id-1",
 		Name:     "Amazon Web Services",
 		Username: "username",
@@ -99,7 +110,7 @@ func TestUpdatePasswordCardService(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(t, pc)
 
-	pc, err = s.UpdatePasswordCard(model.PasswordCard{
+	pc, err = s.UpdatePasswordCard("", model.PasswordCard{
 		ID:       "card-id-2",
 		Name:     "AWS",
 		Username: "username",
@@ -110,7 +121,7 @@ func TestUpdatePasswordCardService(t *testing.T) {
 	assert.EqualError(t, err, `error updating password card: password with URL "https://aws.com/login" already exists`)
 	assert.Nil(t, pc)
 
-	pc, err = s.UpdatePasswordCard(model.PasswordCard{
+	pc, err = s.UpdatePasswordCard("", model.PasswordCard{
 		ID:       "card-id-2",
 		Name:     "AWS",
 		Username: "username",
@@ -141,9 +152,695 @@ func TestDeletePasswordCard(t *testing.T) {
 	})
 	s := NewPasswordCardService(r)
 
-	err := s.DeletePasswordCard("card-id-1")
+	err := s.DeletePasswordCard("", "card-id-1")
 	require.NoError(t, err)
 
-	err = s.DeletePasswordCard("card-id-1")
+	err = s.DeletePasswordCard("", "card-id-1")
 	assert.EqualError(t, err, `error deleting password card: password with ID "card-id-1" not found`)
 }
+
+func TestGetPasswordCard(t *testing.T) {
+	awsCard := model.PasswordCard{
+		ID:       "card-id-1",
+		Name:     "AWS",
+		Username: "username",
+		Password: "supersecret",
+		URL:      "https://aws.com/login",
+	}
+	r := repository.CustomPasswordCardRepository([]model.PasswordCard{awsCard})
+	s := NewPasswordCardService(r)
+
+	pc, err := s.GetPasswordCard("", "card-id-1")
+	require.NoError(t, err)
+	assert.Equal(t, &awsCard, pc)
+
+	pc, err = s.GetPasswordCard("", "card-id-2")
+	assert.EqualError(t, err, `error getting password card: password with ID "card-id-2" not found`)
+	assert.Nil(t, pc)
+
+	// the password cards of other owners are not found
+	pc, err = s.GetPasswordCard("user-id-1", "card-id-1")
+	assert.ErrorIs(t, err, repository.ErrPasswordCardNotFound{ID: "card-id-1"})
+	assert.Nil(t, pc)
+}
+
+func TestEncryptedPasswordCardService(t *testing.T) {
+	r := repository.CustomPasswordCardRepository([]model.PasswordCard{
+		{
+			ID:       "card-id-1",
+			Name:     "AWS",
+			Username: "username",
+			Password: "supersecret",
+			URL:      "https://aws.com/login",
+		},
+	})
+	vs := newTestVaultService(repository.NewVaultHeaderRepository())
+	s := NewEncryptedPasswordCardService(r, vs)
+
+	now := time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	t.Run("returns error while the vault is locked", func(t *testing.T) {
+		_, err := s.CreatePasswordCard("", model.PasswordCard{ID: "card-id-2"})
+		assert.ErrorIs(t, err, ErrVaultLocked)
+
+		err = s.MigrateSecrets()
+		assert.ErrorIs(t, err, ErrVaultLocked)
+	})
+
+	require.NoError(t, vs.Unlock([]byte("master")))
+
+	t.Run("🎉 seals the passwords stored in plaintext", func(t *testing.T) {
+		require.NoError(t, s.MigrateSecrets())
+
+		stored, err := r.GetByID("card-id-1")
+		require.NoError(t, err)
+		assert.True(t, vault.IsEncrypted(stored.Password))
+
+		// sealing again doesn't encrypt the ciphertext
+		require.NoError(t, s.MigrateSecrets())
+
+		again, err := r.GetByID("card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, stored, again)
+	})
+
+	t.Run("🎉 stores only encrypted passwords", func(t *testing.T) {
+		pc, err := s.CreatePasswordCard("", model.PasswordCard{
+			ID:       "card-id-2",
+			Name:     "GCP",
+			Username: "username",
+			Password: "anothersecret",
+			URL:      "https://cloud.google.com/login",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "anothersecret", pc.Password)
+
+		_, err = s.UpdatePasswordCard("", model.PasswordCard{
+			ID:       "card-id-1",
+			Name:     "AWS",
+			Username: "username",
+			Password: "newsupersecret",
+			URL:      "https://aws.com/login",
+		})
+		require.NoError(t, err)
+
+		stored, err := r.GetAll()
+		require.NoError(t, err)
+		for _, passwordCard := range stored {
+			assert.True(t, vault.IsEncrypted(passwordCard.Password))
+			// the strength tells which passwords are easy to guess
+			assert.Nil(t, passwordCard.Strength)
+			assert.True(t, vault.IsEncrypted(passwordCard.SealedStrength))
+		}
+
+		passwordCards, err := s.ListPasswordCards("")
+		require.NoError(t, err)
+		assert.Equal(t, []model.PasswordCard{
+			{
+				ID:                "card-id-1",
+				Name:              "AWS",
+				Username:          "username",
+				Password:          "newsupersecret",
+				URL:               "https://aws.com/login",
+				UpdatedAt:         now,
+				PasswordChangedAt: now,
+				Strength:          &model.PasswordStrength{Score: 1, CrackTimeSeconds: 271.167, CrackTimeDisplay: "6.0 minutes"},
+			},
+			{
+				ID:                "card-id-2",
+				Name:              "GCP",
+				Username:          "username",
+				Password:          "anothersecret",
+				URL:               "https://cloud.google.com/login",
+				CreatedAt:         now,
+				UpdatedAt:         now,
+				PasswordChangedAt: now,
+				Strength:          &model.PasswordStrength{Score: 0, CrackTimeSeconds: 0.955, CrackTimeDisplay: "instant"},
+			},
+		}, passwordCards)
+	})
+
+	t.Run("🎉 keeps the password when the masked one is sent back", func(t *testing.T) {
+		pc, err := s.UpdatePasswordCard("", model.PasswordCard{
+			ID:       "card-id-2",
+			Name:     "Google Cloud Platform",
+			Username: "username",
+			Password: model.MaskedPassword,
+			URL:      "https://cloud.google.com/login",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "anothersecret", pc.Password)
+
+		stored, err := r.GetByID("card-id-2")
+		require.NoError(t, err)
+		assert.True(t, vault.IsEncrypted(stored.Password))
+
+		passwordCard, err := s.GetPasswordCard("", "card-id-2")
+		require.NoError(t, err)
+		assert.Equal(t, "Google Cloud Platform", passwordCard.Name)
+		assert.Equal(t, "anothersecret", passwordCard.Password)
+	})
+}
+
+func TestPasswordCardSealing(t *testing.T) {
+	r := repository.CustomPasswordCardRepository([]model.PasswordCard{
+		{
+			ID:       "card-id-1",
+			Name:     "AWS",
+			Username: "username",
+			// a plaintext password that looks sealed
+			Password: "vault:v1:c3VwZXJzZWNyZXQ",
+			URL:      "https://aws.com/login",
+			Sealing:  model.SealingPlaintext,
+		},
+	})
+	vs := newTestVaultService(repository.NewVaultHeaderRepository())
+	s := NewEncryptedPasswordCardService(r, vs)
+	require.NoError(t, vs.Unlock([]byte("master")))
+
+	t.Run("🎉 reads the sealing instead of the password prefix", func(t *testing.T) {
+		passwordCard, err := s.GetPasswordCard("", "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, "vault:v1:c3VwZXJzZWNyZXQ", passwordCard.Password)
+		assert.Empty(t, passwordCard.Sealing)
+	})
+
+	t.Run("🎉 seals the plaintext cards on migration", func(t *testing.T) {
+		require.NoError(t, s.MigrateSecrets())
+
+		stored, err := r.GetByID("card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, model.SealingDataKey, stored.Sealing)
+		assert.NotEqual(t, "vault:v1:c3VwZXJzZWNyZXQ", stored.Password)
+
+		passwordCard, err := s.GetPasswordCard("", "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, "vault:v1:c3VwZXJzZWNyZXQ", passwordCard.Password)
+	})
+}
+
+func TestPasswordCardStrengthSealing(t *testing.T) {
+	strength := &model.PasswordStrength{Score: 1, CrackTimeSeconds: 271.167, CrackTimeDisplay: "6.0 minutes"}
+	r := repository.CustomPasswordCardRepository([]model.PasswordCard{
+		{
+			ID:       "card-id-1",
+			Name:     "AWS",
+			Username: "username",
+			Password: "newsupersecret",
+			URL:      "https://aws.com/login",
+			// stored in plaintext before the strength was sealed
+			Strength: strength,
+		},
+	})
+	vs := newTestVaultService(repository.NewVaultHeaderRepository())
+	s := NewEncryptedPasswordCardService(r, vs)
+	require.NoError(t, vs.Unlock([]byte("master")))
+
+	t.Run("🎉 seals the plaintext strengths on migration", func(t *testing.T) {
+		require.NoError(t, s.MigrateSecrets())
+
+		stored, err := r.GetByID("card-id-1")
+		require.NoError(t, err)
+		assert.Nil(t, stored.Strength)
+		assert.True(t, vault.IsEncrypted(stored.SealedStrength))
+
+		passwordCard, err := s.GetPasswordCard("", "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, strength, passwordCard.Strength)
+		assert.Empty(t, passwordCard.SealedStrength)
+	})
+
+	t.Run("🎉 encodes the strengths without a vault", func(t *testing.T) {
+		r := repository.NewPasswordCardRepository()
+		s := NewPasswordCardService(r)
+
+		_, err := s.CreatePasswordCard("", model.PasswordCard{
+			ID:       "card-id-1",
+			Name:     "AWS",
+			Username: "username",
+			Password: "newsupersecret",
+			URL:      "https://aws.com/login",
+		})
+		require.NoError(t, err)
+
+		stored, err := r.GetByID("card-id-1")
+		require.NoError(t, err)
+		assert.Nil(t, stored.Strength)
+		assert.JSONEq(t, `{"score":1, "crack_time_seconds":271.167, "crack_time_display":"6.0 minutes"}`, stored.SealedStrength)
+
+		passwordCard, err := s.GetPasswordCard("", "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, strength, passwordCard.Strength)
+	})
+}
+
+func TestRotateMasterKey(t *testing.T) {
+	hr := repository.NewVaultHeaderRepository()
+	vs := newTestVaultService(hr)
+	require.NoError(t, vs.Unlock([]byte("master")))
+
+	v, err := vs.Vault()
+	require.NoError(t, err)
+
+	// a password sealed with the master key, before the data keys existed
+	legacyPassword, err := v.EncryptString("legacysecret", "card-id-1")
+	require.NoError(t, err)
+
+	r := repository.CustomPasswordCardRepository([]model.PasswordCard{
+		{
+			ID:       "card-id-1",
+			Name:     "AWS",
+			Username: "username",
+			Password: legacyPassword,
+			URL:      "https://aws.com/login",
+		},
+	})
+	s := NewEncryptedPasswordCardService(r, vs)
+
+	_, err = s.CreatePasswordCard("", model.PasswordCard{
+		ID:       "card-id-2",
+		Name:     "GCP",
+		Username: "username",
+		Password: "supersecret",
+		URL:      "https://cloud.google.com/login",
+	})
+	require.NoError(t, err)
+
+	before, err := r.GetByID("card-id-2")
+	require.NoError(t, err)
+
+	t.Run("returns error for a wrong master password", func(t *testing.T) {
+		err := s.RotateMasterKey([]byte("wrong"), []byte("new master"))
+		assert.ErrorIs(t, err, vault.ErrWrongMasterPassword)
+	})
+
+	t.Run("🎉 changes the master password rewrapping the data keys", func(t *testing.T) {
+		require.NoError(t, s.RotateMasterKey([]byte("master"), []byte("new master")))
+
+		header, err := hr.LoadVaultHeader()
+		require.NoError(t, err)
+		assert.Nil(t, header.Previous, "the rotation must be finished")
+
+		after, err := r.GetByID("card-id-2")
+		require.NoError(t, err)
+		assert.Equal(t, before.Password, after.Password, "secrets must not be encrypted again")
+		assert.NotEqual(t, before.DataKey, after.DataKey)
+
+		legacy, err := r.GetByID("card-id-1")
+		require.NoError(t, err)
+		assert.False(t, vault.IsMasterKeyEncrypted(legacy.Password))
+
+		passwordCards, err := s.ListPasswordCards("")
+		require.NoError(t, err)
+		assert.Equal(t, "legacysecret", passwordCards[0].Password)
+		assert.Equal(t, "supersecret", passwordCards[1].Password)
+		assert.Empty(t, passwordCards[0].DataKey)
+		assert.Empty(t, passwordCards[1].DataKey)
+
+		vs.Lock()
+		assert.ErrorIs(t, vs.Unlock([]byte("master")), vault.ErrWrongMasterPassword)
+		require.NoError(t, vs.Unlock([]byte("new master")))
+	})
+
+	t.Run("🎉 finishes an interrupted rotation on migration", func(t *testing.T) {
+		// simulate a crash right after the new header was saved
+		require.NoError(t, vs.BeginRotation([]byte("new master"), []byte("newer master")))
+		vs.Lock()
+
+		require.NoError(t, vs.Unlock([]byte("newer master")))
+		require.NoError(t, s.MigrateSecrets())
+
+		header, err := hr.LoadVaultHeader()
+		require.NoError(t, err)
+		assert.Nil(t, header.Previous)
+
+		passwordCards, err := s.ListPasswordCards("")
+		require.NoError(t, err)
+		assert.Equal(t, "legacysecret", passwordCards[0].Password)
+		assert.Equal(t, "supersecret", passwordCards[1].Password)
+	})
+}
+
+func TestPasswordCardOwners(t *testing.T) {
+	r := repository.CustomPasswordCardRepository([]model.PasswordCard{
+		{
+			ID:       "card-id-1",
+			Name:     "AWS",
+			Username: "username",
+			Password: "supersecret",
+			URL:      "https://aws.com/login",
+		},
+	})
+	s := NewPasswordCardService(r)
+
+	awsCard := model.PasswordCard{
+		ID:       "card-id-2",
+		Name:     "AWS",
+		Username: "alice",
+		Password: "supersecret",
+		URL:      "https://aws.com/login",
+	}
+
+	pc, err := s.CreatePasswordCard("alice-id", awsCard)
+	require.NoError(t, err)
+	assert.Equal(t, "alice-id", pc.OwnerID)
+
+	t.Run("lists only the password cards of the owner", func(t *testing.T) {
+		passwordCards, err := s.ListPasswordCards("alice-id")
+		require.NoError(t, err)
+		assert.Equal(t, []model.PasswordCard{*pc}, passwordCards)
+
+		passwordCards, err = s.ListPasswordCards("bob-id")
+		require.NoError(t, err)
+		assert.Empty(t, passwordCards)
+	})
+
+	t.Run("returns not found for password cards of another owner", func(t *testing.T) {
+		_, err := s.UpdatePasswordCard("bob-id", awsCard)
+		assert.ErrorIs(t, err, repository.ErrPasswordCardNotFound{ID: "card-id-2"})
+
+		err = s.DeletePasswordCard("bob-id", "card-id-2")
+		assert.ErrorIs(t, err, repository.ErrPasswordCardNotFound{ID: "card-id-2"})
+	})
+
+	t.Run("🎉 claims the password cards and folders without owner", func(t *testing.T) {
+		work, err := s.CreateFolder("", model.FolderRequest{Name: "Work"})
+		require.NoError(t, err)
+		cloud, err := s.CreateFolder("", model.FolderRequest{Name: "Cloud", ParentID: work.ID})
+		require.NoError(t, err)
+		_, err = s.CreateFolder("bob-id", model.FolderRequest{Name: "Work"})
+		require.NoError(t, err)
+
+		report, err := s.ClaimUnownedPasswordCards("bob-id")
+		require.NoError(t, err)
+		assert.Equal(t, &model.ClaimReport{
+			Claimed:   []model.ImportRow{{Row: 1, ID: "card-id-1", Name: "AWS", URL: "https://aws.com/login"}},
+			Folders:   2,
+			Conflicts: []model.ImportRow{},
+		}, report)
+
+		folders, err := s.ListFolders("bob-id")
+		require.NoError(t, err)
+		require.Len(t, folders, 3)
+		// the name of the claimed folder was taken
+		assert.Equal(t, "Work (2)", folders[0].Name)
+		assert.Equal(t, cloud.ID, folders[1].ID)
+		assert.Equal(t, work.ID, folders[1].ParentID)
+
+		folders, err = s.ListFolders("")
+		require.NoError(t, err)
+		assert.Empty(t, folders)
+
+		passwordCards, err := s.ListPasswordCards("bob-id")
+		require.NoError(t, err)
+		require.Len(t, passwordCards, 1)
+		assert.Equal(t, "card-id-1", passwordCards[0].ID)
+
+		passwordCards, err = s.ListPasswordCards("")
+		require.NoError(t, err)
+		assert.Empty(t, passwordCards)
+	})
+
+	t.Run("🎉 reports the password cards whose URL the owner already uses", func(t *testing.T) {
+		r := repository.CustomPasswordCardRepository([]model.PasswordCard{
+			{ID: "card-id-1", Name: "AWS", Username: "root", Password: "supersecret", URL: "https://aws.com/login", OwnerID: "bob-id"},
+			{ID: "card-id-2", Name: "AWS", Username: "admin", Password: "supersecret", URL: "https://aws.com/login"},
+			{ID: "card-id-3", Name: "GCP", Username: "admin", Password: "supersecret", URL: "https://cloud.google.com/"},
+		})
+		s := NewPasswordCardService(r)
+
+		report, err := s.ClaimUnownedPasswordCards("bob-id")
+		require.NoError(t, err)
+		assert.Equal(t, &model.ClaimReport{
+			Claimed:   []model.ImportRow{{Row: 2, ID: "card-id-3", Name: "GCP", URL: "https://cloud.google.com/"}},
+			Conflicts: []model.ImportRow{{Row: 1, Name: "AWS", URL: "https://aws.com/login", Error: `password with URL "https://aws.com/login" already exists`}},
+		}, report)
+
+		passwordCards, err := s.ListPasswordCards("")
+		require.NoError(t, err)
+		require.Len(t, passwordCards, 1)
+		assert.Equal(t, "card-id-2", passwordCards[0].ID)
+
+		passwordCards, err = s.ListPasswordCards("bob-id")
+		require.NoError(t, err)
+		assert.Len(t, passwordCards, 2)
+	})
+}
+
+func TestMinPasswordScore(t *testing.T) {
+	r := repository.NewPasswordCardRepository()
+	s := NewPasswordCardService(r)
+	s.SetMinPasswordScore(3)
+
+	t.Run("rejects weak new passwords", func(t *testing.T) {
+		_, err := s.CreatePasswordCard("", model.PasswordCard{
+			ID:       "card-id-1",
+			Name:     "AWS",
+			Username: "username",
+			Password: "supersecret",
+			URL:      "https://aws.com/login",
+		})
+		assert.ErrorIs(t, err, ErrWeakPassword{Score: 0, MinScore: 3})
+		assert.EqualError(t, err, "error creating a new password card: password is too weak: its strength score is 0, the minimum is 3")
+	})
+
+	t.Run("🎉 accepts strong passwords and keeps the stored ones", func(t *testing.T) {
+		pc, err := s.CreatePasswordCard("", model.PasswordCard{
+			ID:       "card-id-1",
+			Name:     "AWS",
+			Username: "username",
+			Password: "kX9#mQ2$vL7!pR4@",
+			URL:      "https://aws.com/login",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, model.MaxPasswordScore, pc.Strength.Score)
+
+		_, err = s.UpdatePasswordCard("", model.PasswordCard{
+			ID:       "card-id-1",
+			Name:     "AWS",
+			Username: "username",
+			Password: "password",
+			URL:      "https://aws.com/login",
+		})
+		assert.ErrorIs(t, err, ErrWeakPassword{Score: 0, MinScore: 3})
+
+		// a password stored before the minimum was raised isn't checked when
+		// it doesn't change
+		s.SetMinPasswordScore(0)
+		_, err = s.UpdatePasswordCard("", model.PasswordCard{
+			ID:       "card-id-1",
+			Name:     "AWS",
+			Username: "username",
+			Password: "password",
+			URL:      "https://aws.com/login",
+		})
+		require.NoError(t, err)
+		s.SetMinPasswordScore(3)
+
+		pc, err = s.UpdatePasswordCard("", model.PasswordCard{
+			ID:       "card-id-1",
+			Name:     "Amazon Web Services",
+			Username: "username",
+			Password: model.MaskedPassword,
+			URL:      "https://aws.com/login",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "password", pc.Password)
+		assert.Equal(t, 0, pc.Strength.Score)
+	})
+
+	t.Run("🎉 keeps the password when the revealed one is sent back", func(t *testing.T) {
+		current, err := s.GetPasswordCard("", "card-id-1")
+		require.NoError(t, err)
+
+		s.now = func() time.Time { return current.PasswordChangedAt.Add(time.Hour) }
+		defer func() { s.now = time.Now }()
+
+		pc, err := s.UpdatePasswordCard("", model.PasswordCard{
+			ID:       "card-id-1",
+			Name:     "AWS",
+			Username: "username",
+			Password: "password",
+			URL:      "https://aws.com/login",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, current.PasswordChangedAt.Add(time.Hour), pc.UpdatedAt)
+		assert.Equal(t, current.PasswordChangedAt, pc.PasswordChangedAt)
+	})
+}
+
+func TestPasswordCardItemTypes(t *testing.T) {
+	r := repository.NewPasswordCardRepository()
+	vs := newTestVaultService(repository.NewVaultHeaderRepository())
+	s := NewEncryptedPasswordCardService(r, vs)
+	require.NoError(t, vs.Unlock([]byte("master")))
+	s.SetMinPasswordScore(3)
+	// the empty passwords of the other items would be breached
+	s.SetBreachChecker(breachList{"": 1})
+
+	visa := model.PasswordCard{
+		ID:         "card-id-1",
+		Type:       model.ItemTypeCreditCard,
+		Name:       "Visa",
+		Notes:      "the PIN is 1234",
+		CreditCard: &model.CreditCard{CardholderName: "Jane Doe", Number: "4111 1111 1111 1111", ExpiryMonth: 12, ExpiryYear: 2030, Code: "123"},
+	}
+
+	t.Run("🎉 seals the secrets of the details", func(t *testing.T) {
+		passwordCard, err := s.CreatePasswordCard("user-id-1", visa)
+		require.NoError(t, err)
+		assert.Equal(t, visa.CreditCard, passwordCard.CreditCard)
+		// only the logins have a password to estimate and check
+		assert.Nil(t, passwordCard.Strength)
+		assert.Nil(t, passwordCard.Breached)
+
+		stored, err := r.GetByID("card-id-1")
+		require.NoError(t, err)
+		assert.True(t, vault.IsEncrypted(stored.Notes))
+		assert.True(t, vault.IsEncrypted(stored.CreditCard.Number))
+		assert.True(t, vault.IsEncrypted(stored.CreditCard.Code))
+		assert.Equal(t, "Jane Doe", stored.CreditCard.CardholderName)
+
+		passwordCard, err = s.GetPasswordCard("user-id-1", "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, visa.Notes, passwordCard.Notes)
+		assert.Equal(t, visa.CreditCard, passwordCard.CreditCard)
+	})
+
+	t.Run("🎉 keeps the secrets sent back masked", func(t *testing.T) {
+		masked := visa.Masked()
+		masked.CreditCard.ExpiryYear = 2031
+
+		passwordCard, err := s.UpdatePasswordCard("user-id-1", masked)
+		require.NoError(t, err)
+		assert.Equal(t, "4111 1111 1111 1111", passwordCard.CreditCard.Number)
+		assert.Equal(t, "123", passwordCard.CreditCard.Code)
+		assert.Equal(t, visa.Notes, passwordCard.Notes)
+		assert.Equal(t, 2031, passwordCard.CreditCard.ExpiryYear)
+	})
+
+	t.Run("🎉 stores items without URL", func(t *testing.T) {
+		for _, id := range []string{"note-id-1", "note-id-2"} {
+			_, err := s.CreatePasswordCard("user-id-1", model.PasswordCard{ID: id, Type: model.ItemTypeSecureNote, Name: "Wi-Fi", Notes: "the password is on the router"})
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("🎉 derives the public key of the SSH keys", func(t *testing.T) {
+		passwordCard, err := s.CreatePasswordCard("user-id-1", model.PasswordCard{
+			ID:     "key-id-1",
+			Type:   model.ItemTypeSSHKey,
+			Name:   "Deploy key",
+			SSHKey: &model.SSHKey{PrivateKey: testSSHPrivateKey(t)},
+		})
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(passwordCard.SSHKey.PublicKey, "ssh-ed25519 "), passwordCard.SSHKey.PublicKey)
+		assert.True(t, strings.HasPrefix(passwordCard.SSHKey.Fingerprint, "SHA256:"), passwordCard.SSHKey.Fingerprint)
+	})
+
+	t.Run("🎉 searches the items by type", func(t *testing.T) {
+		page, err := s.SearchPasswordCards("user-id-1", model.PasswordCardQuery{Type: model.ItemTypeSecureNote})
+		require.NoError(t, err)
+		assert.Equal(t, 2, page.Total)
+
+		// the other items have no password to be breached
+		breached := true
+		page, err = s.SearchPasswordCards("user-id-1", model.PasswordCardQuery{Breached: &breached})
+		require.NoError(t, err)
+		assert.Equal(t, 0, page.Total)
+	})
+
+	t.Run("🎉 leaves the other items out of the health report", func(t *testing.T) {
+		report, err := s.HealthReport("user-id-1", model.DefaultHealthReportQuery())
+		require.NoError(t, err)
+		assert.Equal(t, 0, report.TotalCards)
+		assert.Equal(t, 100, report.Score)
+	})
+}
+
+func TestPasswordCardCustomFields(t *testing.T) {
+	r := repository.NewPasswordCardRepository()
+	vs := newTestVaultService(repository.NewVaultHeaderRepository())
+	s := NewEncryptedPasswordCardService(r, vs)
+	require.NoError(t, vs.Unlock([]byte("master")))
+
+	aws := model.PasswordCard{
+		ID:       "card-id-1",
+		Name:     "AWS",
+		Username: "admin",
+		Password: "supersecret",
+		URL:      "https://aws.com/login",
+		CustomFields: []model.CustomField{
+			{Name: "Account ID", Type: model.CustomFieldText, Value: "123456789012"},
+			{Name: "PIN", Type: model.CustomFieldHidden, Value: "4321"},
+			{Name: "MFA enforced", Type: model.CustomFieldBoolean, Value: "true"},
+		},
+	}
+
+	t.Run("🎉 seals the hidden fields", func(t *testing.T) {
+		_, err := s.CreatePasswordCard("user-id-1", aws)
+		require.NoError(t, err)
+
+		stored, err := r.GetByID("card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, "123456789012", stored.CustomFields[0].Value)
+		assert.True(t, vault.IsEncrypted(stored.CustomFields[1].Value))
+
+		passwordCard, err := s.GetPasswordCard("user-id-1", "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, aws.CustomFields, passwordCard.CustomFields)
+	})
+
+	t.Run("🎉 keeps the hidden fields sent back masked", func(t *testing.T) {
+		masked := aws.Masked()
+		masked.CustomFields = append(masked.CustomFields, model.CustomField{Name: "Console", Type: model.CustomFieldLink, Value: "https://console.aws.com/"})
+
+		_, err := s.UpdatePasswordCard("user-id-1", masked)
+		require.NoError(t, err)
+
+		passwordCard, err := s.GetPasswordCard("user-id-1", "card-id-1")
+		require.NoError(t, err)
+		require.Len(t, passwordCard.CustomFields, 4)
+		assert.Equal(t, "4321", passwordCard.CustomFields[1].Value)
+		assert.Equal(t, "https://console.aws.com/", passwordCard.CustomFields[3].Value)
+	})
+
+	t.Run("returns error for a renamed hidden field sent back masked", func(t *testing.T) {
+		masked := aws.Masked()
+		masked.CustomFields[1].Name = "Card PIN"
+
+		_, err := s.UpdatePasswordCard("user-id-1", masked)
+		assert.ErrorIs(t, err, ErrUnknownMaskedSecret{Field: "custom_fields.Card PIN"})
+
+		passwordCard, err := s.GetPasswordCard("user-id-1", "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, "PIN", passwordCard.CustomFields[1].Name)
+		assert.Equal(t, "4321", passwordCard.CustomFields[1].Value)
+	})
+
+	t.Run("🎉 searches the custom fields but the hidden values", func(t *testing.T) {
+		page, err := s.SearchPasswordCards("user-id-1", model.PasswordCardQuery{Search: "123456789012"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, page.Total)
+
+		page, err = s.SearchPasswordCards("user-id-1", model.PasswordCardQuery{Search: "pin"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, page.Total)
+
+		page, err = s.SearchPasswordCards("user-id-1", model.PasswordCardQuery{Search: "4321"})
+		require.NoError(t, err)
+		assert.Equal(t, 0, page.Total)
+	})
+}
+
+// testSSHPrivateKey returns a new ed25519 private key in the OpenSSH format.
+func testSSHPrivateKey(t *testing.T) string {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	block, err := ssh.MarshalPrivateKey(privateKey, "")
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(block))
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/vault"
+)
+
+// SessionTTL is how long a session token is valid after the login.
+const SessionTTL = 24 * time.Hour
+
+var (
+	// ErrInvalidCredentials is returned when the username or the password
+	// don't match. It doesn't tell which one is wrong.
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrInvalidSession is returned for unknown, expired or logged out session
+	// tokens.
+	ErrInvalidSession = errors.New("invalid or expired session")
+	// ErrAdminMasterPassword is returned when an admin username is registered
+	// without the vault master password.
+	ErrAdminMasterPassword = errors.New("admin accounts are registered with the vault master password")
+)
+
+// UserService manages the user accounts and their sessions.
+type UserService struct {
+	userRepository    repository.UserStore
+	sessionRepository repository.SessionStore
+	newKDFParams      func() (vault.KDFParams, error)
+	now               func() time.Time
+	// admins are the lowercase usernames of the users allowed to manage the
+	// vault.
+	admins map[string]bool
+	// vaultService verifies the master password given by the admins when
+	// they register.
+	vaultService *VaultService
+
+	// dummyHash is verified for the unknown usernames, so they take as long
+	// to reject as a wrong password.
+	dummyHash     string
+	dummyHashErr  error
+	dummyHashOnce sync.Once
+}
+
+func NewUserService(userRepository repository.UserStore, sessionRepository repository.SessionStore) *UserService {
+	return CustomUserService(userRepository, sessionRepository, vault.NewKDFParams)
+}
+
+// CustomUserService allows choosing the KDF parameters used to hash the
+// passwords, e.g. to use cheaper ones in tests.
+func CustomUserService(userRepository repository.UserStore, sessionRepository repository.SessionStore, newKDFParams func() (vault.KDFParams, error)) *UserService {
+	return &UserService{
+		userRepository:    userRepository,
+		sessionRepository: sessionRepository,
+		newKDFParams:      newKDFParams,
+		now:               time.Now,
+	}
+}
+
+// SetAdmins gives the admin rights to the users with these usernames, ignoring
+// the case. There are no admins otherwise. Anyone can register, so the admins
+// are only registered with the master password of vaultService: nobody else
+// can take their usernames. Without a vault they can't register.
+func (s *UserService) SetAdmins(usernames []string, vaultService *VaultService) {
+	s.vaultService = vaultService
+	s.admins = make(map[string]bool, len(usernames))
+	for _, username := range usernames {
+		if username = strings.TrimSpace(username); username != "" {
+			s.admins[strings.ToLower(username)] = true
+		}
+	}
+}
+
+// IsAdmin tells whether the user has the admin rights.
+func (s *UserService) IsAdmin(user *model.User) bool {
+	return s.admins[strings.ToLower(user.Username)]
+}
+
+// Register creates a new user. The admins must give the vault master
+// password, ErrAdminMasterPassword is returned otherwise.
+func (s *UserService) Register(credentials model.Credentials) (*model.User, error) {
+	if err := credentials.Validate(); err != nil {
+		return nil, err
+	}
+
+	if s.admins[strings.ToLower(strings.TrimSpace(credentials.Username))] {
+		if err := s.verifyMasterPassword(credentials.MasterPassword); err != nil {
+			return nil, fmt.Errorf("error registering user: %w", err)
+		}
+	}
+
+	params, err := s.newKDFParams()
+	if err != nil {
+		return nil, fmt.Errorf("error registering user: %w", err)
+	}
+
+	passwordHash, err := vault.HashPassword(credentials.Password, params)
+	if err != nil {
+		return nil, fmt.Errorf("error registering user: %w", err)
+	}
+
+	userID, err := randomToken(16, hex.EncodeToString)
+	if err != nil {
+		return nil, fmt.Errorf("error registering user: %w", err)
+	}
+
+	newUser := model.User{
+		ID:           userID,
+		Username:     strings.TrimSpace(credentials.Username),
+		PasswordHash: passwordHash,
+		CreatedAt:    s.now().UTC(),
+	}
+
+	if err := s.userRepository.Insert(newUser); err != nil {
+		return nil, fmt.Errorf("error registering user: %w", err)
+	}
+
+	return &newUser, nil
+}
+
+// verifyMasterPassword checks the master password given by an admin. The
+// wrong, missing or not yet created master passwords are all reported as
+// ErrAdminMasterPassword.
+func (s *UserService) verifyMasterPassword(masterPassword string) error {
+	if s.vaultService == nil || masterPassword == "" {
+		return ErrAdminMasterPassword
+	}
+
+	err := s.vaultService.VerifyMasterPassword([]byte(masterPassword))
+	if errors.Is(err, vault.ErrWrongMasterPassword) || errors.Is(err, ErrVaultNotCreated) {
+		return ErrAdminMasterPassword
+	}
+
+	return err
+}
+
+// Login verifies the credentials and starts a new session. Only the hash of
+// the returned token is stored.
+func (s *UserService) Login(credentials model.Credentials) (token string, session *model.Session, err error) {
+	user, err := s.userRepository.GetByUsername(strings.TrimSpace(credentials.Username))
+	if err != nil {
+		var errNotFound repository.ErrUserNotFound
+		if !errors.As(err, &errNotFound) {
+			return "", nil, fmt.Errorf("error logging in: %w", err)
+		}
+
+		// the hash is verified anyway, so the unknown usernames can't be told
+		// apart by the response time
+		dummyHash, err := s.dummyPasswordHash()
+		if err != nil {
+			return "", nil, fmt.Errorf("error logging in: %w", err)
+		}
+		if _, err := vault.VerifyPassword(credentials.Password, dummyHash); err != nil {
+			return "", nil, fmt.Errorf("error logging in: %w", err)
+		}
+
+		return "", nil, ErrInvalidCredentials
+	}
+
+	ok, err := vault.VerifyPassword(credentials.Password, user.PasswordHash)
+	if err != nil {
+		return "", nil, fmt.Errorf("error logging in: %w", err)
+	}
+
+	if !ok {
+		return "", nil, ErrInvalidCredentials
+	}
+
+	token, err = randomToken(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", nil, fmt.Errorf("error logging in: %w", err)
+	}
+
+	now := s.now().UTC()
+
+	// it's a good moment to forget the old sessions
+	if err := s.sessionRepository.DeleteExpired(now); err != nil {
+		return "", nil, fmt.Errorf("error logging in: %w", err)
+	}
+
+	session = &model.Session{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: now.Add(SessionTTL),
+	}
+
+	if err := s.sessionRepository.Insert(*session); err != nil {
+		return "", nil, fmt.Errorf("error logging in: %w", err)
+	}
+
+	return token, session, nil
+}
+
+// dummyPasswordHash returns the hash of a random password, computed once with
+// the parameters of the new users.
+func (s *UserService) dummyPasswordHash() (string, error) {
+	s.dummyHashOnce.Do(func() {
+		password, err := randomToken(16, hex.EncodeToString)
+		if err != nil {
+			s.dummyHashErr = err
+			return
+		}
+
+		params, err := s.newKDFParams()
+		if err != nil {
+			s.dummyHashErr = err
+			return
+		}
+
+		s.dummyHash, s.dummyHashErr = vault.HashPassword(password, params)
+	})
+
+	return s.dummyHash, s.dummyHashErr
+}
+
+// Logout ends the session of the token.
+func (s *UserService) Logout(token string) error {
+	if err := s.sessionRepository.Delete(hashToken(token)); err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return ErrInvalidSession
+		}
+		return fmt.Errorf("error logging out: %w", err)
+	}
+
+	return nil
+}
+
+// Authenticate returns the user of a session token.
+func (s *UserService) Authenticate(token string) (*model.User, error) {
+	session, err := s.sessionRepository.GetByTokenHash(hashToken(token))
+	if err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return nil, ErrInvalidSession
+		}
+		return nil, fmt.Errorf("error authenticating: %w", err)
+	}
+
+	if !s.now().Before(session.ExpiresAt) {
+		return nil, ErrInvalidSession
+	}
+
+	user, err := s.userRepository.GetByID(session.UserID)
+	if err != nil {
+		var errNotFound repository.ErrUserNotFound
+		if errors.As(err, &errNotFound) {
+			return nil, ErrInvalidSession
+		}
+		return nil, fmt.Errorf("error authenticating: %w", err)
+	}
+
+	return user, nil
+}
+
+// randomToken returns size random bytes encoded with encode.
+func randomToken(size int, encode func([]byte) string) (string, error) {
+	b := make([]byte, size)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", fmt.Errorf("error generating random token: %w", err)
+	}
+
+	return encode(b), nil
+}
+
+// hashToken returns the hash under which a session token is stored. The tokens
+// are random, so a fast hash is enough.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestUserService returns a user service using cheap KDF parameters.
+func newTestUserService() *UserService {
+	return CustomUserService(repository.NewUserRepository(), repository.NewSessionRepository(), func() (vault.KDFParams, error) {
+		return vault.KDFParams{Time: 1, Memory: 64, Threads: 1}, nil
+	})
+}
+
+func TestUserServiceRegister(t *testing.T) {
+	s := newTestUserService()
+
+	_, err := s.Register(model.Credentials{Username: "alice", Password: "short"})
+	assert.EqualError(t, err, "password must have at least 8 characters")
+
+	user, err := s.Register(model.Credentials{Username: " alice ", Password: "supersecret"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Username)
+	assert.NotEmpty(t, user.ID)
+	assert.NotContains(t, user.PasswordHash, "supersecret")
+
+	_, err = s.Register(model.Credentials{Username: "Alice", Password: "supersecret"})
+	assert.ErrorIs(t, err, repository.ErrUserAlreadyExists{Username: "Alice"})
+}
+
+func TestUserServiceLogin(t *testing.T) {
+	s := newTestUserService()
+
+	now := time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	user, err := s.Register(model.Credentials{Username: "alice", Password: "supersecret"})
+	require.NoError(t, err)
+
+	t.Run("returns error for wrong credentials", func(t *testing.T) {
+		_, _, err := s.Login(model.Credentials{Username: "alice", Password: "wrongsecret"})
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+		_, _, err = s.Login(model.Credentials{Username: "bob", Password: "supersecret"})
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		// the unknown usernames are checked against a dummy hash
+		assert.NotEmpty(t, s.dummyHash)
+
+		_, err = s.Authenticate("unknown-token")
+		assert.ErrorIs(t, err, ErrInvalidSession)
+	})
+
+	t.Run("🎉 logs in and out", func(t *testing.T) {
+		token, session, err := s.Login(model.Credentials{Username: "ALICE", Password: "supersecret"})
+		require.NoError(t, err)
+		assert.NotEmpty(t, token)
+		assert.NotEqual(t, token, session.TokenHash)
+		assert.Equal(t, now.Add(SessionTTL), session.ExpiresAt)
+
+		authenticated, err := s.Authenticate(token)
+		require.NoError(t, err)
+		assert.Equal(t, user, authenticated)
+
+		require.NoError(t, s.Logout(token))
+
+		_, err = s.Authenticate(token)
+		assert.ErrorIs(t, err, ErrInvalidSession)
+		assert.ErrorIs(t, s.Logout(token), ErrInvalidSession)
+	})
+
+	t.Run("returns error for expired sessions", func(t *testing.T) {
+		token, _, err := s.Login(model.Credentials{Username: "alice", Password: "supersecret"})
+		require.NoError(t, err)
+
+		now = now.Add(SessionTTL)
+
+		_, err = s.Authenticate(token)
+		assert.ErrorIs(t, err, ErrInvalidSession)
+	})
+}
+
+func TestUserServiceIsAdmin(t *testing.T) {
+	s := newTestUserService()
+
+	alice := &model.User{ID: "user-id-1", Username: "alice"}
+	assert.False(t, s.IsAdmin(alice), "there are no admins by default")
+
+	s.SetAdmins([]string{" Alice", ""}, nil)
+	assert.True(t, s.IsAdmin(alice))
+	assert.False(t, s.IsAdmin(&model.User{ID: "user-id-2", Username: "bob"}))
+}
+
+func TestUserServiceRegisterAdmin(t *testing.T) {
+	vaultService := newTestVaultService(repository.NewVaultHeaderRepository())
+	s := newTestUserService()
+	s.SetAdmins([]string{"alice"}, vaultService)
+
+	t.Run("returns error before the vault is created", func(t *testing.T) {
+		_, err := s.Register(model.Credentials{Username: "alice", Password: "supersecret", MasterPassword: "master"})
+		assert.ErrorIs(t, err, ErrAdminMasterPassword)
+	})
+
+	require.NoError(t, vaultService.Unlock([]byte("master")))
+	vaultService.Lock()
+
+	t.Run("returns error without the right master password", func(t *testing.T) {
+		for _, masterPassword := range []string{"", "wrong"} {
+			_, err := s.Register(model.Credentials{Username: " Alice ", Password: "supersecret", MasterPassword: masterPassword})
+			assert.ErrorIs(t, err, ErrAdminMasterPassword)
+		}
+	})
+
+	t.Run("🎉 registers the admins with the master password", func(t *testing.T) {
+		user, err := s.Register(model.Credentials{Username: "alice", Password: "supersecret", MasterPassword: "master"})
+		require.NoError(t, err)
+		assert.True(t, s.IsAdmin(user))
+		// verifying the master password doesn't unlock the vault
+		assert.True(t, vaultService.IsLocked())
+
+		_, err = s.Register(model.Credentials{Username: "bob", Password: "supersecret"})
+		require.NoError(t, err)
+	})
+
+	t.Run("returns error without a vault", func(t *testing.T) {
+		s := newTestUserService()
+		s.SetAdmins([]string{"alice"}, nil)
+
+		_, err := s.Register(model.Credentials{Username: "alice", Password: "supersecret", MasterPassword: "master"})
+		assert.ErrorIs(t, err, ErrAdminMasterPassword)
+	})
+}
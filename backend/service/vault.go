@@ -0,0 +1,281 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/vault"
+)
+
+var (
+	// ErrVaultLocked is returned by the operations that need the vault key
+	// while the vault wasn't unlocked with the master password.
+	ErrVaultLocked = errors.New("vault is locked")
+	// ErrVaultNotCreated is returned when the master password is verified
+	// before the vault was created by its first unlock.
+	ErrVaultNotCreated = errors.New("vault was not created")
+)
+
+// VaultService manages the master password that protects the vault.
+type VaultService struct {
+	vaultHeaderRepository repository.VaultHeaderStore
+	newKDFParams          func() (vault.KDFParams, error)
+	now                   func() time.Time
+
+	mu           sync.RWMutex
+	vault        *vault.Vault
+	lastActivity time.Time
+
+	// rotationMu serializes the master key rotations.
+	rotationMu sync.Mutex
+}
+
+func NewVaultService(vaultHeaderRepository repository.VaultHeaderStore) *VaultService {
+	return CustomVaultService(vaultHeaderRepository, vault.NewKDFParams)
+}
+
+// CustomVaultService allows choosing the KDF parameters of new vaults, e.g. to
+// use cheaper ones in tests.
+func CustomVaultService(vaultHeaderRepository repository.VaultHeaderStore, newKDFParams func() (vault.KDFParams, error)) *VaultService {
+	return &VaultService{
+		vaultHeaderRepository: vaultHeaderRepository,
+		newKDFParams:          newKDFParams,
+		now:                   time.Now,
+	}
+}
+
+// Unlock derives the vault key from the master password. The first unlock
+// creates the vault, every following one must use the same master password.
+func (s *VaultService) Unlock(masterPassword []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	header, err := s.vaultHeaderRepository.LoadVaultHeader()
+	if err != nil {
+		return fmt.Errorf("error unlocking vault: %w", err)
+	}
+
+	if header != nil {
+		v, err := vault.Open(*header, masterPassword)
+		if err != nil {
+			return fmt.Errorf("error unlocking vault: %w", err)
+		}
+
+		s.vault = v
+		s.lastActivity = s.now()
+		return nil
+	}
+
+	params, err := s.newKDFParams()
+	if err != nil {
+		return fmt.Errorf("error creating vault: %w", err)
+	}
+
+	v, err := vault.Create(masterPassword, params)
+	if err != nil {
+		return fmt.Errorf("error creating vault: %w", err)
+	}
+
+	if err := s.vaultHeaderRepository.SaveVaultHeader(v.Header()); err != nil {
+		return fmt.Errorf("error creating vault: %w", err)
+	}
+
+	s.vault = v
+	s.lastActivity = s.now()
+
+	return nil
+}
+
+// VerifyMasterPassword checks the master password without unlocking the
+// vault. It returns vault.ErrWrongMasterPassword when it doesn't match, and
+// ErrVaultNotCreated before the first unlock.
+func (s *VaultService) VerifyMasterPassword(masterPassword []byte) error {
+	header, err := s.vaultHeaderRepository.LoadVaultHeader()
+	if err != nil {
+		return fmt.Errorf("error verifying master password: %w", err)
+	}
+	if header == nil {
+		return ErrVaultNotCreated
+	}
+
+	v, err := vault.Open(*header, masterPassword)
+	if err != nil {
+		return fmt.Errorf("error verifying master password: %w", err)
+	}
+	v.Wipe()
+
+	return nil
+}
+
+// Lock forgets the vault key. The secrets can't be read again until the vault
+// is unlocked with the master password.
+func (s *VaultService) Lock() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lock()
+}
+
+// lock must be called with the lock held.
+func (s *VaultService) lock() {
+	if s.vault != nil {
+		s.vault.Wipe()
+	}
+
+	s.vault = nil
+}
+
+// IsLocked reports whether the vault needs the master password.
+func (s *VaultService) IsLocked() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.vault == nil
+}
+
+// Touch records an activity, postponing the auto-lock.
+func (s *VaultService) Touch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastActivity = s.now()
+}
+
+// AutoLock locks the vault once it has been idle, i.e. without calls to Touch,
+// for the given timeout. It returns a function that stops the auto-lock.
+func (s *VaultService) AutoLock(timeout time.Duration) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(autoLockInterval(timeout))
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.lockIfIdle(timeout)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// autoLockInterval is how often the idle time is checked. It keeps the vault
+// from staying unlocked much longer than the timeout.
+func autoLockInterval(timeout time.Duration) time.Duration {
+	interval := timeout / 10
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	return interval
+}
+
+// lockIfIdle locks the vault when the last activity is older than timeout.
+// It reports whether the vault was locked by this call.
+func (s *VaultService) lockIfIdle(timeout time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.vault == nil || s.now().Sub(s.lastActivity) < timeout {
+		return false
+	}
+
+	s.lock()
+
+	return true
+}
+
+// BeginRotation replaces the master key by a new one derived from
+// newMasterPassword, which may be the current master password to only rotate
+// the key. The vault keeps working during the rotation: the data keys wrapped
+// by the previous master key are still readable until FinishRotation.
+func (s *VaultService) BeginRotation(masterPassword, newMasterPassword []byte) error {
+	s.rotationMu.Lock()
+	defer s.rotationMu.Unlock()
+
+	if s.IsLocked() {
+		return ErrVaultLocked
+	}
+
+	header, err := s.vaultHeaderRepository.LoadVaultHeader()
+	if err != nil {
+		return fmt.Errorf("error rotating master key: %w", err)
+	}
+	if header == nil {
+		return fmt.Errorf("error rotating master key: %w", ErrVaultNotCreated)
+	}
+
+	// the key derivation is slow, it runs without blocking the vault
+	current, err := vault.Open(*header, masterPassword)
+	if err != nil {
+		return fmt.Errorf("error rotating master key: %w", err)
+	}
+
+	params, err := s.newKDFParams()
+	if err != nil {
+		return fmt.Errorf("error rotating master key: %w", err)
+	}
+
+	rotated, err := current.Rotate(newMasterPassword, params)
+	if err != nil {
+		return fmt.Errorf("error rotating master key: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the header is saved before any data key is rewrapped, so the previous
+	// master key is never lost
+	if err := s.vaultHeaderRepository.SaveVaultHeader(rotated.Header()); err != nil {
+		return fmt.Errorf("error rotating master key: %w", err)
+	}
+
+	s.vault = rotated
+
+	return nil
+}
+
+// FinishRotation forgets the previous master key once every data key was
+// rewrapped. It's a no-op when there's no rotation in progress.
+func (s *VaultService) FinishRotation() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.vault == nil {
+		return ErrVaultLocked
+	}
+
+	if !s.vault.IsRotating() {
+		return nil
+	}
+
+	finished := s.vault.FinishRotation()
+	if err := s.vaultHeaderRepository.SaveVaultHeader(finished.Header()); err != nil {
+		return fmt.Errorf("error finishing master key rotation: %w", err)
+	}
+
+	s.vault = finished
+
+	return nil
+}
+
+// Vault returns the unlocked vault or ErrVaultLocked.
+func (s *VaultService) Vault() (*vault.Vault, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.vault == nil {
+		return nil, ErrVaultLocked
+	}
+
+	return s.vault, nil
+}
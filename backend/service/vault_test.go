@@ -0,0 +1,101 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/repository"
+	"github.com/CaioTeixeira95/password-manager/backend/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestVaultService returns a vault service using cheap KDF parameters.
+func newTestVaultService(vaultHeaderRepository repository.VaultHeaderStore) *VaultService {
+	return CustomVaultService(vaultHeaderRepository, func() (vault.KDFParams, error) {
+		return vault.KDFParams{Salt: []byte("0123456789abcdef"), Time: 1, Memory: 64, Threads: 1}, nil
+	})
+}
+
+func TestVaultServiceUnlock(t *testing.T) {
+	hr := repository.NewVaultHeaderRepository()
+	s := newTestVaultService(hr)
+
+	_, err := s.Vault()
+	assert.ErrorIs(t, err, ErrVaultLocked)
+
+	// the first unlock creates the vault
+	require.NoError(t, s.Unlock([]byte("master")))
+
+	header, err := hr.LoadVaultHeader()
+	require.NoError(t, err)
+	require.NotNil(t, header)
+
+	v, err := s.Vault()
+	require.NoError(t, err)
+	assert.Equal(t, *header, v.Header())
+
+	s = newTestVaultService(hr)
+
+	err = s.Unlock([]byte("wrong"))
+	assert.ErrorIs(t, err, vault.ErrWrongMasterPassword)
+
+	_, err = s.Vault()
+	assert.ErrorIs(t, err, ErrVaultLocked)
+
+	require.NoError(t, s.Unlock([]byte("master")))
+
+	_, err = s.Vault()
+	assert.NoError(t, err)
+}
+
+func TestVaultServiceLock(t *testing.T) {
+	s := newTestVaultService(repository.NewVaultHeaderRepository())
+	assert.True(t, s.IsLocked())
+
+	require.NoError(t, s.Unlock([]byte("master")))
+	assert.False(t, s.IsLocked())
+
+	s.Lock()
+	assert.True(t, s.IsLocked())
+
+	_, err := s.Vault()
+	assert.ErrorIs(t, err, ErrVaultLocked)
+}
+
+func TestVaultServiceAutoLock(t *testing.T) {
+	t.Run("locks only after the idle timeout", func(t *testing.T) {
+		now := time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+
+		s := newTestVaultService(repository.NewVaultHeaderRepository())
+		s.now = func() time.Time { return now }
+
+		require.NoError(t, s.Unlock([]byte("master")))
+
+		now = now.Add(10 * time.Minute)
+		assert.False(t, s.lockIfIdle(15*time.Minute))
+
+		s.Touch()
+
+		now = now.Add(10 * time.Minute)
+		assert.False(t, s.lockIfIdle(15*time.Minute))
+		assert.False(t, s.IsLocked())
+
+		now = now.Add(5 * time.Minute)
+		assert.True(t, s.lockIfIdle(15*time.Minute))
+		assert.True(t, s.IsLocked())
+
+		// an already locked vault isn't locked again
+		assert.False(t, s.lockIfIdle(15*time.Minute))
+	})
+
+	t.Run("🎉 locks the vault in background", func(t *testing.T) {
+		s := newTestVaultService(repository.NewVaultHeaderRepository())
+		require.NoError(t, s.Unlock([]byte("master")))
+
+		stop := s.AutoLock(20 * time.Millisecond)
+		defer stop()
+
+		assert.Eventually(t, s.IsLocked, time.Second, 10*time.Millisecond)
+	})
+}
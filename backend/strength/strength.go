@@ -0,0 +1,58 @@
+// Package strength estimates how hard passwords are to guess.
+//
+// The estimation follows zxcvbn: the password is matched against dictionaries
+// of common passwords, names and words, keyboard patterns, sequences, repeats
+// and dates, and the cheapest combination of matches gives the number of
+// guesses an attacker needs.
+package strength
+
+import (
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	zxcvbn "github.com/nbutton23/zxcvbn-go"
+)
+
+// maxEstimatedLength is the number of characters of the password and of the
+// user inputs that are estimated. The matching is superlinear in the length,
+// and a password this long is out of reach of any attack anyway.
+const maxEstimatedLength = 100
+
+// Estimate returns the strength of password. The userInputs, like the username
+// or the site name, are matched as a dictionary too, since an attacker who
+// targets the account knows them.
+func Estimate(password string, userInputs ...string) model.PasswordStrength {
+	truncatedInputs := make([]string, 0, len(userInputs))
+	for _, userInput := range userInputs {
+		truncatedInputs = append(truncatedInputs, truncate(userInput))
+	}
+
+	result := zxcvbn.PasswordStrength(truncate(password), truncatedInputs)
+
+	return model.PasswordStrength{
+		Score:            result.Score,
+		CrackTimeSeconds: result.CrackTime,
+		CrackTimeDisplay: result.CrackTimeDisplay,
+	}
+}
+
+// truncate returns the first maxEstimatedLength characters of s.
+func truncate(s string) string {
+	if utf8.RuneCountInString(s) <= maxEstimatedLength {
+		return s
+	}
+	return string([]rune(s)[:maxEstimatedLength])
+}
+
+// EstimateCard returns the strength of the password of the card, using its
+// name, username and URL host as user inputs.
+func EstimateCard(passwordCard model.PasswordCard) model.PasswordStrength {
+	userInputs := []string{passwordCard.Name, passwordCard.Username}
+	if u, err := url.Parse(passwordCard.URL); err == nil && u.Hostname() != "" {
+		userInputs = append(userInputs, strings.Split(u.Hostname(), ".")...)
+	}
+
+	return Estimate(passwordCard.Password, userInputs...)
+}
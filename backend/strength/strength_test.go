@@ -0,0 +1,55 @@
+package strength
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CaioTeixeira95/password-manager/backend/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		password string
+		score    int
+	}{
+		{name: "common password", password: "password", score: 0},
+		{name: "keyboard pattern", password: "qwertyuiop", score: 0},
+		{name: "repeat", password: "aaaaaaaaaa", score: 0},
+		{name: "date", password: "19/08/1995", score: 1},
+		{name: "🎉 random password", password: "kX9#mQ2$vL7!pR4@", score: model.MaxPasswordScore},
+		{name: "🎉 passphrase", password: "correct-horse-battery-staple", score: model.MaxPasswordScore},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			estimated := Estimate(tc.password)
+			assert.Equal(t, tc.score, estimated.Score)
+			assert.NotEmpty(t, estimated.CrackTimeDisplay)
+		})
+	}
+}
+
+func TestEstimateLongPassword(t *testing.T) {
+	// only the start of the password is estimated, it's already out of reach
+	password := strings.Repeat("kX9#mQ2$vL7!pR4@", 64)
+
+	start := time.Now()
+	estimated := Estimate(password, password)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, model.MaxPasswordScore, estimated.Score)
+}
+
+func TestEstimateCard(t *testing.T) {
+	passwordCard := model.PasswordCard{
+		Name:     "Acme",
+		Username: "wile.coyote",
+		Password: "wile.coyote.acmecorp",
+		URL:      "https://login.acmecorp.com/",
+	}
+
+	// the password is made of the username and the host, which are known
+	assert.Less(t, EstimateCard(passwordCard).CrackTimeSeconds, Estimate(passwordCard.Password).CrackTimeSeconds)
+}
@@ -0,0 +1,180 @@
+package vault
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrRotationInProgress is returned when a rotation starts before the previous
+// one was finished with Vault.FinishRotation.
+var ErrRotationInProgress = errors.New("a master key rotation is already in progress")
+
+// NewDataKey returns a random key to encrypt the secrets of a single record.
+// Data keys are stored next to the record, wrapped by the master key.
+func NewDataKey() ([]byte, error) {
+	dataKey := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, fmt.Errorf("error generating data key: %w", err)
+	}
+
+	return dataKey, nil
+}
+
+// SealString encrypts plaintext with a data key and encodes it as a printable
+// string that can be told apart from plaintext with IsEncrypted.
+func SealString(dataKey []byte, plaintext, additionalData string) (string, error) {
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := seal(aead, []byte(plaintext), []byte(additionalData))
+	if err != nil {
+		return "", err
+	}
+
+	return dataKeySealedPrefix + base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// OpenString decrypts a string produced by SealString.
+func OpenString(dataKey []byte, ciphertext, additionalData string) (string, error) {
+	if !strings.HasPrefix(ciphertext, dataKeySealedPrefix) {
+		return "", fmt.Errorf("value is not encrypted with a data key")
+	}
+
+	raw, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(ciphertext, dataKeySealedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("error decoding encrypted value: %w", err)
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := open(aead, raw, []byte(additionalData))
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// WrapDataKey encrypts a data key with the master key. The result names the
+// master key that wrapped it, so it can still be unwrapped in the middle of a
+// rotation.
+func (v *Vault) WrapDataKey(dataKey []byte, additionalData string) (string, error) {
+	ciphertext, err := seal(v.current.aead, dataKey, []byte(additionalData))
+	if err != nil {
+		return "", err
+	}
+
+	return wrappedKeyPrefix + v.current.id + ":" + base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// UnwrapDataKey decrypts a data key wrapped by WrapDataKey with either the
+// current master key or, during a rotation, the previous one.
+func (v *Vault) UnwrapDataKey(wrappedKey, additionalData string) ([]byte, error) {
+	keyID, ciphertext, err := parseWrappedKey(wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var mk *masterKey
+	switch {
+	case keyID == v.current.id:
+		mk = v.current
+	case v.previous != nil && keyID == v.previous.id:
+		mk = v.previous
+	default:
+		return nil, fmt.Errorf("data key is wrapped by an unknown master key %q", keyID)
+	}
+
+	return open(mk.aead, ciphertext, []byte(additionalData))
+}
+
+// IsWrappedByCurrentKey reports whether the data key is wrapped by the current
+// master key, i.e. it doesn't need to be rewrapped after a rotation.
+func (v *Vault) IsWrappedByCurrentKey(wrappedKey string) bool {
+	keyID, _, err := parseWrappedKey(wrappedKey)
+	return err == nil && keyID == v.current.id
+}
+
+// RewrapDataKey wraps the data key again with the current master key. The
+// secrets encrypted with the data key don't change.
+func (v *Vault) RewrapDataKey(wrappedKey, additionalData string) (string, error) {
+	dataKey, err := v.UnwrapDataKey(wrappedKey, additionalData)
+	if err != nil {
+		return "", err
+	}
+	defer wipe(dataKey)
+
+	return v.WrapDataKey(dataKey, additionalData)
+}
+
+func parseWrappedKey(wrappedKey string) (string, []byte, error) {
+	keyID, encoded, ok := strings.Cut(strings.TrimPrefix(wrappedKey, wrappedKeyPrefix), ":")
+	if !strings.HasPrefix(wrappedKey, wrappedKeyPrefix) || !ok {
+		return "", nil, fmt.Errorf("invalid wrapped data key")
+	}
+
+	ciphertext, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, fmt.Errorf("error decoding wrapped data key: %w", err)
+	}
+
+	return keyID, ciphertext, nil
+}
+
+// Rotate returns a vault with a new master key derived from newMasterPassword,
+// which may be the same master password. The new header keeps the current
+// master key, sealed by the new one, so the data keys can be rewrapped one by
+// one with RewrapDataKey. Once all of them are rewrapped FinishRotation drops
+// the previous master key.
+func (v *Vault) Rotate(newMasterPassword []byte, params KDFParams) (*Vault, error) {
+	if v.previous != nil {
+		return nil, ErrRotationInProgress
+	}
+
+	rotated, err := Create(newMasterPassword, params)
+	if err != nil {
+		return nil, err
+	}
+
+	sealedKey, err := seal(rotated.current.aead, v.current.key, []byte(v.current.id))
+	if err != nil {
+		return nil, err
+	}
+
+	rotated.header.Previous = &PreviousKey{KeyID: v.current.id, Key: sealedKey}
+	rotated.previous = v.current
+
+	return rotated, nil
+}
+
+// IsRotating reports whether the vault still knows a previous master key.
+func (v *Vault) IsRotating() bool {
+	return v.previous != nil
+}
+
+// FinishRotation returns the vault without the previous master key. Data keys
+// still wrapped by it can't be unwrapped anymore.
+func (v *Vault) FinishRotation() *Vault {
+	header := v.header
+	header.Previous = nil
+
+	return &Vault{header: header, current: v.current}
+}
+
+// Wipe overwrites the master keys kept by the vault. The vault can't wrap keys
+// for a rotation afterwards.
+func (v *Vault) Wipe() {
+	wipe(v.current.key)
+	if v.previous != nil {
+		wipe(v.previous.key)
+	}
+}
@@ -0,0 +1,119 @@
+package vault
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealString(t *testing.T) {
+	dataKey, err := NewDataKey()
+	require.NoError(t, err)
+	assert.Len(t, dataKey, keySize)
+
+	sealed, err := SealString(dataKey, "supersecret", "card-id-1/password")
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(sealed))
+	assert.False(t, IsMasterKeyEncrypted(sealed))
+
+	t.Run("returns error for another data key", func(t *testing.T) {
+		anotherKey, err := NewDataKey()
+		require.NoError(t, err)
+
+		_, err = OpenString(anotherKey, sealed, "card-id-1/password")
+		assert.ErrorIs(t, err, ErrDecrypt)
+	})
+
+	t.Run("returns error for another field", func(t *testing.T) {
+		_, err := OpenString(dataKey, sealed, "card-id-1/username")
+		assert.ErrorIs(t, err, ErrDecrypt)
+	})
+
+	t.Run("🎉 opens the value", func(t *testing.T) {
+		plaintext, err := OpenString(dataKey, sealed, "card-id-1/password")
+		require.NoError(t, err)
+		assert.Equal(t, "supersecret", plaintext)
+	})
+}
+
+func TestWrapDataKey(t *testing.T) {
+	v, err := Create([]byte("master"), testKDFParams)
+	require.NoError(t, err)
+
+	dataKey, err := NewDataKey()
+	require.NoError(t, err)
+
+	wrapped, err := v.WrapDataKey(dataKey, "card-id-1")
+	require.NoError(t, err)
+	assert.True(t, v.IsWrappedByCurrentKey(wrapped))
+
+	t.Run("returns error for another card", func(t *testing.T) {
+		_, err := v.UnwrapDataKey(wrapped, "card-id-2")
+		assert.ErrorIs(t, err, ErrDecrypt)
+	})
+
+	t.Run("returns error for an invalid wrapped key", func(t *testing.T) {
+		_, err := v.UnwrapDataKey("garbage", "card-id-1")
+		assert.EqualError(t, err, "invalid wrapped data key")
+		assert.False(t, v.IsWrappedByCurrentKey("garbage"))
+	})
+
+	t.Run("🎉 unwraps the data key", func(t *testing.T) {
+		unwrapped, err := v.UnwrapDataKey(wrapped, "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, dataKey, unwrapped)
+	})
+}
+
+func TestRotate(t *testing.T) {
+	v, err := Create([]byte("master"), testKDFParams)
+	require.NoError(t, err)
+
+	dataKey, err := NewDataKey()
+	require.NoError(t, err)
+
+	wrapped, err := v.WrapDataKey(dataKey, "card-id-1")
+	require.NoError(t, err)
+
+	params := testKDFParams
+	params.Salt = []byte("fedcba9876543210")
+
+	rotated, err := v.Rotate([]byte("new master"), params)
+	require.NoError(t, err)
+	assert.True(t, rotated.IsRotating())
+	assert.False(t, rotated.IsWrappedByCurrentKey(wrapped))
+
+	_, err = rotated.Rotate([]byte("another master"), params)
+	assert.ErrorIs(t, err, ErrRotationInProgress)
+
+	// the process may stop here: the header alone, opened with the new master
+	// password, still reads the data keys wrapped by the previous master key
+	header := rotated.Header()
+	require.NotNil(t, header.Previous)
+
+	_, err = Open(header, []byte("master"))
+	assert.ErrorIs(t, err, ErrWrongMasterPassword)
+
+	reopened, err := Open(header, []byte("new master"))
+	require.NoError(t, err)
+
+	unwrapped, err := reopened.UnwrapDataKey(wrapped, "card-id-1")
+	require.NoError(t, err)
+	assert.Equal(t, dataKey, unwrapped)
+
+	rewrapped, err := reopened.RewrapDataKey(wrapped, "card-id-1")
+	require.NoError(t, err)
+	assert.True(t, reopened.IsWrappedByCurrentKey(rewrapped))
+
+	finished := reopened.FinishRotation()
+	assert.False(t, finished.IsRotating())
+	assert.Nil(t, finished.Header().Previous)
+
+	_, err = finished.UnwrapDataKey(wrapped, "card-id-1")
+	assert.ErrorContains(t, err, "data key is wrapped by an unknown master key")
+
+	unwrapped, err = finished.UnwrapDataKey(rewrapped, "card-id-1")
+	require.NoError(t, err)
+	assert.Equal(t, dataKey, unwrapped)
+}
@@ -0,0 +1,42 @@
+package vault
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashPassword(t *testing.T) {
+	params := testKDFParams
+	params.Salt = nil
+
+	hash, err := HashPassword("correct horse", params)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"), hash)
+
+	// a new salt is generated for every hash
+	another, err := HashPassword("correct horse", params)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, another)
+
+	t.Run("🎉 verifies the password", func(t *testing.T) {
+		ok, err := VerifyPassword("correct horse", hash)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("rejects a wrong password", func(t *testing.T) {
+		ok, err := VerifyPassword("wrong horse", hash)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("returns error for an invalid hash", func(t *testing.T) {
+		for _, invalid := range []string{"", "plaintext", "$bcrypt$v=19$m=64,t=1,p=1$c2FsdA$aGFzaA", "$argon2id$v=19$m=x$c2FsdA$aGFzaA"} {
+			_, err := VerifyPassword("correct horse", invalid)
+			assert.Error(t, err, invalid)
+		}
+	})
+}
@@ -0,0 +1,291 @@
+// Package vault encrypts the secrets of the password manager with a key
+// derived from a master password.
+//
+// The master key is derived with Argon2id and every secret is sealed with
+// AES-256-GCM, so tampered ciphertexts are detected instead of silently
+// decrypted. The salt and the KDF parameters are kept in a Header that is
+// stored next to the data; the header alone doesn't reveal anything about the
+// master password.
+//
+// Secrets are not sealed with the master key itself but with per-record data
+// keys, which are stored wrapped by the master key (envelope encryption).
+// Rotating the master key only rewraps the data keys.
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	// HeaderVersion is the version of the Header format.
+	HeaderVersion = 1
+
+	keySize  = 32
+	saltSize = 16
+
+	// sealedPrefix marks the strings produced by Vault.EncryptString.
+	sealedPrefix = "vault:v1:"
+	// dataKeySealedPrefix marks the strings produced by SealString.
+	dataKeySealedPrefix = "vault:v2:"
+	// wrappedKeyPrefix marks the data keys wrapped by Vault.WrapDataKey.
+	wrappedKeyPrefix = "key:v1:"
+)
+
+// checkPlaintext is sealed in the header to verify the master password.
+var checkPlaintext = []byte("password-manager vault check")
+
+var (
+	// ErrWrongMasterPassword is returned when the master password doesn't
+	// match the one used to create the vault.
+	ErrWrongMasterPassword = errors.New("wrong master password")
+	// ErrDecrypt is returned when a ciphertext can't be authenticated.
+	ErrDecrypt = errors.New("unable to decrypt: message authentication failed")
+)
+
+// KDFParams are the Argon2id parameters used to derive the vault key.
+type KDFParams struct {
+	Salt    []byte `json:"salt"`
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+}
+
+// NewKDFParams returns the recommended Argon2id parameters with a new random
+// salt.
+func NewKDFParams() (KDFParams, error) {
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return KDFParams{}, fmt.Errorf("error generating salt: %w", err)
+	}
+
+	return KDFParams{
+		Salt:    salt,
+		Time:    3,
+		Memory:  64 * 1024,
+		Threads: 4,
+	}, nil
+}
+
+// DeriveKey derives a 256-bit key from the master password.
+func DeriveKey(masterPassword []byte, params KDFParams) []byte {
+	return argon2.IDKey(masterPassword, params.Salt, params.Time, params.Memory, params.Threads, keySize)
+}
+
+// Header holds what is needed to derive and verify the vault key. It is safe
+// to store it in plaintext.
+type Header struct {
+	Version int       `json:"version"`
+	KDF     KDFParams `json:"kdf"`
+	// Check is a known plaintext sealed with the vault key.
+	Check []byte `json:"check"`
+	// Previous is set while a master key rotation is in progress, see
+	// Vault.Rotate.
+	Previous *PreviousKey `json:"previous,omitempty"`
+}
+
+// PreviousKey is the master key replaced by a rotation, sealed with the new
+// master key. It keeps the data keys wrapped by it readable until they are all
+// rewrapped, even if the process stops in the middle of the rotation.
+type PreviousKey struct {
+	KeyID string `json:"key_id"`
+	Key   []byte `json:"key"`
+}
+
+// Vault encrypts and decrypts secrets with the key derived from the master
+// password, the master key.
+type Vault struct {
+	header   Header
+	current  *masterKey
+	previous *masterKey
+}
+
+type masterKey struct {
+	id   string
+	key  []byte
+	aead cipher.AEAD
+}
+
+func newMasterKey(key []byte) (*masterKey, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// the ID tells which master key wrapped a data key, it's a hash of the
+	// key so it doesn't need to be stored anywhere
+	sum := sha256.Sum256(append([]byte("password-manager key id:"), key...))
+
+	return &masterKey{id: hex.EncodeToString(sum[:4]), key: key, aead: aead}, nil
+}
+
+// Create creates a new vault protected by masterPassword.
+func Create(masterPassword []byte, params KDFParams) (*Vault, error) {
+	current, err := newMasterKey(DeriveKey(masterPassword, params))
+	if err != nil {
+		return nil, err
+	}
+
+	check, err := seal(current.aead, checkPlaintext, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Vault{
+		header:  Header{Version: HeaderVersion, KDF: params, Check: check},
+		current: current,
+	}, nil
+}
+
+// Open opens an existing vault. It returns ErrWrongMasterPassword when the
+// master password doesn't match the header.
+func Open(header Header, masterPassword []byte) (*Vault, error) {
+	if header.Version != HeaderVersion {
+		return nil, fmt.Errorf("unsupported vault header version %d", header.Version)
+	}
+
+	current, err := newMasterKey(DeriveKey(masterPassword, header.KDF))
+	if err != nil {
+		return nil, err
+	}
+
+	check, err := open(current.aead, header.Check, nil)
+	if err != nil || subtle.ConstantTimeCompare(check, checkPlaintext) != 1 {
+		return nil, ErrWrongMasterPassword
+	}
+
+	v := &Vault{header: header, current: current}
+
+	if header.Previous != nil {
+		key, err := open(current.aead, header.Previous.Key, []byte(header.Previous.KeyID))
+		if err != nil {
+			return nil, fmt.Errorf("error opening previous master key: %w", err)
+		}
+
+		v.previous, err = newMasterKey(key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
+// Header returns the header to be stored with the encrypted data.
+func (v *Vault) Header() Header {
+	return v.header
+}
+
+// Seal encrypts and authenticates plaintext with the master key. The
+// additional data is authenticated but not encrypted; the same value must be
+// given to Open.
+func (v *Vault) Seal(plaintext, additionalData []byte) ([]byte, error) {
+	return seal(v.current.aead, plaintext, additionalData)
+}
+
+// Open decrypts a ciphertext produced by Seal.
+func (v *Vault) Open(ciphertext, additionalData []byte) ([]byte, error) {
+	return open(v.current.aead, ciphertext, additionalData)
+}
+
+// EncryptString seals plaintext with the master key and encodes it as a
+// printable string that can be told apart from plaintext with IsEncrypted.
+//
+// Deprecated: secrets are sealed with data keys, see SealString. It's kept to
+// read the values encrypted before the data keys were introduced.
+func (v *Vault) EncryptString(plaintext, additionalData string) (string, error) {
+	ciphertext, err := v.Seal([]byte(plaintext), []byte(additionalData))
+	if err != nil {
+		return "", err
+	}
+
+	return sealedPrefix + base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// DecryptString decrypts a string produced by EncryptString.
+func (v *Vault) DecryptString(ciphertext, additionalData string) (string, error) {
+	if !strings.HasPrefix(ciphertext, sealedPrefix) {
+		return "", fmt.Errorf("value is not encrypted")
+	}
+
+	raw, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(ciphertext, sealedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("error decoding encrypted value: %w", err)
+	}
+
+	plaintext, err := v.Open(raw, []byte(additionalData))
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// IsEncrypted reports whether value was produced by EncryptString or
+// SealString.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix) || strings.HasPrefix(value, dataKeySealedPrefix)
+}
+
+// IsMasterKeyEncrypted reports whether value was produced by EncryptString,
+// i.e. it's sealed with the master key instead of a data key.
+func IsMasterKeyEncrypted(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %w", err)
+	}
+
+	return aead, nil
+}
+
+// seal returns the random nonce followed by the ciphertext.
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("error generating nonce: %w", err)
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	return plaintext, nil
+}
+
+// wipe overwrites key material that is no longer needed.
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
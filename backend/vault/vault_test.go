@@ -0,0 +1,107 @@
+package vault
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKDFParams are cheap parameters, the recommended ones take too long for
+// unit tests.
+var testKDFParams = KDFParams{Salt: []byte("0123456789abcdef"), Time: 1, Memory: 64, Threads: 1}
+
+func TestNewKDFParams(t *testing.T) {
+	first, err := NewKDFParams()
+	require.NoError(t, err)
+	assert.Len(t, first.Salt, saltSize)
+
+	second, err := NewKDFParams()
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Salt, second.Salt)
+}
+
+func TestDeriveKey(t *testing.T) {
+	key := DeriveKey([]byte("master"), testKDFParams)
+	assert.Len(t, key, keySize)
+	assert.Equal(t, key, DeriveKey([]byte("master"), testKDFParams))
+	assert.NotEqual(t, key, DeriveKey([]byte("another"), testKDFParams))
+
+	params := testKDFParams
+	params.Salt = []byte("fedcba9876543210")
+	assert.NotEqual(t, key, DeriveKey([]byte("master"), params))
+}
+
+func TestOpen(t *testing.T) {
+	v, err := Create([]byte("master"), testKDFParams)
+	require.NoError(t, err)
+
+	header := v.Header()
+	assert.Equal(t, HeaderVersion, header.Version)
+	assert.Equal(t, testKDFParams, header.KDF)
+	assert.NotContains(t, string(header.Check), string(checkPlaintext))
+
+	t.Run("returns error for a wrong master password", func(t *testing.T) {
+		_, err := Open(header, []byte("wrong"))
+		assert.ErrorIs(t, err, ErrWrongMasterPassword)
+	})
+
+	t.Run("returns error for an unsupported header version", func(t *testing.T) {
+		header := header
+		header.Version = 42
+
+		_, err := Open(header, []byte("master"))
+		assert.EqualError(t, err, "unsupported vault header version 42")
+	})
+
+	t.Run("🎉 opens the vault with the master password", func(t *testing.T) {
+		sealed, err := v.EncryptString("supersecret", "card-id-1")
+		require.NoError(t, err)
+
+		reopened, err := Open(header, []byte("master"))
+		require.NoError(t, err)
+
+		plaintext, err := reopened.DecryptString(sealed, "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, "supersecret", plaintext)
+	})
+}
+
+func TestEncryptString(t *testing.T) {
+	v, err := Create([]byte("master"), testKDFParams)
+	require.NoError(t, err)
+
+	sealed, err := v.EncryptString("supersecret", "card-id-1")
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(sealed))
+	assert.NotContains(t, sealed, "supersecret")
+
+	again, err := v.EncryptString("supersecret", "card-id-1")
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again, "every encryption must use a new nonce")
+
+	t.Run("returns error for a different additional data", func(t *testing.T) {
+		_, err := v.DecryptString(sealed, "card-id-2")
+		assert.ErrorIs(t, err, ErrDecrypt)
+	})
+
+	t.Run("returns error for a tampered ciphertext", func(t *testing.T) {
+		tampered := []byte(sealed)
+		tampered[len(tampered)-2] ^= 'A' ^ 'B'
+
+		_, err := v.DecryptString(string(tampered), "card-id-1")
+		assert.Error(t, err)
+	})
+
+	t.Run("returns error for a plaintext value", func(t *testing.T) {
+		_, err := v.DecryptString("supersecret", "card-id-1")
+		assert.EqualError(t, err, "value is not encrypted")
+		assert.False(t, IsEncrypted("supersecret"))
+	})
+
+	t.Run("🎉 decrypts the value", func(t *testing.T) {
+		plaintext, err := v.DecryptString(sealed, "card-id-1")
+		require.NoError(t, err)
+		assert.Equal(t, "supersecret", plaintext)
+	})
+}
@@ -1,7 +1,19 @@
+import { useEffect, useState } from "react"
+import { onUnauthorized } from "./api"
 import { Home } from "./pages/Home"
+import { Login } from "./pages/Login"
 
 const App = (): JSX.Element => {
-  return <Home />  
+  // the login is only asked when the API requires it, it may run without
+  // accounts
+  const [loggedOut, setLoggedOut] = useState(false);
+
+  useEffect(() => onUnauthorized(() => setLoggedOut(true)), [])
+
+  if (loggedOut) {
+    return <Login onLogin={() => setLoggedOut(false)} />
+  }
+  return <Home onLogout={() => setLoggedOut(true)} />
 }
 
 export default App
@@ -1,9 +1,46 @@
 import axios from "axios";
 
+// tokenKey is the session storage key holding the session token of the user.
+const tokenKey = "token";
+
 const instance = axios.create({
     baseURL: import.meta.env.VITE_API_BASE_URL,
     timeout: 1000,
     headers: {'Content-Type': 'application/json'}
 });
 
+instance.interceptors.request.use(config => {
+    const token = sessionStorage.getItem(tokenKey);
+    if (token) {
+        config.headers.Authorization = `Bearer ${token}`;
+    }
+    return config;
+});
+
+export function hasToken(): boolean {
+    return sessionStorage.getItem(tokenKey) !== null;
+}
+
+export function setToken(token: string | null): void {
+    if (token) {
+        sessionStorage.setItem(tokenKey, token);
+    } else {
+        sessionStorage.removeItem(tokenKey);
+    }
+}
+
+// onUnauthorized calls handler, after forgetting the token, whenever the API
+// asks for a login. It returns a function removing the handler.
+export function onUnauthorized(handler: () => void): () => void {
+    const id = instance.interceptors.response.use(resp => resp, err => {
+        if (err.response?.status === 401) {
+            setToken(null);
+            handler();
+        }
+        return Promise.reject(err);
+    });
+
+    return () => instance.interceptors.response.eject(id);
+}
+
 export default instance;
@@ -1,25 +1,33 @@
-import { Header as HeaderStyled } from "./styled"
+import { Actions, Header as HeaderStyled, LogoutButton } from "./styled"
 import { Input } from "../Input"
 import SearchIcon from '@mui/icons-material/Search';
+import LogoutIcon from '@mui/icons-material/Logout';
 
 interface IProps {
     searchedValue: string;
     changeSearchedValue: (value: string) => void;
+    onLogout?: () => void;
 }
 
-export const Header = ({ searchedValue, changeSearchedValue }: IProps): JSX.Element => {
+export const Header = ({ searchedValue, changeSearchedValue, onLogout }: IProps): JSX.Element => {
     return (
         <HeaderStyled>
             <span>Password Manager</span>
-            <Input
-                type="text"
-                placeholder="Search"
-                value={searchedValue}
-                onChange={changeSearchedValue}
-                border="none"
-                color="#FFF"
-                width="200px"
-                renderRight={<SearchIcon />} />
+            <Actions>
+                <Input
+                    type="text"
+                    placeholder="Search"
+                    value={searchedValue}
+                    onChange={changeSearchedValue}
+                    border="none"
+                    color="#FFF"
+                    width="200px"
+                    renderRight={<SearchIcon />} />
+                {onLogout &&
+                    <LogoutButton onClick={onLogout}>
+                        <LogoutIcon />
+                    </LogoutButton>}
+            </Actions>
         </HeaderStyled>
     )
 }
@@ -13,3 +13,16 @@ export const Header = styled.header`
     align-items: center;
     justify-content: space-between;
 `
+
+export const Actions = styled.div`
+    display: flex;
+    align-items: center;
+    gap: 20px;
+`
+
+export const LogoutButton = styled.button`
+    background: none;
+    border: none;
+    color: #FFF;
+    cursor: pointer;
+`
@@ -3,13 +3,14 @@ import { Input } from "../Input";
 import { Modal } from "../Modal";
 import { Container, InputGroup, PasswordIconContainer } from "./styled";
 import { Button } from "../Button";
-import { handleValidate } from "../../helpers/utils";
+import { MASKED_PASSWORD, handleValidate } from "../../helpers/utils";
 import api from "../../api"
 import uuid from "react-uuid";
 import { IPassword } from "../../types/password";
 import VisibilityIcon from '@mui/icons-material/Visibility';
 import VisibilityOffIcon from '@mui/icons-material/VisibilityOff';
 import ContentCopyIcon from '@mui/icons-material/ContentCopy';
+import AutorenewIcon from '@mui/icons-material/Autorenew';
 
 interface IProps {
     isOpen: boolean;
@@ -42,6 +43,52 @@ export const PasswordFormModal = ({
         }
     }, [passwordSelected])
 
+    // revealPassword returns the password, asking it to the API when the
+    // form still holds the mask of the card being edited. Every reveal is
+    // audited, so it's only done when the password is shown or copied.
+    function revealPassword(): Promise<string> {
+        if (flow !== "edit" || password !== MASKED_PASSWORD) {
+            return Promise.resolve(password);
+        }
+
+        return api.post(`/password-cards/${passwordSelected?.id}/reveal`).then(resp => {
+            setPassword(resp.data.password);
+            return resp.data.password;
+        });
+    }
+
+    function handleCopyPassword(): void {
+        revealPassword().then(revealed => {
+            navigator.clipboard.writeText(revealed);
+            alert("Copied the password!");
+        }).catch(err => {
+            console.error(err)
+            alert("An error has occurred");
+        })
+    }
+
+    function handleShowPassword(): void {
+        if (showPassword) {
+            setShowPassword(false);
+            return;
+        }
+
+        revealPassword().then(() => setShowPassword(true)).catch(err => {
+            console.error(err)
+            alert("An error has occurred");
+        })
+    }
+
+    function handleGeneratePassword(): void {
+        api.get("/password-generator").then(resp => {
+            setPassword(resp.data.password)
+            setShowPassword(true)
+        }).catch(err => {
+            console.error(err)
+            alert("An error has occurred");
+        })
+    }
+
     function handleSubmit(): void {
         const validation = handleValidate({
             url,
@@ -70,7 +117,10 @@ export const PasswordFormModal = ({
                 alert("An error has occurred");
             })
         } else {
-            api.put(`/password-cards/${passwordSelected?.id}`, pass).then(resp => {
+            // the API replaces the whole card: the fields this form doesn't
+            // edit (type, notes, TOTP, custom fields, tags...) are sent back
+            // as they were listed, the masked secrets included
+            api.put(`/password-cards/${passwordSelected?.id}`, {...passwordSelected, ...pass}).then(resp => {
                 handleUpdatePasswordCards(resp.data)
                 onClose()
             }).catch(err => {
@@ -124,13 +174,13 @@ export const PasswordFormModal = ({
                         error={errors?.password}
                         renderRight={
                             <PasswordIconContainer>
-                                <button onClick={() => {
-                                    navigator.clipboard.writeText(password);
-                                    alert("Copied the password!");
-                                }}>
+                                <button onClick={handleGeneratePassword}>
+                                    <AutorenewIcon />
+                                </button>
+                                <button onClick={handleCopyPassword}>
                                     <ContentCopyIcon />
                                 </button>
-                                <button onClick={() => setShowPassword(!showPassword)}>
+                                <button onClick={handleShowPassword}>
                                     {showPassword ? <VisibilityOffIcon /> : <VisibilityIcon />}
                                 </button>
                             </PasswordIconContainer>
@@ -8,3 +8,7 @@ export function handleValidate(_object: Record<string, string>): Record<string,
 
     return errors
 }
+
+// MASKED_PASSWORD replaces the passwords in the API responses, they are only
+// sent by the reveal endpoint.
+export const MASKED_PASSWORD = "********";
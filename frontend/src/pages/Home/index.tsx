@@ -1,6 +1,6 @@
 import { useEffect, useMemo, useState } from "react";
 import { IPassword } from "../../types/password";
-import api from "../../api";
+import api, { hasToken, setToken } from "../../api";
 import { Header } from "../../components/Header";
 import { CardsWrapper, FABButton } from "./styled";
 import { EmptyState } from "../../components/EmptyState";
@@ -8,7 +8,11 @@ import { Card } from "../../components/Card";
 import { PasswordFormModal } from "../../components/PasswordFormModal";
 import AddIcon from '@mui/icons-material/Add';
 
-export const Home = (): JSX.Element => {
+interface IProps {
+  onLogout: () => void;
+}
+
+export const Home = ({ onLogout }: IProps): JSX.Element => {
     const [openModal, setOpenModal] = useState(false);
   const [searchedValue, setSearchedValue] = useState("");
   const [passwords, setPasswords] = useState<IPassword[]>([]);
@@ -17,9 +21,16 @@ export const Home = (): JSX.Element => {
   useEffect(() => {
     api.get("/password-cards").then(resp => {
       setPasswords(resp.data ?? []);
-    });
+    }).catch(err => console.error(err));
   }, [])
 
+  function handleLogout() {
+    api.post("/auth/logout").catch(err => console.error(err)).finally(() => {
+      setToken(null);
+      onLogout();
+    });
+  }
+
   function handleUpdatePasswordCards(value: IPassword) {
     const index = passwords.findIndex(pass => pass.id === value.id);
 
@@ -50,7 +61,8 @@ export const Home = (): JSX.Element => {
     <>
       <Header
         searchedValue={searchedValue}
-        changeSearchedValue={setSearchedValue} />
+        changeSearchedValue={setSearchedValue}
+        onLogout={hasToken() ? handleLogout : undefined} />
 
       <CardsWrapper>
         {filteredPassword.length === 0 ? 
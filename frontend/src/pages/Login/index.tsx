@@ -0,0 +1,90 @@
+import { useState } from "react";
+import api, { setToken } from "../../api";
+import { Input } from "../../components/Input";
+import { Button } from "../../components/Button";
+import { handleValidate } from "../../helpers/utils";
+import { Buttons, Container, Form, InputGroup } from "./styled";
+
+interface IProps {
+    onLogin: () => void;
+}
+
+export const Login = ({ onLogin }: IProps): JSX.Element => {
+    const [username, setUsername] = useState("");
+    const [password, setPassword] = useState("");
+    const [masterPassword, setMasterPassword] = useState("");
+    const [errors, setErrors] = useState<Record<string, string>>({});
+
+    function login(): Promise<void> {
+        return api.post("/auth/login", { username, password }).then(resp => {
+            setToken(resp.data.token);
+            onLogin();
+        });
+    }
+
+    function handleSubmit(register: boolean): void {
+        const validation = handleValidate({
+            username,
+            password
+        });
+
+        setErrors(validation)
+        if (Object.keys(validation).length > 0) {
+            return;
+        }
+
+        const request = register ?
+            api.post("/auth/register", { username, password, master_password: masterPassword }).then(login) :
+            login();
+
+        request.catch(err => {
+            console.error(err)
+            alert(err.response?.data?.error ?? "An error has occurred");
+        })
+    }
+
+    return (
+        <Container>
+            <Form>
+                <h3>Password Manager</h3>
+
+                <InputGroup>
+                    <label>Username</label>
+                    <Input
+                        placeholder="alice"
+                        value={username}
+                        onChange={setUsername}
+                        error={errors?.username} />
+                </InputGroup>
+
+                <InputGroup>
+                    <label>Password</label>
+                    <Input
+                        type="password"
+                        placeholder="**************"
+                        value={password}
+                        onChange={setPassword}
+                        error={errors?.password} />
+                </InputGroup>
+
+                <InputGroup>
+                    <label>Master password (admins registering only)</label>
+                    <Input
+                        type="password"
+                        placeholder="**************"
+                        value={masterPassword}
+                        onChange={setMasterPassword} />
+                </InputGroup>
+
+                <Buttons>
+                    <Button
+                        label="Login"
+                        onClick={() => handleSubmit(false)} />
+                    <Button
+                        label="Register"
+                        onClick={() => handleSubmit(true)} />
+                </Buttons>
+            </Form>
+        </Container>
+    );
+}
@@ -0,0 +1,33 @@
+import { styled } from "styled-components";
+
+export const Container = styled.div`
+    width: 100%;
+    height: 100%;
+    display: flex;
+    align-items: center;
+    justify-content: center;
+`
+
+export const Form = styled.div`
+    width: 360px;
+    padding: 20px;
+    border-radius: 8px;
+    background-color: #FFF;
+    -webkit-box-shadow: 2px 4px 8px 0px rgba(0,0,0,0.25);
+    -moz-box-shadow: 2px 4px 8px 0px rgba(0,0,0,0.25);
+    box-shadow: 2px 4px 8px 0px rgba(0,0,0,0.25);
+`
+
+export const InputGroup = styled.div`
+    margin-bottom: 20px;
+
+    label {
+        font-weight: 500;
+        color: #4c4c4d;
+    }
+`
+
+export const Buttons = styled.div`
+    display: flex;
+    gap: 10px;
+`